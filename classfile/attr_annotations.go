@@ -0,0 +1,279 @@
+package classfile
+
+/*
+	RuntimeVisibleAnnotations_attribute {
+	    u2         attribute_name_index;
+	    u4         attribute_length;
+	    u2         num_annotations;
+	    annotation annotations[num_annotations];
+	}
+
+RuntimeInvisibleAnnotations_attribute 的结构相同
+*/
+type AnnotationsAttribute struct {
+	cp          ConstantPool
+	visible     bool
+	annotations []*Annotation
+	info        []byte // 属性的原始内容，反射时由 sun.reflect.annotation.AnnotationParser 解析
+}
+
+func (aa *AnnotationsAttribute) readInfo(reader *ClassReader) {
+	start := reader.data
+	aa.annotations = readAnnotations(reader, aa.cp)
+	aa.info = readBytesSince(start, reader)
+}
+func (aa *AnnotationsAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeBytes(aa.info)
+}
+
+func (aa *AnnotationsAttribute) Visible() bool {
+	return aa.visible
+}
+func (aa *AnnotationsAttribute) Annotations() []*Annotation {
+	return aa.annotations
+}
+func (aa *AnnotationsAttribute) Info() []byte {
+	return aa.info
+}
+
+/*
+	RuntimeVisibleParameterAnnotations_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u1 num_parameters;
+	    {   u2         num_annotations;
+	        annotation annotations[num_annotations];
+	    } parameter_annotations[num_parameters];
+	}
+
+RuntimeInvisibleParameterAnnotations_attribute 的结构相同
+*/
+type ParameterAnnotationsAttribute struct {
+	cp                   ConstantPool
+	visible              bool
+	parameterAnnotations [][]*Annotation
+	info                 []byte
+}
+
+func (paa *ParameterAnnotationsAttribute) readInfo(reader *ClassReader) {
+	start := reader.data
+	numParameters := reader.readUint8()
+	paa.parameterAnnotations = make([][]*Annotation, numParameters)
+	for i := range paa.parameterAnnotations {
+		paa.parameterAnnotations[i] = readAnnotations(reader, paa.cp)
+	}
+	paa.info = readBytesSince(start, reader)
+}
+func (paa *ParameterAnnotationsAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeBytes(paa.info)
+}
+
+func (paa *ParameterAnnotationsAttribute) Visible() bool {
+	return paa.visible
+}
+func (paa *ParameterAnnotationsAttribute) ParameterAnnotations() [][]*Annotation {
+	return paa.parameterAnnotations
+}
+func (paa *ParameterAnnotationsAttribute) Info() []byte {
+	return paa.info
+}
+
+/*
+	AnnotationDefault_attribute {
+	    u2            attribute_name_index;
+	    u4            attribute_length;
+	    element_value default_value;
+	}
+*/
+type AnnotationDefaultAttribute struct {
+	cp           ConstantPool
+	defaultValue *ElementValue
+	info         []byte
+}
+
+func (ada *AnnotationDefaultAttribute) readInfo(reader *ClassReader) {
+	start := reader.data
+	ada.defaultValue = readElementValue(reader, ada.cp)
+	ada.info = readBytesSince(start, reader)
+}
+func (ada *AnnotationDefaultAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeBytes(ada.info)
+}
+
+func (ada *AnnotationDefaultAttribute) DefaultValue() *ElementValue {
+	return ada.defaultValue
+}
+func (ada *AnnotationDefaultAttribute) Info() []byte {
+	return ada.info
+}
+
+/*
+	annotation {
+	    u2 type_index;
+	    u2 num_element_value_pairs;
+	    {   u2            element_name_index;
+	        element_value value;
+	    } element_value_pairs[num_element_value_pairs];
+	}
+*/
+type Annotation struct {
+	cp                ConstantPool
+	typeIndex         uint16
+	elementValuePairs []*ElementValuePair
+}
+
+type ElementValuePair struct {
+	cp               ConstantPool
+	elementNameIndex uint16
+	value            *ElementValue
+}
+
+func readAnnotations(reader *ClassReader, cp ConstantPool) []*Annotation {
+	numAnnotations := reader.readUint16()
+	annotations := make([]*Annotation, numAnnotations)
+	for i := range annotations {
+		annotations[i] = readAnnotation(reader, cp)
+	}
+	return annotations
+}
+
+func readAnnotation(reader *ClassReader, cp ConstantPool) *Annotation {
+	annotation := &Annotation{cp: cp, typeIndex: reader.readUint16()}
+	numPairs := reader.readUint16()
+	annotation.elementValuePairs = make([]*ElementValuePair, numPairs)
+	for i := range annotation.elementValuePairs {
+		annotation.elementValuePairs[i] = &ElementValuePair{
+			cp:               cp,
+			elementNameIndex: reader.readUint16(),
+			value:            readElementValue(reader, cp),
+		}
+	}
+	return annotation
+}
+
+// Type 注解类型的描述符，例如 Ljava/lang/Deprecated;
+func (an *Annotation) Type() string {
+	return an.cp.getUtf8(an.typeIndex)
+}
+func (an *Annotation) ElementValuePairs() []*ElementValuePair {
+	return an.elementValuePairs
+}
+
+func (evp *ElementValuePair) ElementName() string {
+	return evp.cp.getUtf8(evp.elementNameIndex)
+}
+func (evp *ElementValuePair) Value() *ElementValue {
+	return evp.value
+}
+
+/*
+	element_value {
+	    u1 tag;
+	    union {
+	        u2 const_value_index;
+	        {   u2 type_name_index;
+	            u2 const_name_index;
+	        } enum_const_value;
+	        u2 class_info_index;
+	        annotation annotation_value;
+	        {   u2            num_values;
+	            element_value values[num_values];
+	        } array_value;
+	    } value;
+	}
+*/
+type ElementValue struct {
+	cp              ConstantPool
+	tag             uint8
+	constValueIndex uint16          // B C D F I J S Z s
+	typeNameIndex   uint16          // e
+	constNameIndex  uint16          // e
+	classInfoIndex  uint16          // c
+	annotationValue *Annotation     // @
+	arrayValue      []*ElementValue // [
+}
+
+func readElementValue(reader *ClassReader, cp ConstantPool) *ElementValue {
+	ev := &ElementValue{cp: cp, tag: reader.readUint8()}
+	switch ev.tag {
+	case 'B', 'C', 'D', 'F', 'I', 'J', 'S', 'Z', 's':
+		ev.constValueIndex = reader.readUint16()
+	case 'e':
+		ev.typeNameIndex = reader.readUint16()
+		ev.constNameIndex = reader.readUint16()
+	case 'c':
+		ev.classInfoIndex = reader.readUint16()
+	case '@':
+		ev.annotationValue = readAnnotation(reader, cp)
+	case '[':
+		numValues := reader.readUint16()
+		ev.arrayValue = make([]*ElementValue, numValues)
+		for i := range ev.arrayValue {
+			ev.arrayValue[i] = readElementValue(reader, cp)
+		}
+	default:
+		panic("java.lang.ClassFormatError: invalid element_value tag " + string(rune(ev.tag)))
+	}
+	return ev
+}
+
+func (ev *ElementValue) Tag() uint8 {
+	return ev.tag
+}
+
+// ConstValue 返回基本类型或者字符串常量的值。
+// B C I S Z 都存放在 CONSTANT_Integer 里，返回 int32；J 返回 int64；F 返回 float32；D 返回 float64；s 返回 string
+func (ev *ElementValue) ConstValue() interface{} {
+	switch ev.tag {
+	case 's':
+		return ev.cp.getUtf8(ev.constValueIndex)
+	case 'B', 'C', 'I', 'S', 'Z':
+		return ev.cp.getConstantInfo(ev.constValueIndex).(*ConstantIntegerInfo).Value()
+	case 'J':
+		return ev.cp.getConstantInfo(ev.constValueIndex).(*ConstantLongInfo).Value()
+	case 'F':
+		return ev.cp.getConstantInfo(ev.constValueIndex).(*ConstantFloatInfo).Value()
+	case 'D':
+		return ev.cp.getConstantInfo(ev.constValueIndex).(*ConstantDoubleInfo).Value()
+	}
+	return nil
+}
+
+// EnumValue 返回枚举类型的描述符和枚举常量名
+func (ev *ElementValue) EnumValue() (string, string) {
+	return ev.cp.getUtf8(ev.typeNameIndex), ev.cp.getUtf8(ev.constNameIndex)
+}
+
+// ClassInfo 返回类对应的返回值描述符，例如 Ljava/lang/Object;，void.class 是 V
+func (ev *ElementValue) ClassInfo() string {
+	return ev.cp.getUtf8(ev.classInfoIndex)
+}
+func (ev *ElementValue) AnnotationValue() *Annotation {
+	return ev.annotationValue
+}
+func (ev *ElementValue) ArrayValue() []*ElementValue {
+	return ev.arrayValue
+}
+
+// readBytesSince 返回从 start 开始到 reader 当前位置已经读过的字节
+func readBytesSince(start []byte, reader *ClassReader) []byte {
+	return start[:len(start)-len(reader.data)]
+}
+
+func findAnnotationsAttribute(attributes []AttributeInfo, visible bool) *AnnotationsAttribute {
+	for _, attrInfo := range attributes {
+		if attr, ok := attrInfo.(*AnnotationsAttribute); ok && attr.visible == visible {
+			return attr
+		}
+	}
+	return nil
+}
+
+func findTypeAnnotationsAttribute(attributes []AttributeInfo, visible bool) *TypeAnnotationsAttribute {
+	for _, attrInfo := range attributes {
+		if attr, ok := attrInfo.(*TypeAnnotationsAttribute); ok && attr.visible == visible {
+			return attr
+		}
+	}
+	return nil
+}
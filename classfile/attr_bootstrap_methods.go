@@ -25,8 +25,26 @@ func (bma *BootstrapMethodsAttribute) readInfo(reader *ClassReader) {
 		}
 	}
 }
+func (bma *BootstrapMethodsAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(bma.bootstrapMethods)))
+	for _, bm := range bma.bootstrapMethods {
+		writer.writeUint16(bm.bootstrapMethodRef)
+		writer.writeUint16s(bm.bootstrapArguments)
+	}
+}
 
 type BootstrapMethod struct {
 	bootstrapMethodRef uint16
 	bootstrapArguments []uint16
 }
+
+func (bma *BootstrapMethodsAttribute) BootstrapMethods() []*BootstrapMethod {
+	return bma.bootstrapMethods
+}
+
+func (bm *BootstrapMethod) BootstrapMethodRef() uint16 {
+	return bm.bootstrapMethodRef
+}
+func (bm *BootstrapMethod) BootstrapArguments() []uint16 {
+	return bm.bootstrapArguments
+}
@@ -35,6 +35,20 @@ func (ca *CodeAttribute) readInfo(reader *ClassReader) {
 	ca.exceptionTable = readExceptionTable(reader)
 	ca.attributes = readAttributes(reader, ca.cp)
 }
+func (ca *CodeAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(ca.maxStack)
+	writer.writeUint16(ca.maxLocals)
+	writer.writeUint32(uint32(len(ca.code)))
+	writer.writeBytes(ca.code)
+	writer.writeUint16(uint16(len(ca.exceptionTable)))
+	for _, entry := range ca.exceptionTable {
+		writer.writeUint16(entry.startPc)
+		writer.writeUint16(entry.endPc)
+		writer.writeUint16(entry.handlerPc)
+		writer.writeUint16(entry.catchType)
+	}
+	writeAttributes(writer, ca.attributes)
+}
 
 func (ca *CodeAttribute) MaxStack() uint {
 	return uint(ca.maxStack)
@@ -59,6 +73,15 @@ func (ca *CodeAttribute) LineNumberTableAttribute() *LineNumberTableAttribute {
 	return nil
 }
 
+func (ca *CodeAttribute) StackMapTableAttribute() *StackMapTableAttribute {
+	for _, attrInfo := range ca.attributes {
+		if attr, ok := attrInfo.(*StackMapTableAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
 type ExceptionTableEntry struct {
 	startPc   uint16
 	endPc     uint16
@@ -92,3 +115,7 @@ func (ca *ExceptionTableEntry) HandlerPc() uint16 {
 func (ca *ExceptionTableEntry) CatchType() uint16 {
 	return ca.catchType
 }
+
+func (ca *CodeAttribute) Attributes() []AttributeInfo {
+	return ca.attributes
+}
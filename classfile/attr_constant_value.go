@@ -14,6 +14,9 @@ type ConstantValueAttribute struct {
 func (self *ConstantValueAttribute) readInfo(reader *ClassReader) {
 	self.constantValueIndex = reader.readUint16()
 }
+func (self *ConstantValueAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(self.constantValueIndex)
+}
 
 func (self *ConstantValueAttribute) ConstantValueIndex() uint16 {
 	return self.constantValueIndex
@@ -18,6 +18,10 @@ func (e *EnclosingMethodAttribute) readInfo(reader *ClassReader) {
 	e.classIndex = reader.readUint16()
 	e.methodIndex = reader.readUint16()
 }
+func (e *EnclosingMethodAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(e.classIndex)
+	writer.writeUint16(e.methodIndex)
+}
 
 func (e *EnclosingMethodAttribute) ClassName() string {
 	return e.cp.getClassName(e.classIndex)
@@ -30,3 +34,11 @@ func (e *EnclosingMethodAttribute) MethodNameAndDescriptor() (string, string) {
 		return "", ""
 	}
 }
+
+func (e *EnclosingMethodAttribute) ClassIndex() uint16 {
+	return e.classIndex
+}
+
+func (e *EnclosingMethodAttribute) MethodIndex() uint16 {
+	return e.methodIndex
+}
@@ -15,6 +15,9 @@ type ExceptionsAttribute struct {
 func (e *ExceptionsAttribute) readInfo(reader *ClassReader) {
 	e.exceptionIndexTable = reader.readUint16s()
 }
+func (e *ExceptionsAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16s(e.exceptionIndexTable)
+}
 
 func (e *ExceptionsAttribute) ExceptionIndexTable() []uint16 {
 	return e.exceptionIndexTable
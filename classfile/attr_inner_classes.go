@@ -35,3 +35,32 @@ func (ica *InnerClassesAttribute) readInfo(reader *ClassReader) {
 		}
 	}
 }
+func (ica *InnerClassesAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(ica.classes)))
+	for _, class := range ica.classes {
+		writer.writeUint16(class.innerClassInfoIndex)
+		writer.writeUint16(class.outerClassInfoIndex)
+		writer.writeUint16(class.innerNameIndex)
+		writer.writeUint16(class.innerClassAccessFlags)
+	}
+}
+
+func (ica *InnerClassesAttribute) Classes() []*InnerClassInfo {
+	return ica.classes
+}
+
+func (ici *InnerClassInfo) InnerClassInfoIndex() uint16 {
+	return ici.innerClassInfoIndex
+}
+
+func (ici *InnerClassInfo) OuterClassInfoIndex() uint16 {
+	return ici.outerClassInfoIndex
+}
+
+func (ici *InnerClassInfo) InnerNameIndex() uint16 {
+	return ici.innerNameIndex
+}
+
+func (ici *InnerClassInfo) InnerClassAccessFlags() uint16 {
+	return ici.innerClassAccessFlags
+}
@@ -29,6 +29,13 @@ func (lnta *LineNumberTableAttribute) readInfo(reader *ClassReader) {
 		}
 	}
 }
+func (lnta *LineNumberTableAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(lnta.lineNumberTable)))
+	for _, entry := range lnta.lineNumberTable {
+		writer.writeUint16(entry.startPc)
+		writer.writeUint16(entry.lineNumber)
+	}
+}
 
 func (lnta *LineNumberTableAttribute) GetLineNumber(pc int) int {
 	for i := len(lnta.lineNumberTable) - 1; i >= 0; i-- {
@@ -39,3 +46,15 @@ func (lnta *LineNumberTableAttribute) GetLineNumber(pc int) int {
 	}
 	return -1
 }
+
+func (lnta *LineNumberTableAttribute) LineNumberTable() []*LineNumberTableEntry {
+	return lnta.lineNumberTable
+}
+
+func (lnte *LineNumberTableEntry) StartPc() uint16 {
+	return lnte.startPc
+}
+
+func (lnte *LineNumberTableEntry) LineNumber() uint16 {
+	return lnte.lineNumber
+}
@@ -38,3 +38,37 @@ func (lta *LocalVariableTableAttribute) readInfo(reader *ClassReader) {
 		}
 	}
 }
+func (lta *LocalVariableTableAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(lta.localVariableTable)))
+	for _, entry := range lta.localVariableTable {
+		writer.writeUint16(entry.startPc)
+		writer.writeUint16(entry.length)
+		writer.writeUint16(entry.nameIndex)
+		writer.writeUint16(entry.descriptorIndex)
+		writer.writeUint16(entry.index)
+	}
+}
+
+func (lta *LocalVariableTableAttribute) LocalVariableTable() []*LocalVariableTableEntry {
+	return lta.localVariableTable
+}
+
+func (lvte *LocalVariableTableEntry) StartPc() uint16 {
+	return lvte.startPc
+}
+
+func (lvte *LocalVariableTableEntry) Length() uint16 {
+	return lvte.length
+}
+
+func (lvte *LocalVariableTableEntry) NameIndex() uint16 {
+	return lvte.nameIndex
+}
+
+func (lvte *LocalVariableTableEntry) DescriptorIndex() uint16 {
+	return lvte.descriptorIndex
+}
+
+func (lvte *LocalVariableTableEntry) Index() uint16 {
+	return lvte.index
+}
@@ -38,3 +38,37 @@ func (self *LocalVariableTypeTableAttribute) readInfo(reader *ClassReader) {
 		}
 	}
 }
+func (self *LocalVariableTypeTableAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(self.localVariableTypeTable)))
+	for _, entry := range self.localVariableTypeTable {
+		writer.writeUint16(entry.startPc)
+		writer.writeUint16(entry.length)
+		writer.writeUint16(entry.nameIndex)
+		writer.writeUint16(entry.signatureIndex)
+		writer.writeUint16(entry.index)
+	}
+}
+
+func (self *LocalVariableTypeTableAttribute) LocalVariableTypeTable() []*LocalVariableTypeTableEntry {
+	return self.localVariableTypeTable
+}
+
+func (lvtte *LocalVariableTypeTableEntry) StartPc() uint16 {
+	return lvtte.startPc
+}
+
+func (lvtte *LocalVariableTypeTableEntry) Length() uint16 {
+	return lvtte.length
+}
+
+func (lvtte *LocalVariableTypeTableEntry) NameIndex() uint16 {
+	return lvtte.nameIndex
+}
+
+func (lvtte *LocalVariableTypeTableEntry) SignatureIndex() uint16 {
+	return lvtte.signatureIndex
+}
+
+func (lvtte *LocalVariableTypeTableEntry) Index() uint16 {
+	return lvtte.index
+}
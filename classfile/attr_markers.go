@@ -25,3 +25,6 @@ type MarkerAttribute struct{}
 func (self *MarkerAttribute) readInfo(reader *ClassReader) {
 	// read nothing
 }
+func (self *MarkerAttribute) writeInfo(writer *ClassWriter) {
+	// write nothing
+}
@@ -0,0 +1,58 @@
+package classfile
+
+/*
+	MethodParameters_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u1 parameters_count;
+	    {   u2 name_index;
+	        u2 access_flags;
+	    } parameters[parameters_count];
+	}
+*/
+type MethodParametersAttribute struct {
+	cp         ConstantPool
+	parameters []*MethodParameterInfo
+}
+
+type MethodParameterInfo struct {
+	cp          ConstantPool
+	nameIndex   uint16
+	accessFlags uint16
+}
+
+func (mpa *MethodParametersAttribute) readInfo(reader *ClassReader) {
+	parametersCount := reader.readUint8()
+	mpa.parameters = make([]*MethodParameterInfo, parametersCount)
+	for i := range mpa.parameters {
+		mpa.parameters[i] = &MethodParameterInfo{
+			cp:          mpa.cp,
+			nameIndex:   reader.readUint16(),
+			accessFlags: reader.readUint16(),
+		}
+	}
+}
+func (mpa *MethodParametersAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint8(uint8(len(mpa.parameters)))
+	for _, parameter := range mpa.parameters {
+		writer.writeUint16(parameter.nameIndex)
+		writer.writeUint16(parameter.accessFlags)
+	}
+}
+
+func (mpa *MethodParametersAttribute) Parameters() []*MethodParameterInfo {
+	return mpa.parameters
+}
+
+// Name 参数名，name_index 为 0 表示没有名字，返回空字符串
+func (mpi *MethodParameterInfo) Name() string {
+	if mpi.nameIndex == 0 {
+		return ""
+	}
+	return mpi.cp.getUtf8(mpi.nameIndex)
+}
+
+// AccessFlags ACC_FINAL、ACC_SYNTHETIC 或 ACC_MANDATED
+func (mpi *MethodParameterInfo) AccessFlags() uint16 {
+	return mpi.accessFlags
+}
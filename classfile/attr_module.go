@@ -0,0 +1,269 @@
+package classfile
+
+/*
+	Module_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+
+	    u2 module_name_index;
+	    u2 module_flags;
+	    u2 module_version_index;
+
+	    u2 requires_count;
+	    {   u2 requires_index;
+	        u2 requires_flags;
+	        u2 requires_version_index;
+	    } requires[requires_count];
+
+	    u2 exports_count;
+	    {   u2 exports_index;
+	        u2 exports_flags;
+	        u2 exports_to_count;
+	        u2 exports_to_index[exports_to_count];
+	    } exports[exports_count];
+
+	    u2 opens_count;
+	    {   u2 opens_index;
+	        u2 opens_flags;
+	        u2 opens_to_count;
+	        u2 opens_to_index[opens_to_count];
+	    } opens[opens_count];
+
+	    u2 uses_count;
+	    u2 uses_index[uses_count];
+
+	    u2 provides_count;
+	    {   u2 provides_index;
+	        u2 provides_with_count;
+	        u2 provides_with_index[provides_with_count];
+	    } provides[provides_count];
+	}
+*/
+type ModuleAttribute struct {
+	cp                 ConstantPool
+	moduleNameIndex    uint16
+	moduleFlags        uint16
+	moduleVersionIndex uint16
+	requires           []*ModuleRequires
+	exports            []*ModuleExports
+	opens              []*ModuleExports
+	uses               []uint16
+	provides           []*ModuleProvides
+}
+
+type ModuleRequires struct {
+	cp                   ConstantPool
+	requiresIndex        uint16
+	requiresFlags        uint16
+	requiresVersionIndex uint16
+}
+
+// ModuleExports exports 和 opens 的结构相同
+type ModuleExports struct {
+	cp      ConstantPool
+	index   uint16
+	flags   uint16
+	toIndex []uint16
+}
+
+type ModuleProvides struct {
+	cp        ConstantPool
+	index     uint16
+	withIndex []uint16
+}
+
+func (ma *ModuleAttribute) readInfo(reader *ClassReader) {
+	ma.moduleNameIndex = reader.readUint16()
+	ma.moduleFlags = reader.readUint16()
+	ma.moduleVersionIndex = reader.readUint16()
+
+	ma.requires = make([]*ModuleRequires, reader.readUint16())
+	for i := range ma.requires {
+		ma.requires[i] = &ModuleRequires{
+			cp:                   ma.cp,
+			requiresIndex:        reader.readUint16(),
+			requiresFlags:        reader.readUint16(),
+			requiresVersionIndex: reader.readUint16(),
+		}
+	}
+	ma.exports = readModuleExports(reader, ma.cp)
+	ma.opens = readModuleExports(reader, ma.cp)
+	ma.uses = reader.readUint16s()
+	ma.provides = make([]*ModuleProvides, reader.readUint16())
+	for i := range ma.provides {
+		ma.provides[i] = &ModuleProvides{
+			cp:        ma.cp,
+			index:     reader.readUint16(),
+			withIndex: reader.readUint16s(),
+		}
+	}
+}
+func (ma *ModuleAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(ma.moduleNameIndex)
+	writer.writeUint16(ma.moduleFlags)
+	writer.writeUint16(ma.moduleVersionIndex)
+
+	writer.writeUint16(uint16(len(ma.requires)))
+	for _, requires := range ma.requires {
+		writer.writeUint16(requires.requiresIndex)
+		writer.writeUint16(requires.requiresFlags)
+		writer.writeUint16(requires.requiresVersionIndex)
+	}
+	writeModuleExports(writer, ma.exports)
+	writeModuleExports(writer, ma.opens)
+	writer.writeUint16s(ma.uses)
+	writer.writeUint16(uint16(len(ma.provides)))
+	for _, provides := range ma.provides {
+		writer.writeUint16(provides.index)
+		writer.writeUint16s(provides.withIndex)
+	}
+}
+
+func readModuleExports(reader *ClassReader, cp ConstantPool) []*ModuleExports {
+	exports := make([]*ModuleExports, reader.readUint16())
+	for i := range exports {
+		exports[i] = &ModuleExports{
+			cp:      cp,
+			index:   reader.readUint16(),
+			flags:   reader.readUint16(),
+			toIndex: reader.readUint16s(),
+		}
+	}
+	return exports
+}
+
+func writeModuleExports(writer *ClassWriter, exports []*ModuleExports) {
+	writer.writeUint16(uint16(len(exports)))
+	for _, export := range exports {
+		writer.writeUint16(export.index)
+		writer.writeUint16(export.flags)
+		writer.writeUint16s(export.toIndex)
+	}
+}
+
+func (ma *ModuleAttribute) ModuleName() string {
+	return ma.cp.getModuleName(ma.moduleNameIndex)
+}
+
+// ModuleFlags ACC_OPEN、ACC_SYNTHETIC 或 ACC_MANDATED
+func (ma *ModuleAttribute) ModuleFlags() uint16 {
+	return ma.moduleFlags
+}
+
+// ModuleVersion 没有版本时返回空字符串
+func (ma *ModuleAttribute) ModuleVersion() string {
+	return optionalUtf8(ma.cp, ma.moduleVersionIndex)
+}
+func (ma *ModuleAttribute) Requires() []*ModuleRequires {
+	return ma.requires
+}
+func (ma *ModuleAttribute) Exports() []*ModuleExports {
+	return ma.exports
+}
+func (ma *ModuleAttribute) Opens() []*ModuleExports {
+	return ma.opens
+}
+
+// Uses 服务接口的类名
+func (ma *ModuleAttribute) Uses() []string {
+	return classNames(ma.cp, ma.uses)
+}
+func (ma *ModuleAttribute) Provides() []*ModuleProvides {
+	return ma.provides
+}
+
+func (mr *ModuleRequires) ModuleName() string {
+	return mr.cp.getModuleName(mr.requiresIndex)
+}
+
+// Flags ACC_TRANSITIVE、ACC_STATIC_PHASE、ACC_SYNTHETIC 或 ACC_MANDATED
+func (mr *ModuleRequires) Flags() uint16 {
+	return mr.requiresFlags
+}
+func (mr *ModuleRequires) Version() string {
+	return optionalUtf8(mr.cp, mr.requiresVersionIndex)
+}
+
+func (me *ModuleExports) PackageName() string {
+	return me.cp.getPackageName(me.index)
+}
+func (me *ModuleExports) Flags() uint16 {
+	return me.flags
+}
+
+// ToModules 限定导出（开放）给哪些模块，为空表示所有模块
+func (me *ModuleExports) ToModules() []string {
+	modules := make([]string, len(me.toIndex))
+	for i, index := range me.toIndex {
+		modules[i] = me.cp.getModuleName(index)
+	}
+	return modules
+}
+
+// ServiceName 服务接口的类名
+func (mp *ModuleProvides) ServiceName() string {
+	return mp.cp.getClassName(mp.index)
+}
+
+// ImplementationNames 服务实现类的类名
+func (mp *ModuleProvides) ImplementationNames() []string {
+	return classNames(mp.cp, mp.withIndex)
+}
+
+/*
+	ModulePackages_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u2 package_count;
+	    u2 package_index[package_count];
+	}
+*/
+type ModulePackagesAttribute struct {
+	cp             ConstantPool
+	packageIndexes []uint16
+}
+
+func (mpa *ModulePackagesAttribute) readInfo(reader *ClassReader) {
+	mpa.packageIndexes = reader.readUint16s()
+}
+func (mpa *ModulePackagesAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16s(mpa.packageIndexes)
+}
+
+func (mpa *ModulePackagesAttribute) PackageNames() []string {
+	names := make([]string, len(mpa.packageIndexes))
+	for i, index := range mpa.packageIndexes {
+		names[i] = mpa.cp.getPackageName(index)
+	}
+	return names
+}
+
+/*
+	ModuleMainClass_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u2 main_class_index;
+	}
+*/
+type ModuleMainClassAttribute struct {
+	cp             ConstantPool
+	mainClassIndex uint16
+}
+
+func (mmca *ModuleMainClassAttribute) readInfo(reader *ClassReader) {
+	mmca.mainClassIndex = reader.readUint16()
+}
+func (mmca *ModuleMainClassAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(mmca.mainClassIndex)
+}
+
+func (mmca *ModuleMainClassAttribute) MainClassName() string {
+	return mmca.cp.getClassName(mmca.mainClassIndex)
+}
+
+func optionalUtf8(cp ConstantPool, index uint16) string {
+	if index == 0 {
+		return ""
+	}
+	return cp.getUtf8(index)
+}
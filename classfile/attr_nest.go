@@ -0,0 +1,56 @@
+package classfile
+
+/*
+	NestHost_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u2 host_class_index;
+	}
+*/
+type NestHostAttribute struct {
+	cp             ConstantPool
+	hostClassIndex uint16
+}
+
+func (nha *NestHostAttribute) readInfo(reader *ClassReader) {
+	nha.hostClassIndex = reader.readUint16()
+}
+func (nha *NestHostAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(nha.hostClassIndex)
+}
+
+func (nha *NestHostAttribute) HostClassName() string {
+	return nha.cp.getClassName(nha.hostClassIndex)
+}
+
+/*
+	NestMembers_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u2 number_of_classes;
+	    u2 classes[number_of_classes];
+	}
+*/
+type NestMembersAttribute struct {
+	cp      ConstantPool
+	classes []uint16
+}
+
+func (nma *NestMembersAttribute) readInfo(reader *ClassReader) {
+	nma.classes = reader.readUint16s()
+}
+func (nma *NestMembersAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16s(nma.classes)
+}
+
+func (nma *NestMembersAttribute) ClassNames() []string {
+	return classNames(nma.cp, nma.classes)
+}
+
+func classNames(cp ConstantPool, indexes []uint16) []string {
+	names := make([]string, len(indexes))
+	for i, index := range indexes {
+		names[i] = cp.getClassName(index)
+	}
+	return names
+}
@@ -0,0 +1,25 @@
+package classfile
+
+/*
+	PermittedSubclasses_attribute {
+	    u2 attribute_name_index;
+	    u4 attribute_length;
+	    u2 number_of_classes;
+	    u2 classes[number_of_classes];
+	}
+*/
+type PermittedSubclassesAttribute struct {
+	cp      ConstantPool
+	classes []uint16
+}
+
+func (psa *PermittedSubclassesAttribute) readInfo(reader *ClassReader) {
+	psa.classes = reader.readUint16s()
+}
+func (psa *PermittedSubclassesAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16s(psa.classes)
+}
+
+func (psa *PermittedSubclassesAttribute) ClassNames() []string {
+	return classNames(psa.cp, psa.classes)
+}
@@ -0,0 +1,77 @@
+package classfile
+
+/*
+	Record_attribute {
+	    u2                    attribute_name_index;
+	    u4                    attribute_length;
+	    u2                    components_count;
+	    record_component_info components[components_count];
+	}
+
+	record_component_info {
+	    u2             name_index;
+	    u2             descriptor_index;
+	    u2             attributes_count;
+	    attribute_info attributes[attributes_count];
+	}
+*/
+type RecordAttribute struct {
+	cp         ConstantPool
+	components []*RecordComponentInfo
+}
+
+type RecordComponentInfo struct {
+	cp              ConstantPool
+	nameIndex       uint16
+	descriptorIndex uint16
+	attributes      []AttributeInfo
+}
+
+func (ra *RecordAttribute) readInfo(reader *ClassReader) {
+	componentsCount := reader.readUint16()
+	ra.components = make([]*RecordComponentInfo, componentsCount)
+	for i := range ra.components {
+		ra.components[i] = &RecordComponentInfo{
+			cp:              ra.cp,
+			nameIndex:       reader.readUint16(),
+			descriptorIndex: reader.readUint16(),
+			attributes:      readAttributes(reader, ra.cp),
+		}
+	}
+}
+func (ra *RecordAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(ra.components)))
+	for _, component := range ra.components {
+		writer.writeUint16(component.nameIndex)
+		writer.writeUint16(component.descriptorIndex)
+		writeAttributes(writer, component.attributes)
+	}
+}
+
+func (ra *RecordAttribute) Components() []*RecordComponentInfo {
+	return ra.components
+}
+
+func (rci *RecordComponentInfo) Name() string {
+	return rci.cp.getUtf8(rci.nameIndex)
+}
+func (rci *RecordComponentInfo) Descriptor() string {
+	return rci.cp.getUtf8(rci.descriptorIndex)
+}
+
+// Signature 泛型签名，没有 Signature 属性时返回空字符串
+func (rci *RecordComponentInfo) Signature() string {
+	for _, attrInfo := range rci.attributes {
+		if attr, ok := attrInfo.(*SignatureAttribute); ok {
+			return attr.Signature()
+		}
+	}
+	return ""
+}
+
+func (rci *RecordComponentInfo) RuntimeVisibleAnnotationsAttribute() *AnnotationsAttribute {
+	return findAnnotationsAttribute(rci.attributes, true)
+}
+func (rci *RecordComponentInfo) RuntimeVisibleTypeAnnotationsAttribute() *TypeAnnotationsAttribute {
+	return findTypeAnnotationsAttribute(rci.attributes, true)
+}
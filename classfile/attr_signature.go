@@ -15,7 +15,14 @@ type SignatureAttribute struct {
 func (self *SignatureAttribute) readInfo(reader *ClassReader) {
 	self.signatureIndex = reader.readUint16()
 }
+func (self *SignatureAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(self.signatureIndex)
+}
 
 func (self *SignatureAttribute) Signature() string {
 	return self.cp.getUtf8(self.signatureIndex)
 }
+
+func (self *SignatureAttribute) SignatureIndex() uint16 {
+	return self.signatureIndex
+}
@@ -15,6 +15,9 @@ type SourceFileAttribute struct {
 func (self *SourceFileAttribute) readInfo(reader *ClassReader) {
 	self.sourceFileIndex = reader.readUint16()
 }
+func (self *SourceFileAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(self.sourceFileIndex)
+}
 
 func (self *SourceFileAttribute) FileName() string {
 	return self.cp.getUtf8(self.sourceFileIndex)
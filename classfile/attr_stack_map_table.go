@@ -0,0 +1,200 @@
+package classfile
+
+import "fmt"
+
+/*
+	StackMapTable_attribute {
+	    u2              attribute_name_index;
+	    u4              attribute_length;
+	    u2              number_of_entries;
+	    stack_map_frame entries[number_of_entries];
+	}
+*/
+type StackMapTableAttribute struct {
+	cp      ConstantPool
+	entries []*StackMapFrame
+}
+
+// verification_type_info 的 tag，jvms 4.7.4
+const (
+	ITEM_Top               = 0
+	ITEM_Integer           = 1
+	ITEM_Float             = 2
+	ITEM_Double            = 3
+	ITEM_Long              = 4
+	ITEM_Null              = 5
+	ITEM_UninitializedThis = 6
+	ITEM_Object            = 7
+	ITEM_Uninitialized     = 8
+)
+
+// stack_map_frame 的类型范围
+const (
+	SAME_FRAME                        = 0   // 0-63
+	SAME_LOCALS_1_STACK_ITEM          = 64  // 64-127
+	SAME_LOCALS_1_STACK_ITEM_EXTENDED = 247 // 247
+	CHOP_FRAME                        = 248 // 248-250
+	SAME_FRAME_EXTENDED               = 251 // 251
+	APPEND_FRAME                      = 252 // 252-254
+	FULL_FRAME                        = 255 // 255
+)
+
+/*
+	union stack_map_frame {
+	    same_frame;
+	    same_locals_1_stack_item_frame;
+	    same_locals_1_stack_item_frame_extended;
+	    chop_frame;
+	    same_frame_extended;
+	    append_frame;
+	    full_frame;
+	}
+*/
+// StackMapFrame 保存 class 文件里的原始帧，locals 和 stack 只包含帧里实际出现的类型，
+// 由验证器根据 frameType 和前一帧还原出完整的局部变量表
+type StackMapFrame struct {
+	frameType   uint8
+	offsetDelta uint16
+	locals      []*VerificationTypeInfo
+	stack       []*VerificationTypeInfo
+}
+
+/*
+	union verification_type_info {
+	    Top_variable_info;
+	    Integer_variable_info;
+	    Float_variable_info;
+	    Long_variable_info;
+	    Double_variable_info;
+	    Null_variable_info;
+	    UninitializedThis_variable_info;
+	    Object_variable_info;
+	    Uninitialized_variable_info;
+	}
+*/
+type VerificationTypeInfo struct {
+	tag       uint8
+	className string // ITEM_Object
+	offset    uint16 // ITEM_Uninitialized，new 指令的位置
+}
+
+func (smta *StackMapTableAttribute) readInfo(reader *ClassReader) {
+	numberOfEntries := reader.readUint16()
+	smta.entries = make([]*StackMapFrame, numberOfEntries)
+	for i := range smta.entries {
+		smta.entries[i] = readStackMapFrame(reader, smta.cp)
+	}
+}
+func (smta *StackMapTableAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(uint16(len(smta.entries)))
+	for _, frame := range smta.entries {
+		writeStackMapFrame(writer, frame)
+	}
+}
+
+func readStackMapFrame(reader *ClassReader, cp ConstantPool) *StackMapFrame {
+	frame := &StackMapFrame{frameType: reader.readUint8()}
+	switch t := frame.frameType; {
+	case t < SAME_LOCALS_1_STACK_ITEM:
+		frame.offsetDelta = uint16(t)
+	case t < 128:
+		frame.offsetDelta = uint16(t - SAME_LOCALS_1_STACK_ITEM)
+		frame.stack = readVerificationTypeInfos(reader, cp, 1)
+	case t < SAME_LOCALS_1_STACK_ITEM_EXTENDED:
+		panic(fmt.Errorf("invalid stack map frame type: %d", t))
+	case t == SAME_LOCALS_1_STACK_ITEM_EXTENDED:
+		frame.offsetDelta = reader.readUint16()
+		frame.stack = readVerificationTypeInfos(reader, cp, 1)
+	case t < SAME_FRAME_EXTENDED: // chop
+		frame.offsetDelta = reader.readUint16()
+	case t == SAME_FRAME_EXTENDED:
+		frame.offsetDelta = reader.readUint16()
+	case t < FULL_FRAME: // append
+		frame.offsetDelta = reader.readUint16()
+		frame.locals = readVerificationTypeInfos(reader, cp, int(t)-SAME_FRAME_EXTENDED)
+	default: // full_frame
+		frame.offsetDelta = reader.readUint16()
+		frame.locals = readVerificationTypeInfos(reader, cp, int(reader.readUint16()))
+		frame.stack = readVerificationTypeInfos(reader, cp, int(reader.readUint16()))
+	}
+	return frame
+}
+
+func readVerificationTypeInfos(reader *ClassReader, cp ConstantPool, n int) []*VerificationTypeInfo {
+	infos := make([]*VerificationTypeInfo, n)
+	for i := range infos {
+		info := &VerificationTypeInfo{tag: reader.readUint8()}
+		switch info.tag {
+		case ITEM_Top, ITEM_Integer, ITEM_Float, ITEM_Double, ITEM_Long, ITEM_Null, ITEM_UninitializedThis:
+		case ITEM_Object:
+			info.className = cp.getClassName(reader.readUint16())
+		case ITEM_Uninitialized:
+			info.offset = reader.readUint16()
+		default:
+			panic(fmt.Errorf("invalid verification type tag: %d", info.tag))
+		}
+		infos[i] = info
+	}
+	return infos
+}
+
+// writeStackMapFrame 和 readStackMapFrame 相反，帧的类型保持不变
+func writeStackMapFrame(writer *ClassWriter, frame *StackMapFrame) {
+	writer.writeUint8(frame.frameType)
+	switch t := frame.frameType; {
+	case t < SAME_LOCALS_1_STACK_ITEM:
+	case t < 128:
+		writeVerificationTypeInfos(writer, frame.stack)
+	case t == SAME_LOCALS_1_STACK_ITEM_EXTENDED:
+		writer.writeUint16(frame.offsetDelta)
+		writeVerificationTypeInfos(writer, frame.stack)
+	case t < FULL_FRAME: // chop, same_frame_extended, append
+		writer.writeUint16(frame.offsetDelta)
+		writeVerificationTypeInfos(writer, frame.locals)
+	default: // full_frame
+		writer.writeUint16(frame.offsetDelta)
+		writer.writeUint16(uint16(len(frame.locals)))
+		writeVerificationTypeInfos(writer, frame.locals)
+		writer.writeUint16(uint16(len(frame.stack)))
+		writeVerificationTypeInfos(writer, frame.stack)
+	}
+}
+
+func writeVerificationTypeInfos(writer *ClassWriter, infos []*VerificationTypeInfo) {
+	for _, info := range infos {
+		writer.writeUint8(info.tag)
+		switch info.tag {
+		case ITEM_Object:
+			writer.writeUint16(writer.classIndex(info.className))
+		case ITEM_Uninitialized:
+			writer.writeUint16(info.offset)
+		}
+	}
+}
+
+func (smta *StackMapTableAttribute) Entries() []*StackMapFrame {
+	return smta.entries
+}
+
+func (smf *StackMapFrame) FrameType() uint8 {
+	return smf.frameType
+}
+func (smf *StackMapFrame) OffsetDelta() uint16 {
+	return smf.offsetDelta
+}
+func (smf *StackMapFrame) Locals() []*VerificationTypeInfo {
+	return smf.locals
+}
+func (smf *StackMapFrame) Stack() []*VerificationTypeInfo {
+	return smf.stack
+}
+
+func (vti *VerificationTypeInfo) Tag() uint8 {
+	return vti.tag
+}
+func (vti *VerificationTypeInfo) ClassName() string {
+	return vti.className
+}
+func (vti *VerificationTypeInfo) Offset() uint16 {
+	return vti.offset
+}
@@ -0,0 +1,167 @@
+package classfile
+
+/*
+	RuntimeVisibleTypeAnnotations_attribute {
+	    u2              attribute_name_index;
+	    u4              attribute_length;
+	    u2              num_annotations;
+	    type_annotation annotations[num_annotations];
+	}
+
+RuntimeInvisibleTypeAnnotations_attribute 的结构相同
+*/
+type TypeAnnotationsAttribute struct {
+	cp          ConstantPool
+	visible     bool
+	annotations []*TypeAnnotation
+	info        []byte
+}
+
+func (taa *TypeAnnotationsAttribute) readInfo(reader *ClassReader) {
+	start := reader.data
+	numAnnotations := reader.readUint16()
+	taa.annotations = make([]*TypeAnnotation, numAnnotations)
+	for i := range taa.annotations {
+		taa.annotations[i] = readTypeAnnotation(reader, taa.cp)
+	}
+	taa.info = readBytesSince(start, reader)
+}
+func (taa *TypeAnnotationsAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeBytes(taa.info)
+}
+
+func (taa *TypeAnnotationsAttribute) Visible() bool {
+	return taa.visible
+}
+func (taa *TypeAnnotationsAttribute) Annotations() []*TypeAnnotation {
+	return taa.annotations
+}
+func (taa *TypeAnnotationsAttribute) Info() []byte {
+	return taa.info
+}
+
+/*
+	type_annotation {
+	    u1 target_type;
+	    union {
+	        type_parameter_target;
+	        supertype_target;
+	        type_parameter_bound_target;
+	        empty_target;
+	        formal_parameter_target;
+	        throws_target;
+	        localvar_target;
+	        catch_target;
+	        offset_target;
+	        type_argument_target;
+	    } target_info;
+	    type_path target_path;
+	    u2        type_index;
+	    u2        num_element_value_pairs;
+	    {   u2            element_name_index;
+	        element_value value;
+	    } element_value_pairs[num_element_value_pairs];
+	}
+*/
+type TypeAnnotation struct {
+	targetType uint8
+	targetInfo *TypeAnnotationTarget
+	targetPath []*TypePathEntry
+	*Annotation
+}
+
+// TypeAnnotationTarget target_info，只有 target_type 对应的字段有意义
+type TypeAnnotationTarget struct {
+	TypeParameterIndex   uint8                  // type_parameter_target, type_parameter_bound_target
+	BoundIndex           uint8                  // type_parameter_bound_target
+	SupertypeIndex       uint16                 // supertype_target，0xFFFF 表示父类，否则是接口的下标
+	FormalParameterIndex uint8                  // formal_parameter_target
+	ThrowsTypeIndex      uint16                 // throws_target
+	LocalVarTable        []*LocalVarTargetEntry // localvar_target
+	ExceptionTableIndex  uint16                 // catch_target
+	Offset               uint16                 // offset_target, type_argument_target
+	TypeArgumentIndex    uint8                  // type_argument_target
+}
+
+type LocalVarTargetEntry struct {
+	StartPc uint16
+	Length  uint16
+	Index   uint16
+}
+
+/*
+	type_path {
+	    u1 path_length;
+	    {   u1 type_path_kind;
+	        u1 type_argument_index;
+	    } path[path_length];
+	}
+*/
+type TypePathEntry struct {
+	TypePathKind      uint8
+	TypeArgumentIndex uint8
+}
+
+func readTypeAnnotation(reader *ClassReader, cp ConstantPool) *TypeAnnotation {
+	ta := &TypeAnnotation{targetType: reader.readUint8()}
+	ta.targetInfo = readTypeAnnotationTarget(reader, ta.targetType)
+	pathLength := reader.readUint8()
+	ta.targetPath = make([]*TypePathEntry, pathLength)
+	for i := range ta.targetPath {
+		ta.targetPath[i] = &TypePathEntry{
+			TypePathKind:      reader.readUint8(),
+			TypeArgumentIndex: reader.readUint8(),
+		}
+	}
+	ta.Annotation = readAnnotation(reader, cp)
+	return ta
+}
+
+// jvms 4.7.20.1
+func readTypeAnnotationTarget(reader *ClassReader, targetType uint8) *TypeAnnotationTarget {
+	target := &TypeAnnotationTarget{}
+	switch targetType {
+	case 0x00, 0x01: // type_parameter_target
+		target.TypeParameterIndex = reader.readUint8()
+	case 0x10: // supertype_target
+		target.SupertypeIndex = reader.readUint16()
+	case 0x11, 0x12: // type_parameter_bound_target
+		target.TypeParameterIndex = reader.readUint8()
+		target.BoundIndex = reader.readUint8()
+	case 0x13, 0x14, 0x15: // empty_target
+	case 0x16: // formal_parameter_target
+		target.FormalParameterIndex = reader.readUint8()
+	case 0x17: // throws_target
+		target.ThrowsTypeIndex = reader.readUint16()
+	case 0x40, 0x41: // localvar_target
+		tableLength := reader.readUint16()
+		target.LocalVarTable = make([]*LocalVarTargetEntry, tableLength)
+		for i := range target.LocalVarTable {
+			target.LocalVarTable[i] = &LocalVarTargetEntry{
+				StartPc: reader.readUint16(),
+				Length:  reader.readUint16(),
+				Index:   reader.readUint16(),
+			}
+		}
+	case 0x42: // catch_target
+		target.ExceptionTableIndex = reader.readUint16()
+	case 0x43, 0x44, 0x45, 0x46: // offset_target
+		target.Offset = reader.readUint16()
+	case 0x47, 0x48, 0x49, 0x4A, 0x4B: // type_argument_target
+		target.Offset = reader.readUint16()
+		target.TypeArgumentIndex = reader.readUint8()
+	default:
+		panic("java.lang.ClassFormatError: invalid type annotation target type")
+	}
+	return target
+}
+
+func (ta *TypeAnnotation) TargetType() uint8 {
+	return ta.targetType
+}
+func (ta *TypeAnnotation) TargetInfo() *TypeAnnotationTarget {
+	return ta.targetInfo
+}
+func (ta *TypeAnnotation) TargetPath() []*TypePathEntry {
+	return ta.targetPath
+}
@@ -16,6 +16,9 @@ type UnparsedAttribute struct {
 func (self *UnparsedAttribute) readInfo(reader *ClassReader) {
 	self.info = reader.readBytes(self.length)
 }
+func (self *UnparsedAttribute) writeInfo(writer *ClassWriter) {
+	writer.writeBytes(self.info)
+}
 
 func (self *UnparsedAttribute) Info() []byte {
 	return self.info
@@ -1,5 +1,9 @@
 package classfile
 
+import "encoding/binary"
+import "fmt"
+import "strings"
+
 var (
 	_attrDeprecated = &DeprecatedAttribute{}
 	_attrSynthetic  = &SyntheticAttribute{}
@@ -14,6 +18,7 @@ var (
 */
 type AttributeInfo interface {
 	readInfo(reader *ClassReader)
+	writeInfo(writer *ClassWriter)
 }
 
 func readAttributes(reader *ClassReader, cp ConstantPool) []AttributeInfo {
@@ -25,18 +30,131 @@ func readAttributes(reader *ClassReader, cp ConstantPool) []AttributeInfo {
 	return attributes
 }
 
+// readAttribute 每个属性从只包含 attribute_length 个字节的 ClassReader 里解析，
+// 内容比 attribute_length 长或者短都是格式错误，不会让后面的内容错位
 func readAttribute(reader *ClassReader, cp ConstantPool) AttributeInfo {
 	attrNameIndex := reader.readUint16()
 	attrName := cp.getUtf8(attrNameIndex)
 	attrLen := reader.readUint32()
+	if uint64(attrLen) > uint64(len(reader.data)) {
+		panic(fmt.Errorf("java.lang.ClassFormatError: truncated %s attribute", attrName))
+	}
+	attrReader := &ClassReader{reader.readBytes(attrLen)}
 	attrInfo := newAttributeInfo(attrName, attrLen, cp)
-	attrInfo.readInfo(reader)
+	readAttributeInfo(attrInfo, attrReader, attrName)
+	if len(attrReader.data) != 0 {
+		panic(fmt.Errorf("java.lang.ClassFormatError: %s attribute has %d extra bytes", attrName, len(attrReader.data)))
+	}
 	return attrInfo
 }
 
+func readAttributeInfo(attrInfo AttributeInfo, reader *ClassReader, attrName string) {
+	defer func() {
+		if r := recover(); r != nil {
+			if err, ok := r.(error); ok && strings.HasPrefix(err.Error(), "java.lang.") {
+				panic(r)
+			}
+			if s, ok := r.(string); ok && strings.HasPrefix(s, "java.lang.") {
+				panic(r)
+			}
+			// 读到了属性的末尾之外
+			panic(fmt.Errorf("java.lang.ClassFormatError: truncated %s attribute", attrName))
+		}
+	}()
+	attrInfo.readInfo(reader)
+}
+
+func writeAttributes(writer *ClassWriter, attributes []AttributeInfo) {
+	writer.writeUint16(uint16(len(attributes)))
+	for _, attrInfo := range attributes {
+		writeAttribute(writer, attrInfo)
+	}
+}
+
+// writeAttribute 先写出属性内容，再回填 attribute_length
+func writeAttribute(writer *ClassWriter, attrInfo AttributeInfo) {
+	writer.writeUint16(writer.utf8Index(AttributeName(attrInfo)))
+	lengthPos := len(writer.data)
+	writer.writeUint32(0)
+	attrInfo.writeInfo(writer)
+	binary.BigEndian.PutUint32(writer.data[lengthPos:], uint32(len(writer.data)-lengthPos-4))
+}
+
+// AttributeName 和 newAttributeInfo 相反，返回属性的名字
+func AttributeName(attrInfo AttributeInfo) string {
+	switch attr := attrInfo.(type) {
+	case *AnnotationDefaultAttribute:
+		return "AnnotationDefault"
+	case *BootstrapMethodsAttribute:
+		return "BootstrapMethods"
+	case *CodeAttribute:
+		return "Code"
+	case *ConstantValueAttribute:
+		return "ConstantValue"
+	case *DeprecatedAttribute:
+		return "Deprecated"
+	case *EnclosingMethodAttribute:
+		return "EnclosingMethod"
+	case *ExceptionsAttribute:
+		return "Exceptions"
+	case *InnerClassesAttribute:
+		return "InnerClasses"
+	case *LineNumberTableAttribute:
+		return "LineNumberTable"
+	case *LocalVariableTableAttribute:
+		return "LocalVariableTable"
+	case *LocalVariableTypeTableAttribute:
+		return "LocalVariableTypeTable"
+	case *MethodParametersAttribute:
+		return "MethodParameters"
+	case *ModuleAttribute:
+		return "Module"
+	case *ModuleMainClassAttribute:
+		return "ModuleMainClass"
+	case *ModulePackagesAttribute:
+		return "ModulePackages"
+	case *NestHostAttribute:
+		return "NestHost"
+	case *NestMembersAttribute:
+		return "NestMembers"
+	case *PermittedSubclassesAttribute:
+		return "PermittedSubclasses"
+	case *RecordAttribute:
+		return "Record"
+	case *AnnotationsAttribute:
+		if attr.visible {
+			return "RuntimeVisibleAnnotations"
+		}
+		return "RuntimeInvisibleAnnotations"
+	case *ParameterAnnotationsAttribute:
+		if attr.visible {
+			return "RuntimeVisibleParameterAnnotations"
+		}
+		return "RuntimeInvisibleParameterAnnotations"
+	case *TypeAnnotationsAttribute:
+		if attr.visible {
+			return "RuntimeVisibleTypeAnnotations"
+		}
+		return "RuntimeInvisibleTypeAnnotations"
+	case *SignatureAttribute:
+		return "Signature"
+	case *SourceFileAttribute:
+		return "SourceFile"
+	case *StackMapTableAttribute:
+		return "StackMapTable"
+	case *SyntheticAttribute:
+		return "Synthetic"
+	case *UnparsedAttribute:
+		return attr.name
+	default:
+		panic(fmt.Errorf("unknown attribute type %T", attrInfo))
+	}
+}
+
 func newAttributeInfo(attrName string, attrLen uint32, cp ConstantPool) AttributeInfo {
 	switch attrName {
-	// case "AnnotationDefault":
+	case "AnnotationDefault":
+		return &AnnotationDefaultAttribute{cp: cp}
 	case "BootstrapMethods":
 		return &BootstrapMethodsAttribute{}
 	case "Code":
@@ -57,19 +175,41 @@ func newAttributeInfo(attrName string, attrLen uint32, cp ConstantPool) Attribut
 		return &LocalVariableTableAttribute{}
 	case "LocalVariableTypeTable":
 		return &LocalVariableTypeTableAttribute{}
-	// case "MethodParameters":
-	// case "RuntimeInvisibleAnnotations":
-	// case "RuntimeInvisibleParameterAnnotations":
-	// case "RuntimeInvisibleTypeAnnotations":
-	// case "RuntimeVisibleAnnotations":
-	// case "RuntimeVisibleParameterAnnotations":
-	// case "RuntimeVisibleTypeAnnotations":
+	case "MethodParameters":
+		return &MethodParametersAttribute{cp: cp}
+	case "Module":
+		return &ModuleAttribute{cp: cp}
+	case "ModuleMainClass":
+		return &ModuleMainClassAttribute{cp: cp}
+	case "ModulePackages":
+		return &ModulePackagesAttribute{cp: cp}
+	case "NestHost":
+		return &NestHostAttribute{cp: cp}
+	case "NestMembers":
+		return &NestMembersAttribute{cp: cp}
+	case "PermittedSubclasses":
+		return &PermittedSubclassesAttribute{cp: cp}
+	case "Record":
+		return &RecordAttribute{cp: cp}
+	case "RuntimeInvisibleAnnotations":
+		return &AnnotationsAttribute{cp: cp}
+	case "RuntimeInvisibleParameterAnnotations":
+		return &ParameterAnnotationsAttribute{cp: cp}
+	case "RuntimeInvisibleTypeAnnotations":
+		return &TypeAnnotationsAttribute{cp: cp}
+	case "RuntimeVisibleAnnotations":
+		return &AnnotationsAttribute{cp: cp, visible: true}
+	case "RuntimeVisibleParameterAnnotations":
+		return &ParameterAnnotationsAttribute{cp: cp, visible: true}
+	case "RuntimeVisibleTypeAnnotations":
+		return &TypeAnnotationsAttribute{cp: cp, visible: true}
 	case "Signature":
 		return &SignatureAttribute{cp: cp}
 	case "SourceFile":
 		return &SourceFileAttribute{cp: cp}
 	// case "SourceDebugExtension":
-	// case "StackMapTable":
+	case "StackMapTable":
+		return &StackMapTableAttribute{cp: cp}
 	case "Synthetic":
 		return _attrSynthetic
 	default:
@@ -0,0 +1,401 @@
+package classfile
+
+import "fmt"
+import "math"
+
+// ClassBuilder 构造新的类文件，或者在已有类文件的基础上修改。
+// 常量池只追加常量，已有常量的索引保持不变，所以从 class 文件里读出来的属性可以原样写回去。
+// 添加常量的方法都返回常量池索引，相同的常量只添加一次
+type ClassBuilder struct {
+	cf     *ClassFile
+	consts map[string]uint16 // constantKey -> 常量池索引
+}
+
+// NewClassBuilder 创建一个空类，superName 为空表示没有父类（只有 java/lang/Object 这样）
+func NewClassBuilder(majorVersion, accessFlags uint16, name, superName string, interfaces ...string) *ClassBuilder {
+	cb := &ClassBuilder{
+		cf:     &ClassFile{majorVersion: majorVersion, accessFlags: accessFlags, constantPool: ConstantPool{nil}},
+		consts: map[string]uint16{},
+	}
+	cb.cf.thisClass = cb.Class(name)
+	if superName != "" {
+		cb.cf.superClass = cb.Class(superName)
+	}
+	for _, iface := range interfaces {
+		cb.AddInterface(iface)
+	}
+	return cb
+}
+
+// NewClassBuilderFrom 在已有类文件的基础上修改，cf 本身不会被修改
+func NewClassBuilderFrom(cf *ClassFile) *ClassBuilder {
+	cb := &ClassBuilder{
+		cf: &ClassFile{
+			minorVersion: cf.minorVersion,
+			majorVersion: cf.majorVersion,
+			constantPool: append(ConstantPool(nil), cf.constantPool...),
+			accessFlags:  cf.accessFlags,
+			thisClass:    cf.thisClass,
+			superClass:   cf.superClass,
+			interfaces:   append([]uint16(nil), cf.interfaces...),
+			fields:       copyMembers(cf.fields),
+			methods:      copyMembers(cf.methods),
+			attributes:   append([]AttributeInfo(nil), cf.attributes...),
+		},
+		consts: map[string]uint16{},
+	}
+	for i, c := range cb.cf.constantPool {
+		if c != nil {
+			if _, ok := cb.consts[constantKey(c)]; !ok {
+				cb.consts[constantKey(c)] = uint16(i)
+			}
+		}
+	}
+	return cb
+}
+
+func copyMembers(members []*MemberInfo) []*MemberInfo {
+	copied := make([]*MemberInfo, len(members))
+	for i, member := range members {
+		m := *member
+		m.attributes = append([]AttributeInfo(nil), member.attributes...)
+		copied[i] = &m
+	}
+	return copied
+}
+
+// Bytes 返回 class 文件的内容
+func (cb *ClassBuilder) Bytes() []byte {
+	return cb.cf.Bytes()
+}
+
+// Build 把构造好的类编码以后重新解析，返回的 ClassFile 和用 Parse 读到的一样
+func (cb *ClassBuilder) Build() (*ClassFile, error) {
+	return Parse(cb.Bytes())
+}
+
+func (cb *ClassBuilder) SetVersion(majorVersion, minorVersion uint16) {
+	cb.cf.majorVersion = majorVersion
+	cb.cf.minorVersion = minorVersion
+}
+func (cb *ClassBuilder) SetAccessFlags(accessFlags uint16) {
+	cb.cf.accessFlags = accessFlags
+}
+func (cb *ClassBuilder) SetSuperClass(name string) {
+	cb.cf.superClass = cb.Class(name)
+}
+func (cb *ClassBuilder) AddInterface(name string) {
+	cb.cf.interfaces = append(cb.cf.interfaces, cb.Class(name))
+}
+
+// 常量
+
+// constantKey 相同的常量有相同的键，引用其他常量的常量只比较索引
+func constantKey(c ConstantInfo) string {
+	switch x := c.(type) {
+	case *ConstantUtf8Info:
+		return fmt.Sprint(CONSTANT_Utf8, ":", x.str)
+	case *ConstantIntegerInfo:
+		return fmt.Sprint(CONSTANT_Integer, ":", x.val)
+	case *ConstantFloatInfo: // 比较位模式，NaN 和 -0.0 也能区分
+		return fmt.Sprint(CONSTANT_Float, ":", math.Float32bits(x.val))
+	case *ConstantLongInfo:
+		return fmt.Sprint(CONSTANT_Long, ":", x.val)
+	case *ConstantDoubleInfo:
+		return fmt.Sprint(CONSTANT_Double, ":", math.Float64bits(x.val))
+	case *ConstantClassInfo:
+		return fmt.Sprint(CONSTANT_Class, ":", x.nameIndex)
+	case *ConstantStringInfo:
+		return fmt.Sprint(CONSTANT_String, ":", x.stringIndex)
+	case *ConstantFieldrefInfo:
+		return fmt.Sprint(CONSTANT_Fieldref, ":", x.classIndex, ":", x.nameAndTypeIndex)
+	case *ConstantMethodrefInfo:
+		return fmt.Sprint(CONSTANT_Methodref, ":", x.classIndex, ":", x.nameAndTypeIndex)
+	case *ConstantInterfaceMethodrefInfo:
+		return fmt.Sprint(CONSTANT_InterfaceMethodref, ":", x.classIndex, ":", x.nameAndTypeIndex)
+	case *ConstantNameAndTypeInfo:
+		return fmt.Sprint(CONSTANT_NameAndType, ":", x.nameIndex, ":", x.descriptorIndex)
+	case *ConstantMethodHandleInfo:
+		return fmt.Sprint(CONSTANT_MethodHandle, ":", x.referenceKind, ":", x.referenceIndex)
+	case *ConstantMethodTypeInfo:
+		return fmt.Sprint(CONSTANT_MethodType, ":", x.descriptorIndex)
+	case *ConstantInvokeDynamicInfo:
+		return fmt.Sprint(CONSTANT_InvokeDynamic, ":", x.bootstrapMethodAttrIndex, ":", x.nameAndTypeIndex)
+	case *ConstantModuleInfo:
+		return fmt.Sprint(CONSTANT_Module, ":", x.nameIndex)
+	case *ConstantPackageInfo:
+		return fmt.Sprint(CONSTANT_Package, ":", x.nameIndex)
+	default:
+		panic(fmt.Errorf("unknown constant type %T", c))
+	}
+}
+
+func (cb *ClassBuilder) addConstant(c ConstantInfo) uint16 {
+	key := constantKey(c)
+	if index, ok := cb.consts[key]; ok {
+		return index
+	}
+	cp := cb.cf.constantPool
+	width := 1
+	switch c.(type) {
+	case *ConstantLongInfo, *ConstantDoubleInfo:
+		width = 2 // 占两个索引
+	}
+	if len(cp)+width > math.MaxUint16 {
+		panic("java.lang.ClassFormatError: too many constants")
+	}
+	index := uint16(len(cp))
+	cp = append(cp, c)
+	if width == 2 {
+		cp = append(cp, nil)
+	}
+	cb.cf.constantPool = cp
+	cb.consts[key] = index
+	return index
+}
+
+func (cb *ClassBuilder) Utf8(s string) uint16 {
+	return cb.addConstant(&ConstantUtf8Info{str: s})
+}
+func (cb *ClassBuilder) Integer(val int32) uint16 {
+	return cb.addConstant(&ConstantIntegerInfo{val: val})
+}
+func (cb *ClassBuilder) Float(val float32) uint16 {
+	return cb.addConstant(&ConstantFloatInfo{val: val})
+}
+func (cb *ClassBuilder) Long(val int64) uint16 {
+	return cb.addConstant(&ConstantLongInfo{val: val})
+}
+func (cb *ClassBuilder) Double(val float64) uint16 {
+	return cb.addConstant(&ConstantDoubleInfo{val: val})
+}
+
+// Class 类名是内部形式，例如 java/lang/Object 和 [I
+func (cb *ClassBuilder) Class(name string) uint16 {
+	nameIndex := cb.Utf8(name)
+	return cb.addConstant(&ConstantClassInfo{cp: cb.cf.constantPool, nameIndex: nameIndex})
+}
+func (cb *ClassBuilder) String(s string) uint16 {
+	stringIndex := cb.Utf8(s)
+	return cb.addConstant(&ConstantStringInfo{cp: cb.cf.constantPool, stringIndex: stringIndex})
+}
+func (cb *ClassBuilder) NameAndType(name, descriptor string) uint16 {
+	nameIndex := cb.Utf8(name)
+	descriptorIndex := cb.Utf8(descriptor)
+	return cb.addConstant(&ConstantNameAndTypeInfo{nameIndex: nameIndex, descriptorIndex: descriptorIndex})
+}
+func (cb *ClassBuilder) Fieldref(className, name, descriptor string) uint16 {
+	return cb.addConstant(&ConstantFieldrefInfo{cb.memberref(className, name, descriptor)})
+}
+func (cb *ClassBuilder) Methodref(className, name, descriptor string) uint16 {
+	return cb.addConstant(&ConstantMethodrefInfo{cb.memberref(className, name, descriptor)})
+}
+func (cb *ClassBuilder) InterfaceMethodref(className, name, descriptor string) uint16 {
+	return cb.addConstant(&ConstantInterfaceMethodrefInfo{cb.memberref(className, name, descriptor)})
+}
+func (cb *ClassBuilder) memberref(className, name, descriptor string) ConstantMemberrefInfo {
+	classIndex := cb.Class(className)
+	nameAndTypeIndex := cb.NameAndType(name, descriptor)
+	return ConstantMemberrefInfo{cp: cb.cf.constantPool, classIndex: classIndex, nameAndTypeIndex: nameAndTypeIndex}
+}
+
+// MethodHandle referenceIndex 指向字段或方法的符号引用，referenceKind 见 jvms 5.4.3.5
+func (cb *ClassBuilder) MethodHandle(referenceKind uint8, referenceIndex uint16) uint16 {
+	return cb.addConstant(&ConstantMethodHandleInfo{referenceKind: referenceKind, referenceIndex: referenceIndex})
+}
+func (cb *ClassBuilder) MethodType(descriptor string) uint16 {
+	descriptorIndex := cb.Utf8(descriptor)
+	return cb.addConstant(&ConstantMethodTypeInfo{cp: cb.cf.constantPool, descriptorIndex: descriptorIndex})
+}
+
+// InvokeDynamic bootstrapMethodIndex 是 BootstrapMethod 返回的引导方法下标
+func (cb *ClassBuilder) InvokeDynamic(bootstrapMethodIndex uint16, name, descriptor string) uint16 {
+	nameAndTypeIndex := cb.NameAndType(name, descriptor)
+	return cb.addConstant(&ConstantInvokeDynamicInfo{
+		cp:                       cb.cf.constantPool,
+		bootstrapMethodAttrIndex: bootstrapMethodIndex,
+		nameAndTypeIndex:         nameAndTypeIndex,
+	})
+}
+func (cb *ClassBuilder) Module(name string) uint16 {
+	nameIndex := cb.Utf8(name)
+	return cb.addConstant(&ConstantModuleInfo{cp: cb.cf.constantPool, nameIndex: nameIndex})
+}
+func (cb *ClassBuilder) Package(name string) uint16 {
+	nameIndex := cb.Utf8(name)
+	return cb.addConstant(&ConstantPackageInfo{cp: cb.cf.constantPool, nameIndex: nameIndex})
+}
+
+// BootstrapMethod 在 BootstrapMethods 属性里添加一个引导方法，返回它的下标。
+// methodHandleIndex 是 MethodHandle 常量，arguments 是静态参数的常量池索引
+func (cb *ClassBuilder) BootstrapMethod(methodHandleIndex uint16, arguments ...uint16) uint16 {
+	bmAttr := &BootstrapMethodsAttribute{}
+	if oldAttr := cb.cf.BootstrapMethodsAttribute(); oldAttr != nil {
+		// 不修改原来的属性，它可能属于另一个 ClassFile
+		bmAttr.bootstrapMethods = append(bmAttr.bootstrapMethods, oldAttr.bootstrapMethods...)
+	}
+	bmAttr.bootstrapMethods = append(bmAttr.bootstrapMethods, &BootstrapMethod{
+		bootstrapMethodRef: methodHandleIndex,
+		bootstrapArguments: append([]uint16(nil), arguments...),
+	})
+	cb.SetAttribute(bmAttr)
+	return uint16(len(bmAttr.bootstrapMethods) - 1)
+}
+
+// 字段和方法
+
+func (cb *ClassBuilder) AddField(accessFlags uint16, name, descriptor string, attributes ...AttributeInfo) *MemberInfo {
+	field := cb.newMember(accessFlags, name, descriptor, attributes)
+	cb.cf.fields = append(cb.cf.fields, field)
+	return field
+}
+
+// AddMethod 添加方法，字节码用 SetCode 设置
+func (cb *ClassBuilder) AddMethod(accessFlags uint16, name, descriptor string, attributes ...AttributeInfo) *MemberInfo {
+	method := cb.newMember(accessFlags, name, descriptor, attributes)
+	cb.cf.methods = append(cb.cf.methods, method)
+	return method
+}
+
+func (cb *ClassBuilder) newMember(accessFlags uint16, name, descriptor string, attributes []AttributeInfo) *MemberInfo {
+	nameIndex := cb.Utf8(name)
+	descriptorIndex := cb.Utf8(descriptor)
+	cb.addAttributeNames(attributes)
+	return &MemberInfo{
+		cp:              cb.cf.constantPool,
+		accessFlags:     accessFlags,
+		nameIndex:       nameIndex,
+		descriptorIndex: descriptorIndex,
+		attributes:      append([]AttributeInfo(nil), attributes...),
+	}
+}
+
+// Field 查找字段，找不到时返回 nil
+func (cb *ClassBuilder) Field(name, descriptor string) *MemberInfo {
+	return findMember(cb.cf.fields, name, descriptor)
+}
+
+// Method 查找方法，找不到时返回 nil
+func (cb *ClassBuilder) Method(name, descriptor string) *MemberInfo {
+	return findMember(cb.cf.methods, name, descriptor)
+}
+
+func findMember(members []*MemberInfo, name, descriptor string) *MemberInfo {
+	for _, member := range members {
+		if member.Name() == name && member.Descriptor() == descriptor {
+			return member
+		}
+	}
+	return nil
+}
+
+func (cb *ClassBuilder) RemoveField(name, descriptor string) bool {
+	return removeMember(&cb.cf.fields, name, descriptor)
+}
+func (cb *ClassBuilder) RemoveMethod(name, descriptor string) bool {
+	return removeMember(&cb.cf.methods, name, descriptor)
+}
+
+func removeMember(members *[]*MemberInfo, name, descriptor string) bool {
+	for i, member := range *members {
+		if member.Name() == name && member.Descriptor() == descriptor {
+			*members = append((*members)[:i:i], (*members)[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (cb *ClassBuilder) SetMemberAccessFlags(member *MemberInfo, accessFlags uint16) {
+	member.accessFlags = accessFlags
+}
+
+// 属性
+
+// SetAttribute 设置类的属性，已经有同名属性时替换它
+func (cb *ClassBuilder) SetAttribute(attrInfo AttributeInfo) {
+	cb.cf.attributes = cb.setAttribute(cb.cf.attributes, attrInfo)
+}
+
+// RemoveAttribute 删除类的同名属性
+func (cb *ClassBuilder) RemoveAttribute(name string) {
+	cb.cf.attributes = removeAttribute(cb.cf.attributes, name)
+}
+
+// SetMemberAttribute 设置字段或者方法的属性，已经有同名属性时替换它
+func (cb *ClassBuilder) SetMemberAttribute(member *MemberInfo, attrInfo AttributeInfo) {
+	member.attributes = cb.setAttribute(member.attributes, attrInfo)
+}
+
+func (cb *ClassBuilder) RemoveMemberAttribute(member *MemberInfo, name string) {
+	member.attributes = removeAttribute(member.attributes, name)
+}
+
+func (cb *ClassBuilder) setAttribute(attributes []AttributeInfo, attrInfo AttributeInfo) []AttributeInfo {
+	cb.addAttributeNames([]AttributeInfo{attrInfo})
+	name := AttributeName(attrInfo)
+	for i, attr := range attributes {
+		if AttributeName(attr) == name {
+			attributes[i] = attrInfo
+			return attributes
+		}
+	}
+	return append(attributes, attrInfo)
+}
+
+func removeAttribute(attributes []AttributeInfo, name string) []AttributeInfo {
+	kept := attributes[:0:0]
+	for _, attr := range attributes {
+		if AttributeName(attr) != name {
+			kept = append(kept, attr)
+		}
+	}
+	return kept
+}
+
+// addAttributeNames 写出属性时要用到属性名的常量池索引，包括嵌套在 Code 和 Record 属性里的属性
+func (cb *ClassBuilder) addAttributeNames(attributes []AttributeInfo) {
+	for _, attrInfo := range attributes {
+		cb.Utf8(AttributeName(attrInfo))
+		switch attr := attrInfo.(type) {
+		case *CodeAttribute:
+			cb.addAttributeNames(attr.attributes)
+		case *RecordAttribute:
+			for _, component := range attr.components {
+				cb.addAttributeNames(component.attributes)
+			}
+		}
+	}
+}
+
+func (cb *ClassBuilder) SourceFile(fileName string) *SourceFileAttribute {
+	return &SourceFileAttribute{cp: cb.cf.constantPool, sourceFileIndex: cb.Utf8(fileName)}
+}
+func (cb *ClassBuilder) Signature(signature string) *SignatureAttribute {
+	return &SignatureAttribute{cp: cb.cf.constantPool, signatureIndex: cb.Utf8(signature)}
+}
+
+// ConstantValue constantValueIndex 是 Integer、Float、Long、Double 或 String 常量
+func (cb *ClassBuilder) ConstantValue(constantValueIndex uint16) *ConstantValueAttribute {
+	return &ConstantValueAttribute{constantValueIndex: constantValueIndex}
+}
+func (cb *ClassBuilder) Exceptions(classNames ...string) *ExceptionsAttribute {
+	attr := &ExceptionsAttribute{exceptionIndexTable: make([]uint16, len(classNames))}
+	for i, className := range classNames {
+		attr.exceptionIndexTable[i] = cb.Class(className)
+	}
+	return attr
+}
+
+// Attribute 其他属性，info 是 attribute_length 后面的内容，引用的常量由调用者添加。
+// 重新解析以后，虚拟机认识的属性会被解析成对应的类型
+func (cb *ClassBuilder) Attribute(name string, info []byte) *UnparsedAttribute {
+	return &UnparsedAttribute{name: name, length: uint32(len(info)), info: info}
+}
+
+// SetCode 设置方法的 Code 属性，没有用 SetMaxStack、SetMaxLocals 指定时，
+// max_stack 和 max_locals 根据字节码和方法描述符计算
+func (cb *ClassBuilder) SetCode(method *MemberInfo, code *CodeBuilder) {
+	isStatic := method.accessFlags&0x0008 != 0 // ACC_STATIC
+	cb.SetMemberAttribute(method, code.attribute(isStatic, method.Descriptor()))
+}
@@ -0,0 +1,85 @@
+package classfile
+
+import (
+	"bytes"
+	"testing"
+)
+
+// newTestClass 构造一个用到各种常量、属性和指令形式的类
+func newTestClass() []byte {
+	cb := NewClassBuilder(52, 0x0021, "test/RoundTrip", "java/lang/Object", "java/lang/Runnable")
+	cb.SetAttribute(cb.SourceFile("RoundTrip.java"))
+	cb.SetAttribute(cb.Signature("Ljava/lang/Object;Ljava/lang/Runnable;"))
+	cb.SetAttribute(cb.Attribute("Custom", []byte{1, 2, 3}))
+
+	cb.AddField(0x001a, "L", "J", cb.ConstantValue(cb.Long(1<<40)))
+	cb.AddField(0x001a, "D", "D", cb.ConstantValue(cb.Double(0.5)))
+	cb.AddField(0x0002, "s", "Ljava/lang/String;")
+
+	init := cb.AddMethod(0x0001, "<init>", "()V")
+	code := cb.NewCode()
+	code.Load('L', 0)
+	code.Invoke(0xb7, "java/lang/Object", "<init>", "()V") // invokespecial
+	code.Op(0xb1)                                          // return
+	cb.SetCode(init, code)
+
+	run := cb.AddMethod(0x0001, "run", "()V", cb.Exceptions("java/lang/Exception"))
+	code = cb.NewCode()
+	start, end, handler := code.NewLabel(), code.NewLabel(), code.NewLabel()
+	l1, l2, dflt := code.NewLabel(), code.NewLabel(), code.NewLabel()
+	code.LineNumber(10)
+	code.Mark(start)
+	code.Int(100000)
+	code.TableSwitch(dflt, 1, l1, l2)
+	code.Mark(l1)
+	code.Ldc(cb.String("one"))
+	code.Load('L', 0)
+	code.Op(0x5f) // swap
+	code.Field(0xb5, "test/RoundTrip", "s", "Ljava/lang/String;")
+	code.Mark(l2)
+	code.Int(-7)
+	code.LookupSwitch(dflt, []int32{300, -2}, []*Label{l1, end})
+	code.Mark(end)
+	code.Op(0xb1) // return
+	code.Mark(dflt)
+	code.Ldc(cb.Long(3))
+	code.Op(0x58) // pop2
+	code.Op(0xb1) // return
+	code.Mark(handler)
+	code.LineNumber(20)
+	code.Store('L', 300)
+	code.Iinc(1, 1000)
+	code.Op(0x01) // aconst_null
+	code.Op(0xbf) // athrow
+	code.TryCatch(start, end, handler, "java/lang/Exception")
+	cb.SetCode(run, code)
+
+	main := cb.AddMethod(0x0009, "main", "([Ljava/lang/String;)V")
+	code = cb.NewCode()
+	bsm := cb.BootstrapMethod(cb.MethodHandle(6, cb.Methodref("test/RoundTrip", "bsm",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/CallSite;")),
+		cb.MethodType("()V"))
+	code.InvokeDynamic(bsm, "run", "()Ljava/lang/Runnable;")
+	code.Invoke(0xb9, "java/lang/Runnable", "run", "()V") // invokeinterface
+	code.Op(0xb1)                                         // return
+	cb.SetCode(main, code)
+
+	return cb.Bytes()
+}
+
+func TestClassFileRoundTrip(t *testing.T) {
+	data := newTestClass()
+	cf, err := Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if cf.ClassName() != "test/RoundTrip" {
+		t.Errorf("class name = %s", cf.ClassName())
+	}
+	if got := cf.Bytes(); !bytes.Equal(got, data) {
+		t.Errorf("Parse(data).Bytes() differs from data: %d bytes vs %d bytes", len(got), len(data))
+	}
+	if got := NewClassBuilderFrom(cf).Bytes(); !bytes.Equal(got, data) {
+		t.Errorf("NewClassBuilderFrom(cf).Bytes() differs from data: %d bytes vs %d bytes", len(got), len(data))
+	}
+}
@@ -79,6 +79,28 @@ func (cf *ClassFile) read(reader *ClassReader) {
 	cf.attributes = readAttributes(reader, cf.constantPool)
 }
 
+// Bytes 把类文件编码成 class 文件格式，是 Parse 的逆操作
+func (cf *ClassFile) Bytes() []byte {
+	writer := newClassWriter(cf.constantPool)
+	cf.write(writer)
+	return writer.data
+}
+
+// write 按 read 的顺序写出 ClassFile 结构体中的所有信息
+func (cf *ClassFile) write(writer *ClassWriter) {
+	writer.writeUint32(0xCAFEBABE)
+	writer.writeUint16(cf.minorVersion)
+	writer.writeUint16(cf.majorVersion)
+	writeConstantPool(writer, cf.constantPool)
+	writer.writeUint16(cf.accessFlags)
+	writer.writeUint16(cf.thisClass)
+	writer.writeUint16(cf.superClass)
+	writer.writeUint16s(cf.interfaces)
+	writeMembers(writer, cf.fields)
+	writeMembers(writer, cf.methods)
+	writeAttributes(writer, cf.attributes)
+}
+
 func (cf *ClassFile) readAndCheckMagic(reader *ClassReader) {
 	magic := reader.readUint32()
 	if magic != 0xCAFEBABE {
@@ -96,6 +118,13 @@ func (cf *ClassFile) readAndCheckVersion(reader *ClassReader) {
 		if cf.minorVersion == 0 {
 			return
 		}
+	default:
+		// Java 9 到 Java 21。这些版本新增的属性里 Module、ModulePackages、ModuleMainClass、
+		// NestHost、NestMembers、Record 和 PermittedSubclasses 会被解析，其他的当作未知属性跳过；
+		// 常量池里的 CONSTANT_Dynamic 不支持。预览特性（minor_version 0xFFFF）也不支持
+		if cf.majorVersion > 52 && cf.majorVersion <= 65 && cf.minorVersion == 0 {
+			return
+		}
 	}
 
 	panic("java.lang.UnsupportedClassVersionError!")
@@ -156,3 +185,121 @@ func (cf *ClassFile) SourceFileAttribute() *SourceFileAttribute {
 	}
 	return nil
 }
+
+func (cf *ClassFile) NestHostAttribute() *NestHostAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*NestHostAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) NestMembersAttribute() *NestMembersAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*NestMembersAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) PermittedSubclassesAttribute() *PermittedSubclassesAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*PermittedSubclassesAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) RecordAttribute() *RecordAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*RecordAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+// 下面三个属性只出现在 module-info.class 里
+
+func (cf *ClassFile) ModuleAttribute() *ModuleAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*ModuleAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) ModulePackagesAttribute() *ModulePackagesAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*ModulePackagesAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) ModuleMainClassAttribute() *ModuleMainClassAttribute {
+	for _, attrInfo := range cf.attributes {
+		if attr, ok := attrInfo.(*ModuleMainClassAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) RuntimeVisibleAnnotationsAttribute() *AnnotationsAttribute {
+	return findAnnotationsAttribute(cf.attributes, true)
+}
+func (cf *ClassFile) RuntimeInvisibleAnnotationsAttribute() *AnnotationsAttribute {
+	return findAnnotationsAttribute(cf.attributes, false)
+}
+func (cf *ClassFile) RuntimeVisibleTypeAnnotationsAttribute() *TypeAnnotationsAttribute {
+	return findTypeAnnotationsAttribute(cf.attributes, true)
+}
+func (cf *ClassFile) RuntimeInvisibleTypeAnnotationsAttribute() *TypeAnnotationsAttribute {
+	return findTypeAnnotationsAttribute(cf.attributes, false)
+}
+
+// RuntimeVisibleAnnotationsAttributeData 返回属性的原始内容，由 Java 代码解析
+func (cf *ClassFile) RuntimeVisibleAnnotationsAttributeData() []byte {
+	if attr := cf.RuntimeVisibleAnnotationsAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+func (cf *ClassFile) RuntimeVisibleTypeAnnotationsAttributeData() []byte {
+	if attr := cf.RuntimeVisibleTypeAnnotationsAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+
+func (cf *ClassFile) BootstrapMethodsAttribute() *BootstrapMethodsAttribute {
+	for _, attrInfo := range cf.attributes {
+		switch attrInfo.(type) {
+		case *BootstrapMethodsAttribute:
+			return attrInfo.(*BootstrapMethodsAttribute)
+		}
+	}
+	return nil
+}
+
+func (cf *ClassFile) ThisClass() uint16 {
+	return cf.thisClass
+}
+
+func (cf *ClassFile) SuperClass() uint16 {
+	return cf.superClass
+}
+
+func (cf *ClassFile) Interfaces() []uint16 {
+	return cf.interfaces
+}
+
+func (cf *ClassFile) Attributes() []AttributeInfo {
+	return cf.attributes
+}
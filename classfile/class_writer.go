@@ -0,0 +1,87 @@
+package classfile
+
+import "encoding/binary"
+import "fmt"
+
+// ClassWriter 和 ClassReader 相反，把类文件的各个部分依次追加到 data 后面。
+// 属性名和 StackMapTable 里的类名要写成常量池索引，所以 ClassWriter 需要知道常量池
+type ClassWriter struct {
+	data         []byte
+	cp           ConstantPool
+	utf8Indexes  map[string]uint16 // 第一次用到时建立
+	classIndexes map[string]uint16
+}
+
+func newClassWriter(cp ConstantPool) *ClassWriter {
+	return &ClassWriter{cp: cp}
+}
+
+// writeUint8 写入一个 uint8 类型的数据。
+func (cw *ClassWriter) writeUint8(val uint8) {
+	cw.data = append(cw.data, val)
+}
+
+// writeUint16 写入一个 uint16 (u2) 类型的数据，使用 BigEndian 字节序。
+func (cw *ClassWriter) writeUint16(val uint16) {
+	cw.data = binary.BigEndian.AppendUint16(cw.data, val)
+}
+
+// writeUint32 写入一个 uint32 (u4) 类型的数据。
+func (cw *ClassWriter) writeUint32(val uint32) {
+	cw.data = binary.BigEndian.AppendUint32(cw.data, val)
+}
+
+// writeUint64 写入一个 uint64 类型的数据。
+func (cw *ClassWriter) writeUint64(val uint64) {
+	cw.data = binary.BigEndian.AppendUint64(cw.data, val)
+}
+
+// writeUint16s 先写入数组长度 (u2)，再写入每个元素。
+func (cw *ClassWriter) writeUint16s(s []uint16) {
+	cw.writeUint16(uint16(len(s)))
+	for _, val := range s {
+		cw.writeUint16(val)
+	}
+}
+
+// writeBytes 写入字节数组，不写长度。
+func (cw *ClassWriter) writeBytes(bytes []byte) {
+	cw.data = append(cw.data, bytes...)
+}
+
+// utf8Index 返回常量池里字符串 s 的 CONSTANT_Utf8_info 的索引，找不到时 panic
+func (cw *ClassWriter) utf8Index(s string) uint16 {
+	if cw.utf8Indexes == nil {
+		cw.utf8Indexes = map[string]uint16{}
+		for i, c := range cw.cp {
+			if utf8Info, ok := c.(*ConstantUtf8Info); ok {
+				if _, found := cw.utf8Indexes[utf8Info.str]; !found {
+					cw.utf8Indexes[utf8Info.str] = uint16(i)
+				}
+			}
+		}
+	}
+	if index, ok := cw.utf8Indexes[s]; ok {
+		return index
+	}
+	panic(fmt.Errorf("missing CONSTANT_Utf8 %q in constant pool", s))
+}
+
+// classIndex 返回常量池里类 name 的 CONSTANT_Class_info 的索引，找不到时 panic
+func (cw *ClassWriter) classIndex(name string) uint16 {
+	if cw.classIndexes == nil {
+		cw.classIndexes = map[string]uint16{}
+		for i, c := range cw.cp {
+			if classInfo, ok := c.(*ConstantClassInfo); ok {
+				className := cw.cp.getUtf8(classInfo.nameIndex)
+				if _, found := cw.classIndexes[className]; !found {
+					cw.classIndexes[className] = uint16(i)
+				}
+			}
+		}
+	}
+	if index, ok := cw.classIndexes[name]; ok {
+		return index
+	}
+	panic(fmt.Errorf("missing CONSTANT_Class %q in constant pool", name))
+}
@@ -0,0 +1,362 @@
+package classfile
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// Label 字节码里的位置，可以在 Mark 之前用作跳转目标，SetCode 时回填偏移量
+type Label struct {
+	pc int // 还没有 Mark 时是 -1
+}
+
+// CodeBuilder 生成方法的字节码、异常处理表和行号表。
+// 引用常量的指令通过所属的 ClassBuilder 添加常量
+type CodeBuilder struct {
+	cb          *ClassBuilder
+	code        []byte
+	fixups      []labelFixup
+	tryCatches  []tryCatch
+	lineNumbers []lineNumber
+	attributes  []AttributeInfo
+	maxStack    int // 不是 -1 时不计算 max_stack，见 SetMaxStack
+	maxLocals   int
+}
+
+type labelFixup struct {
+	opcodePc int // 偏移量相对于指令的起始位置
+	pos      int // 偏移量在 code 里的位置
+	wide     bool
+	label    *Label
+}
+
+type tryCatch struct {
+	start, end, handler *Label
+	catchType           uint16
+}
+
+type lineNumber struct {
+	start *Label
+	line  uint16
+}
+
+// NewCode 开始生成一个方法的字节码，完成后用 SetCode 设置到方法上
+func (cb *ClassBuilder) NewCode() *CodeBuilder {
+	return &CodeBuilder{cb: cb, maxStack: -1, maxLocals: -1}
+}
+
+func (b *CodeBuilder) NewLabel() *Label {
+	return &Label{pc: -1}
+}
+
+// Mark 把 label 放在下一条指令的位置
+func (b *CodeBuilder) Mark(label *Label) {
+	if label.pc >= 0 {
+		panic(fmt.Errorf("label already marked at pc %d", label.pc))
+	}
+	label.pc = len(b.code)
+}
+
+// Pc 下一条指令的位置
+func (b *CodeBuilder) Pc() int {
+	return len(b.code)
+}
+
+// SetMaxStack 直接指定 max_stack，不再根据字节码计算。
+// 用来构造操作数栈溢出之类的非法方法，同时指定 max_locals 时字节码可以是无法分析的
+func (b *CodeBuilder) SetMaxStack(maxStack uint16) {
+	b.maxStack = int(maxStack)
+}
+
+// SetMaxLocals 直接指定 max_locals，不再根据字节码和方法描述符计算
+func (b *CodeBuilder) SetMaxLocals(maxLocals uint16) {
+	b.maxLocals = int(maxLocals)
+}
+
+// Emit 原样写入字节，用来生成指定形式的指令（比如 wide iload 1）或者非法的字节码
+func (b *CodeBuilder) Emit(bytes ...byte) {
+	b.code = append(b.code, bytes...)
+}
+
+// Op 没有操作数的指令，比如 iadd、areturn
+func (b *CodeBuilder) Op(opcode uint8) {
+	b.code = append(b.code, opcode)
+}
+
+// OpU1 有一个字节操作数的指令，比如 bipush、newarray
+func (b *CodeBuilder) OpU1(opcode, operand uint8) {
+	b.code = append(b.code, opcode, operand)
+}
+
+// OpU2 有两个字节操作数的指令，比如 sipush、new、getfield
+func (b *CodeBuilder) OpU2(opcode uint8, operand uint16) {
+	b.code = append(b.code, opcode, byte(operand>>8), byte(operand))
+}
+
+// Branch 跳转指令，goto_w 和 jsr_w 的偏移量是 4 个字节，其他是 2 个字节
+func (b *CodeBuilder) Branch(opcode uint8, label *Label) {
+	pc := len(b.code)
+	b.code = append(b.code, opcode)
+	wide := opcode == 0xc8 || opcode == 0xc9 // goto_w, jsr_w
+	b.addOffset(pc, wide, label)
+}
+
+func (b *CodeBuilder) addOffset(opcodePc int, wide bool, label *Label) {
+	b.fixups = append(b.fixups, labelFixup{opcodePc: opcodePc, pos: len(b.code), wide: wide, label: label})
+	if wide {
+		b.code = append(b.code, 0, 0, 0, 0)
+	} else {
+		b.code = append(b.code, 0, 0)
+	}
+}
+
+func (b *CodeBuilder) appendInt32(val int32) {
+	b.code = append(b.code, byte(val>>24), byte(val>>16), byte(val>>8), byte(val))
+}
+
+// switch 指令的操作数从 4 字节对齐的位置开始
+func (b *CodeBuilder) switchPadding() {
+	for len(b.code)%4 != 0 {
+		b.code = append(b.code, 0)
+	}
+}
+
+// TableSwitch labels 依次对应 low, low+1, ...
+func (b *CodeBuilder) TableSwitch(defaultLabel *Label, low int32, labels ...*Label) {
+	pc := len(b.code)
+	b.code = append(b.code, 0xaa) // tableswitch
+	b.switchPadding()
+	b.addOffset(pc, true, defaultLabel)
+	b.appendInt32(low)
+	b.appendInt32(low + int32(len(labels)) - 1)
+	for _, label := range labels {
+		b.addOffset(pc, true, label)
+	}
+}
+
+// LookupSwitch keys 不需要有序，生成的 match-offset 对按 key 排序
+func (b *CodeBuilder) LookupSwitch(defaultLabel *Label, keys []int32, labels []*Label) {
+	if len(keys) != len(labels) {
+		panic(fmt.Errorf("lookupswitch: %d keys but %d labels", len(keys), len(labels)))
+	}
+	order := make([]int, len(keys))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool { return keys[order[i]] < keys[order[j]] })
+
+	pc := len(b.code)
+	b.code = append(b.code, 0xab) // lookupswitch
+	b.switchPadding()
+	b.addOffset(pc, true, defaultLabel)
+	b.appendInt32(int32(len(keys)))
+	for _, i := range order {
+		b.appendInt32(keys[i])
+		b.addOffset(pc, true, labels[i])
+	}
+}
+
+// TryCatch 添加异常处理表项，className 为空时捕获所有异常（finally）
+func (b *CodeBuilder) TryCatch(start, end, handler *Label, className string) {
+	var catchType uint16
+	if className != "" {
+		catchType = b.cb.Class(className)
+	}
+	b.tryCatches = append(b.tryCatches, tryCatch{start: start, end: end, handler: handler, catchType: catchType})
+}
+
+// LineNumber 从下一条指令开始的代码对应源文件的第 line 行
+func (b *CodeBuilder) LineNumber(line uint16) {
+	label := b.NewLabel()
+	b.Mark(label)
+	b.lineNumbers = append(b.lineNumbers, lineNumber{start: label, line: line})
+}
+
+// AddAttribute 添加 Code 属性的其他属性，比如 LocalVariableTable
+func (b *CodeBuilder) AddAttribute(attrInfo AttributeInfo) {
+	b.attributes = append(b.attributes, attrInfo)
+}
+
+// 常用指令
+
+// Int 把 int 常量推入栈顶，根据大小选择 iconst_<i>、bipush、sipush 或者 ldc
+func (b *CodeBuilder) Int(val int32) {
+	switch {
+	case val >= -1 && val <= 5:
+		b.Op(uint8(0x03 + val)) // iconst_<i>
+	case val >= math.MinInt8 && val <= math.MaxInt8:
+		b.OpU1(0x10, uint8(val)) // bipush
+	case val >= math.MinInt16 && val <= math.MaxInt16:
+		b.OpU2(0x11, uint16(val)) // sipush
+	default:
+		b.Ldc(b.cb.Integer(val))
+	}
+}
+
+// Ldc 加载常量池里的常量，long 和 double 用 ldc2_w
+func (b *CodeBuilder) Ldc(index uint16) {
+	switch b.cb.cf.constantPool[index].(type) {
+	case *ConstantLongInfo, *ConstantDoubleInfo:
+		b.OpU2(0x14, index) // ldc2_w
+	default:
+		if index <= math.MaxUint8 {
+			b.OpU1(0x12, uint8(index)) // ldc
+		} else {
+			b.OpU2(0x13, index) // ldc_w
+		}
+	}
+}
+
+// Load 加载局部变量，t 是类型描述符的第一个字符
+func (b *CodeBuilder) Load(t byte, index uint16) {
+	b.localInsn(0x15, 0x1a, t, index) // iload, iload_0
+}
+
+// Store 存储局部变量，t 是类型描述符的第一个字符
+func (b *CodeBuilder) Store(t byte, index uint16) {
+	b.localInsn(0x36, 0x3b, t, index) // istore, istore_0
+}
+
+func (b *CodeBuilder) localInsn(opcode, opcode0 uint8, t byte, index uint16) {
+	var kind uint8
+	switch t {
+	case 'Z', 'B', 'C', 'S', 'I':
+		kind = 0
+	case 'J':
+		kind = 1
+	case 'F':
+		kind = 2
+	case 'D':
+		kind = 3
+	case 'L', '[':
+		kind = 4
+	default:
+		panic(fmt.Errorf("invalid local variable type %q", t))
+	}
+	switch {
+	case index <= 3:
+		b.Op(opcode0 + kind*4 + uint8(index))
+	case index <= math.MaxUint8:
+		b.OpU1(opcode+kind, uint8(index))
+	default:
+		b.Op(0xc4) // wide
+		b.OpU2(opcode+kind, index)
+	}
+}
+
+// Iinc 局部变量加上 delta，超出一个字节时用 wide
+func (b *CodeBuilder) Iinc(index uint16, delta int16) {
+	if index <= math.MaxUint8 && delta >= math.MinInt8 && delta <= math.MaxInt8 {
+		b.code = append(b.code, 0x84, uint8(index), uint8(delta))
+	} else {
+		b.code = append(b.code, 0xc4, 0x84, byte(index>>8), byte(index), byte(delta>>8), byte(delta))
+	}
+}
+
+// Invoke invokevirtual、invokespecial、invokestatic 或者 invokeinterface
+func (b *CodeBuilder) Invoke(opcode uint8, className, name, descriptor string) {
+	if opcode == 0xb9 { // invokeinterface
+		argSlots, _ := descriptorSlots(descriptor)
+		index := b.cb.InterfaceMethodref(className, name, descriptor)
+		b.code = append(b.code, opcode, byte(index>>8), byte(index), uint8(argSlots+1), 0)
+	} else {
+		b.OpU2(opcode, b.cb.Methodref(className, name, descriptor))
+	}
+}
+
+// InvokeInterfaceMethod 用 invokestatic 或 invokespecial 调用接口里的方法（Java 8 以后）
+func (b *CodeBuilder) InvokeInterfaceMethod(opcode uint8, className, name, descriptor string) {
+	b.OpU2(opcode, b.cb.InterfaceMethodref(className, name, descriptor))
+}
+
+// InvokeDynamic bootstrapMethodIndex 是 ClassBuilder.BootstrapMethod 返回的下标
+func (b *CodeBuilder) InvokeDynamic(bootstrapMethodIndex uint16, name, descriptor string) {
+	index := b.cb.InvokeDynamic(bootstrapMethodIndex, name, descriptor)
+	b.code = append(b.code, 0xba, byte(index>>8), byte(index), 0, 0)
+}
+
+// Field getstatic、putstatic、getfield 或者 putfield
+func (b *CodeBuilder) Field(opcode uint8, className, name, descriptor string) {
+	b.OpU2(opcode, b.cb.Fieldref(className, name, descriptor))
+}
+
+// TypeInsn new、anewarray、checkcast 或者 instanceof
+func (b *CodeBuilder) TypeInsn(opcode uint8, className string) {
+	b.OpU2(opcode, b.cb.Class(className))
+}
+
+// attribute 回填跳转偏移量，计算 max_stack 和 max_locals，生成 Code 属性
+func (b *CodeBuilder) attribute(isStatic bool, descriptor string) *CodeAttribute {
+	if len(b.code) == 0 || len(b.code) > math.MaxUint16 {
+		panic(fmt.Errorf("invalid code length %d", len(b.code)))
+	}
+	code := append([]byte(nil), b.code...)
+	for _, fixup := range b.fixups {
+		offset := labelPc(fixup.label) - fixup.opcodePc
+		if fixup.wide {
+			code[fixup.pos] = byte(offset >> 24)
+			code[fixup.pos+1] = byte(offset >> 16)
+			code[fixup.pos+2] = byte(offset >> 8)
+			code[fixup.pos+3] = byte(offset)
+		} else {
+			if offset < math.MinInt16 || offset > math.MaxInt16 {
+				panic(fmt.Errorf("branch offset %d at pc %d out of range, use goto_w", offset, fixup.opcodePc))
+			}
+			code[fixup.pos] = byte(offset >> 8)
+			code[fixup.pos+1] = byte(offset)
+		}
+	}
+
+	exceptionTable := make([]*ExceptionTableEntry, len(b.tryCatches))
+	for i, tc := range b.tryCatches {
+		exceptionTable[i] = &ExceptionTableEntry{
+			startPc:   uint16(labelPc(tc.start)),
+			endPc:     uint16(labelPc(tc.end)),
+			handlerPc: uint16(labelPc(tc.handler)),
+			catchType: tc.catchType,
+		}
+	}
+
+	var attributes []AttributeInfo
+	if len(b.lineNumbers) > 0 {
+		lntAttr := &LineNumberTableAttribute{}
+		for _, ln := range b.lineNumbers {
+			lntAttr.lineNumberTable = append(lntAttr.lineNumberTable,
+				&LineNumberTableEntry{startPc: uint16(labelPc(ln.start)), lineNumber: ln.line})
+		}
+		attributes = append(attributes, lntAttr)
+	}
+	attributes = append(attributes, b.attributes...)
+
+	cp := b.cb.cf.constantPool
+	maxStack, maxLocals := uint16(b.maxStack), uint16(b.maxLocals)
+	if b.maxStack < 0 || b.maxLocals < 0 {
+		argSlots, _ := descriptorSlots(descriptor)
+		if !isStatic {
+			argSlots++ // this
+		}
+		computedStack, computedLocals := computeMaxs(cp, code, exceptionTable, argSlots)
+		if b.maxStack < 0 {
+			maxStack = computedStack
+		}
+		if b.maxLocals < 0 {
+			maxLocals = computedLocals
+		}
+	}
+	return &CodeAttribute{
+		cp:             cp,
+		maxStack:       maxStack,
+		maxLocals:      maxLocals,
+		code:           code,
+		exceptionTable: exceptionTable,
+		attributes:     attributes,
+	}
+}
+
+func labelPc(label *Label) int {
+	if label.pc < 0 {
+		panic("label is not marked")
+	}
+	return label.pc
+}
@@ -0,0 +1,308 @@
+package classfile
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// computeMaxs 计算方法的 max_stack 和 max_locals。
+// 从方法入口和每个异常处理器开始沿控制流模拟操作数栈的深度（按槽位计算，long 和 double 占两个），
+// 异常处理器入口的栈上只有异常对象。max_locals 取参数槽位数和指令用到的最大局部变量槽位中较大的一个
+func computeMaxs(cp ConstantPool, code []byte, exceptionTable []*ExceptionTableEntry, argSlots int) (uint16, uint16) {
+	depths := make([]int, len(code))
+	for i := range depths {
+		depths[i] = -1
+	}
+	var worklist []int
+	visit := func(pc, depth int) {
+		if pc < 0 || pc >= len(code) {
+			panic(fmt.Errorf("jump target %d out of code", pc))
+		}
+		if depths[pc] < 0 {
+			depths[pc] = depth
+			worklist = append(worklist, pc)
+		}
+	}
+	visit(0, 0)
+	for _, entry := range exceptionTable {
+		visit(int(entry.handlerPc), 1)
+	}
+
+	maxStack := 0
+	for len(worklist) > 0 {
+		pc := worklist[len(worklist)-1]
+		worklist = worklist[:len(worklist)-1]
+		depth := depths[pc]
+
+		opcode := code[pc]
+		delta := stackDelta(cp, code, pc)
+		if depth+delta < 0 {
+			panic(fmt.Errorf("operand stack underflow at pc %d", pc))
+		}
+		// 先弹出再压入的指令，中间的深度不会超过两者的较大值
+		if depth+delta > maxStack {
+			maxStack = depth + delta
+		}
+		depth += delta
+		next := pc + instructionLength(code, pc)
+
+		switch {
+		case opcode >= 0x99 && opcode <= 0xa6, opcode == 0xc6, opcode == 0xc7: // if<cond>, if_icmp<cond>, if_acmp<cond>, ifnull, ifnonnull
+			visit(pc+int(int16(binary.BigEndian.Uint16(code[pc+1:]))), depth)
+			visit(next, depth)
+		case opcode == 0xa7: // goto
+			visit(pc+int(int16(binary.BigEndian.Uint16(code[pc+1:]))), depth)
+		case opcode == 0xc8: // goto_w
+			visit(pc+int(int32(binary.BigEndian.Uint32(code[pc+1:]))), depth)
+		case opcode == 0xa8, opcode == 0xc9: // jsr, jsr_w
+			// 子程序的栈顶是返回地址，ret 回到 jsr 的下一条指令，栈深度和调用前相同
+			var offset int
+			if opcode == 0xa8 {
+				offset = int(int16(binary.BigEndian.Uint16(code[pc+1:])))
+			} else {
+				offset = int(int32(binary.BigEndian.Uint32(code[pc+1:])))
+			}
+			visit(pc+offset, depth)
+			visit(next, depth-1)
+		case opcode == 0xaa || opcode == 0xab: // tableswitch, lookupswitch
+			for _, offset := range switchOffsets(code, pc) {
+				visit(pc+offset, depth)
+			}
+		case opcode >= 0xac && opcode <= 0xb1, opcode == 0xbf, opcode == 0xa9: // xreturn, athrow, ret
+		case opcode == 0xc4 && code[pc+1] == 0xa9: // wide ret
+		default:
+			if next < len(code) {
+				visit(next, depth)
+			} else {
+				panic(fmt.Errorf("falling off the end of the code at pc %d", pc))
+			}
+		}
+	}
+
+	maxLocals := argSlots
+	for pc := 0; pc < len(code); pc += instructionLength(code, pc) {
+		if slots := localSlots(code, pc); slots > maxLocals {
+			maxLocals = slots
+		}
+	}
+	if maxStack > 0xffff || maxLocals > 0xffff {
+		panic(fmt.Errorf("max_stack %d or max_locals %d too large", maxStack, maxLocals))
+	}
+	return uint16(maxStack), uint16(maxLocals)
+}
+
+// instructionLength 返回 pc 处指令的字节数
+func instructionLength(code []byte, pc int) int {
+	opcode := code[pc]
+	switch opcode {
+	case 0xaa: // tableswitch
+		pos := (pc + 4) &^ 3
+		low := int32(binary.BigEndian.Uint32(code[pos+4:]))
+		high := int32(binary.BigEndian.Uint32(code[pos+8:]))
+		return pos + 12 + int(high-low+1)*4 - pc
+	case 0xab: // lookupswitch
+		pos := (pc + 4) &^ 3
+		npairs := int(int32(binary.BigEndian.Uint32(code[pos+4:])))
+		return pos + 8 + npairs*8 - pc
+	case 0xc4: // wide
+		if code[pc+1] == 0x84 { // iinc
+			return 6
+		}
+		return 4
+	}
+	if instructionLengths[opcode] == 0 {
+		panic(fmt.Errorf("invalid opcode 0x%x at pc %d", opcode, pc))
+	}
+	return instructionLengths[opcode]
+}
+
+// switchOffsets 返回 switch 指令的所有跳转偏移量，包括 default
+func switchOffsets(code []byte, pc int) []int {
+	pos := (pc + 4) &^ 3
+	offsets := []int{int(int32(binary.BigEndian.Uint32(code[pos:])))}
+	if code[pc] == 0xaa { // tableswitch
+		low := int32(binary.BigEndian.Uint32(code[pos+4:]))
+		high := int32(binary.BigEndian.Uint32(code[pos+8:]))
+		for i := 0; i < int(high-low+1); i++ {
+			offsets = append(offsets, int(int32(binary.BigEndian.Uint32(code[pos+12+i*4:]))))
+		}
+	} else {
+		npairs := int(int32(binary.BigEndian.Uint32(code[pos+4:])))
+		for i := 0; i < npairs; i++ {
+			offsets = append(offsets, int(int32(binary.BigEndian.Uint32(code[pos+12+i*8:]))))
+		}
+	}
+	return offsets
+}
+
+// 定长指令的字节数，0 表示无效的操作码
+var instructionLengths = func() [256]int {
+	var lengths [256]int
+	for op := 0x00; op <= 0xc9; op++ {
+		lengths[op] = 1
+	}
+	for _, op := range []int{0x10, 0x12, 0x15, 0x16, 0x17, 0x18, 0x19, 0x36, 0x37, 0x38, 0x39, 0x3a, 0xa9, 0xbc} {
+		lengths[op] = 2
+	}
+	for _, op := range []int{0x11, 0x13, 0x14, 0x84, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6, 0xb7, 0xb8, 0xbb, 0xbd, 0xc0, 0xc1, 0xc6, 0xc7} {
+		lengths[op] = 3
+	}
+	for op := 0x99; op <= 0xa8; op++ { // if<cond> ... goto, jsr
+		lengths[op] = 3
+	}
+	lengths[0xc5] = 4 // multianewarray
+	for _, op := range []int{0xb9, 0xba, 0xc8, 0xc9} {
+		lengths[op] = 5
+	}
+	return lengths
+}()
+
+// 不引用常量池的指令对操作数栈深度的影响（槽位数），常量池相关的指令由 stackDelta 计算
+var stackDeltas = [256]int8{
+	0x00: 0, 1, 1, 1, 1, 1, 1, 1, 1, 2, 2, 1, 1, 1, 2, 2, // nop ... dconst_1
+	0x10: 1, 1, 0, 0, 0, 1, 2, 1, 2, 1, 1, 1, 1, 1, 2, 2, // bipush ... lload_1
+	0x20: 2, 2, 1, 1, 1, 1, 2, 2, 2, 2, 1, 1, 1, 1, -1, 0, // lload_2 ... laload
+	0x30: -1, 0, -1, -1, -1, -1, -1, -2, -1, -2, -1, -1, -1, -1, -1, -2, // faload ... lstore_0
+	0x40: -2, -2, -2, -1, -1, -1, -1, -2, -2, -2, -2, -1, -1, -1, -1, -3, // lstore_1 ... iastore
+	0x50: -4, -3, -4, -3, -3, -3, -3, -1, -2, 1, 1, 1, 2, 2, 2, 0, // lastore ... swap
+	0x60: -1, -2, -1, -2, -1, -2, -1, -2, -1, -2, -1, -2, -1, -2, -1, -2, // add, sub, mul, div
+	0x70: -1, -2, -1, -2, 0, 0, 0, 0, -1, -1, -1, -1, -1, -1, -1, -2, // rem, neg, shift, iand, land
+	0x80: -1, -2, -1, -2, 0, 1, 0, 1, -1, -1, 0, 0, 1, 1, -1, 0, // ior ... d2l
+	0x90: -1, 0, 0, 0, -3, -1, -1, -3, -3, -1, -1, -1, -1, -1, -1, -2, // d2f ... if_icmpeq
+	0xa0: -2, -2, -2, -2, -2, -2, -2, 0, 1, 0, -1, -1, -1, -2, -1, -2, // if_icmpne ... dreturn
+	0xb0: -1, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, // areturn ... athrow
+	0xc0: 0, 0, -1, -1, 0, 0, -1, -1, 0, 1, // checkcast ... jsr_w
+}
+
+// stackDelta 返回 pc 处指令执行后操作数栈深度的变化
+func stackDelta(cp ConstantPool, code []byte, pc int) int {
+	opcode := code[pc]
+	switch opcode {
+	case 0x12: // ldc
+		return constantSlots(cp, uint16(code[pc+1]))
+	case 0x13, 0x14: // ldc_w, ldc2_w
+		return constantSlots(cp, binary.BigEndian.Uint16(code[pc+1:]))
+	case 0xb2, 0xb3, 0xb4, 0xb5: // getstatic, putstatic, getfield, putfield
+		_, _, descriptor := cp.memberref(binary.BigEndian.Uint16(code[pc+1:]))
+		size := typeSlots(descriptor)
+		switch opcode {
+		case 0xb2:
+			return size
+		case 0xb3:
+			return -size
+		case 0xb4:
+			return size - 1
+		default:
+			return -size - 1
+		}
+	case 0xb6, 0xb7, 0xb8, 0xb9: // invokevirtual, invokespecial, invokestatic, invokeinterface
+		_, _, descriptor := cp.memberref(binary.BigEndian.Uint16(code[pc+1:]))
+		argSlots, returnSlots := descriptorSlots(descriptor)
+		if opcode != 0xb8 {
+			argSlots++ // this
+		}
+		return returnSlots - argSlots
+	case 0xba: // invokedynamic
+		idInfo := cp.getConstantInfo(binary.BigEndian.Uint16(code[pc+1:])).(*ConstantInvokeDynamicInfo)
+		_, descriptor := cp.getNameAndType(idInfo.nameAndTypeIndex)
+		argSlots, returnSlots := descriptorSlots(descriptor)
+		return returnSlots - argSlots
+	case 0xc4: // wide
+		if code[pc+1] == 0x84 { // iinc
+			return 0
+		}
+		return int(stackDeltas[code[pc+1]])
+	case 0xc5: // multianewarray
+		return 1 - int(code[pc+3])
+	}
+	return int(stackDeltas[opcode])
+}
+
+// localSlots 返回 pc 处的指令用到的局部变量槽位上限（索引 + 大小），不访问局部变量的指令返回 0
+func localSlots(code []byte, pc int) int {
+	opcode := code[pc]
+	index := -1
+	if opcode == 0xc4 { // wide
+		opcode = code[pc+1]
+		index = int(binary.BigEndian.Uint16(code[pc+2:]))
+	}
+	switch {
+	case opcode >= 0x15 && opcode <= 0x19, opcode >= 0x36 && opcode <= 0x3a, opcode == 0x84, opcode == 0xa9: // xload, xstore, iinc, ret
+		if index < 0 {
+			index = int(code[pc+1])
+		}
+		if opcode == 0x16 || opcode == 0x18 || opcode == 0x37 || opcode == 0x39 { // lload, dload, lstore, dstore
+			return index + 2
+		}
+		return index + 1
+	case opcode >= 0x1a && opcode <= 0x2d: // xload_<n>
+		n := int(opcode-0x1a) % 4
+		if kind := (opcode - 0x1a) / 4; kind == 1 || kind == 3 { // lload_<n>, dload_<n>
+			return n + 2
+		}
+		return n + 1
+	case opcode >= 0x3b && opcode <= 0x4e: // xstore_<n>
+		n := int(opcode-0x3b) % 4
+		if kind := (opcode - 0x3b) / 4; kind == 1 || kind == 3 { // lstore_<n>, dstore_<n>
+			return n + 2
+		}
+		return n + 1
+	}
+	return 0
+}
+
+func constantSlots(cp ConstantPool, index uint16) int {
+	switch cp.getConstantInfo(index).(type) {
+	case *ConstantLongInfo, *ConstantDoubleInfo:
+		return 2
+	}
+	return 1
+}
+
+// memberref 返回字段或方法符号引用的类名、名字和描述符
+func (cp ConstantPool) memberref(index uint16) (string, string, string) {
+	var ref *ConstantMemberrefInfo
+	switch info := cp.getConstantInfo(index).(type) {
+	case *ConstantFieldrefInfo:
+		ref = &info.ConstantMemberrefInfo
+	case *ConstantMethodrefInfo:
+		ref = &info.ConstantMemberrefInfo
+	case *ConstantInterfaceMethodrefInfo:
+		ref = &info.ConstantMemberrefInfo
+	default:
+		panic(fmt.Errorf("constant %d is not a member reference", index))
+	}
+	name, descriptor := cp.getNameAndType(ref.nameAndTypeIndex)
+	return cp.getClassName(ref.classIndex), name, descriptor
+}
+
+// typeSlots 字段描述符占用的槽位数
+func typeSlots(descriptor string) int {
+	switch descriptor[0] {
+	case 'J', 'D':
+		return 2
+	case 'V':
+		return 0
+	}
+	return 1
+}
+
+// descriptorSlots 返回方法描述符的参数槽位数（不包括 this）和返回值槽位数
+func descriptorSlots(descriptor string) (int, int) {
+	argSlots := 0
+	i := 1 // 跳过 (
+	for descriptor[i] != ')' {
+		start := i
+		for descriptor[i] == '[' {
+			i++
+		}
+		if descriptor[i] == 'L' {
+			for descriptor[i] != ';' {
+				i++
+			}
+		}
+		i++
+		argSlots += typeSlots(descriptor[start:i])
+	}
+	return argSlots, typeSlots(descriptor[i+1:])
+}
@@ -0,0 +1,144 @@
+package classfile
+
+import "testing"
+
+func TestComputeMaxs(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      uint16
+		descriptor string
+		gen        func(code *CodeBuilder)
+		maxStack   uint
+		maxLocals  uint
+	}{
+		{"args", 0x0001, "(JI[I)V", func(code *CodeBuilder) {
+			code.Op(0xb1) // return
+		}, 0, 5},
+		{"jsr", 0x0008, "()V", func(code *CodeBuilder) {
+			sub := code.NewLabel()
+			code.Op(0x09) // lconst_0
+			code.Branch(0xa8, sub)
+			code.Op(0x58) // pop2
+			code.Op(0xb1) // return
+			code.Mark(sub)
+			code.Store('L', 1)
+			code.Op(0x0a)      // lconst_1
+			code.Op(0x0a)      // lconst_1
+			code.Op(0x58)      // pop2
+			code.Op(0x58)      // pop2
+			code.OpU1(0xa9, 1) // ret 1
+		}, 6, 2},
+		{"jsr_w", 0x0008, "()V", func(code *CodeBuilder) {
+			sub := code.NewLabel()
+			code.Branch(0xc9, sub)
+			code.Op(0xb1) // return
+			code.Mark(sub)
+			code.Emit(0xc4, 0x3a, 0x01, 0x00) // wide astore 256
+			code.Emit(0xc4, 0xa9, 0x01, 0x00) // wide ret 256
+		}, 1, 257},
+		{"wide", 0x0008, "()V", func(code *CodeBuilder) {
+			code.Emit(0xc4, 0x16, 0x01, 0x2c) // wide lload 300
+			code.Op(0x58)                     // pop2
+			code.Iinc(200, 1000)              // wide iinc 200 1000
+			code.Iinc(3, 1)
+			code.Op(0xb1) // return
+		}, 2, 302},
+		{"tableswitch", 0x0008, "(I)V", func(code *CodeBuilder) {
+			l0, l1, dflt := code.NewLabel(), code.NewLabel(), code.NewLabel()
+			code.Load('I', 0)
+			code.TableSwitch(dflt, -1, l0, l1)
+			code.Mark(l0)
+			code.Op(0xb1) // return
+			code.Mark(l1)
+			code.Op(0x0e) // dconst_0
+			code.Op(0x0e) // dconst_0
+			code.Op(0x0e) // dconst_0
+			code.Op(0x58) // pop2
+			code.Op(0x58) // pop2
+			code.Op(0x58) // pop2
+			code.Op(0xb1) // return
+			code.Mark(dflt)
+			code.Op(0x04) // iconst_1
+			code.Op(0x57) // pop
+			code.Op(0xb1) // return
+		}, 6, 1},
+		{"lookupswitch", 0x0008, "(I)V", func(code *CodeBuilder) {
+			l0, l1, dflt := code.NewLabel(), code.NewLabel(), code.NewLabel()
+			code.Load('I', 0)
+			code.LookupSwitch(dflt, []int32{1000, -1000}, []*Label{l0, l1})
+			code.Mark(l0)
+			code.Op(0xb1) // return
+			code.Mark(l1)
+			code.Op(0xb1) // return
+			code.Mark(dflt)
+			code.Op(0x03) // iconst_0
+			code.Op(0x03) // iconst_0
+			code.Op(0x03) // iconst_0
+			code.Op(0x03) // iconst_0
+			code.Store('I', 5)
+			code.Op(0x57) // pop
+			code.Op(0x57) // pop
+			code.Op(0x57) // pop
+			code.Op(0xb1) // return
+		}, 4, 6},
+		{"exception handler", 0x0008, "()V", func(code *CodeBuilder) {
+			start, end, handler := code.NewLabel(), code.NewLabel(), code.NewLabel()
+			code.Mark(start)
+			code.Op(0x01) // aconst_null
+			code.Op(0xbf) // athrow
+			code.Mark(end)
+			code.Mark(handler)
+			code.Op(0x59) // dup
+			code.Op(0x59) // dup
+			code.Store('L', 2)
+			code.Op(0x57) // pop
+			code.Op(0xbf) // athrow
+			code.TryCatch(start, end, handler, "java/lang/Throwable")
+		}, 3, 3},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := NewClassBuilder(49, 0x0021, "test/Maxs", "java/lang/Object")
+			method := cb.AddMethod(test.flags, "m", test.descriptor)
+			code := cb.NewCode()
+			test.gen(code)
+			cb.SetCode(method, code)
+
+			cf, err := Parse(cb.Bytes())
+			if err != nil {
+				t.Fatalf("Parse: %v", err)
+			}
+			codeAttr := cf.Methods()[0].CodeAttribute()
+			if codeAttr.MaxStack() != test.maxStack || codeAttr.MaxLocals() != test.maxLocals {
+				t.Errorf("max_stack, max_locals = %d, %d; want %d, %d",
+					codeAttr.MaxStack(), codeAttr.MaxLocals(), test.maxStack, test.maxLocals)
+			}
+		})
+	}
+}
+
+func TestComputeMaxsErrors(t *testing.T) {
+	tests := []struct {
+		name           string
+		code           []byte
+		exceptionTable []*ExceptionTableEntry
+	}{
+		{"underflow", []byte{0x57, 0xb1}, nil},              // pop, return
+		{"falling off the end", []byte{0x03, 0x57}, nil},    // iconst_0, pop
+		{"jump out of code", []byte{0xa7, 0x00, 0x10}, nil}, // goto +16
+		{"invalid opcode", []byte{0xcb}, nil},               // 0xcb
+		{"handler underflow", []byte{0x03, 0x57, 0xb1, 0x57, 0x57, 0xb1},
+			[]*ExceptionTableEntry{{startPc: 0, endPc: 2, handlerPc: 3}}}, // handler 入口只有异常对象
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("computeMaxs(% x) did not panic", test.code)
+				}
+			}()
+			computeMaxs(ConstantPool{nil}, test.code, test.exceptionTable, 0)
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package classfile
 
+import "fmt"
+
 // Constant pool tags
 const (
 	CONSTANT_Class              = 7
@@ -16,6 +18,8 @@ const (
 	CONSTANT_MethodHandle       = 15
 	CONSTANT_MethodType         = 16
 	CONSTANT_InvokeDynamic      = 18
+	CONSTANT_Module             = 19
+	CONSTANT_Package            = 20
 )
 
 /*
@@ -26,6 +30,7 @@ const (
 */
 type ConstantInfo interface {
 	readInfo(reader *ClassReader)
+	writeInfo(writer *ClassWriter)
 }
 
 func readConstantInfo(reader *ClassReader, cp ConstantPool) ConstantInfo {
@@ -35,6 +40,51 @@ func readConstantInfo(reader *ClassReader, cp ConstantPool) ConstantInfo {
 	return c
 }
 
+func writeConstantInfo(writer *ClassWriter, c ConstantInfo) {
+	writer.writeUint8(constantTag(c))
+	c.writeInfo(writer)
+}
+
+// constantTag 和 newConstantInfo 相反，返回常量对应的 tag
+func constantTag(c ConstantInfo) uint8 {
+	switch c.(type) {
+	case *ConstantIntegerInfo:
+		return CONSTANT_Integer
+	case *ConstantFloatInfo:
+		return CONSTANT_Float
+	case *ConstantLongInfo:
+		return CONSTANT_Long
+	case *ConstantDoubleInfo:
+		return CONSTANT_Double
+	case *ConstantUtf8Info:
+		return CONSTANT_Utf8
+	case *ConstantStringInfo:
+		return CONSTANT_String
+	case *ConstantClassInfo:
+		return CONSTANT_Class
+	case *ConstantFieldrefInfo:
+		return CONSTANT_Fieldref
+	case *ConstantMethodrefInfo:
+		return CONSTANT_Methodref
+	case *ConstantInterfaceMethodrefInfo:
+		return CONSTANT_InterfaceMethodref
+	case *ConstantNameAndTypeInfo:
+		return CONSTANT_NameAndType
+	case *ConstantMethodTypeInfo:
+		return CONSTANT_MethodType
+	case *ConstantMethodHandleInfo:
+		return CONSTANT_MethodHandle
+	case *ConstantInvokeDynamicInfo:
+		return CONSTANT_InvokeDynamic
+	case *ConstantModuleInfo:
+		return CONSTANT_Module
+	case *ConstantPackageInfo:
+		return CONSTANT_Package
+	default:
+		panic(fmt.Errorf("unknown constant type %T", c))
+	}
+}
+
 // todo ugly code
 func newConstantInfo(tag uint8, cp ConstantPool) ConstantInfo {
 	switch tag {
@@ -61,11 +111,15 @@ func newConstantInfo(tag uint8, cp ConstantPool) ConstantInfo {
 	case CONSTANT_NameAndType:
 		return &ConstantNameAndTypeInfo{}
 	case CONSTANT_MethodType:
-		return &ConstantMethodTypeInfo{}
+		return &ConstantMethodTypeInfo{cp: cp}
 	case CONSTANT_MethodHandle:
 		return &ConstantMethodHandleInfo{}
 	case CONSTANT_InvokeDynamic:
-		return &ConstantInvokeDynamicInfo{}
+		return &ConstantInvokeDynamicInfo{cp: cp}
+	case CONSTANT_Module:
+		return &ConstantModuleInfo{cp: cp}
+	case CONSTANT_Package:
+		return &ConstantPackageInfo{cp: cp}
 	default:
 		panic("java.lang.ClassFormatError: constant pool tag!")
 	}
@@ -37,6 +37,16 @@ func readConstantPool(reader *ClassReader) ConstantPool {
 	return cp
 }
 
+// writeConstantPool 写出常量池，long 和 double 后面不可用的索引不占空间
+func writeConstantPool(writer *ClassWriter, cp ConstantPool) {
+	writer.writeUint16(uint16(len(cp)))
+	for _, cpInfo := range cp {
+		if cpInfo != nil {
+			writeConstantInfo(writer, cpInfo)
+		}
+	}
+}
+
 // getConstantInfo 获取指定索引的常量信息。
 //
 // 参数：
@@ -89,6 +99,16 @@ func (cp ConstantPool) getClassName(index uint16) string {
 	return cp.getUtf8(classInfo.nameIndex)                      // 获取类名
 }
 
+// getModuleName 获取 CONSTANT_Module_info 常量的模块名
+func (cp ConstantPool) getModuleName(index uint16) string {
+	return cp.getConstantInfo(index).(*ConstantModuleInfo).Name()
+}
+
+// getPackageName 获取 CONSTANT_Package_info 常量的包名
+func (cp ConstantPool) getPackageName(index uint16) string {
+	return cp.getConstantInfo(index).(*ConstantPackageInfo).Name()
+}
+
 // getUtf8 获取指定索引的 UTF-8 字符串。
 // 该索引指向一个 CONSTANT_Utf8_info 常量。
 //
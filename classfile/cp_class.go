@@ -14,6 +14,13 @@ type ConstantClassInfo struct {
 func (cci *ConstantClassInfo) readInfo(reader *ClassReader) {
 	cci.nameIndex = reader.readUint16()
 }
+func (cci *ConstantClassInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cci.nameIndex)
+}
 func (cci *ConstantClassInfo) Name() string {
 	return cci.cp.getUtf8(cci.nameIndex)
 }
+
+func (cci *ConstantClassInfo) NameIndex() uint16 {
+	return cci.nameIndex
+}
@@ -16,6 +16,16 @@ func (cmi *ConstantMethodHandleInfo) readInfo(reader *ClassReader) {
 	cmi.referenceKind = reader.readUint8()
 	cmi.referenceIndex = reader.readUint16()
 }
+func (cmi *ConstantMethodHandleInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint8(cmi.referenceKind)
+	writer.writeUint16(cmi.referenceIndex)
+}
+func (cmi *ConstantMethodHandleInfo) ReferenceKind() uint8 {
+	return cmi.referenceKind
+}
+func (cmi *ConstantMethodHandleInfo) ReferenceIndex() uint16 {
+	return cmi.referenceIndex
+}
 
 /*
 	CONSTANT_MethodType_info {
@@ -24,12 +34,19 @@ func (cmi *ConstantMethodHandleInfo) readInfo(reader *ClassReader) {
 	}
 */
 type ConstantMethodTypeInfo struct {
+	cp              ConstantPool
 	descriptorIndex uint16
 }
 
 func (cmi *ConstantMethodTypeInfo) readInfo(reader *ClassReader) {
 	cmi.descriptorIndex = reader.readUint16()
 }
+func (cmi *ConstantMethodTypeInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cmi.descriptorIndex)
+}
+func (cmi *ConstantMethodTypeInfo) Descriptor() string {
+	return cmi.cp.getUtf8(cmi.descriptorIndex)
+}
 
 /*
 	CONSTANT_InvokeDynamic_info {
@@ -39,6 +56,7 @@ func (cmi *ConstantMethodTypeInfo) readInfo(reader *ClassReader) {
 	}
 */
 type ConstantInvokeDynamicInfo struct {
+	cp                       ConstantPool
 	bootstrapMethodAttrIndex uint16
 	nameAndTypeIndex         uint16
 }
@@ -47,3 +65,23 @@ func (cmi *ConstantInvokeDynamicInfo) readInfo(reader *ClassReader) {
 	cmi.bootstrapMethodAttrIndex = reader.readUint16()
 	cmi.nameAndTypeIndex = reader.readUint16()
 }
+func (cmi *ConstantInvokeDynamicInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cmi.bootstrapMethodAttrIndex)
+	writer.writeUint16(cmi.nameAndTypeIndex)
+}
+
+// BootstrapMethodAttrIndex 是 BootstrapMethods 属性中引导方法表的索引
+func (cmi *ConstantInvokeDynamicInfo) BootstrapMethodAttrIndex() uint16 {
+	return cmi.bootstrapMethodAttrIndex
+}
+func (cmi *ConstantInvokeDynamicInfo) NameAndType() (string, string) {
+	return cmi.cp.getNameAndType(cmi.nameAndTypeIndex)
+}
+
+func (cmi *ConstantMethodTypeInfo) DescriptorIndex() uint16 {
+	return cmi.descriptorIndex
+}
+
+func (cmi *ConstantInvokeDynamicInfo) NameAndTypeIndex() uint16 {
+	return cmi.nameAndTypeIndex
+}
@@ -33,6 +33,10 @@ func (cni *ConstantMemberrefInfo) readInfo(reader *ClassReader) {
 	cni.classIndex = reader.readUint16()
 	cni.nameAndTypeIndex = reader.readUint16()
 }
+func (cni *ConstantMemberrefInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cni.classIndex)
+	writer.writeUint16(cni.nameAndTypeIndex)
+}
 
 func (cni *ConstantMemberrefInfo) ClassName() string {
 	return cni.cp.getClassName(cni.classIndex)
@@ -40,3 +44,11 @@ func (cni *ConstantMemberrefInfo) ClassName() string {
 func (cni *ConstantMemberrefInfo) NameAndDescriptor() (string, string) {
 	return cni.cp.getNameAndType(cni.nameAndTypeIndex)
 }
+
+func (cni *ConstantMemberrefInfo) ClassIndex() uint16 {
+	return cni.classIndex
+}
+
+func (cni *ConstantMemberrefInfo) NameAndTypeIndex() uint16 {
+	return cni.nameAndTypeIndex
+}
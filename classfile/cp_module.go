@@ -0,0 +1,53 @@
+package classfile
+
+/*
+	CONSTANT_Module_info {
+	    u1 tag;
+	    u2 name_index;
+	}
+*/
+type ConstantModuleInfo struct {
+	cp        ConstantPool
+	nameIndex uint16
+}
+
+func (cmi *ConstantModuleInfo) readInfo(reader *ClassReader) {
+	cmi.nameIndex = reader.readUint16()
+}
+func (cmi *ConstantModuleInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cmi.nameIndex)
+}
+func (cmi *ConstantModuleInfo) Name() string {
+	return cmi.cp.getUtf8(cmi.nameIndex)
+}
+
+/*
+	CONSTANT_Package_info {
+	    u1 tag;
+	    u2 name_index;
+	}
+*/
+type ConstantPackageInfo struct {
+	cp        ConstantPool
+	nameIndex uint16
+}
+
+func (cpi *ConstantPackageInfo) readInfo(reader *ClassReader) {
+	cpi.nameIndex = reader.readUint16()
+}
+func (cpi *ConstantPackageInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cpi.nameIndex)
+}
+
+// Name 包名，内部形式，例如 java/lang
+func (cpi *ConstantPackageInfo) Name() string {
+	return cpi.cp.getUtf8(cpi.nameIndex)
+}
+
+func (cmi *ConstantModuleInfo) NameIndex() uint16 {
+	return cmi.nameIndex
+}
+
+func (cpi *ConstantPackageInfo) NameIndex() uint16 {
+	return cpi.nameIndex
+}
@@ -16,3 +16,15 @@ func (cnti *ConstantNameAndTypeInfo) readInfo(reader *ClassReader) {
 	cnti.nameIndex = reader.readUint16()
 	cnti.descriptorIndex = reader.readUint16()
 }
+func (cnti *ConstantNameAndTypeInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(cnti.nameIndex)
+	writer.writeUint16(cnti.descriptorIndex)
+}
+
+func (cnti *ConstantNameAndTypeInfo) NameIndex() uint16 {
+	return cnti.nameIndex
+}
+
+func (cnti *ConstantNameAndTypeInfo) DescriptorIndex() uint16 {
+	return cnti.descriptorIndex
+}
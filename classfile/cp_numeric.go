@@ -16,6 +16,9 @@ func (cii *ConstantIntegerInfo) readInfo(reader *ClassReader) {
 	bytes := reader.readUint32()
 	cii.val = int32(bytes)
 }
+func (cii *ConstantIntegerInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint32(uint32(cii.val))
+}
 func (cii *ConstantIntegerInfo) Value() int32 {
 	return cii.val
 }
@@ -34,6 +37,9 @@ func (cii *ConstantFloatInfo) readInfo(reader *ClassReader) {
 	bytes := reader.readUint32()
 	cii.val = math.Float32frombits(bytes)
 }
+func (cii *ConstantFloatInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint32(math.Float32bits(cii.val))
+}
 func (cii *ConstantFloatInfo) Value() float32 {
 	return cii.val
 }
@@ -53,6 +59,9 @@ func (cii *ConstantLongInfo) readInfo(reader *ClassReader) {
 	bytes := reader.readUint64()
 	cii.val = int64(bytes)
 }
+func (cii *ConstantLongInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint64(uint64(cii.val))
+}
 func (cii *ConstantLongInfo) Value() int64 {
 	return cii.val
 }
@@ -72,6 +81,9 @@ func (cii *ConstantDoubleInfo) readInfo(reader *ClassReader) {
 	bytes := reader.readUint64()
 	cii.val = math.Float64frombits(bytes)
 }
+func (cii *ConstantDoubleInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint64(math.Float64bits(cii.val))
+}
 func (cii *ConstantDoubleInfo) Value() float64 {
 	return cii.val
 }
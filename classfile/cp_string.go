@@ -14,6 +14,13 @@ type ConstantStringInfo struct {
 func (csi *ConstantStringInfo) readInfo(reader *ClassReader) {
 	csi.stringIndex = reader.readUint16()
 }
+func (csi *ConstantStringInfo) writeInfo(writer *ClassWriter) {
+	writer.writeUint16(csi.stringIndex)
+}
 func (csi *ConstantStringInfo) String() string {
 	return csi.cp.getUtf8(csi.stringIndex)
 }
+
+func (csi *ConstantStringInfo) StringIndex() uint16 {
+	return csi.stringIndex
+}
@@ -19,6 +19,11 @@ func (cui *ConstantUtf8Info) readInfo(reader *ClassReader) {
 	bytes := reader.readBytes(length)
 	cui.str = decodeMUTF8(bytes)
 }
+func (cui *ConstantUtf8Info) writeInfo(writer *ClassWriter) {
+	bytes := encodeMUTF8(cui.str)
+	writer.writeUint16(uint16(len(bytes)))
+	writer.writeBytes(bytes)
+}
 
 func (cui *ConstantUtf8Info) Str() string {
 	return cui.str
@@ -93,3 +98,21 @@ func decodeMUTF8(bytearr []byte) string {
 	runes := utf16.Decode(chararr)
 	return string(runes)
 }
+
+// encodeMUTF8 和 decodeMUTF8 相反，按 DataOutputStream.writeUTF 的格式编码：
+// 字符串先转成 UTF-16，\u0000 写成两个字节，补充字符的两个代理项分别写成三个字节
+func encodeMUTF8(s string) []byte {
+	chars := utf16.Encode([]rune(s))
+	bytes := make([]byte, 0, len(chars))
+	for _, c := range chars {
+		switch {
+		case c >= 0x0001 && c <= 0x007F:
+			bytes = append(bytes, byte(c))
+		case c <= 0x07FF:
+			bytes = append(bytes, byte(0xC0|c>>6&0x1F), byte(0x80|c&0x3F))
+		default:
+			bytes = append(bytes, byte(0xE0|c>>12&0x0F), byte(0x80|c>>6&0x3F), byte(0x80|c&0x3F))
+		}
+	}
+	return bytes
+}
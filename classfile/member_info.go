@@ -45,6 +45,16 @@ func readMember(reader *ClassReader, cp ConstantPool) *MemberInfo {
 	}
 }
 
+func writeMembers(writer *ClassWriter, members []*MemberInfo) {
+	writer.writeUint16(uint16(len(members)))
+	for _, member := range members {
+		writer.writeUint16(member.accessFlags)
+		writer.writeUint16(member.nameIndex)
+		writer.writeUint16(member.descriptorIndex)
+		writeAttributes(writer, member.attributes)
+	}
+}
+
 func (mIn *MemberInfo) AccessFlags() uint16 {
 	return mIn.accessFlags
 }
@@ -85,25 +95,79 @@ func (mIn *MemberInfo) ExceptionsAttribute() *ExceptionsAttribute {
 	return nil
 }
 
-func (mIn *MemberInfo) RuntimeVisibleAnnotationsAttributeData() []byte {
-	return mIn.getUnparsedAttributeData("RuntimeVisibleAnnotations")
+func (mIn *MemberInfo) MethodParametersAttribute() *MethodParametersAttribute {
+	for _, attrInfo := range mIn.attributes {
+		if attr, ok := attrInfo.(*MethodParametersAttribute); ok {
+			return attr
+		}
+	}
+	return nil
 }
-func (mIn *MemberInfo) RuntimeVisibleParameterAnnotationsAttributeData() []byte {
-	return mIn.getUnparsedAttributeData("RuntimeVisibleParameterAnnotationsAttribute")
+
+func (mIn *MemberInfo) RuntimeVisibleAnnotationsAttribute() *AnnotationsAttribute {
+	return findAnnotationsAttribute(mIn.attributes, true)
 }
-func (mIn *MemberInfo) AnnotationDefaultAttributeData() []byte {
-	return mIn.getUnparsedAttributeData("AnnotationDefault")
+func (mIn *MemberInfo) RuntimeInvisibleAnnotationsAttribute() *AnnotationsAttribute {
+	return findAnnotationsAttribute(mIn.attributes, false)
+}
+func (mIn *MemberInfo) RuntimeVisibleTypeAnnotationsAttribute() *TypeAnnotationsAttribute {
+	return findTypeAnnotationsAttribute(mIn.attributes, true)
+}
+func (mIn *MemberInfo) RuntimeInvisibleTypeAnnotationsAttribute() *TypeAnnotationsAttribute {
+	return findTypeAnnotationsAttribute(mIn.attributes, false)
 }
 
-func (mIn *MemberInfo) getUnparsedAttributeData(name string) []byte {
+func (mIn *MemberInfo) RuntimeVisibleParameterAnnotationsAttribute() *ParameterAnnotationsAttribute {
+	return mIn.parameterAnnotationsAttribute(true)
+}
+func (mIn *MemberInfo) RuntimeInvisibleParameterAnnotationsAttribute() *ParameterAnnotationsAttribute {
+	return mIn.parameterAnnotationsAttribute(false)
+}
+func (mIn *MemberInfo) parameterAnnotationsAttribute(visible bool) *ParameterAnnotationsAttribute {
 	for _, attrInfo := range mIn.attributes {
-		switch attrInfo.(type) {
-		case *UnparsedAttribute:
-			unparsedAttr := attrInfo.(*UnparsedAttribute)
-			if unparsedAttr.name == name {
-				return unparsedAttr.info
-			}
+		if attr, ok := attrInfo.(*ParameterAnnotationsAttribute); ok && attr.visible == visible {
+			return attr
 		}
 	}
 	return nil
 }
+
+func (mIn *MemberInfo) AnnotationDefaultAttribute() *AnnotationDefaultAttribute {
+	for _, attrInfo := range mIn.attributes {
+		if attr, ok := attrInfo.(*AnnotationDefaultAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+// 下面几个方法返回属性的原始内容，由 Java 代码（sun.reflect.annotation.AnnotationParser）解析
+
+func (mIn *MemberInfo) RuntimeVisibleAnnotationsAttributeData() []byte {
+	if attr := mIn.RuntimeVisibleAnnotationsAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+func (mIn *MemberInfo) RuntimeVisibleParameterAnnotationsAttributeData() []byte {
+	if attr := mIn.RuntimeVisibleParameterAnnotationsAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+func (mIn *MemberInfo) RuntimeVisibleTypeAnnotationsAttributeData() []byte {
+	if attr := mIn.RuntimeVisibleTypeAnnotationsAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+func (mIn *MemberInfo) AnnotationDefaultAttributeData() []byte {
+	if attr := mIn.AnnotationDefaultAttribute(); attr != nil {
+		return attr.info
+	}
+	return nil
+}
+
+func (mIn *MemberInfo) Attributes() []AttributeInfo {
+	return mIn.attributes
+}
@@ -12,21 +12,48 @@ type Classpath struct {
 	userClasspath Entry
 }
 
-// Parse 解析启动类路径和扩展类路径
+// Parse 解析启动类路径和扩展类路径，虚拟机只能使用 JDK 8 的 jre
 func Parse(jreOption, cpOption string) *Classpath {
+	return parse(jreOption, cpOption, false)
+}
+
+// ParseImage 和 Parse 一样，但也接受 JDK 9+ 的运行时镜像 lib/modules 作为启动类路径。
+// 镜像里的核心类需要模块系统的启动流程，虚拟机还不支持，只有 javap 这样只读取 class 文件的工具可以使用
+func ParseImage(jreOption, cpOption string) *Classpath {
+	return parse(jreOption, cpOption, true)
+}
+
+func parse(jreOption, cpOption string, allowImage bool) *Classpath {
 	cp := &Classpath{}
 	// 优先使用用户输入的-Xjre选项作为jre目录。如果没有输入该选项，则在当前目录下寻找jre目录
-	cp.parseBootAndExtClasspath(jreOption)
+	cp.parseBootAndExtClasspath(jreOption, allowImage)
 	// 使用用户输入的-cp选项或-classpath选项作为用户类路径。如果没有输入该选项，则使用当前目录作为用户类路径
 	cp.parseUserClasspath(cpOption)
 	return cp
 }
 
+// ParseUserClasspath 只解析用户类路径，找不到 jre 目录时 javap 仍然可以读取用户的类
+func ParseUserClasspath(cpOption string) *Classpath {
+	cp := &Classpath{bootClasspath: CompositeEntry{}, extClasspath: CompositeEntry{}}
+	cp.parseUserClasspath(cpOption)
+	return cp
+}
+
 // 解析启动类路径和扩展类路径
-func (cp *Classpath) parseBootAndExtClasspath(jreOption string) {
+func (cp *Classpath) parseBootAndExtClasspath(jreOption string, allowImage bool) {
 	// 获取jre目录
 	jreDir := getJreDir(jreOption)
 
+	// JDK 9+ 没有 jre 目录和扩展类路径，所有的核心类都在运行时镜像 lib/modules 里
+	if modulesPath := filepath.Join(jreDir, "lib", "modules"); exists(modulesPath) {
+		if !allowImage {
+			panic("Can not find jre folder! " + jreDir + " is a JDK 9+ runtime image, the VM needs a JDK 8 jre")
+		}
+		cp.bootClasspath = newJImageEntry(modulesPath)
+		cp.extClasspath = CompositeEntry{}
+		return
+	}
+
 	// jre/lib/*
 	jreLibPath := filepath.Join(jreDir, "lib", "*")
 	cp.bootClasspath = newWildcardEntry(jreLibPath)
@@ -36,7 +63,7 @@ func (cp *Classpath) parseBootAndExtClasspath(jreOption string) {
 	cp.extClasspath = newWildcardEntry(jreExtPath)
 }
 
-// 获取jre目录
+// 获取jre目录，JDK 9+ 的 JAVA_HOME 里没有 jre 目录，直接使用包含 lib/modules 的 JAVA_HOME
 func getJreDir(jreOption string) string {
 	if jreOption != "" && exists(jreOption) {
 		return jreOption
@@ -45,7 +72,12 @@ func getJreDir(jreOption string) string {
 		return "./jre"
 	}
 	if jh := os.Getenv("JAVA_HOME"); jh != "" {
-		return filepath.Join(jh, "jre")
+		if jreDir := filepath.Join(jh, "jre"); exists(jreDir) {
+			return jreDir
+		}
+		if exists(filepath.Join(jh, "lib", "modules")) {
+			return jh
+		}
 	}
 	panic("Can not find jre folder!")
 }
@@ -64,20 +96,37 @@ func (cp *Classpath) parseUserClasspath(cpOption string) {
 	if cpOption == "" {
 		cpOption = "."
 	}
-	cp.userClasspath = newEntry(cpOption)
+	// jar 包 MANIFEST 里的 Class-Path 也是用户类路径
+	cp.userClasspath = withManifestClassPath(newEntry(cpOption))
 }
 
 // ReadClass
 // className: fully/qualified/ClassName
 func (cp *Classpath) ReadClass(className string) ([]byte, Entry, error) {
-	className = className + ".class"
-	if data, entry, err := cp.bootClasspath.readClass(className); err == nil {
+	return cp.ReadResource(className + ".class")
+}
+
+// ReadResource 依次在启动类路径、扩展类路径和用户类路径里查找资源
+// name: path/to/resource，不以 / 开头
+func (cp *Classpath) ReadResource(name string) ([]byte, Entry, error) {
+	if data, entry, err := cp.bootClasspath.readResource(name); err == nil {
 		return data, entry, err
 	}
-	if data, entry, err := cp.extClasspath.readClass(className); err == nil {
+	if data, entry, err := cp.extClasspath.readResource(name); err == nil {
 		return data, entry, err
 	}
-	return cp.userClasspath.readClass(className)
+	return cp.userClasspath.readResource(name)
+}
+
+// ResourceURL 返回 ReadResource 找到的资源的 URL，jimage 里的资源没有对应的 URL，返回空字符串
+func ResourceURL(entry Entry, name string) string {
+	switch e := entry.(type) {
+	case *DirEntry:
+		return "file:" + filepath.ToSlash(filepath.Join(e.absDir, name))
+	case *ZipEntry:
+		return "jar:file:" + filepath.ToSlash(e.absPath) + "!/" + name
+	}
+	return ""
 }
 
 func (cp *Classpath) String() string {
@@ -7,8 +7,9 @@ import "strings"
 const pathListSeparator = string(os.PathListSeparator)
 
 type Entry interface {
-	// className: fully/qualified/ClassName.class
-	readClass(className string) ([]byte, Entry, error)
+	// 读取类路径里的资源，类文件也是资源
+	// name: fully/qualified/ClassName.class 或者 path/to/config.properties
+	readResource(name string) ([]byte, Entry, error)
 	String() string
 }
 
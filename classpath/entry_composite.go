@@ -16,15 +16,15 @@ func newCompositeEntry(pathList string) CompositeEntry {
 	return compositeEntry
 }
 
-func (self CompositeEntry) readClass(className string) ([]byte, Entry, error) {
+func (self CompositeEntry) readResource(name string) ([]byte, Entry, error) {
 	for _, entry := range self {
-		data, from, err := entry.readClass(className)
+		data, from, err := entry.readResource(name)
 		if err == nil {
 			return data, from, nil
 		}
 	}
 
-	return nil, nil, errors.New("class not found: " + className)
+	return nil, nil, errors.New("resource not found: " + name)
 }
 
 func (self CompositeEntry) String() string {
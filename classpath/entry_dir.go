@@ -1,7 +1,9 @@
 package classpath
 
+import "errors"
 import "io/ioutil"
 import "path/filepath"
+import "strings"
 
 type DirEntry struct {
 	absDir string
@@ -15,8 +17,12 @@ func newDirEntry(path string) *DirEntry {
 	return &DirEntry{absDir}
 }
 
-func (self *DirEntry) readClass(className string) ([]byte, Entry, error) {
-	fileName := filepath.Join(self.absDir, className)
+func (self *DirEntry) readResource(name string) ([]byte, Entry, error) {
+	fileName := filepath.Join(self.absDir, name)
+	// 资源名里的 .. 不能跳出类路径目录
+	if !strings.HasPrefix(fileName, self.absDir+string(filepath.Separator)) {
+		return nil, nil, errors.New("resource not found: " + name)
+	}
 	data, err := ioutil.ReadFile(fileName)
 	return data, self, err
 }
@@ -0,0 +1,280 @@
+package classpath
+
+import "bytes"
+import "compress/zlib"
+import "encoding/binary"
+import "errors"
+import "io"
+import "os"
+import "path/filepath"
+import "strings"
+
+// JDK 9+ 运行时镜像 lib/modules 的格式（jimage），参考 jdk.internal.jimage.BasicImageReader
+//
+//	header     magic(0xCAFEDADA) version flags resourceCount tableLength locationsSize stringsSize
+//	redirect   [tableLength]s4  类名哈希 -> offsets 的下标
+//	offsets    [tableLength]u4  locations 里的偏移
+//	locations  [locationsSize]u1  每个资源的属性，见 imageLocation
+//	strings    [stringsSize]u1  以 0 结尾的 UTF-8 字符串
+//	resources  资源内容，从索引（以上各部分）结束的地方开始
+//
+// 头部和两个表按生成镜像的机器的字节序存储，通过魔数判断
+const (
+	jimageMagic          = 0xCAFEDADA
+	jimageHeaderSize     = 7 * 4
+	jimageHashMultiplier = 0x01000193
+
+	jimageCompressedMagic      = 0xCAFEFAFA
+	jimageCompressedHeaderSize = 29
+)
+
+// location 属性的种类
+const (
+	attributeEnd = iota
+	attributeModule
+	attributeParent
+	attributeBase
+	attributeExtension
+	attributeOffset
+	attributeCompressed
+	attributeUncompressed
+	attributeCount
+)
+
+// jimage 类路径，只供 javap 读取 JDK 9+ 运行时镜像里的类，见 ParseImage
+type JImageEntry struct {
+	// lib/modules 的绝对路径
+	absPath string
+	file    *os.File
+	order   binary.ByteOrder
+
+	redirect  []int32
+	offsets   []uint32
+	locations []byte
+	strings   []byte
+	// 资源内容的起始位置
+	indexSize int64
+	// 包名（java/lang）-> 模块名（java.base）
+	packageToModule map[string]string
+}
+
+type imageLocation [attributeCount]uint64
+
+func newJImageEntry(path string) *JImageEntry {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		panic(err)
+	}
+	return &JImageEntry{absPath: absPath}
+}
+
+// 读取资源，name 形如 java/lang/Object.class，先根据包名找到资源所在的模块，
+// 再查找资源 /java.base/java/lang/Object.class
+func (jie *JImageEntry) readResource(name string) ([]byte, Entry, error) {
+	if jie.file == nil {
+		if err := jie.open(); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	pkg := ""
+	if i := strings.LastIndexByte(name, '/'); i >= 0 {
+		pkg = name[:i]
+	}
+	module, ok := jie.packageToModule[pkg]
+	if !ok {
+		return nil, nil, errors.New("resource not found: " + name)
+	}
+	loc, ok := jie.findLocation("/" + module + "/" + name)
+	if !ok {
+		return nil, nil, errors.New("resource not found: " + name)
+	}
+	data, err := jie.readLocation(loc)
+	return data, jie, err
+}
+
+// open 第一次读取资源时打开镜像文件并读入索引。之后一直从这个文件读取资源内容，
+// 类路径在虚拟机退出之前都会用到，所以文件不关闭，由进程退出时释放
+func (jie *JImageEntry) open() error {
+	f, err := os.Open(jie.absPath)
+	if err != nil {
+		return err
+	}
+
+	header := make([]byte, jimageHeaderSize)
+	if _, err := f.ReadAt(header, 0); err != nil {
+		f.Close()
+		return err
+	}
+	order := binary.ByteOrder(binary.LittleEndian)
+	if order.Uint32(header) != jimageMagic {
+		order = binary.BigEndian
+		if order.Uint32(header) != jimageMagic {
+			f.Close()
+			return errors.New("not a jimage file: " + jie.absPath)
+		}
+	}
+	if major := order.Uint32(header[4:]) >> 16; major != 1 {
+		f.Close()
+		return errors.New("unsupported jimage version: " + jie.absPath)
+	}
+	tableLength := int64(order.Uint32(header[16:]))
+	locationsSize := int64(order.Uint32(header[20:]))
+	stringsSize := int64(order.Uint32(header[24:]))
+
+	index := make([]byte, tableLength*8+locationsSize+stringsSize)
+	if _, err := f.ReadAt(index, jimageHeaderSize); err != nil {
+		f.Close()
+		return err
+	}
+	jie.redirect = make([]int32, tableLength)
+	jie.offsets = make([]uint32, tableLength)
+	for i := int64(0); i < tableLength; i++ {
+		jie.redirect[i] = int32(order.Uint32(index[i*4:]))
+		jie.offsets[i] = order.Uint32(index[(tableLength+i)*4:])
+	}
+	jie.locations = index[tableLength*8 : tableLength*8+locationsSize]
+	jie.strings = index[tableLength*8+locationsSize:]
+	jie.indexSize = jimageHeaderSize + int64(len(index))
+	jie.file = f
+	jie.order = order
+	jie.buildPackageMap()
+	return nil
+}
+
+// 遍历所有资源，记录每个包所在的模块。/modules 和 /packages 下是镜像自己的目录信息，不是资源
+func (jie *JImageEntry) buildPackageMap() {
+	jie.packageToModule = make(map[string]string)
+	for _, offset := range jie.offsets {
+		loc := jie.decodeLocation(offset)
+		module := jie.getString(loc[attributeModule])
+		if module == "" || module == "modules" || module == "packages" {
+			continue
+		}
+		parent := jie.getString(loc[attributeParent])
+		if _, ok := jie.packageToModule[parent]; !ok {
+			jie.packageToModule[parent] = module
+		}
+	}
+}
+
+// 按名字查找资源，名字的哈希值经过 redirect 表得到 offsets 的下标，最后还要比较完整的名字
+func (jie *JImageEntry) findLocation(name string) (imageLocation, bool) {
+	length := int32(len(jie.redirect))
+	if length == 0 {
+		return imageLocation{}, false
+	}
+	index := jimageHash(name, jimageHashMultiplier) % length
+	value := jie.redirect[index]
+	switch {
+	case value < 0:
+		index = -1 - value
+	case value > 0:
+		index = jimageHash(name, value) % length
+	default:
+		return imageLocation{}, false
+	}
+
+	loc := jie.decodeLocation(jie.offsets[index])
+	if jie.locationName(loc) != name {
+		return imageLocation{}, false
+	}
+	return loc, true
+}
+
+// 和 ImageStringsReader.hashCode 一样的 FNV 哈希
+func jimageHash(name string, seed int32) int32 {
+	for i := 0; i < len(name); i++ {
+		seed = (seed * jimageHashMultiplier) ^ int32(name[i])
+	}
+	return seed & 0x7FFFFFFF
+}
+
+// location 的每个属性以一个字节开始，高 5 位是种类，低 3 位是值的字节数减 1，值按大端序存储
+func (jie *JImageEntry) decodeLocation(offset uint32) imageLocation {
+	var loc imageLocation
+	for i := int(offset); i < len(jie.locations); {
+		b := jie.locations[i]
+		kind := b >> 3
+		if kind == attributeEnd || kind >= attributeCount {
+			break
+		}
+		n := int(b&0x7) + 1
+		var value uint64
+		for j := 1; j <= n && i+j < len(jie.locations); j++ {
+			value = value<<8 | uint64(jie.locations[i+j])
+		}
+		loc[kind] = value
+		i += n + 1
+	}
+	return loc
+}
+
+// 资源的完整名字 /module/parent/base.extension
+func (jie *JImageEntry) locationName(loc imageLocation) string {
+	var sb strings.Builder
+	if module := jie.getString(loc[attributeModule]); module != "" {
+		sb.WriteString("/" + module + "/")
+	}
+	if parent := jie.getString(loc[attributeParent]); parent != "" {
+		sb.WriteString(parent + "/")
+	}
+	sb.WriteString(jie.getString(loc[attributeBase]))
+	if extension := jie.getString(loc[attributeExtension]); extension != "" {
+		sb.WriteString("." + extension)
+	}
+	return sb.String()
+}
+
+func (jie *JImageEntry) getString(offset uint64) string {
+	if offset >= uint64(len(jie.strings)) {
+		return ""
+	}
+	s := jie.strings[offset:]
+	if end := bytes.IndexByte(s, 0); end >= 0 {
+		s = s[:end]
+	}
+	return string(s)
+}
+
+// 读取资源内容，使用 jlink --compress 生成的镜像里的资源可能是压缩过的
+func (jie *JImageEntry) readLocation(loc imageLocation) ([]byte, error) {
+	size := loc[attributeUncompressed]
+	if compressed := loc[attributeCompressed]; compressed != 0 {
+		size = compressed
+	}
+	data := make([]byte, size)
+	if _, err := jie.file.ReadAt(data, jie.indexSize+int64(loc[attributeOffset])); err != nil {
+		return nil, err
+	}
+	if loc[attributeCompressed] != 0 {
+		return jie.decompress(data)
+	}
+	return data, nil
+}
+
+// 压缩过的资源以一个头部开始：
+// magic(u4) compressedSize(u8) uncompressedSize(u8) decompressorNameOffset(u4) contentOffset(u4) isTerminal(u1)。
+// 可能被压缩了多次，一直解压到没有这个头部为止。只支持 zip 压缩
+func (jie *JImageEntry) decompress(data []byte) ([]byte, error) {
+	for len(data) >= jimageCompressedHeaderSize && jie.order.Uint32(data) == jimageCompressedMagic {
+		decompressor := jie.getString(uint64(jie.order.Uint32(data[20:])))
+		if decompressor != "zip" {
+			return nil, errors.New("unsupported jimage decompressor: " + decompressor)
+		}
+		r, err := zlib.NewReader(bytes.NewReader(data[jimageCompressedHeaderSize:]))
+		if err != nil {
+			return nil, err
+		}
+		data, err = io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return data, nil
+}
+
+func (jie *JImageEntry) String() string {
+	return jie.absPath
+}
@@ -23,8 +23,8 @@ func newZipEntry(path string) *ZipEntry {
 	return &ZipEntry{absPath, nil}
 }
 
-// 读取类文件
-func (zipE *ZipEntry) readClass(className string) ([]byte, Entry, error) {
+// 读取压缩包里的文件
+func (zipE *ZipEntry) readResource(name string) ([]byte, Entry, error) {
 	if zipE.zipRC == nil {
 		err := zipE.openJar()
 		if err != nil {
@@ -32,12 +32,12 @@ func (zipE *ZipEntry) readClass(className string) ([]byte, Entry, error) {
 		}
 	}
 
-	classFile := zipE.findClass(className)
-	if classFile == nil {
-		return nil, nil, errors.New("class not found: " + className)
+	file := zipE.findFile(name)
+	if file == nil {
+		return nil, nil, errors.New("resource not found: " + name)
 	}
 
-	data, err := readClass(classFile)
+	data, err := readFile(file)
 	return data, zipE, err
 }
 
@@ -50,21 +50,21 @@ func (zipE *ZipEntry) openJar() error {
 	return err
 }
 
-func (zipE *ZipEntry) findClass(className string) *zip.File {
+func (zipE *ZipEntry) findFile(name string) *zip.File {
 	for _, f := range zipE.zipRC.File {
-		if f.Name == className {
+		if f.Name == name {
 			return f
 		}
 	}
 	return nil
 }
 
-func readClass(classFile *zip.File) ([]byte, error) {
-	rc, err := classFile.Open()
+func readFile(file *zip.File) ([]byte, error) {
+	rc, err := file.Open()
 	if err != nil {
 		return nil, err
 	}
-	// read class data
+	// read file data
 	data, err := ioutil.ReadAll(rc)
 	rc.Close()
 	if err != nil {
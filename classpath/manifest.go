@@ -0,0 +1,127 @@
+package classpath
+
+import "bufio"
+import "bytes"
+import "errors"
+import "net/url"
+import "os"
+import "path/filepath"
+import "strings"
+
+const manifestName = "META-INF/MANIFEST.MF"
+
+// ReadMainClass 读取 jar 包 MANIFEST 里的 Main-Class，用于 -jar 模式
+func ReadMainClass(jarPath string) (string, error) {
+	zipE := newZipEntry(jarPath)
+	if err := zipE.openJar(); err != nil {
+		return "", errors.New("Unable to access jarfile " + jarPath)
+	}
+	defer zipE.zipRC.Close()
+	if mainClass := zipE.manifest()["main-class"]; mainClass != "" {
+		return mainClass, nil
+	}
+	return "", errors.New("no main manifest attribute, in " + jarPath)
+}
+
+// manifest 返回 jar 包 MANIFEST 的主属性，属性名统一转成小写。没有 MANIFEST 时返回 nil
+func (zipE *ZipEntry) manifest() map[string]string {
+	if zipE.zipRC == nil && zipE.openJar() != nil {
+		return nil
+	}
+	f := zipE.findFile(manifestName)
+	if f == nil {
+		return nil
+	}
+	data, err := readFile(f)
+	if err != nil {
+		return nil
+	}
+	return parseManifest(data)
+}
+
+// parseManifest 解析 MANIFEST 的主属性部分（第一个空行之前），每行是 "名字: 值"，
+// 一行最长 72 字节，超出的部分写在下一行，以一个空格开头
+func parseManifest(data []byte) map[string]string {
+	attrs := map[string]string{}
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if line == "" {
+			break
+		}
+		if line[0] == ' ' && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+		} else {
+			lines = append(lines, line)
+		}
+	}
+	for _, line := range lines {
+		if i := strings.Index(line, ": "); i > 0 {
+			attrs[strings.ToLower(line[:i])] = strings.TrimSpace(line[i+2:])
+		}
+	}
+	return attrs
+}
+
+// manifestClassPath 返回 MANIFEST 里 Class-Path 列出的类路径的绝对路径。
+// Class-Path 是空格分隔的相对 URL，相对于 jar 包所在的目录，以 / 结尾的是目录
+func (zipE *ZipEntry) manifestClassPath() []string {
+	var paths []string
+	baseDir := filepath.Dir(zipE.absPath)
+	for _, ref := range strings.Fields(zipE.manifest()["class-path"]) {
+		u, err := url.Parse(ref)
+		if err != nil || (u.Scheme != "" && u.Scheme != "file") {
+			continue
+		}
+		path := filepath.FromSlash(u.Path)
+		if !filepath.IsAbs(path) {
+			path = filepath.Join(baseDir, path)
+		}
+		if strings.HasSuffix(u.Path, "/") {
+			path += string(filepath.Separator)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+// withManifestClassPath 把类路径里 jar 包的 Class-Path 加到这个 jar 包的后面，
+// Class-Path 引用的 jar 包的 Class-Path 也要继续展开，已经在类路径里的 jar 包和目录不再重复加入
+func withManifestClassPath(entry Entry) Entry {
+	visited := map[string]bool{}
+	expanded := CompositeEntry{}
+	var expand func(entry Entry)
+	expand = func(entry Entry) {
+		switch e := entry.(type) {
+		case CompositeEntry:
+			for _, child := range e {
+				expand(child)
+			}
+		case *ZipEntry:
+			if visited[e.absPath] {
+				return
+			}
+			visited[e.absPath] = true
+			expanded = append(expanded, e)
+			for _, path := range e.manifestClassPath() {
+				if info, err := os.Stat(path); err != nil {
+					continue // 和 java 一样忽略不存在的类路径
+				} else if info.IsDir() {
+					expand(newDirEntry(path))
+				} else {
+					expand(newZipEntry(path))
+				}
+			}
+		case *DirEntry:
+			if !visited[e.absDir] {
+				visited[e.absDir] = true
+				expanded = append(expanded, e)
+			}
+		default:
+			expanded = append(expanded, e)
+		}
+	}
+	expand(entry)
+	return expanded
+}
@@ -2,9 +2,13 @@ package main
 
 import "flag"
 import "fmt"
+import "math"
 import "os"
+import "strconv"
+import "strings"
 
 // java [-options] class [args...]
+// java [-options] -jar jarfile [args...]
 
 // Cmd 结构体存储命令行参数。
 type Cmd struct {
@@ -14,6 +18,10 @@ type Cmd struct {
 	verboseInstFlag  bool     // -verbose:inst 选项，启用指令执行的详细输出
 	cpOption         string   // -classpath 或 -cp 选项，指定类路径
 	XjreOption       string   // -Xjre 选项，指定JRE路径
+	XssOption        uint64   // -Xss 选项，线程栈大小（字节）
+	XmxOption        uint64   // -Xmx 选项，最大堆大小（字节）
+	XverifyOption    string   // -Xverify 选项，字节码验证的范围：none、remote 或 all
+	jarOption        string   // -jar 选项，执行 jar 包，主类由 MANIFEST 的 Main-Class 指定
 	class            string   // 要执行的类名
 	args             []string // 传递给main方法的参数
 }
@@ -35,13 +43,20 @@ func parseCmd() *Cmd {
 	flag.StringVar(&cmd.cpOption, "classpath", "", "指定类路径")
 	flag.StringVar(&cmd.cpOption, "cp", "", "指定类路径")
 	flag.StringVar(&cmd.XjreOption, "Xjre", "", "指定JRE路径")
+	flag.Var((*memorySize)(&cmd.XssOption), "Xss", "设置线程栈大小，例如 512k")
+	flag.Var((*memorySize)(&cmd.XmxOption), "Xmx", "设置最大堆大小，例如 256m")
+	cmd.XverifyOption = "remote"
+	flag.Var((*verifyOption)(&cmd.XverifyOption), "Xverify", "设置字节码验证的范围：none、remote 或 all")
+	flag.StringVar(&cmd.jarOption, "jar", "", "执行 jar 包")
 
 	// 解析命令行选项。
-	flag.Parse()
+	flag.CommandLine.Parse(normalizeArgs(os.Args[1:]))
 
 	// 获取非选项参数（类名和程序参数）。
 	args := flag.Args()
-	if len(args) > 0 {
+	if cmd.jarOption != "" {
+		cmd.args = args // -jar 模式下，jar 包之后的参数都是程序参数
+	} else if len(args) > 0 {
 		cmd.class = args[0] // 第一个非选项参数是类名
 		cmd.args = args[1:] // 后续的非选项参数是程序参数
 	}
@@ -52,5 +67,95 @@ func parseCmd() *Cmd {
 // printUsage 打印使用方法。
 func printUsage() {
 	fmt.Printf("Usage: %s [-options] class [args...]\n", os.Args[0])
+	fmt.Printf("   or  %s [-options] -jar jarfile [args...]\n", os.Args[0])
+	fmt.Printf("   or  %s javap [-c] [-v] [-p] [-l] [-s] [-cp path] class|file.class...\n", os.Args[0])
+	fmt.Printf("   or  %s jasmin [-d dir] file.j...\n", os.Args[0])
 	// flag.PrintDefaults()  // 可以选择取消注释，打印详细的选项说明
 }
+
+// normalizeArgs 把 -Xss512k、-Xmx1g 这种选项名和值连在一起的写法改成 -Xss=512k，
+// -Xverify:none 改成 -Xverify=none，这样 flag 包才能识别。遇到类名或者 jar 包之后的参数原样保留
+func normalizeArgs(args []string) []string {
+	normalized := make([]string, 0, len(args))
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if !strings.HasPrefix(arg, "-") {
+			return append(normalized, args[i:]...) // 类名和程序参数
+		}
+		switch {
+		case arg == "-classpath" || arg == "-cp" || arg == "-Xjre" || arg == "-Xss" || arg == "-Xmx":
+			normalized = append(normalized, arg) // 下一个参数是选项的值
+			if i+1 < len(args) {
+				i++
+				normalized = append(normalized, args[i])
+			}
+			continue
+		case arg == "-jar":
+			// jar 包之后的参数都是程序参数，即使以 - 开头，用 -- 结束选项的解析
+			normalized = append(normalized, arg)
+			if i+1 < len(args) {
+				normalized = append(normalized, args[i+1], "--")
+				normalized = append(normalized, args[i+2:]...)
+			}
+			return normalized
+		case (strings.HasPrefix(arg, "-Xss") || strings.HasPrefix(arg, "-Xmx")) &&
+			len(arg) > 4 && arg[4] != '=':
+			arg = arg[:4] + "=" + arg[4:]
+		case strings.HasPrefix(arg, "-Xverify:"):
+			arg = "-Xverify=" + arg[len("-Xverify:"):]
+		}
+		normalized = append(normalized, arg)
+	}
+	return normalized
+}
+
+// memorySize 解析 -Xss 和 -Xmx 的值，支持 k、m、g 后缀（不区分大小写）
+type memorySize uint64
+
+func (size *memorySize) String() string {
+	return strconv.FormatUint(uint64(*size), 10)
+}
+
+func (size *memorySize) Set(value string) error {
+	if value == "" {
+		return fmt.Errorf("invalid memory size")
+	}
+	unit := uint64(1)
+	switch strings.ToLower(value[len(value)-1:]) {
+	case "k":
+		unit = 1024
+	case "m":
+		unit = 1024 * 1024
+	case "g":
+		unit = 1024 * 1024 * 1024
+	}
+	if unit > 1 {
+		value = value[:len(value)-1]
+	}
+
+	n, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || n == 0 {
+		return fmt.Errorf("invalid memory size")
+	}
+	if n > math.MaxInt64/unit {
+		return fmt.Errorf("memory size too large")
+	}
+	*size = memorySize(n * unit) // 不超过 math.MaxInt64，转成 int64 不会变成负数
+	return nil
+}
+
+// verifyOption 解析 -Xverify 的值
+type verifyOption string
+
+func (mode *verifyOption) String() string {
+	return string(*mode)
+}
+
+func (mode *verifyOption) Set(value string) error {
+	switch value {
+	case "none", "remote", "all":
+		*mode = verifyOption(value)
+		return nil
+	}
+	return fmt.Errorf("invalid verify mode")
+}
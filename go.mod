@@ -1,3 +1,3 @@
 module jvm-go
 
-go 1.22.3
+go 1.24
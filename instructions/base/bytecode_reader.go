@@ -1,5 +1,10 @@
 package base
 
+import "errors"
+
+// ErrTruncatedCode 读取的字节码超出了 code 数组的末尾，BytecodeReader 用它 panic
+var ErrTruncatedCode = errors.New("truncated bytecode")
+
 type BytecodeReader struct {
 	code []byte // bytecodes
 	pc   int
@@ -18,6 +23,9 @@ func (self *BytecodeReader) ReadInt8() int8 {
 	return int8(self.ReadUint8())
 }
 func (self *BytecodeReader) ReadUint8() uint8 {
+	if self.pc >= len(self.code) {
+		panic(ErrTruncatedCode)
+	}
 	i := self.code[self.pc]
 	self.pc++
 	return i
@@ -42,6 +50,10 @@ func (self *BytecodeReader) ReadInt32() int32 {
 
 // used by lookupswitch and tableswitch
 func (self *BytecodeReader) ReadInt32s(n int32) []int32 {
+	// 先检查剩下的字节数，避免按错误的 high - low 或 npairs 分配很大的数组
+	if n < 0 || int(n) > (len(self.code)-self.pc)/4 {
+		panic(ErrTruncatedCode)
+	}
 	ints := make([]int32, n)
 	for i := range ints {
 		ints[i] = self.ReadInt32()
@@ -1,55 +1,143 @@
 package base
 
 import (
+	"jvm-go/native"
 	"jvm-go/rtda"
 	"jvm-go/rtda/heap"
 )
 
 // jvms 5.5
 
-// InitClass 初始化一个类。这涉及将类标记为初始化进行中，安排其 <clinit> 方法执行，
-// 以及在必要时递归初始化其超类。
+// 初始化 shim 栈帧的局部变量
+const (
+	initVarClass       = 0 // 要初始化的类（java.lang.Class 对象）
+	initVarStep        = 1 // 已经完成的步骤数
+	initVarRequesterPC = 2 // 请求初始化的栈帧里触发初始化的指令地址
+)
+
+func init() {
+	initMethod := heap.ShimInitClassMethod()
+	native.Register(initMethod.Class().Name(), initMethod.Name(), initMethod.Descriptor(), initClassStep)
+}
+
+// InitClass 初始化一个类。
+// 其他线程正在初始化这个类时先等待它完成；类已经初始化完成或者正在由当前线程初始化时什么也不做；
+// 之前初始化失败过的类抛出 NoClassDefFoundError。
+// 否则压入一个初始化 shim 栈帧，由它依次初始化父类和父接口、执行 <clinit>，最后标记类初始化完成。
+// 调用者需要先撤销当前指令（RevertNextPC），初始化完成后重新执行这条指令
 func InitClass(thread *rtda.Thread, class *heap.Class) {
-	// 将类标记为初始化进行中，以防止循环初始化。
-	class.StartInit()
+	if !class.BeginInit(thread) {
+		return
+	}
+
+	initFrame := thread.NewFrame(heap.ShimInitClassMethod())
+	vars := initFrame.LocalVars()
+	vars.SetRef(initVarClass, class.JClass())
+	vars.SetInt(initVarStep, 0)
+	if !thread.IsStackEmpty() {
+		vars.SetInt(initVarRequesterPC, int32(thread.CurrentFrame().NextPC()))
+	}
+	thread.PushFrame(initFrame)
+}
+
+// initClassStep 初始化 shim 栈帧每次回到栈顶时执行下一步，
+// 需要执行 Java 代码时撤销 invokenative 指令并压入新的栈帧，等它们返回后再继续
+func initClassStep(frame *rtda.Frame) {
+	thread := frame.Thread()
+	vars := frame.LocalVars()
+	class := vars.GetRef(initVarClass).Extra().(*heap.Class)
+	step := int(vars.GetInt(initVarStep))
 
-	// 安排类初始化方法 (<clinit>) 执行。
-	scheduleClinit(thread, class)
+	// 第 7 步：初始化父类和父接口
+	supers := superTypesToInit(class)
+	for step < len(supers) {
+		super := supers[step]
+		step++
+		vars.SetInt(initVarStep, int32(step))
+		if super.NeedsInit(thread) {
+			frame.RevertNextPC()
+			InitClass(thread, super)
+			return
+		}
+	}
+
+	// 第 9 步：执行 <clinit>
+	if step == len(supers) {
+		vars.SetInt(initVarStep, int32(step+1))
+		if clinit := class.GetClinitMethod(); clinit != nil && clinit.Class() == class {
+			frame.RevertNextPC()
+			thread.PushFrame(thread.NewFrame(clinit))
+			return
+		}
+	}
 
-	// 递归初始化超类（如果存在并且尚未初始化）。
-	initSuperClass(thread, class)
+	// 第 10 步
+	class.FinishInit()
 }
 
-// scheduleClinit 安排给定类的 <clinit> 方法执行。
-// <clinit> 方法负责初始化静态字段和执行静态初始化块。
-// 保证每个类只执行一次。
-func scheduleClinit(thread *rtda.Thread, class *heap.Class) {
-	// 获取类的 <clinit> 方法。
-	clinit := class.GetClinitMethod()
+// superTypesToInit 返回初始化类之前要初始化的父类和父接口。
+// 接口不需要初始化父接口；类要初始化父类，以及声明了非抽象、非静态方法（默认方法）的父接口，
+// 父接口按照 interfaces 的顺序深度优先排列，父接口排在子接口前面
+func superTypesToInit(class *heap.Class) []*heap.Class {
+	if class.IsInterface() {
+		return nil
+	}
 
-	// 确保 clinit 方法存在并且属于此类（而不是超类）。
-	// 此检查可防止循环继承情况下的无限递归。
-	if clinit != nil && clinit.Class() == class {
-		// 为 <clinit> 方法创建一个新的帧。
-		newFrame := thread.NewFrame(clinit)
+	var supers []*heap.Class
+	if class.SuperClass() != nil {
+		supers = append(supers, class.SuperClass())
+	}
+	return appendInterfacesToInit(supers, class.Interfaces())
+}
 
-		// 将新帧推送到线程的操作数栈上。这有效地安排了 <clinit> 方法由虚拟机执行。
-		thread.PushFrame(newFrame)
+func appendInterfacesToInit(supers []*heap.Class, ifaces []*heap.Class) []*heap.Class {
+	for _, iface := range ifaces {
+		supers = appendInterfacesToInit(supers, iface.Interfaces())
+		if declaresDefaultMethod(iface) && !containsClass(supers, iface) {
+			supers = append(supers, iface)
+		}
 	}
+	return supers
 }
 
-// initSuperClass 初始化给定类的超类（如果存在且尚未开始初始化）。
-// 这确保在子类之前初始化超类。
-func initSuperClass(thread *rtda.Thread, class *heap.Class) {
-	// 仅初始化非接口类的超类。接口没有传统意义上的超类（它们隐式继承自 java.lang.Object，
-	// 但该初始化在别处处理）。
-	if !class.IsInterface() {
-		// 获取类的超类。
-		superClass := class.SuperClass()
+func declaresDefaultMethod(iface *heap.Class) bool {
+	for _, method := range iface.Methods() {
+		if !method.IsAbstract() && !method.IsStatic() {
+			return true
+		}
+	}
+	return false
+}
 
-		// 如果超类存在并且尚未开始初始化，则递归初始化它。
-		if superClass != nil && !superClass.InitStarted() {
-			InitClass(thread, superClass)
+func containsClass(classes []*heap.Class, class *heap.Class) bool {
+	for _, c := range classes {
+		if c == class {
+			return true
 		}
 	}
+	return false
+}
+
+// failClassInit 异常经过初始化 shim 栈帧时调用，这时 shim 栈帧已经弹出。
+// 把类标记为错误状态（第 11、12 步），不是 Error 的异常包装成 ExceptionInInitializerError，
+// 返回 true 表示已经安排抛出包装后的异常
+func failClassInit(thread *rtda.Thread, initFrame *rtda.Frame, ex *heap.Object) bool {
+	vars := initFrame.LocalVars()
+	class := vars.GetRef(initVarClass).Extra().(*heap.Class)
+	class.FailInit()
+
+	if !thread.IsStackEmpty() {
+		// 请求初始化的指令已经被撤销，让异常处理表按这条指令查找
+		requester := thread.CurrentFrame()
+		requester.SetNextPC(int(vars.GetInt(initVarRequesterPC)) + 1)
+	}
+
+	loader := class.Loader()
+	if ex.IsInstanceOf(loader.LoadClass("java/lang/Error")) {
+		return false
+	}
+	eiieClass := loader.LoadClass("java/lang/ExceptionInInitializerError")
+	ctor := eiieClass.GetConstructor("(Ljava/lang/Throwable;)V")
+	throwNew(thread, eiieClass, ctor, ex)
+	return true
 }
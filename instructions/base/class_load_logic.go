@@ -0,0 +1,65 @@
+package base
+
+import (
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"strings"
+)
+
+// 加载类的 shim 栈帧的局部变量
+const (
+	loadVarLoader      = 0 // java.lang.ClassLoader 对象
+	loadVarName        = 1 // 要加载的类名，java.lang.String
+	loadVarStep        = 2 // 已经完成的步骤数
+	loadVarRequesterPC = 3 // 请求加载类的栈帧里触发加载的指令地址
+)
+
+func init() {
+	loadMethod := heap.ShimLoadClassMethod()
+	native.Register(loadMethod.Class().Name(), loadMethod.Name(), loadMethod.Descriptor(), loadClassStep)
+}
+
+// LoadClassByJava 用 Java 代码为用户自定义类加载器加载类，见 heap.LoadRequest。
+// 压入一个 shim 栈帧，由它调用 loader.loadClass(name) 并把返回的类记录到类加载器里，
+// 然后重新执行当前栈帧里触发加载的指令（地址是 pc）。
+// 加载失败时异常从 shim 栈帧抛出，当前栈帧的 nextPC 仍然在这条指令之后，能找到正确的异常处理项
+func LoadClassByJava(thread *rtda.Thread, req *heap.LoadRequest, pc int) {
+	loadFrame := thread.NewFrame(heap.ShimLoadClassMethod())
+	vars := loadFrame.LocalVars()
+	vars.SetRef(loadVarLoader, req.Loader.JLoader())
+	vars.SetRef(loadVarName, heap.JString(req.Loader, strings.ReplaceAll(req.Name, "/", ".")))
+	vars.SetInt(loadVarStep, 0)
+	vars.SetInt(loadVarRequesterPC, int32(pc))
+	thread.PushFrame(loadFrame)
+}
+
+// loadClassStep 第一次执行时调用 loadClass，loadClass 返回后检查并记录加载的类
+func loadClassStep(frame *rtda.Frame) {
+	thread := frame.Thread()
+	vars := frame.LocalVars()
+	jLoader := vars.GetRef(loadVarLoader)
+	jName := vars.GetRef(loadVarName)
+	stack := frame.OperandStack()
+
+	if vars.GetInt(loadVarStep) == 0 {
+		vars.SetInt(loadVarStep, 1)
+		frame.RevertNextPC()
+		loadClass := heap.LookupMethodInClass(jLoader.Class(), "loadClass", "(Ljava/lang/String;)Ljava/lang/Class;")
+		stack.PushRef(jLoader)
+		stack.PushRef(jName)
+		InvokeMethod(frame, loadClass)
+		return
+	}
+
+	name := strings.ReplaceAll(heap.GoString(jName), ".", "/")
+	jClass := stack.PopRef()
+	if jClass == nil || jClass.Extra().(*heap.Class).Name() != name {
+		panic("java.lang.NoClassDefFoundError: " + name)
+	}
+	heap.LoaderOf(jLoader).AddLoadedClass(name, jClass.Extra().(*heap.Class))
+
+	// 弹出 shim 栈帧，重新执行请求加载类的指令
+	thread.PopFrame()
+	thread.CurrentFrame().SetNextPC(int(vars.GetInt(loadVarRequesterPC)))
+}
@@ -0,0 +1,149 @@
+package base
+
+import (
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// ParseJavaException 把 panic("java.lang.ArithmeticException: / by zero") 这样的错误
+// 拆成异常类名（java/lang/ArithmeticException）和消息，其他 panic 返回 ok == false
+func ParseJavaException(r interface{}) (className, message string, ok bool) {
+	s, isString := r.(string)
+	if !isString || !strings.HasPrefix(s, "java") {
+		return "", "", false
+	}
+
+	end := strings.IndexFunc(s, func(c rune) bool {
+		return !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '.' && c != '_' && c != '$'
+	})
+	if end < 0 {
+		end = len(s)
+	}
+	className = strings.ReplaceAll(s[:end], ".", "/")
+	message = strings.TrimPrefix(s[end:], ": ")
+	if message == s[end:] {
+		message = "" // 例如 "java.lang.UnsupportedClassVersionError!"
+	}
+	return className, message, true
+}
+
+// ThrowException 抛出虚拟机发现的异常，例如空指针、除零和类型转换错误。
+// 先创建异常对象并调用构造函数（构造函数会填写调用栈），
+// 然后由 shim 栈帧里的 athrow 指令像用户代码抛出异常一样查找异常处理代码。
+// frame 是出错的栈帧，它的 nextPC 必须在出错指令之后，这样才能找到正确的异常处理项
+func ThrowException(frame *rtda.Frame, className, message string) {
+	thread := frame.Thread()
+	loader := classLoaderOf(thread)
+	exClass := loader.LoadClass(className)
+
+	var ctor *heap.Method
+	if message != "" {
+		ctor = exClass.GetConstructor("(Ljava/lang/String;)V")
+	}
+	if ctor == nil {
+		ctor = exClass.GetConstructor("()V")
+		message = ""
+	}
+
+	if message == "" {
+		throwNew(thread, exClass, ctor)
+	} else {
+		throwNew(thread, exClass, ctor, heap.JString(loader, message))
+	}
+}
+
+// throwNew 创建异常对象并调用构造函数，构造函数返回后由 shim 栈帧里的 athrow 抛出
+func throwNew(thread *rtda.Thread, exClass *heap.Class, ctor *heap.Method, args ...*heap.Object) {
+	// [ex, ex, args...]，构造函数返回后栈顶剩下 ex
+	ex := exClass.NewObject()
+	ops := rtda.NewOperandStack(uint(len(args) + 2))
+	ops.PushRef(ex)
+	ops.PushRef(ex)
+	for _, arg := range args {
+		ops.PushRef(arg)
+	}
+	shimFrame := rtda.NewAthrowShimFrame(thread, ops)
+	thread.PushFrame(shimFrame)
+	InvokeMethod(shimFrame, ctor)
+
+	if exClass.NeedsInit(thread) {
+		InitClass(thread, exClass)
+	}
+}
+
+// shim 栈帧的类没有类加载器，找离栈顶最近的 Java 方法的类加载器
+func classLoaderOf(thread *rtda.Thread) *heap.ClassLoader {
+	for _, frame := range thread.GetFrames() {
+		if loader := frame.Method().Class().Loader(); loader != nil {
+			return loader
+		}
+	}
+	panic("no class loader available")
+}
+
+// FindAndGotoExceptionHandler 从当前栈帧开始逐个查找异常处理表，
+// 找到后清空操作数栈、压入异常对象并跳转到处理代码；找不到的栈帧直接弹出。
+// 异常经过类初始化的 shim 栈帧时可能被包装成 ExceptionInInitializerError 重新抛出，这时也返回 true
+func FindAndGotoExceptionHandler(thread *rtda.Thread, ex *heap.Object) bool {
+	for {
+		frame := thread.CurrentFrame()
+		if frame.Method() == heap.ShimInitClassMethod() {
+			// 异常经过正在初始化的类
+			thread.PopFrame()
+			if failClassInit(thread, frame, ex) {
+				return true
+			}
+		} else {
+			pc := frame.NextPC() - 1
+			handlerPC := frame.Method().FindExceptionHandler(ex.Class(), pc)
+			if handlerPC >= 0 {
+				stack := frame.OperandStack()
+				stack.Clear()
+				stack.PushRef(ex)
+				frame.SetNextPC(handlerPC)
+				return true
+			}
+			thread.PopFrame()
+		}
+
+		if thread.IsStackEmpty() {
+			break
+		}
+	}
+	return false
+}
+
+// HandleUncaughtException 线程因为没有被捕获的异常而结束，调用 Thread.dispatchUncaughtException()
+// 交给线程的 UncaughtExceptionHandler 处理，Thread.exit() 由线程结束时的 ExitThread 调用。
+// 线程还没有 Thread 对象，或者异常是 dispatchUncaughtException() 和 exit() 抛出的，直接打印异常和它的调用栈
+func HandleUncaughtException(thread *rtda.Thread, ex *heap.Object) {
+	thread.ClearStack()
+	if thread.JThread() != nil && !thread.IsExiting() {
+		thread.SetExiting()
+		invokeThreadMethod(thread, "dispatchUncaughtException", "(Ljava/lang/Throwable;)V", ex)
+		return
+	}
+	printUncaughtException(ex)
+}
+
+func printUncaughtException(ex *heap.Object) {
+	msg := ex.Class().JavaName()
+	if jMsg := ex.GetRefVar("detailMessage", "Ljava/lang/String;"); jMsg != nil {
+		msg += ": " + heap.GoString(jMsg)
+	}
+	println(msg)
+
+	if ex.Extra() == nil {
+		return
+	}
+	stes := reflect.ValueOf(ex.Extra())
+	for i := 0; i < stes.Len(); i++ {
+		ste := stes.Index(i).Interface().(interface {
+			String() string
+		})
+		println("\tat " + ste.String())
+	}
+}
@@ -0,0 +1,53 @@
+package base
+
+import (
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+// InvokeMethodHandle 执行 MethodHandle.invokeExact() 和 invoke() 这样的签名多态方法。
+// 操作数栈上依次是方法句柄和按调用点描述符 descriptor 排列的参数
+func InvokeMethodHandle(frame *rtda.Frame, name, descriptor string) {
+	md := heap.ParseMethodDescriptor(descriptor)
+	jMethodHandle := frame.OperandStack().GetRefFromTop(md.ArgSlotCount())
+	if jMethodHandle == nil {
+		panic("java.lang.NullPointerException")
+	}
+
+	mh, ok := jMethodHandle.Extra().(*heap.MethodHandle)
+	if !ok || (name != "invokeExact" && name != "invoke") {
+		panic("java.lang.UnsupportedOperationException: " +
+			jMethodHandle.Class().JavaName() + "." + name + descriptor)
+	}
+	if name == "invokeExact" && descriptor != mh.Descriptor() {
+		panic("java.lang.invoke.WrongMethodTypeException: expected " +
+			mh.Descriptor() + " but found " + descriptor)
+	}
+
+	InvokeMethod(frame, mh.Invoker(descriptor))
+}
+
+// InitJMethodHandle 把 heap.NewJMethodHandle 创建的对象压入 frame 的操作数栈，然后调用构造函数
+// DirectMethodHandle(MethodType, LambdaForm, MemberName) 初始化它。不支持 LambdaForm，form 是 null。
+// 构造函数返回以后 frame 接着执行，栈顶就是初始化好的对象
+func InitJMethodHandle(frame *rtda.Frame, jMethodHandle, jMemberName *heap.Object) {
+	thread := frame.Thread()
+	class := jMethodHandle.Class()
+	mh := jMethodHandle.Extra().(*heap.MethodHandle)
+	frame.OperandStack().PushRef(jMethodHandle)
+
+	ops := rtda.NewOperandStack(4)
+	ops.PushRef(jMethodHandle)
+	ops.PushRef(heap.NewJMethodType(class.Loader(), mh.Descriptor()))
+	ops.PushRef(nil)
+	ops.PushRef(jMemberName)
+	shimFrame := rtda.NewShimFrame(thread, ops)
+	thread.PushFrame(shimFrame)
+	InvokeMethod(shimFrame, class.GetConstructor(
+		"(Ljava/lang/invoke/MethodType;Ljava/lang/invoke/LambdaForm;Ljava/lang/invoke/MemberName;)V"))
+
+	// 类初始化的栈帧在构造函数上面，先执行
+	if class.NeedsInit(thread) {
+		InitClass(thread, class)
+	}
+}
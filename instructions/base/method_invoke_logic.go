@@ -27,6 +27,19 @@ func InvokeMethod(invokerFrame *rtda.Frame, method *heap.Method) {
 			newFrame.LocalVars().SetSlot(uint(i), slot)   // 将参数设置到被调用方法的局部变量表
 		}
 	}
+
+	// synchronized 方法：静态方法锁类对象，实例方法锁 this
+	if method.IsSynchronized() {
+		var lockObj *heap.Object
+		if method.IsStatic() {
+			lockObj = method.Class().JClass()
+		} else {
+			lockObj = newFrame.LocalVars().GetThis()
+		}
+		monitor := lockObj.Monitor()
+		monitor.Enter(thread)
+		newFrame.SetMonitor(monitor) // 栈帧弹出时释放
+	}
 }
 
 // _logInvoke  打印方法调用信息 (用于调试)
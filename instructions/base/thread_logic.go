@@ -0,0 +1,30 @@
+package base
+
+import (
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+// ExitThread 线程的栈执行完以后调用 Thread.exit()，把线程从线程组里移除。
+// 线程没有 Thread 对象时返回 false，否则压入栈帧并返回 true，调用者要接着解释执行
+func ExitThread(thread *rtda.Thread) bool {
+	if thread.JThread() == nil {
+		return false
+	}
+	thread.SetExiting()
+	invokeThreadMethod(thread, "exit", "()V")
+	return true
+}
+
+// invokeThreadMethod 通过 shim 栈帧调用线程的 Thread 对象的实例方法
+func invokeThreadMethod(thread *rtda.Thread, name, descriptor string, args ...*heap.Object) {
+	jThread := thread.JThread()
+	ops := rtda.NewOperandStack(uint(len(args) + 1))
+	ops.PushRef(jThread)
+	for _, arg := range args {
+		ops.PushRef(arg)
+	}
+	shimFrame := rtda.NewShimFrame(thread, ops)
+	thread.PushFrame(shimFrame)
+	InvokeMethod(shimFrame, heap.LookupMethodInClass(jThread.Class(), name, descriptor))
+}
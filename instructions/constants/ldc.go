@@ -37,12 +37,33 @@ func _ldc(frame *rtda.Frame, index uint) {
 		classRef := c.(*heap.ClassRef)
 		classObj := classRef.ResolvedClass().JClass()
 		stack.PushRef(classObj)
-	// case MethodType, MethodHandle
+	case *heap.MethodTypeRef:
+		stack.PushRef(c.(*heap.MethodTypeRef).ResolvedMethodType())
+	case *heap.MethodHandleRef:
+		ldcMethodHandle(frame, c.(*heap.MethodHandleRef))
 	default:
 		panic("todo: ldc!")
 	}
 }
 
+// ldcMethodHandle 第一次执行时解析方法句柄，创建对象并缓存在常量池里，然后调用构造函数初始化它
+func ldcMethodHandle(frame *rtda.Frame, ref *heap.MethodHandleRef) {
+	if jMethodHandle := ref.JMethodHandle(); jMethodHandle != nil {
+		frame.OperandStack().PushRef(jMethodHandle)
+		return
+	}
+
+	loader := frame.Method().Class().Loader()
+	mh := ref.ResolvedMethodHandle()
+	jMethodHandle := heap.NewJMethodHandle(loader, mh)
+	if !ref.SetJMethodHandle(jMethodHandle) {
+		frame.OperandStack().PushRef(ref.JMethodHandle())
+		return
+	}
+	jMemberName := heap.NewJMemberName(loader, mh.Member(), mh.Kind())
+	base.InitJMethodHandle(frame, jMethodHandle, jMemberName)
+}
+
 // Push long or double from run-time constant pool (wide index)
 type LDC2_W struct{ base.Index16Instruction }
 
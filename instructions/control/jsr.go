@@ -0,0 +1,23 @@
+package control
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+)
+
+// Jump subroutine
+// 把下一条指令的地址作为 returnAddress 压栈，然后跳转到子程序
+type JSR struct{ base.BranchInstruction }
+
+func (self *JSR) Execute(frame *rtda.Frame) {
+	frame.OperandStack().PushReturnAddress(frame.NextPC())
+	base.Branch(frame, self.Offset)
+}
+
+// Return from subroutine
+// 局部变量里保存的是 jsr 压栈的 returnAddress，ret 不修改局部变量表
+type RET struct{ base.Index8Instruction }
+
+func (self *RET) Execute(frame *rtda.Frame) {
+	frame.SetNextPC(frame.LocalVars().GetReturnAddress(self.Index))
+}
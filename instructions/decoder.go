@@ -0,0 +1,49 @@
+package instructions
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda/heap"
+)
+
+// DecodedInstruction 解码后的指令和下一条指令的地址
+type DecodedInstruction struct {
+	base.Instruction
+	NextPC int
+}
+
+// Decode 返回方法解码后的字节码，下标是指令地址，不是指令开头的位置为空。
+// 每个方法只在第一次执行时解码一次，之后直接使用缓存的指令
+func Decode(method *heap.Method) []DecodedInstruction {
+	if decoded, ok := method.DecodedCode().([]DecodedInstruction); ok {
+		return decoded
+	}
+	decoded := decode(method.Code())
+	method.SetDecodedCode(decoded) // 多个线程同时解码时结果相同，保留哪一份都可以
+	return decoded
+}
+
+func decode(code []byte) (decoded []DecodedInstruction) {
+	decoded = make([]DecodedInstruction, len(code))
+
+	// 方法末尾不可达的代码可能有不支持的操作码或者不完整的指令，
+	// 停止解码，等真正执行到那里时再报错；其他 panic 是虚拟机自己的错误，继续抛出
+	defer func() {
+		if r := recover(); r != nil && r != base.ErrTruncatedCode {
+			panic(r)
+		}
+	}()
+
+	reader := &base.BytecodeReader{}
+	reader.Reset(code, 0)
+	for reader.PC() < len(code) {
+		pc := reader.PC()
+		opcode := reader.ReadUint8()
+		if !IsSupported(opcode) {
+			break
+		}
+		inst := NewInstruction(opcode)
+		inst.FetchOperands(reader)
+		decoded[pc] = DecodedInstruction{inst, reader.PC()}
+	}
+	return decoded
+}
@@ -0,0 +1,30 @@
+package instructions
+
+import "testing"
+
+// 不支持的操作码和不完整的指令只停止解码，之前的指令照常解码
+func TestDecodeStopsAtBadCode(t *testing.T) {
+	tests := []struct {
+		name string
+		code []byte
+	}{
+		{"unsupported opcode", []byte{0x03, 0xac, 0xcb, 0x00}}, // iconst_0, ireturn, 0xcb
+		{"truncated operand", []byte{0x03, 0xac, 0x11, 0x00}},  // iconst_0, ireturn, sipush
+		{"truncated tableswitch", []byte{0x03, 0xac, 0xaa, 0x00, 0x00, // iconst_0, ireturn, tableswitch
+			0, 0, 0, 0, 0, 0, 0, 0, 0x7f, 0xff, 0xff, 0xff}}, // default, low 0, high 0x7fffffff
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			decoded := decode(test.code)
+			if decoded[0].Instruction == nil || decoded[0].NextPC != 1 ||
+				decoded[1].Instruction == nil || decoded[1].NextPC != 2 {
+				t.Errorf("leading instructions not decoded: %v", decoded[:2])
+			}
+			for pc := 2; pc < len(decoded); pc++ {
+				if decoded[pc].Instruction != nil {
+					t.Errorf("pc %d decoded as %T", pc, decoded[pc].Instruction)
+				}
+			}
+		})
+	}
+}
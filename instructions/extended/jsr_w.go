@@ -0,0 +1,19 @@
+package extended
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+)
+
+// Jump subroutine (wide index)
+type JSR_W struct {
+	offset int
+}
+
+func (self *JSR_W) FetchOperands(reader *base.BytecodeReader) {
+	self.offset = int(reader.ReadInt32())
+}
+func (self *JSR_W) Execute(frame *rtda.Frame) {
+	frame.OperandStack().PushReturnAddress(frame.NextPC())
+	base.Branch(frame, self.offset)
+}
@@ -2,6 +2,7 @@ package extended
 
 import (
 	"jvm-go/instructions/base"
+	"jvm-go/instructions/control"
 	"jvm-go/instructions/loads"
 	"jvm-go/instructions/math"
 	"jvm-go/instructions/stores"
@@ -61,8 +62,10 @@ func (self *WIDE) FetchOperands(reader *base.BytecodeReader) {
 		inst.Index = uint(reader.ReadUint16())
 		inst.Const = int32(reader.ReadInt16())
 		self.modifiedInstruction = inst
-	case 0xa9: // ret
-		panic("Unsupported opcode: 0xa9!")
+	case 0xa9:
+		inst := &control.RET{}
+		inst.Index = uint(reader.ReadUint16())
+		self.modifiedInstruction = inst
 	}
 }
 
@@ -166,6 +166,11 @@ var (
 	invoke_native = &INVOKE_NATIVE{}
 )
 
+// IsSupported 判断解释器是否支持操作码，NewInstruction 遇到不支持的操作码会 panic
+func IsSupported(opcode byte) bool {
+	return opcode <= 0xc9 || opcode == 0xfe
+}
+
 func NewInstruction(opcode byte) base.Instruction {
 	switch opcode {
 	case 0x00:
@@ -504,10 +509,10 @@ func NewInstruction(opcode byte) base.Instruction {
 		return &IF_ACMPNE{}
 	case 0xa7:
 		return &GOTO{}
-	// case 0xa8:
-	// 	return &JSR{}
-	// case 0xa9:
-	// 	return &RET{}
+	case 0xa8:
+		return &JSR{}
+	case 0xa9:
+		return &RET{}
 	case 0xaa:
 		return &TABLE_SWITCH{}
 	case 0xab:
@@ -540,8 +545,8 @@ func NewInstruction(opcode byte) base.Instruction {
 		return &INVOKE_STATIC{}
 	case 0xb9:
 		return &INVOKE_INTERFACE{}
-	// case 0xba:
-	// 	return &INVOKE_DYNAMIC{}
+	case 0xba:
+		return &INVOKE_DYNAMIC{}
 	case 0xbb:
 		return &NEW{}
 	case 0xbc:
@@ -570,8 +575,8 @@ func NewInstruction(opcode byte) base.Instruction {
 		return &IFNONNULL{}
 	case 0xc8:
 		return &GOTO_W{}
-	// case 0xc9:
-	// 	return &JSR_W{}
+	case 0xc9:
+		return &JSR_W{}
 	// case 0xca: breakpoint
 	case 0xfe:
 		return invoke_native
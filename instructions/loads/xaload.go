@@ -4,6 +4,7 @@ import (
 	"jvm-go/instructions/base"
 	"jvm-go/rtda"
 	"jvm-go/rtda/heap"
+	"strconv"
 )
 
 // Load reference from array
@@ -125,6 +126,6 @@ func checkNotNil(ref *heap.Object) {
 }
 func checkIndex(arrLen int, index int32) {
 	if index < 0 || index >= int32(arrLen) {
-		panic("ArrayIndexOutOfBoundsException")
+		panic("java.lang.ArrayIndexOutOfBoundsException: " + strconv.Itoa(int(index)))
 	}
 }
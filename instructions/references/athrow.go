@@ -3,8 +3,6 @@ package references
 import (
 	"jvm-go/instructions/base"
 	"jvm-go/rtda"
-	"jvm-go/rtda/heap"
-	"reflect"
 )
 
 // Throw exception or error
@@ -17,46 +15,7 @@ func (self *ATHROW) Execute(frame *rtda.Frame) {
 	}
 
 	thread := frame.Thread()
-	if !findAndGotoExceptionHandler(thread, ex) {
-		handleUncaughtException(thread, ex)
-	}
-}
-
-func findAndGotoExceptionHandler(thread *rtda.Thread, ex *heap.Object) bool {
-	for {
-		frame := thread.CurrentFrame()
-		pc := frame.NextPC() - 1
-
-		handlerPC := frame.Method().FindExceptionHandler(ex.Class(), pc)
-		if handlerPC > 0 {
-			stack := frame.OperandStack()
-			stack.Clear()
-			stack.PushRef(ex)
-			frame.SetNextPC(handlerPC)
-			return true
-		}
-
-		thread.PopFrame()
-		if thread.IsStackEmpty() {
-			break
-		}
-	}
-	return false
-}
-
-// todo
-func handleUncaughtException(thread *rtda.Thread, ex *heap.Object) {
-	thread.ClearStack()
-
-	jMsg := ex.GetRefVar("detailMessage", "Ljava/lang/String;")
-	goMsg := heap.GoString(jMsg)
-	println(ex.Class().JavaName() + ": " + goMsg)
-
-	stes := reflect.ValueOf(ex.Extra())
-	for i := 0; i < stes.Len(); i++ {
-		ste := stes.Index(i).Interface().(interface {
-			String() string
-		})
-		println("\tat " + ste.String())
+	if !base.FindAndGotoExceptionHandler(thread, ex) {
+		base.HandleUncaughtException(thread, ex)
 	}
 }
@@ -21,6 +21,7 @@ func (self *CHECK_CAST) Execute(frame *rtda.Frame) {
 	classRef := cp.GetConstant(self.Index).(*heap.ClassRef)
 	class := classRef.ResolvedClass()
 	if !ref.IsInstanceOf(class) {
-		panic("java.lang.ClassCastException")
+		panic("java.lang.ClassCastException: " + ref.Class().JavaName() +
+			" cannot be cast to " + class.JavaName())
 	}
 }
@@ -14,7 +14,7 @@ func (self *GET_STATIC) Execute(frame *rtda.Frame) {
 	fieldRef := cp.GetConstant(self.Index).(*heap.FieldRef)
 	field := fieldRef.ResolvedField()
 	class := field.Class()
-	if !class.InitStarted() {
+	if class.NeedsInit(frame.Thread()) {
 		frame.RevertNextPC()
 		base.InitClass(frame.Thread(), class)
 		return
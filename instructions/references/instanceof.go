@@ -11,15 +11,18 @@ type INSTANCE_OF struct{ base.Index16Instruction }
 
 func (self *INSTANCE_OF) Execute(frame *rtda.Frame) {
 	stack := frame.OperandStack()
-	ref := stack.PopRef()
+	ref := stack.GetRefFromTop(0)
 	if ref == nil {
+		stack.PopRef()
 		stack.PushInt(0)
 		return
 	}
 
+	// 解析类时用户自定义类加载器可能要先执行 Java 代码，之后重新执行这条指令，所以解析完再弹出 ref
 	cp := frame.Method().Class().ConstantPool()
 	classRef := cp.GetConstant(self.Index).(*heap.ClassRef)
 	class := classRef.ResolvedClass()
+	stack.PopRef()
 	if ref.IsInstanceOf(class) {
 		stack.PushInt(1)
 	} else {
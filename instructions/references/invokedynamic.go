@@ -0,0 +1,58 @@
+package references
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+// CallSite 链接好的调用点，从操作数栈弹出参数并把结果压回操作数栈
+type CallSite func(frame *rtda.Frame)
+
+// BootstrapMethod 内置的引导方法，根据调用点限定符链接调用点。
+// 引导方法按类名、方法名和描述符匹配，不会真的调用 Java 代码，
+// 所以只支持 LambdaMetafactory、StringConcatFactory 和 ObjectMethods 这几个引导方法
+type BootstrapMethod func(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite
+
+var bootstrapMethods = map[string]BootstrapMethod{}
+
+func registerBootstrapMethod(className, methodName, methodDescriptor string, bsm BootstrapMethod) {
+	key := className + "~" + methodName + "~" + methodDescriptor
+	bootstrapMethods[key] = bsm
+}
+
+// Invoke dynamic method
+type INVOKE_DYNAMIC struct {
+	index uint
+	// zero uint8
+	// zero uint8
+}
+
+func (self *INVOKE_DYNAMIC) FetchOperands(reader *base.BytecodeReader) {
+	self.index = uint(reader.ReadUint16())
+	reader.ReadUint8() // must be 0
+	reader.ReadUint8() // must be 0
+}
+
+func (self *INVOKE_DYNAMIC) Execute(frame *rtda.Frame) {
+	cp := frame.Method().Class().ConstantPool()
+	ref := cp.GetConstant(self.index).(*heap.InvokeDynamicRef)
+	callSite, ok := ref.CallSite().(CallSite)
+	if !ok {
+		callSite = linkCallSite(frame.Method().Class(), ref)
+		ref.SetCallSite(callSite)
+	}
+	callSite(frame)
+}
+
+// jvms8 5.4.3.6
+func linkCallSite(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	bsmRef := ref.BootstrapMethod().MemberRef()
+	key := bsmRef.ClassName() + "~" + bsmRef.Name() + "~" + bsmRef.Descriptor()
+	if bsm, ok := bootstrapMethods[key]; ok {
+		return bsm(caller, ref)
+	}
+	panic("java.lang.BootstrapMethodError: unsupported bootstrap method " +
+		bsmRef.ClassName() + "." + bsmRef.Name() + bsmRef.Descriptor() +
+		", only LambdaMetafactory/StringConcatFactory/ObjectMethods bootstraps are supported")
+}
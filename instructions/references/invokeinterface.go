@@ -23,7 +23,7 @@ func (self *INVOKE_INTERFACE) Execute(frame *rtda.Frame) {
 	cp := frame.Method().Class().ConstantPool()
 	methodRef := cp.GetConstant(self.index).(*heap.InterfaceMethodRef)
 	resolvedMethod := methodRef.ResolvedInterfaceMethod()
-	if resolvedMethod.IsStatic() || resolvedMethod.IsPrivate() {
+	if resolvedMethod.IsStatic() {
 		panic("java.lang.IncompatibleClassChangeError")
 	}
 
@@ -35,12 +35,22 @@ func (self *INVOKE_INTERFACE) Execute(frame *rtda.Frame) {
 		panic("java.lang.IncompatibleClassChangeError")
 	}
 
-	methodToBeInvoked := heap.LookupMethodInClass(ref.Class(),
-		methodRef.Name(), methodRef.Descriptor())
+	// jvms 6.5.invokeinterface 私有方法不需要选择，其他方法通过接口方法表选择，
+	// 类没有实现的方法由默认方法实现
+	var methodToBeInvoked *heap.Method
+	if resolvedMethod.IsPrivate() {
+		methodToBeInvoked = resolvedMethod
+	} else if index := methodRef.ItableIndex(); index >= 0 {
+		methodToBeInvoked = ref.Class().InterfaceMethod(resolvedMethod.Class(), index)
+	} else {
+		// 解析到的是 java.lang.Object 的方法
+		methodToBeInvoked = heap.LookupMethodInClass(ref.Class(),
+			methodRef.Name(), methodRef.Descriptor())
+	}
 	if methodToBeInvoked == nil || methodToBeInvoked.IsAbstract() {
 		panic("java.lang.AbstractMethodError")
 	}
-	if !methodToBeInvoked.IsPublic() {
+	if !methodToBeInvoked.IsPublic() && !methodToBeInvoked.IsPrivate() {
 		panic("java.lang.IllegalAccessError")
 	}
 
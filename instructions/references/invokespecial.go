@@ -13,9 +13,7 @@ type INVOKE_SPECIAL struct{ base.Index16Instruction }
 func (self *INVOKE_SPECIAL) Execute(frame *rtda.Frame) {
 	currentClass := frame.Method().Class()
 	cp := currentClass.ConstantPool()
-	methodRef := cp.GetConstant(self.Index).(*heap.MethodRef)
-	resolvedClass := methodRef.ResolvedClass()
-	resolvedMethod := methodRef.ResolvedMethod()
+	resolvedClass, resolvedMethod := resolveMethodRef(cp, self.Index)
 	if resolvedMethod.Name() == "<init>" && resolvedMethod.Class() != resolvedClass {
 		panic("java.lang.NoSuchMethodError")
 	}
@@ -30,20 +28,25 @@ func (self *INVOKE_SPECIAL) Execute(frame *rtda.Frame) {
 
 	if resolvedMethod.IsProtected() &&
 		resolvedMethod.Class().IsSuperClassOf(currentClass) &&
-		resolvedMethod.Class().GetPackageName() != currentClass.GetPackageName() &&
+		!resolvedMethod.Class().IsSameRuntimePackage(currentClass) &&
 		ref.Class() != currentClass &&
 		!ref.Class().IsSubClassOf(currentClass) {
 
 		panic("java.lang.IllegalAccessError")
 	}
 
+	// jvms 6.5.invokespecial 调用父类方法时从直接父类开始查找，
+	// 否则从被引用的类开始查找，接口（Iface.super.m()）还要查找最具体的父接口默认方法
 	methodToBeInvoked := resolvedMethod
-	if currentClass.IsSuper() &&
-		resolvedClass.IsSuperClassOf(currentClass) &&
-		resolvedMethod.Name() != "<init>" {
-
-		methodToBeInvoked = heap.LookupMethodInClass(currentClass.SuperClass(),
-			methodRef.Name(), methodRef.Descriptor())
+	if resolvedMethod.Name() != "<init>" {
+		class := resolvedClass
+		if currentClass.IsSuper() &&
+			!resolvedClass.IsInterface() &&
+			resolvedClass.IsSuperClassOf(currentClass) {
+
+			class = currentClass.SuperClass()
+		}
+		methodToBeInvoked = heap.LookupSpecialMethod(class, resolvedMethod.Name(), resolvedMethod.Descriptor())
 	}
 
 	if methodToBeInvoked == nil || methodToBeInvoked.IsAbstract() {
@@ -3,7 +3,6 @@ package references
 import (
 	"jvm-go/instructions/base"
 	"jvm-go/rtda"
-	"jvm-go/rtda/heap"
 )
 
 // Invoke a class (static) method
@@ -11,14 +10,13 @@ type INVOKE_STATIC struct{ base.Index16Instruction }
 
 func (self *INVOKE_STATIC) Execute(frame *rtda.Frame) {
 	cp := frame.Method().Class().ConstantPool()
-	methodRef := cp.GetConstant(self.Index).(*heap.MethodRef)
-	resolvedMethod := methodRef.ResolvedMethod()
+	_, resolvedMethod := resolveMethodRef(cp, self.Index)
 	if !resolvedMethod.IsStatic() {
 		panic("java.lang.IncompatibleClassChangeError")
 	}
 
 	class := resolvedMethod.Class()
-	if !class.InitStarted() {
+	if class.NeedsInit(frame.Thread()) {
 		frame.RevertNextPC()
 		base.InitClass(frame.Thread(), class)
 		return
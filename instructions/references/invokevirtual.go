@@ -17,6 +17,10 @@ func (self *INVOKE_VIRTUAL) Execute(frame *rtda.Frame) {
 	if resolvedMethod.IsStatic() {
 		panic("java.lang.IncompatibleClassChangeError")
 	}
+	if resolvedMethod.IsSignaturePolymorphic() {
+		base.InvokeMethodHandle(frame, methodRef.Name(), methodRef.Descriptor())
+		return
+	}
 
 	ref := frame.OperandStack().GetRefFromTop(resolvedMethod.ArgSlotCount() - 1)
 	if ref == nil {
@@ -25,7 +29,7 @@ func (self *INVOKE_VIRTUAL) Execute(frame *rtda.Frame) {
 
 	if resolvedMethod.IsProtected() &&
 		resolvedMethod.Class().IsSuperClassOf(currentClass) &&
-		resolvedMethod.Class().GetPackageName() != currentClass.GetPackageName() &&
+		!resolvedMethod.Class().IsSameRuntimePackage(currentClass) &&
 		ref.Class() != currentClass &&
 		!ref.Class().IsSubClassOf(currentClass) {
 
@@ -34,8 +38,13 @@ func (self *INVOKE_VIRTUAL) Execute(frame *rtda.Frame) {
 		}
 	}
 
-	methodToBeInvoked := heap.LookupMethodInClass(ref.Class(),
-		methodRef.Name(), methodRef.Descriptor())
+	var methodToBeInvoked *heap.Method
+	if index := methodRef.VtableIndex(); index >= 0 {
+		methodToBeInvoked = ref.Class().VirtualMethod(index)
+	} else {
+		// 私有方法不在虚方法表里，不需要动态分派
+		methodToBeInvoked = resolvedMethod
+	}
 	if methodToBeInvoked == nil || methodToBeInvoked.IsAbstract() {
 		panic("java.lang.AbstractMethodError")
 	}
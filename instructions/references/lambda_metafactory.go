@@ -0,0 +1,116 @@
+package references
+
+import (
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const lambdaMetafactory = "java/lang/invoke/LambdaMetafactory"
+
+// altMetafactory 的标志位
+const (
+	FLAG_SERIALIZABLE = 1 << 0
+	FLAG_MARKERS      = 1 << 1
+	FLAG_BRIDGES      = 1 << 2
+)
+
+func init() {
+	registerBootstrapMethod(lambdaMetafactory, "metafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/invoke/MethodType;Ljava/lang/invoke/MethodHandle;Ljava/lang/invoke/MethodType;)"+
+			"Ljava/lang/invoke/CallSite;", metafactory)
+	registerBootstrapMethod(lambdaMetafactory, "altMetafactory",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;", altMetafactory)
+}
+
+// 静态参数：samMethodType, implMethod, instantiatedMethodType
+func metafactory(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	info := newLambdaInfo(caller, ref, ref.BootstrapArguments())
+	return newLambdaCallSite(info)
+}
+
+// 静态参数：samMethodType, implMethod, instantiatedMethodType, flags,
+// [markerInterfaceCount, markerInterfaces...], [bridgeCount, bridges...]
+func altMetafactory(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	args := ref.BootstrapArguments()
+	info := newLambdaInfo(caller, ref, args)
+
+	flags := args[3].(int32)
+	i := 4
+	if flags&FLAG_MARKERS != 0 {
+		count := int(args[i].(int32))
+		for _, arg := range args[i+1 : i+1+count] {
+			info.Interfaces = appendIfMissing(info.Interfaces, arg.(*heap.ClassRef).ClassName())
+		}
+		i += 1 + count
+	}
+	if flags&FLAG_BRIDGES != 0 {
+		count := int(args[i].(int32))
+		for _, arg := range args[i+1 : i+1+count] {
+			info.MethodTypes = appendIfMissing(info.MethodTypes, arg.(*heap.MethodTypeRef).Descriptor())
+		}
+		i += 1 + count
+	}
+	if flags&FLAG_SERIALIZABLE != 0 {
+		info.Interfaces = appendIfMissing(info.Interfaces, "java/io/Serializable")
+	}
+	return newLambdaCallSite(info)
+}
+
+func newLambdaInfo(caller *heap.Class, ref *heap.InvokeDynamicRef, args []heap.Constant) *heap.LambdaInfo {
+	// 调用点的描述符是 (捕获参数)函数式接口
+	md := ref.ParsedDescriptor()
+	interfaceType := md.ReturnType()
+	return &heap.LambdaInfo{
+		Caller:        caller,
+		Interfaces:    []string{interfaceType[1 : len(interfaceType)-1]},
+		MethodName:    ref.Name(),
+		MethodTypes:   []string{args[0].(*heap.MethodTypeRef).Descriptor()},
+		CapturedTypes: md.ParameterTypes(),
+		Impl:          args[1].(*heap.MethodHandleRef),
+	}
+}
+
+func newLambdaCallSite(info *heap.LambdaInfo) CallSite {
+	class := heap.NewLambdaClass(info)
+	if len(info.CapturedTypes) == 0 {
+		// 不捕获参数的 lambda 没有状态，所有调用共用一个实例
+		instance := class.NewObject()
+		return func(frame *rtda.Frame) {
+			frame.OperandStack().PushRef(instance)
+		}
+	}
+
+	fields := class.Fields()
+	return func(frame *rtda.Frame) {
+		stack := frame.OperandStack()
+		obj := class.NewObject()
+		slots := obj.Fields()
+		for i := len(fields) - 1; i >= 0; i-- {
+			field := fields[i]
+			switch field.Descriptor()[0] {
+			case 'J':
+				slots.SetLong(field.SlotId(), stack.PopLong())
+			case 'D':
+				slots.SetDouble(field.SlotId(), stack.PopDouble())
+			case 'F':
+				slots.SetFloat(field.SlotId(), stack.PopFloat())
+			case 'L', '[':
+				slots.SetRef(field.SlotId(), stack.PopRef())
+			default:
+				slots.SetInt(field.SlotId(), stack.PopInt())
+			}
+		}
+		stack.PushRef(obj)
+	}
+}
+
+func appendIfMissing(names []string, name string) []string {
+	for _, n := range names {
+		if n == name {
+			return names
+		}
+	}
+	return append(names, name)
+}
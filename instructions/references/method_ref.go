@@ -0,0 +1,16 @@
+package references
+
+import "jvm-go/rtda/heap"
+
+// resolveMethodRef 解析 invokespecial 和 invokestatic 引用的方法，返回被引用的类和解析后的方法。
+// 从 class 文件版本 52 开始，这两条指令也可以引用接口方法（默认方法、私有方法和静态方法）
+func resolveMethodRef(cp *heap.ConstantPool, index uint) (*heap.Class, *heap.Method) {
+	switch ref := cp.GetConstant(index).(type) {
+	case *heap.MethodRef:
+		return ref.ResolvedClass(), ref.ResolvedMethod()
+	case *heap.InterfaceMethodRef:
+		return ref.ResolvedClass(), ref.ResolvedInterfaceMethod()
+	default:
+		panic("java.lang.IncompatibleClassChangeError")
+	}
+}
@@ -8,21 +8,25 @@ import (
 // Enter monitor for object
 type MONITOR_ENTER struct{ base.NoOperandsInstruction }
 
-// todo
 func (self *MONITOR_ENTER) Execute(frame *rtda.Frame) {
 	ref := frame.OperandStack().PopRef()
 	if ref == nil {
 		panic("java.lang.NullPointerException")
 	}
+
+	ref.Monitor().Enter(frame.Thread())
 }
 
 // Exit monitor for object
 type MONITOR_EXIT struct{ base.NoOperandsInstruction }
 
-// todo
 func (self *MONITOR_EXIT) Execute(frame *rtda.Frame) {
 	ref := frame.OperandStack().PopRef()
 	if ref == nil {
 		panic("java.lang.NullPointerException")
 	}
+
+	if !ref.Monitor().Exit(frame.Thread()) {
+		panic("java.lang.IllegalMonitorStateException")
+	}
 }
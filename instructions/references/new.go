@@ -28,7 +28,7 @@ func (ne *NEW) Execute(frame *rtda.Frame) {
 
 	// 4. 检查类是否已初始化
 	// 如果类还没有被初始化，则先初始化类，并暂停当前方法的执行，直到类初始化完成。
-	if !class.InitStarted() {
+	if class.NeedsInit(frame.Thread()) {
 		// 恢复下一条指令的地址，以便在类初始化完成后继续执行当前方法。
 		frame.RevertNextPC()
 		// 初始化类。这会执行类的 <clinit> 方法。
@@ -0,0 +1,62 @@
+package references
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"strings"
+)
+
+const objectMethods = "java/lang/runtime/ObjectMethods"
+
+func init() {
+	registerBootstrapMethod(objectMethods, "bootstrap",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/TypeDescriptor;"+
+			"Ljava/lang/Class;Ljava/lang/String;[Ljava/lang/invoke/MethodHandle;)Ljava/lang/Object;", objectMethodsBootstrap)
+}
+
+// 记录类的 toString、hashCode 和 equals 方法由 javac 编译成 invokedynamic 指令。
+// 静态参数：recordClass, names, getters...，names 是分号分隔的组件名，getters 是组件字段的 getField 方法句柄
+func objectMethodsBootstrap(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	args := ref.BootstrapArguments()
+	recordClass := args[0].(*heap.ClassRef).ResolvedClass()
+	var names []string
+	if recipe := args[1].(string); recipe != "" {
+		names = strings.Split(recipe, ";")
+	}
+	getters := args[2:]
+	if len(names) != len(getters) {
+		panic("java.lang.IllegalArgumentException: names " + args[1].(string) + " do not match getters")
+	}
+	fields := make([]*heap.Field, len(getters))
+	for i, getter := range getters {
+		mhRef := getter.(*heap.MethodHandleRef)
+		fieldRef, ok := mhRef.Reference().(*heap.FieldRef)
+		if !ok || mhRef.ReferenceKind() != heap.REF_getField {
+			panic("java.lang.IllegalArgumentException: getter is not a getField method handle")
+		}
+		fields[i] = fieldRef.ResolvedField()
+	}
+
+	recordType := recordClass.Descriptor()
+	var expected string
+	switch ref.Name() {
+	case "toString":
+		expected = "(" + recordType + ")Ljava/lang/String;"
+	case "hashCode":
+		expected = "(" + recordType + ")I"
+	case "equals":
+		expected = "(" + recordType + "Ljava/lang/Object;)Z"
+	default:
+		panic("java.lang.IllegalArgumentException: " + ref.Name())
+	}
+	if ref.Descriptor() != expected {
+		panic("java.lang.IllegalArgumentException: " + ref.Name() + ref.Descriptor())
+	}
+
+	class := heap.NewObjectMethodsClass(caller, recordClass, ref.Name(), ref.Descriptor(), names, fields)
+	method := class.GetStaticMethod(ref.Name(), ref.Descriptor())
+	return func(frame *rtda.Frame) {
+		base.InvokeMethod(frame, method)
+	}
+}
@@ -0,0 +1,255 @@
+package references
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+)
+
+const stringConcatFactory = "java/lang/invoke/StringConcatFactory"
+
+// 拼接配方里的标记字符
+const (
+	TAG_ARG   = '\u0001' // 取下一个调用参数
+	TAG_CONST = '\u0002' // 取下一个引导方法静态参数
+)
+
+func init() {
+	registerBootstrapMethod(stringConcatFactory, "makeConcat",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;)"+
+			"Ljava/lang/invoke/CallSite;", makeConcat)
+	registerBootstrapMethod(stringConcatFactory, "makeConcatWithConstants",
+		"(Ljava/lang/invoke/MethodHandles$Lookup;Ljava/lang/String;Ljava/lang/invoke/MethodType;"+
+			"Ljava/lang/String;[Ljava/lang/Object;)Ljava/lang/invoke/CallSite;", makeConcatWithConstants)
+	shim := heap.ShimStringConcatMethod()
+	native.Register(shim.Class().Name(), shim.Name(), shim.Descriptor(), stringConcat)
+}
+
+// concatPart 拼接配方的一段，要么是常量，要么是调用参数
+type concatPart struct {
+	constant []uint16
+	argIndex int // constant 为 nil 时使用
+}
+
+// 没有配方，依次拼接所有参数
+func makeConcat(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	parts := make([]concatPart, len(ref.ParsedDescriptor().ParameterTypes()))
+	for i := range parts {
+		parts[i].argIndex = i
+	}
+	return newStringConcatCallSite(caller, ref, parts)
+}
+
+// 静态参数：recipe, constants...
+func makeConcatWithConstants(caller *heap.Class, ref *heap.InvokeDynamicRef) CallSite {
+	args := ref.BootstrapArguments()
+	recipe := args[0].(string)
+	constants := args[1:]
+
+	var parts []concatPart
+	var constant []uint16
+	argIndex := 0
+	for _, r := range recipe {
+		switch r {
+		case TAG_ARG:
+			if constant != nil {
+				parts = append(parts, concatPart{constant: constant})
+				constant = nil
+			}
+			parts = append(parts, concatPart{argIndex: argIndex})
+			argIndex++
+		case TAG_CONST:
+			if len(constants) == 0 {
+				panic("java.lang.invoke.StringConcatException: missing constant in recipe " + recipe)
+			}
+			constant = append(constant, constantChars(constants[0])...)
+			constants = constants[1:]
+		default:
+			constant = append(constant, utf16.Encode([]rune{r})...)
+		}
+	}
+	if constant != nil {
+		parts = append(parts, concatPart{constant: constant})
+	}
+	if argIndex != len(ref.ParsedDescriptor().ParameterTypes()) {
+		panic("java.lang.invoke.StringConcatException: mismatched number of arguments in recipe " + recipe)
+	}
+	return newStringConcatCallSite(caller, ref, parts)
+}
+
+func newStringConcatCallSite(caller *heap.Class, ref *heap.InvokeDynamicRef, parts []concatPart) CallSite {
+	loader := caller.Loader()
+	paramTypes := ref.ParsedDescriptor().ParameterTypes()
+
+	if !needsValueOf(paramTypes) {
+		// 参数都是基本类型或 String，直接用操作数栈上的值拼接
+		argSlotCount := uint(0)
+		for _, paramType := range paramTypes {
+			argSlotCount += typeSlotCount(paramType)
+		}
+		return func(frame *rtda.Frame) {
+			stack := frame.OperandStack()
+			vars := make(rtda.LocalVars, argSlotCount)
+			for i := int(argSlotCount) - 1; i >= 0; i-- {
+				vars.SetSlot(uint(i), stack.PopSlot())
+			}
+			stack.PushRef(concat(loader, parts, paramTypes, vars))
+		}
+	}
+
+	// 其他对象需要先调用 String.valueOf()，交给生成的类执行 Java 代码，再由 stringConcat 拼接
+	class := heap.NewStringConcatClass(caller, ref.Descriptor(), parts)
+	concatMethod := class.GetStaticMethod("concat", ref.Descriptor())
+	return func(frame *rtda.Frame) {
+		base.InvokeMethod(frame, concatMethod)
+	}
+}
+
+// stringConcat 是 shim 方法 <stringConcat> 的实现，参数是生成的类和转换好的 String 数组，
+// 按生成的类里保存的配方拼接
+func stringConcat(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	class := vars.GetRef(0).Extra().(*heap.Class)
+	parts := class.StringConcatRecipe().([]concatPart)
+	strs := vars.GetRef(1).Refs()
+	args := make([][]uint16, len(strs))
+	for i, jStr := range strs {
+		args[i] = stringValueChars(jStr)
+	}
+	frame.OperandStack().PushRef(joinParts(class.Loader(), parts, args))
+}
+
+func needsValueOf(paramTypes []string) bool {
+	for _, paramType := range paramTypes {
+		if (paramType[0] == 'L' || paramType[0] == '[') && paramType != "Ljava/lang/String;" {
+			return true
+		}
+	}
+	return false
+}
+
+// 按配方拼接 vars 里的参数，引用类型的参数只能是 String 或 null
+func concat(loader *heap.ClassLoader, parts []concatPart, paramTypes []string, vars rtda.LocalVars) *heap.Object {
+	args := make([][]uint16, len(paramTypes))
+	slot := uint(0)
+	for i, paramType := range paramTypes {
+		switch paramType[0] {
+		case 'Z':
+			args[i] = stringChars(strconv.FormatBool(vars.GetInt(slot) != 0))
+		case 'C':
+			args[i] = []uint16{uint16(vars.GetInt(slot))}
+		case 'B', 'S', 'I':
+			args[i] = stringChars(strconv.Itoa(int(vars.GetInt(slot))))
+		case 'J':
+			args[i] = stringChars(strconv.FormatInt(vars.GetLong(slot), 10))
+		case 'F':
+			args[i] = stringChars(javaFloatString(float64(vars.GetFloat(slot)), 32))
+		case 'D':
+			args[i] = stringChars(javaFloatString(vars.GetDouble(slot), 64))
+		default:
+			args[i] = stringValueChars(vars.GetRef(slot))
+		}
+		slot += typeSlotCount(paramType)
+	}
+	return joinParts(loader, parts, args)
+}
+
+// joinParts 把配方里的常量和参数依次连接起来
+func joinParts(loader *heap.ClassLoader, parts []concatPart, args [][]uint16) *heap.Object {
+	length := 0
+	for _, part := range parts {
+		if part.constant != nil {
+			length += len(part.constant)
+		} else {
+			length += len(args[part.argIndex])
+		}
+	}
+	chars := make([]uint16, 0, length)
+	for _, part := range parts {
+		if part.constant != nil {
+			chars = append(chars, part.constant...)
+		} else {
+			chars = append(chars, args[part.argIndex]...)
+		}
+	}
+	return heap.NewJString(loader, chars)
+}
+
+// stringValueChars 返回 String 对象的字符，null 转换成 "null"
+func stringValueChars(jStr *heap.Object) []uint16 {
+	if jStr == nil {
+		return stringChars("null")
+	}
+	return jStr.GetRefVar("value", "[C").Chars()
+}
+
+func constantChars(c heap.Constant) []uint16 {
+	switch x := c.(type) {
+	case string:
+		return stringChars(x)
+	case int32:
+		return stringChars(strconv.Itoa(int(x)))
+	case int64:
+		return stringChars(strconv.FormatInt(x, 10))
+	case float32:
+		return stringChars(javaFloatString(float64(x), 32))
+	case float64:
+		return stringChars(javaFloatString(x, 64))
+	default:
+		panic("java.lang.invoke.StringConcatException: unsupported constant in recipe")
+	}
+}
+
+func stringChars(s string) []uint16 {
+	return utf16.Encode([]rune(s))
+}
+
+// 按 Float.toString() 和 Double.toString() 的格式输出浮点数
+func javaFloatString(f float64, bitSize int) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	case f == 0:
+		if math.Signbit(f) {
+			return "-0.0"
+		}
+		return "0.0"
+	}
+
+	if abs := math.Abs(f); abs >= 1e-3 && abs < 1e7 {
+		s := strconv.FormatFloat(f, 'f', -1, bitSize)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+
+	// 1.5E-05 -> 1.5E-5, 1E+10 -> 1.0E10
+	s := strconv.FormatFloat(f, 'E', -1, bitSize)
+	mantissa, exponent, _ := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	sign := ""
+	if exponent[0] == '-' {
+		sign = "-"
+	}
+	exponent = strings.TrimLeft(exponent[1:], "0")
+	return mantissa + "E" + sign + exponent
+}
+
+func typeSlotCount(descriptor string) uint {
+	if descriptor == "J" || descriptor == "D" {
+		return 2
+	}
+	return 1
+}
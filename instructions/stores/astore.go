@@ -36,7 +36,8 @@ func (self *ASTORE_3) Execute(frame *rtda.Frame) {
 	_astore(frame, 3)
 }
 
+// astore 也用来把 jsr 压栈的 returnAddress 存进局部变量，所以整个 slot 一起复制
 func _astore(frame *rtda.Frame, index uint) {
-	ref := frame.OperandStack().PopRef()
-	frame.LocalVars().SetRef(index, ref)
+	slot := frame.OperandStack().PopSlot()
+	frame.LocalVars().SetSlot(index, slot)
 }
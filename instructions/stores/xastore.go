@@ -4,6 +4,7 @@ import (
 	"jvm-go/instructions/base"
 	"jvm-go/rtda"
 	"jvm-go/rtda/heap"
+	"strconv"
 )
 
 // Store into reference array
@@ -133,6 +134,6 @@ func checkNotNil(ref *heap.Object) {
 }
 func checkIndex(arrLen int, index int32) {
 	if index < 0 || index >= int32(arrLen) {
-		panic("ArrayIndexOutOfBoundsException")
+		panic("java.lang.ArrayIndexOutOfBoundsException: " + strconv.Itoa(int(index)))
 	}
 }
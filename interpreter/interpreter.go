@@ -0,0 +1,156 @@
+package interpreter
+
+import (
+	"fmt"
+	"jvm-go/instructions"
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+// Interpret 解释执行字节码。
+// thread: 当前线程
+// logInst: 是否打印指令执行信息
+func Interpret(thread *rtda.Thread, logInst bool) {
+	verboseInst.Store(logInst) // 记录下来，供之后启动的 Java 线程沿用
+	interpret(thread, logInst)
+}
+
+func interpret(thread *rtda.Thread, logInst bool) {
+	// 使用 defer 和 recover 机制捕获运行时错误，并在发生错误时打印栈帧信息。
+	defer catchErr(thread)
+	loop(thread, logInst)
+}
+
+// catchErr 捕获 panic 并打印栈帧信息。
+// thread: 当前线程
+func catchErr(thread *rtda.Thread) {
+	if r := recover(); r != nil {
+		logFrames(thread)
+		panic(r) // 重新抛出异常，以便上层处理
+	}
+}
+
+// loop 解释执行循环。
+// thread: 当前线程
+// logInst: 是否打印指令执行信息
+func loop(thread *rtda.Thread, logInst bool) {
+	for !execute(thread, logInst) {
+	}
+}
+
+// execute 执行指令直到线程的栈为空（返回 true），
+// 或者指令执行时虚拟机发现了异常（返回 false，异常已经抛给 Java 代码处理）。
+// 空指针、除零、数组越界等错误由指令以 panic("java.lang.XxxException: msg") 的形式报告，
+// 这里把它们转换成真正的 Java 异常
+func execute(thread *rtda.Thread, logInst bool) (done bool) {
+	var frame *rtda.Frame
+	var pc int
+	var method *heap.Method                    // 当前执行的方法
+	var code []instructions.DecodedInstruction // 当前方法解码后的字节码
+	defer func() {
+		if r := recover(); r != nil {
+			if req, ok := r.(*heap.LoadRequest); ok {
+				done = loadClassByJava(thread, frame, pc, req)
+				return
+			}
+			done = throwJavaException(thread, frame, pc, r)
+		}
+	}()
+
+	for {
+		frame = thread.CurrentFrame() // 获取当前栈帧
+		pc = frame.NextPC()           // 获取下一条指令的地址
+		thread.SetPC(pc)              // 设置线程的程序计数器
+
+		// 取出解码好的指令，方法第一次执行时解码
+		if frame.Method() != method {
+			method = frame.Method()
+			code = instructions.Decode(method)
+		}
+		inst := code[pc].Instruction
+		if inst == nil {
+			panic(fmt.Errorf("unsupported opcode: 0x%x", method.Code()[pc]))
+		}
+		frame.SetNextPC(code[pc].NextPC) // 更新下一条指令的地址
+
+		// 打印指令执行信息（如果开启了日志）
+		if logInst {
+			logInstruction(frame, inst)
+		}
+
+		// 执行指令
+		inst.Execute(frame)
+
+		// 如果操作数栈为空，则退出循环（程序执行结束）
+		if thread.IsStackEmpty() {
+			return true
+		}
+	}
+}
+
+// throwJavaException 在出错的栈帧里抛出 r 对应的 Java 异常，
+// r 不是 Java 异常时（虚拟机自身的错误）继续 panic。
+// frame: 出错的栈帧
+// pc: 出错指令的地址
+func throwJavaException(thread *rtda.Thread, frame *rtda.Frame, pc int, r interface{}) bool {
+	className, msg, ok := base.ParseJavaException(r)
+	if !ok || frame == nil {
+		panic(r)
+	}
+
+	// 指令可能已经压入了新的栈帧（比如调用本地方法），先把它们弹出
+	for !thread.IsStackEmpty() && thread.CurrentFrame() != frame {
+		thread.PopFrame()
+	}
+	if thread.IsStackEmpty() {
+		panic(r)
+	}
+
+	// 让 athrow 按出错指令的地址查找异常处理项
+	frame.SetNextPC(pc + 1)
+	base.ThrowException(frame, className, msg)
+	return thread.IsStackEmpty()
+}
+
+// loadClassByJava 指令执行时用户自定义类加载器需要调用 Java 代码加载类，
+// 弹出指令已经压入的栈帧，由 shim 栈帧加载类之后重新执行这条指令
+func loadClassByJava(thread *rtda.Thread, frame *rtda.Frame, pc int, req *heap.LoadRequest) bool {
+	if frame == nil {
+		panic(req)
+	}
+	for !thread.IsStackEmpty() && thread.CurrentFrame() != frame {
+		thread.PopFrame()
+	}
+	if thread.IsStackEmpty() {
+		panic(req)
+	}
+
+	base.LoadClassByJava(thread, req, pc)
+	return false
+}
+
+// logInstruction 打印指令执行信息。
+// frame: 当前栈帧
+// inst: 当前指令
+func logInstruction(frame *rtda.Frame, inst base.Instruction) {
+	method := frame.Method()
+	className := method.Class().Name()
+	methodName := method.Name()
+	pc := frame.Thread().PC()
+	fmt.Printf("%v.%v() #%2d %T %v\n", className, methodName, pc, inst, inst)
+}
+
+// logFrames 打印栈帧信息。
+// thread: 当前线程
+func logFrames(thread *rtda.Thread) {
+	// 遍历所有栈帧并打印信息
+	for !thread.IsStackEmpty() {
+		frame := thread.PopFrame()                      // 弹出栈帧
+		method := frame.Method()                        // 获取方法
+		className := method.Class().Name()              // 获取类名
+		lineNum := method.GetLineNumber(frame.NextPC()) // 获取行号
+		fmt.Printf(">> line:%4d pc:%4d %v.%v%v \n",
+			lineNum, frame.NextPC(), className, method.Name(), method.Descriptor())
+	}
+}
@@ -0,0 +1,86 @@
+package interpreter
+
+import (
+	"jvm-go/classfile"
+	"jvm-go/classpath"
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newTestLoader 把最小的核心类和 classes 写到临时目录，用它作为 jre 目录和用户类路径创建类加载器
+func newTestLoader(t *testing.T, classes ...*classfile.ClassBuilder) *heap.ClassLoader {
+	object := classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_SUPER, "java/lang/Object", "")
+	init := object.AddMethod(heap.ACC_PUBLIC, "<init>", "()V")
+	code := object.NewCode()
+	code.Op(0xb1) // return
+	object.SetCode(init, code)
+	str := classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_FINAL|heap.ACC_SUPER, "java/lang/String", "java/lang/Object")
+	str.AddField(heap.ACC_PRIVATE|heap.ACC_FINAL, "value", "[C")
+	classes = append(classes, object, str,
+		classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_FINAL|heap.ACC_SUPER, "java/lang/Class", "java/lang/Object"),
+		classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_INTERFACE|heap.ACC_ABSTRACT, "java/lang/Cloneable", "java/lang/Object"),
+		classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_INTERFACE|heap.ACC_ABSTRACT, "java/io/Serializable", "java/lang/Object"))
+
+	dir := t.TempDir()
+	for _, cb := range classes {
+		data := cb.Bytes()
+		cf, err := classfile.Parse(data)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(cf.ClassName())+".class")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return heap.NewClassLoader(classpath.Parse(dir, dir), false)
+}
+
+// invokeInt 在新线程里执行静态方法 m()I，返回它的返回值
+func invokeInt(t *testing.T, class *heap.Class) int32 {
+	t.Helper()
+	thread := rtda.NewThread()
+	ops := rtda.NewOperandStack(1)
+	shimFrame := rtda.NewShimFrame(thread, ops)
+	thread.PushFrame(shimFrame)
+	base.InvokeMethod(shimFrame, class.GetStaticMethod("m", "()I"))
+	Interpret(thread, false)
+	return ops.PopInt()
+}
+
+// 嵌套的子程序：外层用 astore_0/ret 0，内层由 jsr_w 调用，用 wide astore/wide ret 保存返回地址
+func TestJsrRet(t *testing.T) {
+	cb := classfile.NewClassBuilder(49, heap.ACC_PUBLIC|heap.ACC_SUPER, "test/Sub", "java/lang/Object")
+	method := cb.AddMethod(heap.ACC_PUBLIC|heap.ACC_STATIC, "m", "()I")
+	code := cb.NewCode()
+	outer, inner := code.NewLabel(), code.NewLabel()
+	code.Op(0x03) // iconst_0
+	code.Store('I', 1)
+	code.Branch(0xa8, outer) // jsr
+	code.Branch(0xa8, outer) // jsr，同一个子程序返回到不同的地址
+	code.Load('I', 1)
+	code.Op(0xac) // ireturn
+	code.Mark(outer)
+	code.Store('L', 0) // returnAddress
+	code.Iinc(1, 1)
+	code.Branch(0xc9, inner) // jsr_w
+	code.Iinc(1, 100)        // 内层子程序返回到这里
+	code.OpU1(0xa9, 0)       // ret 0
+	code.Mark(inner)
+	code.Emit(0xc4, 0x3a, 0x01, 0x00) // wide astore 256
+	code.Iinc(1, 10)
+	code.Emit(0xc4, 0xa9, 0x01, 0x00) // wide ret 256
+	cb.SetCode(method, code)
+
+	class := newTestLoader(t, cb).LoadClass("test/Sub")
+	if got := invokeInt(t, class); got != 222 {
+		t.Errorf("m() = %d, want 222", got)
+	}
+}
@@ -0,0 +1,44 @@
+package interpreter
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/rtda"
+	"sync"
+	"sync/atomic"
+)
+
+var (
+	verboseInst      atomic.Bool    // 是否打印指令执行信息
+	nonDaemonThreads sync.WaitGroup // 仍在运行的非守护线程
+)
+
+// StartThread 在新的 goroutine 中解释执行 thread，thread 的栈上应已压入要执行的栈帧。
+// daemon: 是否为守护线程，虚拟机退出前只等待非守护线程
+func StartThread(thread *rtda.Thread, daemon bool) {
+	if !daemon {
+		nonDaemonThreads.Add(1)
+	}
+
+	go func() {
+		defer func() {
+			thread.Exit()
+			if !daemon {
+				nonDaemonThreads.Done()
+			}
+		}()
+		interpret(thread, verboseInst.Load())
+		ExitThread(thread)
+	}()
+}
+
+// ExitThread 线程的栈执行完（run() 返回或者没有被捕获的异常处理完）以后执行 Thread.exit()
+func ExitThread(thread *rtda.Thread) {
+	if base.ExitThread(thread) {
+		interpret(thread, verboseInst.Load())
+	}
+}
+
+// WaitNonDaemonThreads 等待所有非守护线程执行完毕
+func WaitNonDaemonThreads() {
+	nonDaemonThreads.Wait()
+}
@@ -0,0 +1,434 @@
+package jasmin
+
+import (
+	"fmt"
+	"jvm-go/classfile"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// assembler 逐行处理源代码。类和方法都用 classfile 包里的 ClassBuilder、CodeBuilder 生成
+type assembler struct {
+	line         int // 正在处理的行号，报错用
+	majorVersion uint16
+	minorVersion uint16
+	sourceFile   string
+	cb           *classfile.ClassBuilder
+	method       *method // 正在汇编的方法，.end method 之后是 nil
+}
+
+// method .method 和 .end method 之间的状态
+type method struct {
+	info        *classfile.MemberInfo
+	accessFlags uint16
+	code        *classfile.CodeBuilder
+	labels      map[string]*label
+	throws      []string
+	vars        []localVar
+	sw          *switchInsn // 正在读取分支的 tableswitch 或 lookupswitch
+}
+
+type label struct {
+	label *classfile.Label
+	pc    int // 还没有定义时是 -1
+	line  int // 第一次出现的行号
+}
+
+// localVar .var index is name descriptor from start to end
+type localVar struct {
+	index      uint16
+	name       string
+	descriptor string
+	start, end string // 为空时表示整个方法
+	line       int
+}
+
+var accessFlagNames = map[string]uint16{
+	"public": 0x0001, "private": 0x0002, "protected": 0x0004, "static": 0x0008,
+	"final": 0x0010, "synchronized": 0x0020, "super": 0x0020, "volatile": 0x0040,
+	"bridge": 0x0040, "transient": 0x0080, "varargs": 0x0080, "native": 0x0100,
+	"interface": 0x0200, "abstract": 0x0400, "strict": 0x0800, "strictfp": 0x0800,
+	"synthetic": 0x1000, "annotation": 0x2000, "enum": 0x4000,
+}
+
+func newAssembler() *assembler {
+	return &assembler{majorVersion: 49}
+}
+
+func (a *assembler) fail(format string, args ...interface{}) {
+	panic(&Error{Line: a.line, Msg: fmt.Sprintf(format, args...)})
+}
+
+func (a *assembler) assemble(src []byte) {
+	lines := strings.Split(string(src), "\n")
+	for i, line := range lines {
+		a.line = i + 1
+		if tokens := a.splitLine(line); len(tokens) > 0 {
+			a.statement(tokens)
+		}
+	}
+	a.line = len(lines)
+}
+
+func (a *assembler) finish() []byte {
+	if a.cb == nil {
+		a.fail("missing .class or .interface")
+	}
+	if a.method != nil {
+		a.fail("missing .end method")
+	}
+	a.cb.SetVersion(a.majorVersion, a.minorVersion)
+	if a.sourceFile != "" {
+		a.cb.SetAttribute(a.cb.SourceFile(a.sourceFile))
+	}
+	return a.cb.Bytes()
+}
+
+func (a *assembler) statement(tokens []token) {
+	if a.method != nil && a.method.sw != nil {
+		a.switchEntry(tokens)
+		return
+	}
+	if !tokens[0].quoted && strings.HasPrefix(tokens[0].text, ".") {
+		a.directive(tokens[0].text, tokens[1:])
+		return
+	}
+	// 标签可以单独一行，也可以写在指令前面
+	if len(tokens) >= 2 && tokens[1].text == ":" && !tokens[1].quoted {
+		a.markLabel(tokens[0].text)
+		tokens = tokens[2:]
+		if len(tokens) == 0 {
+			return
+		}
+	}
+	a.instruction(tokens)
+}
+
+func (a *assembler) directive(name string, args []token) {
+	switch name {
+	case ".bytecode": // .bytecode 49.0
+		a.checkArgs(name, args, 1, 1)
+		major, minor, _ := strings.Cut(args[0].text, ".")
+		a.majorVersion = uint16(a.int(major, 45, math.MaxUint16))
+		if minor != "" {
+			a.minorVersion = uint16(a.int(minor, 0, math.MaxUint16))
+		}
+	case ".source":
+		a.checkArgs(name, args, 1, 1)
+		a.sourceFile = args[0].text
+	case ".class", ".interface": // .class public final Foo
+		if a.cb != nil {
+			a.fail("duplicate %s", name)
+		}
+		a.checkArgs(name, args, 1, -1)
+		accessFlags := a.accessFlags(args[:len(args)-1])
+		if name == ".interface" {
+			accessFlags |= 0x0200 | 0x0400 // ACC_INTERFACE, ACC_ABSTRACT
+		} else {
+			accessFlags |= 0x0020 // ACC_SUPER，和 javac 一样
+		}
+		a.cb = classfile.NewClassBuilder(a.majorVersion, accessFlags, args[len(args)-1].text, "java/lang/Object")
+	case ".super":
+		a.checkArgs(name, args, 1, 1)
+		a.classBuilder().SetSuperClass(args[0].text)
+	case ".implements":
+		a.checkArgs(name, args, 1, 1)
+		a.classBuilder().AddInterface(args[0].text)
+	case ".signature": // 在方法里是方法的签名，否则是类的签名
+		a.checkArgs(name, args, 1, 1)
+		attr := a.classBuilder().Signature(args[0].text)
+		if a.method != nil {
+			a.cb.SetMemberAttribute(a.method.info, attr)
+		} else {
+			a.cb.SetAttribute(attr)
+		}
+	case ".field":
+		a.field(args)
+	case ".method":
+		a.startMethod(args)
+	case ".end":
+		a.checkArgs(name, args, 1, 1)
+		if args[0].text != "method" {
+			a.fail("unknown directive .end %s", args[0].text)
+		}
+		a.endMethod()
+	case ".limit": // .limit stack 2，.limit locals 1
+		a.checkArgs(name, args, 2, 2)
+		n := uint16(a.int(args[1].text, 0, math.MaxUint16))
+		switch args[0].text {
+		case "stack":
+			a.code().SetMaxStack(n)
+		case "locals":
+			a.code().SetMaxLocals(n)
+		default:
+			a.fail("unknown limit %s", args[0].text)
+		}
+	case ".line":
+		a.checkArgs(name, args, 1, 1)
+		a.code().LineNumber(uint16(a.int(args[0].text, 0, math.MaxUint16)))
+	case ".var": // .var 0 is this LFoo; from L0 to L1
+		a.localVar(args)
+	case ".throws":
+		a.checkArgs(name, args, 1, 1)
+		m := a.currentMethod()
+		m.throws = append(m.throws, args[0].text)
+	case ".catch": // .catch java/lang/Exception from L0 to L1 using L2，all 表示捕获所有异常
+		a.checkArgs(name, args, 7, 7)
+		a.expect(args[1], "from")
+		a.expect(args[3], "to")
+		a.expect(args[5], "using")
+		className := args[0].text
+		if className == "all" {
+			className = ""
+		}
+		a.code().TryCatch(a.labelRef(args[2].text), a.labelRef(args[4].text), a.labelRef(args[6].text), className)
+	default:
+		a.fail("unknown directive %s", name)
+	}
+}
+
+// field .field 访问标志 名字 描述符 [signature "签名"] [= 常量值]
+func (a *assembler) field(args []token) {
+	cb := a.classBuilder()
+	if a.method != nil {
+		a.fail(".field inside method")
+	}
+	i := 0
+	for i < len(args) && !args[i].quoted && accessFlagNames[args[i].text] != 0 {
+		i++
+	}
+	accessFlags := a.accessFlags(args[:i])
+	args = args[i:]
+	a.checkArgs(".field", args, 2, -1)
+	name, descriptor := args[0].text, args[1].text
+	args = args[2:]
+
+	var attributes []classfile.AttributeInfo
+	if len(args) >= 2 && args[0].text == "signature" {
+		attributes = append(attributes, cb.Signature(args[1].text))
+		args = args[2:]
+	}
+	if len(args) > 0 {
+		a.expect(args[0], "=")
+		a.checkArgs(".field", args[1:], 1, 1)
+		attributes = append(attributes, cb.ConstantValue(a.fieldConstant(descriptor, args[1])))
+	}
+	cb.AddField(accessFlags, name, descriptor, attributes...)
+}
+
+// fieldConstant 常量的类型由字段的描述符决定
+func (a *assembler) fieldConstant(descriptor string, value token) uint16 {
+	switch descriptor {
+	case "Z", "B", "C", "S", "I":
+		return a.cb.Integer(a.int32(value.text))
+	case "J":
+		return a.cb.Long(a.long(value.text))
+	case "F":
+		return a.cb.Float(float32(a.float(value.text, 32)))
+	case "D":
+		return a.cb.Double(a.float(value.text, 64))
+	case "Ljava/lang/String;":
+		return a.cb.String(value.text)
+	}
+	a.fail("field of type %s can not have a constant value", descriptor)
+	return 0
+}
+
+// startMethod .method 访问标志 名字(参数)返回值
+func (a *assembler) startMethod(args []token) {
+	cb := a.classBuilder()
+	if a.method != nil {
+		a.fail("missing .end method")
+	}
+	a.checkArgs(".method", args, 1, -1)
+	nameAndDescriptor := args[len(args)-1].text
+	paren := strings.IndexByte(nameAndDescriptor, '(')
+	if paren <= 0 {
+		a.fail("bad method name and descriptor %s", nameAndDescriptor)
+	}
+	accessFlags := a.accessFlags(args[:len(args)-1])
+	a.method = &method{
+		info:        cb.AddMethod(accessFlags, nameAndDescriptor[:paren], nameAndDescriptor[paren:]),
+		accessFlags: accessFlags,
+		code:        cb.NewCode(),
+		labels:      map[string]*label{},
+	}
+}
+
+// endMethod 抽象方法和本地方法没有指令时不生成 Code 属性
+func (a *assembler) endMethod() {
+	m := a.currentMethod()
+	if m.sw != nil {
+		a.fail("missing default in switch")
+	}
+	if len(m.throws) > 0 {
+		a.cb.SetMemberAttribute(m.info, a.cb.Exceptions(m.throws...))
+	}
+	if m.code.Pc() > 0 || m.accessFlags&(0x0400|0x0100) == 0 { // ACC_ABSTRACT, ACC_NATIVE
+		for name, l := range m.labels {
+			if l.pc < 0 {
+				a.line = l.line
+				a.fail("undefined label %s", name)
+			}
+		}
+		if len(m.vars) > 0 {
+			m.code.AddAttribute(a.localVariableTable(m))
+		}
+		a.cb.SetCode(m.info, m.code)
+	}
+	a.method = nil
+}
+
+// localVar .var 索引 is 名字 描述符 [from 开始标签 to 结束标签]
+func (a *assembler) localVar(args []token) {
+	m := a.currentMethod()
+	if len(args) != 4 && len(args) != 8 {
+		a.fail(".var: expected 4 or 8 arguments, got %d", len(args))
+	}
+	a.expect(args[1], "is")
+	v := localVar{
+		index:      uint16(a.int(args[0].text, 0, math.MaxUint16)),
+		name:       args[2].text,
+		descriptor: args[3].text,
+		line:       a.line,
+	}
+	if len(args) == 8 {
+		a.expect(args[4], "from")
+		a.expect(args[6], "to")
+		v.start, v.end = args[5].text, args[7].text
+		a.labelRef(v.start)
+		a.labelRef(v.end)
+	}
+	m.vars = append(m.vars, v)
+}
+
+// localVariableTable 标签的位置在 .end method 时才都确定，所以 LocalVariableTable 最后生成
+func (a *assembler) localVariableTable(m *method) classfile.AttributeInfo {
+	info := []byte{byte(len(m.vars) >> 8), byte(len(m.vars))}
+	u2 := func(val int) {
+		info = append(info, byte(val>>8), byte(val))
+	}
+	for _, v := range m.vars {
+		start, end := 0, m.code.Pc()
+		if v.start != "" {
+			start, end = m.labels[v.start].pc, m.labels[v.end].pc
+		}
+		if end < start {
+			a.line = v.line
+			a.fail(".var %s: end label before start label", v.name)
+		}
+		u2(start)
+		u2(end - start)
+		u2(int(a.cb.Utf8(v.name)))
+		u2(int(a.cb.Utf8(v.descriptor)))
+		u2(int(v.index))
+	}
+	return a.cb.Attribute("LocalVariableTable", info)
+}
+
+// 标签
+
+func (a *assembler) labelRef(name string) *classfile.Label {
+	m := a.currentMethod()
+	l := m.labels[name]
+	if l == nil {
+		l = &label{label: m.code.NewLabel(), pc: -1, line: a.line}
+		m.labels[name] = l
+	}
+	return l.label
+}
+
+func (a *assembler) markLabel(name string) {
+	m := a.currentMethod()
+	a.labelRef(name)
+	l := m.labels[name]
+	if l.pc >= 0 {
+		a.fail("duplicate label %s", name)
+	}
+	m.code.Mark(l.label)
+	l.pc = m.code.Pc()
+}
+
+// 辅助函数
+
+func (a *assembler) classBuilder() *classfile.ClassBuilder {
+	if a.cb == nil {
+		a.fail("missing .class or .interface")
+	}
+	return a.cb
+}
+
+func (a *assembler) currentMethod() *method {
+	if a.method == nil {
+		a.fail("not inside .method")
+	}
+	return a.method
+}
+
+func (a *assembler) code() *classfile.CodeBuilder {
+	return a.currentMethod().code
+}
+
+// checkArgs 检查参数个数，max 为 -1 时不限制
+func (a *assembler) checkArgs(name string, args []token, min, max int) {
+	if len(args) < min || (max >= 0 && len(args) > max) {
+		a.fail("%s: wrong number of arguments", name)
+	}
+}
+
+func (a *assembler) expect(tok token, word string) {
+	if tok.text != word || tok.quoted {
+		a.fail("expected %s, got %s", word, tok.text)
+	}
+}
+
+func (a *assembler) accessFlags(words []token) uint16 {
+	var flags uint16
+	for _, word := range words {
+		flag, ok := accessFlagNames[word.text]
+		if !ok || word.quoted {
+			a.fail("unknown access flag %s", word.text)
+		}
+		flags |= flag
+	}
+	return flags
+}
+
+// int 解析整数，可以是十进制、十六进制（0x）或者八进制（0）
+func (a *assembler) int(s string, min, max int64) int64 {
+	val, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		a.fail("bad integer %s", s)
+	}
+	if val < min || val > max {
+		a.fail("integer %s out of range [%d, %d]", s, min, max)
+	}
+	return val
+}
+
+// int32 十六进制可以写到 0xffffffff，也就是 -1
+func (a *assembler) int32(s string) int32 {
+	digits := strings.ToLower(strings.TrimLeft(s, "+-"))
+	if strings.HasPrefix(digits, "0x") {
+		return int32(a.int(s, math.MinInt32, math.MaxUint32))
+	}
+	return int32(a.int(s, math.MinInt32, math.MaxInt32))
+}
+
+func (a *assembler) long(s string) int64 {
+	val, err := strconv.ParseInt(strings.TrimRight(s, "Ll"), 0, 64)
+	if err != nil {
+		a.fail("bad long %s", s)
+	}
+	return val
+}
+
+// float 解析浮点数，可以是 NaN、Infinity 和 -Infinity
+func (a *assembler) float(s string, bitSize int) float64 {
+	val, err := strconv.ParseFloat(strings.TrimRight(s, "FfDd"), bitSize)
+	if err != nil {
+		a.fail("bad floating-point number %s", s)
+	}
+	return val
+}
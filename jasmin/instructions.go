@@ -0,0 +1,290 @@
+package jasmin
+
+import (
+	"jvm-go/classfile"
+	"jvm-go/instructions"
+	"math"
+	"reflect"
+	"strings"
+)
+
+// opcodes 助记符 -> 操作码，包括解释器支持的所有指令。
+// 除了 javap 的助记符，也可以用 instructions 包里指令结构体的名字，比如 array_length、invoke_virtual
+var opcodes = map[string]uint8{}
+
+func init() {
+	for i := 0; i < 256; i++ {
+		opcode := uint8(i)
+		inst := newInstruction(opcode)
+		if inst == nil {
+			continue
+		}
+		opcodes[instructions.OpcodeName(opcode)] = opcode
+		name := strings.ToLower(reflect.TypeOf(inst).Elem().Name())
+		if _, ok := opcodes[name]; !ok {
+			opcodes[name] = opcode
+		}
+	}
+}
+
+// newInstruction 解释器不支持的操作码返回 nil
+func newInstruction(opcode uint8) interface{} {
+	if !instructions.IsSupported(opcode) {
+		return nil
+	}
+	return instructions.NewInstruction(opcode)
+}
+
+var arrayTypes = map[string]uint8{
+	"boolean": 4, "char": 5, "float": 6, "double": 7, "byte": 8, "short": 9, "int": 10, "long": 11,
+}
+
+// canBeWide 可以加 wide 前缀的指令：xload、xstore、ret 和 iinc
+func canBeWide(opcode uint8) bool {
+	return opcode >= 0x15 && opcode <= 0x19 || opcode >= 0x36 && opcode <= 0x3a || opcode == 0xa9 || opcode == 0x84
+}
+
+// instruction 汇编一条指令。局部变量索引超过一个字节时自动加 wide 前缀，
+// 也可以写成 wide iload 1 或者 javap 的 iload_w 1 强制使用 wide
+func (a *assembler) instruction(tokens []token) {
+	code := a.code()
+	name, args := tokens[0].text, tokens[1:]
+	wide := false
+	if name == "wide" {
+		if len(args) == 0 {
+			a.fail("wide: missing instruction")
+		}
+		name, args, wide = args[0].text, args[1:], true
+	} else if _, ok := opcodes[name]; !ok && strings.HasSuffix(name, "_w") {
+		name, wide = strings.TrimSuffix(name, "_w"), true
+	}
+	opcode, ok := opcodes[name]
+	if !ok || tokens[0].quoted {
+		a.fail("unknown instruction %s", tokens[0].text)
+	}
+	if wide && !canBeWide(opcode) {
+		a.fail("%s can not be wide", name)
+	}
+
+	switch {
+	case opcode == 0x10: // bipush
+		a.checkArgs(name, args, 1, 1)
+		code.OpU1(opcode, uint8(a.int(args[0].text, math.MinInt8, math.MaxInt8)))
+	case opcode == 0x11: // sipush
+		a.checkArgs(name, args, 1, 1)
+		code.OpU2(opcode, uint16(a.int(args[0].text, math.MinInt16, math.MaxInt16)))
+	case opcode == 0x12: // ldc
+		index := a.ldcConstant(name, args, false)
+		if index > math.MaxUint8 {
+			a.fail("ldc: constant pool index %d too large, use ldc_w", index)
+		}
+		code.OpU1(opcode, uint8(index))
+	case opcode == 0x13 || opcode == 0x14: // ldc_w, ldc2_w
+		code.OpU2(opcode, a.ldcConstant(name, args, opcode == 0x14))
+	case opcode >= 0x15 && opcode <= 0x19, opcode >= 0x36 && opcode <= 0x3a, opcode == 0xa9: // xload, xstore, ret
+		a.checkArgs(name, args, 1, 1)
+		index := uint16(a.int(args[0].text, 0, math.MaxUint16))
+		if wide || index > math.MaxUint8 {
+			code.Emit(0xc4, opcode, byte(index>>8), byte(index))
+		} else {
+			code.OpU1(opcode, uint8(index))
+		}
+	case opcode == 0x84: // iinc
+		a.checkArgs(name, args, 2, 2)
+		index := uint16(a.int(args[0].text, 0, math.MaxUint16))
+		delta := int16(a.int(args[1].text, math.MinInt16, math.MaxInt16))
+		if wide || index > math.MaxUint8 || delta < math.MinInt8 || delta > math.MaxInt8 {
+			code.Emit(0xc4, opcode, byte(index>>8), byte(index), byte(delta>>8), byte(delta))
+		} else {
+			code.Emit(opcode, uint8(index), uint8(delta))
+		}
+	case opcode >= 0x99 && opcode <= 0xa8, opcode >= 0xc6 && opcode <= 0xc9: // if<cond>, goto, jsr, ifnull, ifnonnull, goto_w, jsr_w
+		a.checkArgs(name, args, 1, 1)
+		code.Branch(opcode, a.labelRef(args[0].text))
+	case opcode == 0xaa: // tableswitch low [high]，之后每行一个标签，最后是 default : 标签
+		a.checkArgs(name, args, 1, 2)
+		sw := &switchInsn{opcode: opcode, low: int32(a.int(args[0].text, math.MinInt32, math.MaxInt32))}
+		if len(args) == 2 {
+			sw.high = int32(a.int(args[1].text, math.MinInt32, math.MaxInt32))
+			sw.hasHigh = true
+		}
+		a.method.sw = sw
+	case opcode == 0xab: // lookupswitch，之后每行一个 key : 标签，最后是 default : 标签
+		a.checkArgs(name, args, 0, 0)
+		a.method.sw = &switchInsn{opcode: opcode}
+	case opcode >= 0xb2 && opcode <= 0xb5: // getstatic java/lang/System/out Ljava/io/PrintStream;
+		a.checkArgs(name, args, 2, 2)
+		className, fieldName := a.splitMember(args[0].text)
+		code.Field(opcode, className, fieldName, args[1].text)
+	case opcode >= 0xb6 && opcode <= 0xb8: // invokestatic [interface] java/lang/Math/abs(I)I
+		a.checkArgs(name, args, 1, 2)
+		if len(args) == 2 {
+			a.expect(args[0], "interface") // 调用接口的静态方法或者私有方法
+			className, methodName, descriptor := a.splitMethod(args[1].text)
+			code.InvokeInterfaceMethod(opcode, className, methodName, descriptor)
+		} else {
+			className, methodName, descriptor := a.splitMethod(args[0].text)
+			code.Invoke(opcode, className, methodName, descriptor)
+		}
+	case opcode == 0xb9: // invokeinterface java/util/List/size()I [count]
+		a.checkArgs(name, args, 1, 2)
+		className, methodName, descriptor := a.splitMethod(args[0].text)
+		if len(args) == 2 {
+			index := a.cb.InterfaceMethodref(className, methodName, descriptor)
+			count := uint8(a.int(args[1].text, 0, math.MaxUint8))
+			code.Emit(opcode, byte(index>>8), byte(index), count, 0)
+		} else {
+			code.Invoke(opcode, className, methodName, descriptor)
+		}
+	case opcode == 0xba: // invokedynamic 名字(描述符) 启动方法所在的类/启动方法(描述符) [静态参数...]
+		a.checkArgs(name, args, 2, -1)
+		paren := strings.IndexByte(args[0].text, '(')
+		if paren <= 0 {
+			a.fail("bad method name and descriptor %s", args[0].text)
+		}
+		className, methodName, descriptor := a.splitMethod(args[1].text)
+		bootstrapMethod := a.cb.MethodHandle(6, a.cb.Methodref(className, methodName, descriptor)) // REF_invokeStatic
+		var arguments []uint16
+		for rest := args[2:]; len(rest) > 0; {
+			index, n, _ := a.constant(rest, false)
+			arguments = append(arguments, index)
+			rest = rest[n:]
+		}
+		code.InvokeDynamic(a.cb.BootstrapMethod(bootstrapMethod, arguments...), args[0].text[:paren], args[0].text[paren:])
+	case opcode == 0xbb, opcode == 0xbd, opcode == 0xc0, opcode == 0xc1: // new, anewarray, checkcast, instanceof
+		a.checkArgs(name, args, 1, 1)
+		code.TypeInsn(opcode, args[0].text)
+	case opcode == 0xbc: // newarray int
+		a.checkArgs(name, args, 1, 1)
+		atype, ok := arrayTypes[args[0].text]
+		if !ok {
+			atype = uint8(a.int(args[0].text, 0, math.MaxUint8))
+		}
+		code.OpU1(opcode, atype)
+	case opcode == 0xc5: // multianewarray [[I 2
+		a.checkArgs(name, args, 2, 2)
+		index := a.cb.Class(args[0].text)
+		code.Emit(opcode, byte(index>>8), byte(index), uint8(a.int(args[1].text, 0, math.MaxUint8)))
+	case opcode == 0xc4: // wide
+		a.fail("wide: missing instruction")
+	default:
+		a.checkArgs(name, args, 0, 0)
+		code.Op(opcode)
+	}
+}
+
+// ldcConstant ldc 和 ldc_w 不能加载 long 和 double，ldc2_w 只能加载 long 和 double
+func (a *assembler) ldcConstant(name string, args []token, wide bool) uint16 {
+	a.checkArgs(name, args, 1, 2)
+	index, n, long := a.constant(args, wide)
+	if n != len(args) {
+		a.fail("%s: wrong number of arguments", name)
+	}
+	if long != wide {
+		if wide {
+			a.fail("%s: can only load long or double", name)
+		}
+		a.fail("%s: can not load long or double, use ldc2_w", name)
+	}
+	return index
+}
+
+// constant 解析一个常量，返回常量池索引、用掉的单词数和是不是 long 或 double：
+//   - 带引号的字符串是 String
+//   - class java/lang/Object 是 Class
+//   - 整数默认是 int，后缀 L 表示 long；浮点数默认是 float，后缀 D 表示 double。
+//     wide 为 true 时（ldc2_w）默认是 long 和 double
+func (a *assembler) constant(args []token, wide bool) (index uint16, n int, long bool) {
+	tok := args[0]
+	if tok.quoted {
+		return a.cb.String(tok.text), 1, false
+	}
+	if tok.text == "class" {
+		if len(args) < 2 {
+			a.fail("class: missing class name")
+		}
+		return a.cb.Class(args[1].text), 2, false
+	}
+
+	s := tok.text
+	digits := strings.ToLower(strings.TrimLeft(s, "+-"))
+	if digits == "" {
+		a.fail("bad constant %s", s)
+	}
+	hex := strings.HasPrefix(digits, "0x")
+	floating := !hex && (strings.ContainsAny(digits, ".e") || strings.HasPrefix(digits, "nan") || strings.HasPrefix(digits, "inf"))
+	switch suffix := digits[len(digits)-1]; {
+	case suffix == 'l':
+		return a.cb.Long(a.long(s)), 1, true
+	case !hex && suffix == 'd', floating && wide && suffix != 'f':
+		return a.cb.Double(a.float(s, 64)), 1, true
+	case !hex && suffix == 'f', floating:
+		return a.cb.Float(float32(a.float(s, 32))), 1, false
+	case wide:
+		return a.cb.Long(a.long(s)), 1, true
+	default:
+		return a.cb.Integer(a.int32(s)), 1, false
+	}
+}
+
+// splitMember java/lang/System/out -> java/lang/System, out
+func (a *assembler) splitMember(s string) (string, string) {
+	slash := strings.LastIndexByte(s, '/')
+	if slash <= 0 || slash == len(s)-1 {
+		a.fail("bad member reference %s", s)
+	}
+	return s[:slash], s[slash+1:]
+}
+
+// splitMethod java/lang/Object/equals(Ljava/lang/Object;)Z -> java/lang/Object, equals, (Ljava/lang/Object;)Z
+func (a *assembler) splitMethod(s string) (string, string, string) {
+	paren := strings.IndexByte(s, '(')
+	if paren < 0 {
+		a.fail("bad method reference %s", s)
+	}
+	className, methodName := a.splitMember(s[:paren])
+	return className, methodName, s[paren:]
+}
+
+// switchInsn tableswitch 或 lookupswitch 的分支，读到 default 时生成指令
+type switchInsn struct {
+	opcode  uint8
+	low     int32
+	high    int32
+	hasHigh bool
+	keys    []int32
+	labels  []*classfile.Label
+}
+
+// switchEntry tableswitch 的分支每行一个标签，也可以像 javap 一样写成 key : 标签；
+// lookupswitch 的分支是 key : 标签
+func (a *assembler) switchEntry(tokens []token) {
+	sw := a.method.sw
+	if len(tokens) == 3 && tokens[0].text == "default" && tokens[1].text == ":" {
+		a.method.sw = nil
+		defaultLabel := a.labelRef(tokens[2].text)
+		if sw.opcode == 0xab { // lookupswitch
+			a.code().LookupSwitch(defaultLabel, sw.keys, sw.labels)
+			return
+		}
+		if sw.hasHigh && int64(sw.high) != int64(sw.low)+int64(len(sw.labels))-1 {
+			a.fail("tableswitch: %d labels for %d to %d", len(sw.labels), sw.low, sw.high)
+		}
+		a.code().TableSwitch(defaultLabel, sw.low, sw.labels...)
+		return
+	}
+
+	switch {
+	case len(tokens) == 3 && tokens[1].text == ":":
+		key := int32(a.int(tokens[0].text, math.MinInt32, math.MaxInt32))
+		if sw.opcode == 0xaa && int64(key) != int64(sw.low)+int64(len(sw.labels)) {
+			a.fail("tableswitch: expected key %d, got %d", int64(sw.low)+int64(len(sw.labels)), key)
+		}
+		sw.keys = append(sw.keys, key)
+		sw.labels = append(sw.labels, a.labelRef(tokens[2].text))
+	case len(tokens) == 1 && sw.opcode == 0xaa:
+		sw.labels = append(sw.labels, a.labelRef(tokens[0].text))
+	default:
+		a.fail("bad %s entry", instructions.OpcodeName(sw.opcode))
+	}
+}
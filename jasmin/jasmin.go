@@ -0,0 +1,99 @@
+// Package jasmin 实现一个 Jasmin 风格的汇编器，把文本形式的类定义汇编成 class 文件，
+// 用来手写 javac 不会生成的字节码（jsr、wide、各种 switch 边界情况），作为解释器和验证器的测试用例。
+//
+//	.class public Hello
+//	.super java/lang/Object
+//
+//	.method public static main([Ljava/lang/String;)V
+//	    getstatic java/lang/System/out Ljava/io/PrintStream;
+//	    ldc "Hello, world"
+//	    invokevirtual java/io/PrintStream/println(Ljava/lang/String;)V
+//	    return
+//	.end method
+//
+// 助记符和 javap 的输出一致，也可以用 instructions 包里指令结构体的名字（比如 array_length、invoke_virtual）。
+// 汇编器不生成 StackMapTable，默认的版本号是 49.0，这样虚拟机用类型推导验证字节码
+package jasmin
+
+import (
+	"flag"
+	"fmt"
+	"jvm-go/classfile"
+	"os"
+	"path/filepath"
+)
+
+// Error 源文件里的错误，Line 从 1 开始
+type Error struct {
+	Line int
+	Msg  string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Assemble 把一个类的汇编源代码汇编成 class 文件，出错时返回 *Error
+func Assemble(src []byte) (data []byte, err error) {
+	a := newAssembler()
+	defer func() {
+		if r := recover(); r != nil {
+			e, ok := r.(*Error)
+			if !ok {
+				// CodeBuilder 回填标签、计算 max_stack 时的错误
+				e = &Error{Line: a.line, Msg: fmt.Sprint(r)}
+			}
+			data, err = nil, e
+		}
+	}()
+	a.assemble(src)
+	return a.finish(), nil
+}
+
+// Main 执行 jasmin 子命令，args 是 jasmin 之后的参数，返回进程的退出码。
+// class 文件按照类名写到 -d 指定的目录下，包名对应子目录
+//
+//	jvm-go jasmin [-d dir] file.j...
+func Main(args []string) int {
+	flags := flag.NewFlagSet("jasmin", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s jasmin [-d dir] file.j...\n", os.Args[0])
+	}
+	outDir := flags.String("d", ".", "class 文件的输出目录")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	exitCode := 0
+	for _, file := range flags.Args() {
+		if err := assembleFile(file, *outDir); err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+func assembleFile(file, outDir string) error {
+	src, err := os.ReadFile(file)
+	if err != nil {
+		return err
+	}
+	data, err := Assemble(src)
+	if err != nil {
+		return err
+	}
+	cf, err := classfile.Parse(data)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(outDir, filepath.FromSlash(cf.ClassName())+".class")
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0644)
+}
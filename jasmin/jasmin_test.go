@@ -0,0 +1,215 @@
+package jasmin
+
+import (
+	"bytes"
+	"jvm-go/classfile"
+	"testing"
+)
+
+const header = `.class public test/Asm
+.super java/lang/Object
+`
+
+// assembleCode 汇编只有一个方法的类，经过 classfile.Parse 之后返回方法的字节码
+func assembleCode(t *testing.T, method string) []byte {
+	t.Helper()
+	data, err := Assemble([]byte(header + method))
+	if err != nil {
+		t.Fatalf("Assemble: %v", err)
+	}
+	cf, err := classfile.Parse(data)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	if got := cf.Bytes(); !bytes.Equal(got, data) {
+		t.Errorf("Parse(data).Bytes() differs from data")
+	}
+	if cf.ClassName() != "test/Asm" || cf.MajorVersion() != 49 {
+		t.Errorf("class %s version %d", cf.ClassName(), cf.MajorVersion())
+	}
+	return cf.Methods()[0].CodeAttribute().Code()
+}
+
+func TestAssemble(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		code   []byte
+	}{
+		{"labels", `
+.method public static m(I)V
+Loop:
+    iload_0
+    ifeq End
+    goto Loop
+End: return
+.end method
+`, []byte{
+			0x1a,             // iload_0
+			0x99, 0x00, 0x06, // ifeq +6
+			0xa7, 0xff, 0xfc, // goto -4
+			0xb1, // return
+		}},
+		{"tableswitch padding", `
+.method public static m(I)V
+    iload_0
+    tableswitch 1 2
+      One
+      Two
+      default : Default
+One: return
+Two: return
+Default: return
+.end method
+`, []byte{
+			0x1a,       // iload_0
+			0xaa, 0, 0, // tableswitch，填充到 4 字节对齐
+			0, 0, 0, 25, // default
+			0, 0, 0, 1, // low
+			0, 0, 0, 2, // high
+			0, 0, 0, 23,
+			0, 0, 0, 24,
+			0xb1, 0xb1, 0xb1,
+		}},
+		{"lookupswitch without padding", `
+.method public static m(I)V
+    iload_0
+    nop
+    nop
+    lookupswitch
+      10 : B
+      -1 : A
+      default : A
+A: return
+B: return
+.end method
+`, []byte{
+			0x1a, 0x00, 0x00, // iload_0, nop, nop
+			0xab,        // lookupswitch，已经是 4 字节对齐
+			0, 0, 0, 25, // default
+			0, 0, 0, 2, // npairs
+			0xff, 0xff, 0xff, 0xff, 0, 0, 0, 25, // 按 key 排序
+			0, 0, 0, 10, 0, 0, 0, 26,
+			0xb1, 0xb1,
+		}},
+		{"jsr and ret", `
+.method public static m()V
+    jsr Sub
+    return
+Sub:
+    astore 1
+    ret 1
+.end method
+`, []byte{
+			0xa8, 0x00, 0x04, // jsr +4
+			0xb1,       // return
+			0x3a, 0x01, // astore 1
+			0xa9, 0x01, // ret 1
+		}},
+		{"wide jsr and ret", `
+.method public static m()V
+    jsr_w Sub
+    return
+Sub:
+    astore 256
+    ret 256
+.end method
+`, []byte{
+			0xc9, 0x00, 0x00, 0x00, 0x06, // jsr_w +6
+			0xb1,                   // return
+			0xc4, 0x3a, 0x01, 0x00, // wide astore 256
+			0xc4, 0xa9, 0x01, 0x00, // wide ret 256
+		}},
+		{"wide iinc and load/store", `
+.method public static m()V
+    iinc 1 1
+    iinc 300 1
+    iinc 1 1000
+    wide iinc 2 1
+    iload 300
+    istore_w 2
+    lload 1
+    lstore 255
+    return
+.end method
+`, []byte{
+			0x84, 0x01, 0x01, // iinc 1 1
+			0xc4, 0x84, 0x01, 0x2c, 0x00, 0x01, // 索引超过一个字节
+			0xc4, 0x84, 0x00, 0x01, 0x03, 0xe8, // 增量超过一个字节
+			0xc4, 0x84, 0x00, 0x02, 0x00, 0x01, // wide 前缀
+			0xc4, 0x15, 0x01, 0x2c, // wide iload 300
+			0xc4, 0x36, 0x00, 0x02, // istore_w 2
+			0x16, 0x01, // lload 1
+			0x37, 0xff, // lstore 255
+			0xb1,
+		}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if code := assembleCode(t, test.method); !bytes.Equal(code, test.code) {
+				t.Errorf("code = % x\nwant   % x", code, test.code)
+			}
+		})
+	}
+}
+
+func TestAssembleErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		line int
+		msg  string
+	}{
+		{"undefined label", `
+.method public static m()V
+    goto Nowhere
+    return
+.end method
+`, 5, "undefined label Nowhere"},
+		{"unknown instruction", `
+.method public static m()V
+    iload_0
+    frobnicate 1
+    return
+.end method
+`, 6, "unknown instruction frobnicate"},
+		{"duplicate label", `
+.method public static m()V
+L: nop
+L: return
+.end method
+`, 6, "duplicate label L"},
+		{"tableswitch labels", `
+.method public static m(I)V
+    iload_0
+    tableswitch 0 2
+      L
+      default : L
+L: return
+.end method
+`, 8, "tableswitch: 1 labels for 0 to 2"},
+		{"wide", `
+.method public static m()V
+    wide nop
+.end method
+`, 5, "nop can not be wide"},
+		{"missing end method", `
+.method public static m()V
+    return
+`, 6, "missing .end method"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := Assemble([]byte(header + test.src))
+			e, ok := err.(*Error)
+			if !ok {
+				t.Fatalf("err = %v, want *Error", err)
+			}
+			if e.Line != test.line || e.Msg != test.msg {
+				t.Errorf("err = %v, want line %d: %s", e, test.line, test.msg)
+			}
+		})
+	}
+}
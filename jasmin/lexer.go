@@ -0,0 +1,54 @@
+package jasmin
+
+import (
+	"strconv"
+	"strings"
+)
+
+// token 一行里的一个单词。带引号的字符串已经去掉引号、处理了转义
+type token struct {
+	text   string
+	quoted bool
+}
+
+// splitLine 把一行源代码拆成单词。空白和逗号是分隔符，冒号单独作为一个单词（标签、switch 的分支），
+// 单词开头的 ; 表示注释，描述符里的 ; 不是注释
+func (a *assembler) splitLine(line string) []token {
+	var tokens []token
+	for i := 0; i < len(line); {
+		switch c := line[i]; {
+		case c == ' ' || c == '\t' || c == '\r' || c == ',':
+			i++
+		case c == ';':
+			return tokens
+		case c == ':':
+			tokens = append(tokens, token{text: ":"})
+			i++
+		case c == '"':
+			end := i + 1
+			for end < len(line) && line[end] != '"' {
+				if line[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(line) {
+				a.fail("unterminated string")
+			}
+			s, err := strconv.Unquote(line[i : end+1])
+			if err != nil {
+				a.fail("bad string %s: %v", line[i:end+1], err)
+			}
+			tokens = append(tokens, token{text: s, quoted: true})
+			i = end + 1
+		default:
+			end := i
+			for end < len(line) && !strings.ContainsRune(" \t\r,:\"", rune(line[end])) {
+				end++
+			}
+			tokens = append(tokens, token{text: line[i:end]})
+			i = end
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,283 @@
+package javap
+
+import (
+	"fmt"
+	"jvm-go/classfile"
+	"strconv"
+	"strings"
+)
+
+// printAttribute 输出 javap -v 格式的属性，method 是 Code 属性所属的方法，类和字段的属性传 nil
+func (p *printer) printAttribute(indent int, attrInfo classfile.AttributeInfo, method *classfile.MemberInfo) {
+	switch attr := attrInfo.(type) {
+	case *classfile.CodeAttribute:
+		p.println(indent, "Code:")
+		argsSize := argsSize(method.Descriptor())
+		if method.AccessFlags()&0x0008 == 0 {
+			argsSize++ // this
+		}
+		p.println(indent+2, fmt.Sprintf("stack=%d, locals=%d, args_size=%d", attr.MaxStack(), attr.MaxLocals(), argsSize))
+		p.printInstructions(indent+2, attr)
+		p.printExceptionTable(indent+2, attr)
+		for _, nested := range attr.Attributes() {
+			p.printAttribute(indent+2, nested, method)
+		}
+	case *classfile.LineNumberTableAttribute:
+		p.println(indent, "LineNumberTable:")
+		for _, entry := range attr.LineNumberTable() {
+			p.println(indent+2, fmt.Sprintf("line %d: %d", entry.LineNumber(), entry.StartPc()))
+		}
+	case *classfile.LocalVariableTableAttribute:
+		p.println(indent, "LocalVariableTable:")
+		p.println(indent+2, "Start  Length  Slot  Name   Signature")
+		for _, entry := range attr.LocalVariableTable() {
+			p.println(indent+2, fmt.Sprintf("%5d %7d %5d %5s   %s", entry.StartPc(), entry.Length(), entry.Index(),
+				p.utf8(entry.NameIndex()), p.utf8(entry.DescriptorIndex())))
+		}
+	case *classfile.LocalVariableTypeTableAttribute:
+		p.println(indent, "LocalVariableTypeTable:")
+		p.println(indent+2, "Start  Length  Slot  Name   Signature")
+		for _, entry := range attr.LocalVariableTypeTable() {
+			p.println(indent+2, fmt.Sprintf("%5d %7d %5d %5s   %s", entry.StartPc(), entry.Length(), entry.Index(),
+				p.utf8(entry.NameIndex()), p.utf8(entry.SignatureIndex())))
+		}
+	case *classfile.StackMapTableAttribute:
+		p.printStackMapTable(indent, attr)
+	case *classfile.ExceptionsAttribute:
+		p.println(indent, "Exceptions:")
+		var names []string
+		for _, index := range attr.ExceptionIndexTable() {
+			names = append(names, classTypeName(p.className(index)))
+		}
+		p.println(indent+2, "throws "+strings.Join(names, ", "))
+	case *classfile.SignatureAttribute:
+		p.printComment(indent, fmt.Sprintf("Signature: #%d", attr.SignatureIndex()), attr.Signature())
+	case *classfile.SourceFileAttribute:
+		p.println(indent, fmt.Sprintf("SourceFile: %q", attr.FileName()))
+	case *classfile.ConstantValueAttribute:
+		p.println(indent, "ConstantValue: "+p.constantValue(attr.ConstantValueIndex()))
+	case *classfile.DeprecatedAttribute:
+		p.println(indent, "Deprecated: true")
+	case *classfile.SyntheticAttribute:
+		p.println(indent, "Synthetic: true")
+	case *classfile.InnerClassesAttribute:
+		p.printInnerClasses(indent, attr)
+	case *classfile.EnclosingMethodAttribute:
+		comment := javaName(attr.ClassName())
+		if attr.MethodIndex() != 0 {
+			name, _ := attr.MethodNameAndDescriptor()
+			comment += "." + name
+		}
+		p.printComment(indent, fmt.Sprintf("EnclosingMethod: #%d.#%d", attr.ClassIndex(), attr.MethodIndex()), comment)
+	case *classfile.BootstrapMethodsAttribute:
+		p.println(indent, "BootstrapMethods:")
+		for i, bm := range attr.BootstrapMethods() {
+			p.println(indent+2, fmt.Sprintf("%d: #%d %s", i, bm.BootstrapMethodRef(), p.constantComment(bm.BootstrapMethodRef(), false)))
+			p.println(indent+4, "Method arguments:")
+			for _, arg := range bm.BootstrapArguments() {
+				p.println(indent+6, fmt.Sprintf("#%d %s", arg, p.constantComment(arg, false)))
+			}
+		}
+	case *classfile.NestHostAttribute:
+		p.println(indent, "NestHost: class "+attr.HostClassName())
+	case *classfile.NestMembersAttribute:
+		p.printNames(indent, "NestMembers:", attr.ClassNames())
+	case *classfile.PermittedSubclassesAttribute:
+		p.printNames(indent, "PermittedSubclasses:", attr.ClassNames())
+	case *classfile.RecordAttribute:
+		p.println(indent, "Record:")
+		for _, component := range attr.Components() {
+			componentType := fieldTypeName(component.Descriptor())
+			if signature := component.Signature(); signature != "" {
+				componentType = fieldTypeName(signature)
+			}
+			p.println(indent+2, componentType+" "+component.Name()+";")
+			p.println(indent+4, "descriptor: "+component.Descriptor())
+			if signature := component.Signature(); signature != "" {
+				p.println(indent+4, "Signature: "+signature)
+			}
+		}
+	case *classfile.MethodParametersAttribute:
+		p.println(indent, "MethodParameters:")
+		p.println(indent+2, fmt.Sprintf("%-30s %s", "Name", "Flags"))
+		for _, param := range attr.Parameters() {
+			var flags []string
+			for _, fn := range []flagName{{0x0010, "final"}, {0x1000, "synthetic"}, {0x8000, "mandated"}} {
+				if param.AccessFlags()&fn.flag != 0 {
+					flags = append(flags, fn.name)
+				}
+			}
+			name := param.Name()
+			if name == "" {
+				name = "<no name>"
+			}
+			p.println(indent+2, strings.TrimSpace(fmt.Sprintf("%-30s %s", name, strings.Join(flags, " "))))
+		}
+	case *classfile.ModuleAttribute:
+		p.printModule(indent, attr)
+	case *classfile.ModulePackagesAttribute:
+		p.printNames(indent, "ModulePackages:", attr.PackageNames())
+	case *classfile.ModuleMainClassAttribute:
+		p.println(indent, "ModuleMainClass: "+attr.MainClassName())
+	case *classfile.AnnotationsAttribute:
+		p.println(indent, classfile.AttributeName(attr)+":")
+		for i, an := range attr.Annotations() {
+			p.println(indent+2, fmt.Sprintf("%d: %s", i, annotationString(an)))
+		}
+	case *classfile.ParameterAnnotationsAttribute:
+		p.println(indent, classfile.AttributeName(attr)+":")
+		for i, annotations := range attr.ParameterAnnotations() {
+			p.println(indent+2, fmt.Sprintf("parameter %d:", i))
+			for j, an := range annotations {
+				p.println(indent+4, fmt.Sprintf("%d: %s", j, annotationString(an)))
+			}
+		}
+	case *classfile.AnnotationDefaultAttribute:
+		p.println(indent, "AnnotationDefault:")
+		p.println(indent+2, "default_value: "+elementValueString(attr.DefaultValue()))
+	case *classfile.TypeAnnotationsAttribute:
+		p.println(indent, classfile.AttributeName(attr)+":")
+		for i, ta := range attr.Annotations() {
+			p.println(indent+2, fmt.Sprintf("%d: %s", i, annotationString(ta.Annotation)))
+			p.println(indent+4, fmt.Sprintf("target_type: 0x%02x", ta.TargetType()))
+		}
+	case *classfile.UnparsedAttribute:
+		info := attr.Info()
+		p.println(indent, fmt.Sprintf("%s: length = 0x%X", classfile.AttributeName(attr), len(info)))
+		for i := 0; i < len(info); i += 16 {
+			var hex []string
+			for _, b := range info[i:min(i+16, len(info))] {
+				hex = append(hex, fmt.Sprintf("%02X", b))
+			}
+			p.println(indent+2, strings.Join(hex, " "))
+		}
+	default:
+		p.println(indent, classfile.AttributeName(attrInfo)+":")
+	}
+}
+
+func (p *printer) printNames(indent int, title string, names []string) {
+	p.println(indent, title)
+	for _, name := range names {
+		p.println(indent+2, name)
+	}
+}
+
+// constantValue int 5、long 100l、String hello
+func (p *printer) constantValue(index uint16) string {
+	var typeName string
+	switch p.constant(index).(type) {
+	case *classfile.ConstantIntegerInfo:
+		typeName = "int"
+	case *classfile.ConstantFloatInfo:
+		typeName = "float"
+	case *classfile.ConstantLongInfo:
+		typeName = "long"
+	case *classfile.ConstantDoubleInfo:
+		typeName = "double"
+	case *classfile.ConstantStringInfo:
+		typeName = "String"
+	}
+	return typeName + " " + p.constantComment(index, false)
+}
+
+// printInnerClasses public static #7= #6 of #2;    // Inner=class Outer$Inner of class Outer
+func (p *printer) printInnerClasses(indent int, attr *classfile.InnerClassesAttribute) {
+	p.println(indent, "InnerClasses:")
+	for _, ic := range attr.Classes() {
+		flags := ic.InnerClassAccessFlags()
+		mods := modifiers(flags, 0x0001|0x0002|0x0004|0x0008|0x0010|0x0400)
+		if flags&0x0200 != 0 {
+			mods = strings.Replace(mods, "abstract ", "", 1) + "interface " // 接口总是 abstract
+		}
+		var s, comment string
+		if ic.InnerNameIndex() != 0 {
+			s = fmt.Sprintf("#%d= ", ic.InnerNameIndex())
+			comment = p.utf8(ic.InnerNameIndex()) + "="
+		}
+		s += fmt.Sprintf("#%d", ic.InnerClassInfoIndex())
+		comment += "class " + p.className(ic.InnerClassInfoIndex())
+		if ic.OuterClassInfoIndex() != 0 {
+			s += fmt.Sprintf(" of #%d", ic.OuterClassInfoIndex())
+			comment += " of class " + p.className(ic.OuterClassInfoIndex())
+		}
+		p.printComment(indent+2, mods+s+";", comment)
+	}
+}
+
+func (p *printer) printModule(indent int, attr *classfile.ModuleAttribute) {
+	p.println(indent, "Module:")
+	p.println(indent+2, fmt.Sprintf("%s 0x%04x %s", attr.ModuleName(), attr.ModuleFlags(), attr.ModuleVersion()))
+	p.println(indent+2, fmt.Sprintf("%d // requires", len(attr.Requires())))
+	for _, req := range attr.Requires() {
+		p.println(indent+4, fmt.Sprintf("%s 0x%04x %s", req.ModuleName(), req.Flags(), req.Version()))
+	}
+	for _, section := range []struct {
+		name    string
+		exports []*classfile.ModuleExports
+	}{{"exports", attr.Exports()}, {"opens", attr.Opens()}} {
+		p.println(indent+2, fmt.Sprintf("%d // %s", len(section.exports), section.name))
+		for _, export := range section.exports {
+			line := fmt.Sprintf("%s 0x%04x", export.PackageName(), export.Flags())
+			if to := export.ToModules(); len(to) > 0 {
+				line += " to " + strings.Join(to, ", ")
+			}
+			p.println(indent+4, line)
+		}
+	}
+	p.println(indent+2, fmt.Sprintf("%d // uses", len(attr.Uses())))
+	for _, use := range attr.Uses() {
+		p.println(indent+4, use)
+	}
+	p.println(indent+2, fmt.Sprintf("%d // provides", len(attr.Provides())))
+	for _, provide := range attr.Provides() {
+		p.println(indent+4, provide.ServiceName()+" with "+strings.Join(provide.ImplementationNames(), ", "))
+	}
+}
+
+// annotationString java.lang.Deprecated(since="9",forRemoval=true)
+func annotationString(an *classfile.Annotation) string {
+	var sb strings.Builder
+	sb.WriteString(fieldTypeName(an.Type()))
+	if pairs := an.ElementValuePairs(); len(pairs) > 0 {
+		var values []string
+		for _, pair := range pairs {
+			values = append(values, pair.ElementName()+"="+elementValueString(pair.Value()))
+		}
+		sb.WriteString("(" + strings.Join(values, ",") + ")")
+	}
+	return sb.String()
+}
+
+func elementValueString(ev *classfile.ElementValue) string {
+	switch ev.Tag() {
+	case 's':
+		return strconv.Quote(ev.ConstValue().(string))
+	case 'Z':
+		return strconv.FormatBool(ev.ConstValue().(int32) != 0)
+	case 'C':
+		return strconv.QuoteRune(rune(ev.ConstValue().(int32)))
+	case 'J':
+		return fmt.Sprintf("%dl", ev.ConstValue())
+	case 'F':
+		return javaFloat(float64(ev.ConstValue().(float32)), 32) + "f"
+	case 'D':
+		return javaFloat(ev.ConstValue().(float64), 64) + "d"
+	case 'B', 'I', 'S':
+		return fmt.Sprint(ev.ConstValue())
+	case 'e':
+		typeName, constName := ev.EnumValue()
+		return fieldTypeName(typeName) + "." + constName
+	case 'c':
+		return fieldTypeName(ev.ClassInfo()) + ".class"
+	case '@':
+		return "@" + annotationString(ev.AnnotationValue())
+	case '[':
+		var values []string
+		for _, v := range ev.ArrayValue() {
+			values = append(values, elementValueString(v))
+		}
+		return "[" + strings.Join(values, ",") + "]"
+	}
+	return "?"
+}
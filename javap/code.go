@@ -0,0 +1,258 @@
+package javap
+
+import (
+	"encoding/binary"
+	"fmt"
+	"jvm-go/classfile"
+	"jvm-go/instructions"
+	"jvm-go/instructions/base"
+	"strings"
+)
+
+var arrayTypeNames = map[uint8]string{
+	4: "boolean", 5: "char", 6: "float", 7: "double", 8: "byte", 9: "short", 10: "int", 11: "long",
+}
+
+// printInstructions 反汇编字节码。indent 是 Code: 下一级的缩进，
+// 每行是 pc: 助记符 操作数，引用常量池的指令在后面用注释写出常量的值
+func (p *printer) printInstructions(indent int, codeAttr *classfile.CodeAttribute) {
+	code := codeAttr.Code()
+	reader := &base.BytecodeReader{}
+	for pc := 0; pc < len(code); {
+		inst := newInstruction(code[pc])
+		if inst == nil {
+			p.println(indent, fmt.Sprintf("%4d: <illegal opcode 0x%02x>", pc, code[pc]))
+			return
+		}
+		// 操作数的长度由解释器的指令决定，tableswitch 和 lookupswitch 的填充也一样
+		reader.Reset(code, pc+1)
+		inst.FetchOperands(reader)
+		p.printInstruction(indent, code, pc)
+		pc = reader.PC()
+	}
+}
+
+// newInstruction 解释器不支持的操作码返回 nil
+func newInstruction(opcode byte) base.Instruction {
+	if !instructions.IsSupported(opcode) {
+		return nil
+	}
+	return instructions.NewInstruction(opcode)
+}
+
+func (p *printer) printInstruction(indent int, code []byte, pc int) {
+	opcode := code[pc]
+	name := instructions.OpcodeName(opcode)
+	u1 := func(offset int) uint8 { return code[pc+offset] }
+	u2 := func(offset int) uint16 { return binary.BigEndian.Uint16(code[pc+offset:]) }
+	s4 := func(offset int) int32 { return int32(binary.BigEndian.Uint32(code[pc+offset:])) }
+
+	var operands, comment string
+	switch {
+	case opcode == 0x10: // bipush
+		operands = fmt.Sprint(int8(u1(1)))
+	case opcode == 0x11: // sipush
+		operands = fmt.Sprint(int16(u2(1)))
+	case opcode == 0x12: // ldc
+		operands, comment = fmt.Sprintf("#%d", u1(1)), p.ldcComment(uint16(u1(1)))
+	case opcode == 0x13 || opcode == 0x14: // ldc_w, ldc2_w
+		operands, comment = fmt.Sprintf("#%d", u2(1)), p.ldcComment(u2(1))
+	case opcode >= 0x15 && opcode <= 0x19, opcode >= 0x36 && opcode <= 0x3a, opcode == 0xa9: // xload, xstore, ret
+		operands = fmt.Sprint(u1(1))
+	case opcode == 0x84: // iinc
+		operands = fmt.Sprintf("%d, %d", u1(1), int8(u1(2)))
+	case opcode >= 0x99 && opcode <= 0xa8, opcode == 0xc6, opcode == 0xc7: // if<cond>, goto, jsr, ifnull, ifnonnull
+		operands = fmt.Sprint(pc + int(int16(u2(1))))
+	case opcode == 0xc8 || opcode == 0xc9: // goto_w, jsr_w
+		operands = fmt.Sprint(pc + int(s4(1)))
+	case opcode >= 0xb2 && opcode <= 0xb8: // get/put field, invoke
+		operands, comment = fmt.Sprintf("#%d", u2(1)), p.refComment(u2(1))
+	case opcode == 0xb9: // invokeinterface
+		operands, comment = fmt.Sprintf("#%d,  %d", u2(1), u1(3)), p.refComment(u2(1))
+	case opcode == 0xba: // invokedynamic
+		operands, comment = fmt.Sprintf("#%d,  0", u2(1)), p.refComment(u2(1))
+	case opcode == 0xbb || opcode == 0xbd || opcode == 0xc0 || opcode == 0xc1: // new, anewarray, checkcast, instanceof
+		operands, comment = fmt.Sprintf("#%d", u2(1)), p.refComment(u2(1))
+	case opcode == 0xc5: // multianewarray
+		operands, comment = fmt.Sprintf("#%d,  %d", u2(1), u1(3)), p.refComment(u2(1))
+	case opcode == 0xbc: // newarray
+		operands = arrayTypeNames[u1(1)]
+	case opcode == 0xc4: // wide，javap 输出成 iload_w、iinc_w
+		name = instructions.OpcodeName(u1(1)) + "_w"
+		operands = fmt.Sprint(u2(2))
+		if u1(1) == 0x84 {
+			operands += fmt.Sprintf(", %d", int16(u2(4)))
+		}
+	case opcode == 0xaa || opcode == 0xab: // tableswitch, lookupswitch
+		p.printSwitch(indent, code, pc)
+		return
+	}
+
+	line := fmt.Sprintf("%s%4d: %s", strings.Repeat(" ", indent), pc, name)
+	if operands != "" {
+		line = fmt.Sprintf("%s%4d: %-13s %s", strings.Repeat(" ", indent), pc, name, operands)
+	}
+	if comment != "" {
+		line = tab(line, indent+tabColumn) + "// " + comment
+	}
+	p.println(0, line)
+}
+
+// printSwitch 跳转目标写成绝对位置
+//
+//	2: tableswitch   { // 0 to 2
+//	              0: 28
+//	        default: 43
+//	   }
+func (p *printer) printSwitch(indent int, code []byte, pc int) {
+	s4 := func(pos int) int32 { return int32(binary.BigEndian.Uint32(code[pos:])) }
+	pos := (pc + 4) &^ 3 // 操作数 4 字节对齐
+	defaultOffset := s4(pos)
+	prefix := strings.Repeat(" ", indent)
+	if code[pc] == 0xaa { // tableswitch
+		low, high := s4(pos+4), s4(pos+8)
+		p.println(0, fmt.Sprintf("%s%4d: %-13s { // %d to %d", prefix, pc, "tableswitch", low, high))
+		for i := int32(0); i <= high-low; i++ {
+			p.println(0, fmt.Sprintf("%s%18d: %d", prefix, low+i, pc+int(s4(pos+12+int(i)*4))))
+		}
+	} else {
+		npairs := s4(pos + 4)
+		p.println(0, fmt.Sprintf("%s%4d: %-13s { // %d", prefix, pc, "lookupswitch", npairs))
+		for i := 0; i < int(npairs); i++ {
+			p.println(0, fmt.Sprintf("%s%18d: %d", prefix, s4(pos+8+i*8), pc+int(s4(pos+12+i*8))))
+		}
+	}
+	p.println(0, fmt.Sprintf("%s%18s: %d", prefix, "default", pc+int(defaultOffset)))
+	p.println(0, prefix+"      }")
+}
+
+// ldcComment // String Hello、// int 100、// class java/lang/Object
+func (p *printer) ldcComment(index uint16) string {
+	var kind string
+	switch p.constant(index).(type) {
+	case *classfile.ConstantStringInfo:
+		kind = "String"
+	case *classfile.ConstantIntegerInfo:
+		kind = "int"
+	case *classfile.ConstantFloatInfo:
+		kind = "float"
+	case *classfile.ConstantLongInfo:
+		kind = "long"
+	case *classfile.ConstantDoubleInfo:
+		kind = "double"
+	case *classfile.ConstantClassInfo:
+		kind = "class"
+	case *classfile.ConstantMethodTypeInfo:
+		kind = "MethodType"
+	case *classfile.ConstantMethodHandleInfo:
+		kind = "MethodHandle"
+	}
+	return kind + " " + p.constantComment(index, true)
+}
+
+// refComment // Field out:I、// Method java/lang/Object."<init>":()V、// class java/lang/String
+func (p *printer) refComment(index uint16) string {
+	var kind string
+	switch p.constant(index).(type) {
+	case *classfile.ConstantFieldrefInfo:
+		kind = "Field"
+	case *classfile.ConstantMethodrefInfo:
+		kind = "Method"
+	case *classfile.ConstantInterfaceMethodrefInfo:
+		kind = "InterfaceMethod"
+	case *classfile.ConstantInvokeDynamicInfo:
+		kind = "InvokeDynamic"
+	case *classfile.ConstantClassInfo:
+		kind = "class"
+	}
+	return kind + " " + p.constantComment(index, true)
+}
+
+// printExceptionTable 异常处理表为空时不输出
+//
+//	Exception table:
+//	   from    to  target type
+//	       0     5     8   Class java/lang/Exception
+func (p *printer) printExceptionTable(indent int, codeAttr *classfile.CodeAttribute) {
+	exceptionTable := codeAttr.ExceptionTable()
+	if len(exceptionTable) == 0 {
+		return
+	}
+	p.println(indent, "Exception table:")
+	p.println(indent+2, " from    to  target type")
+	for _, entry := range exceptionTable {
+		catchType := "any"
+		if entry.CatchType() != 0 {
+			catchType = "Class " + p.className(entry.CatchType())
+		}
+		p.println(indent+2, fmt.Sprintf(" %5d %5d %5d   %s", entry.StartPc(), entry.EndPc(), entry.HandlerPc(), catchType))
+	}
+}
+
+func (p *printer) printStackMapTable(indent int, attr *classfile.StackMapTableAttribute) {
+	entries := attr.Entries()
+	p.println(indent, fmt.Sprintf("StackMapTable: number_of_entries = %d", len(entries)))
+	for _, frame := range entries {
+		frameType := frame.FrameType()
+		var kind string
+		switch {
+		case frameType < 64:
+			kind = "same"
+		case frameType < 128:
+			kind = "same_locals_1_stack_item"
+		case frameType == 247:
+			kind = "same_locals_1_stack_item_frame_extended"
+		case frameType >= 248 && frameType <= 250:
+			kind = "chop"
+		case frameType == 251:
+			kind = "same_frame_extended"
+		case frameType >= 252 && frameType <= 254:
+			kind = "append"
+		case frameType == 255:
+			kind = "full_frame"
+		default:
+			kind = "reserved"
+		}
+		p.println(indent+2, fmt.Sprintf("frame_type = %d /* %s */", frameType, kind))
+		if frameType >= 247 {
+			p.println(indent+4, fmt.Sprintf("offset_delta = %d", frame.OffsetDelta()))
+		}
+		if frameType >= 252 {
+			p.println(indent+4, "locals = "+p.verificationTypes(frame.Locals()))
+		}
+		if (frameType >= 64 && frameType < 128) || frameType == 247 || frameType == 255 {
+			p.println(indent+4, "stack = "+p.verificationTypes(frame.Stack()))
+		}
+	}
+}
+
+// verificationTypes [ int, class java/lang/String ]
+func (p *printer) verificationTypes(infos []*classfile.VerificationTypeInfo) string {
+	if len(infos) == 0 {
+		return "[]"
+	}
+	names := make([]string, len(infos))
+	for i, vti := range infos {
+		switch vti.Tag() {
+		case 0:
+			names[i] = "top"
+		case 1:
+			names[i] = "int"
+		case 2:
+			names[i] = "float"
+		case 3:
+			names[i] = "double"
+		case 4:
+			names[i] = "long"
+		case 5:
+			names[i] = "null"
+		case 6:
+			names[i] = "this"
+		case 7:
+			names[i] = "class " + quoteName(vti.ClassName())
+		case 8:
+			names[i] = fmt.Sprintf("uninitialized %d", vti.Offset())
+		}
+	}
+	return "[ " + strings.Join(names, ", ") + " ]"
+}
@@ -0,0 +1,138 @@
+// Package javap 实现 jvm-go javap 子命令，输出格式尽量和 JDK 的 javap 一致，方便对比两者的结果
+package javap
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"flag"
+	"fmt"
+	"jvm-go/classfile"
+	"jvm-go/classpath"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// options javap 的选项
+type options struct {
+	code       bool // -c 反汇编字节码
+	verbose    bool // -v 输出常量池、访问标志和所有属性
+	private    bool // -p 显示所有成员，包括 private
+	lines      bool // -l 输出行号表和局部变量表
+	sigs       bool // -s 输出描述符
+	cpOption   string
+	XjreOption string
+}
+
+// Main 执行 javap 子命令，args 是 javap 之后的参数，返回进程的退出码
+//
+//	jvm-go javap [-c] [-v] [-p] [-l] [-s] [-cp path] class|file.class...
+func Main(args []string) int {
+	opts := &options{}
+	flags := flag.NewFlagSet("javap", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s javap [-c] [-v] [-p] [-l] [-s] [-cp path] class|file.class...\n", os.Args[0])
+	}
+	flags.BoolVar(&opts.code, "c", false, "反汇编字节码")
+	flags.BoolVar(&opts.verbose, "v", false, "输出附加信息")
+	flags.BoolVar(&opts.verbose, "verbose", false, "输出附加信息")
+	flags.BoolVar(&opts.private, "p", false, "显示所有类和成员")
+	flags.BoolVar(&opts.private, "private", false, "显示所有类和成员")
+	flags.BoolVar(&opts.lines, "l", false, "输出行号和局部变量表")
+	flags.BoolVar(&opts.sigs, "s", false, "输出内部类型签名")
+	flags.StringVar(&opts.cpOption, "classpath", "", "指定查找类的路径")
+	flags.StringVar(&opts.cpOption, "cp", "", "指定查找类的路径")
+	flags.StringVar(&opts.XjreOption, "Xjre", "", "指定JRE路径")
+	if err := flags.Parse(args); err != nil {
+		return 2
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return 2
+	}
+
+	out := bufio.NewWriter(os.Stdout)
+	defer out.Flush()
+	exitCode := 0
+	var cp *classpath.Classpath
+	for _, arg := range flags.Args() {
+		data, source, err := readClass(arg, opts, &cp)
+		if err == nil {
+			err = disassemble(out, opts, data, source)
+		}
+		if err != nil {
+			out.Flush()
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			exitCode = 1
+		}
+	}
+	return exitCode
+}
+
+// readClass 参数是 .class 文件的路径时直接读取文件，否则在类路径里查找类，
+// 类名可以用 . 或 / 分隔。返回的 source 是 javap -v 输出的 Classfile 那一行
+func readClass(arg string, opts *options, cp **classpath.Classpath) ([]byte, string, error) {
+	if strings.HasSuffix(arg, ".class") {
+		data, err := os.ReadFile(arg)
+		if err == nil {
+			if abs, absErr := filepath.Abs(arg); absErr == nil {
+				arg = abs
+			}
+			return data, arg, nil
+		}
+		if _, statErr := os.Stat(arg); statErr == nil {
+			return nil, "", err
+		}
+	}
+
+	if *cp == nil {
+		*cp = parseClasspath(opts)
+	}
+	className := strings.ReplaceAll(strings.TrimSuffix(arg, ".class"), ".", "/")
+	data, entry, err := (*cp).ReadClass(className)
+	if err != nil {
+		return nil, "", fmt.Errorf("class not found: %s", arg)
+	}
+	if info, err := os.Stat(entry.String()); err == nil && info.IsDir() {
+		return data, filepath.Join(entry.String(), className+".class"), nil
+	}
+	return data, "jar:file:" + entry.String() + "!/" + className + ".class", nil
+}
+
+// parseClasspath 找不到 jre 目录时只在用户类路径里查找
+func parseClasspath(opts *options) (cp *classpath.Classpath) {
+	defer func() {
+		if r := recover(); r != nil {
+			cp = classpath.ParseUserClasspath(opts.cpOption)
+		}
+	}()
+	return classpath.ParseImage(opts.XjreOption, opts.cpOption)
+}
+
+func disassemble(out *bufio.Writer, opts *options, data []byte, source string) (err error) {
+	cf, err := classfile.Parse(data)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if r := recover(); r != nil { // 常量池索引无效之类的错误
+			err = fmt.Errorf("%v", r)
+		}
+	}()
+	p := &printer{out: out, opts: opts, cf: cf, cp: cf.ConstantPool()}
+	if opts.verbose {
+		p.printHeader(data, source)
+	}
+	p.printClass()
+	return nil
+}
+
+func (p *printer) printHeader(data []byte, source string) {
+	p.println(0, "Classfile "+source)
+	if info, err := os.Stat(source); err == nil {
+		p.println(2, fmt.Sprintf("Last modified %s; size %d bytes", info.ModTime().Format("Jan 2, 2006"), len(data)))
+	} else {
+		p.println(2, fmt.Sprintf("Size %d bytes", len(data)))
+	}
+	p.println(2, fmt.Sprintf("SHA-256 checksum %x", sha256.Sum256(data)))
+}
@@ -0,0 +1,171 @@
+package javap
+
+import (
+	"bufio"
+	"bytes"
+	"jvm-go/classfile"
+	"strings"
+	"testing"
+)
+
+// newTestClass 用到 tableswitch、lookupswitch、wide 指令和各种常量
+func newTestClass() []byte {
+	cb := classfile.NewClassBuilder(52, 0x0021, "test/Switch", "java/lang/Object")
+	cb.AddField(0x0019, "L", "J", cb.ConstantValue(cb.Long(1<<40)))
+	m := cb.AddMethod(0x0009, "m", "(I)I")
+	code := cb.NewCode()
+	l1, l2, dflt, end := code.NewLabel(), code.NewLabel(), code.NewLabel(), code.NewLabel()
+	code.Load('I', 0)
+	code.TableSwitch(dflt, 1, l1, l2)
+	code.Mark(l1)
+	code.Iinc(300, 1000)
+	code.Load('I', 300)
+	code.Op(0xac) // ireturn
+	code.Mark(l2)
+	code.Load('I', 0)
+	code.LookupSwitch(dflt, []int32{100, -5}, []*classfile.Label{end, dflt})
+	code.Mark(end)
+	code.Ldc(cb.String("hi"))
+	code.Op(0x57) // pop
+	code.Ldc(cb.Float(1.5))
+	code.Op(0x57) // pop
+	code.Ldc(cb.Long(3))
+	code.Op(0x58) // pop2
+	code.Mark(dflt)
+	code.Field(0xb2, "java/lang/System", "out", "Ljava/io/PrintStream;") // getstatic
+	code.Op(0x57)                                                        // pop
+	code.Op(0x03)                                                        // iconst_0
+	code.Op(0xac)                                                        // ireturn
+	cb.SetCode(m, code)
+	return cb.Bytes()
+}
+
+const codeOutput = `public class test.Switch {
+  public static final long L;
+
+  public static int m(int);
+    Code:
+       0: iload_0
+       1: tableswitch   { // 1 to 2
+                     1: 24
+                     2: 35
+               default: 74
+          }
+      24: iinc_w        300, 1000
+      30: iload_w       300
+      34: ireturn
+      35: iload_0
+      36: lookupswitch  { // 2
+                    -5: 74
+                   100: 64
+               default: 74
+          }
+      64: ldc           #13                 // String hi
+      66: pop
+      67: ldc           #14                 // float 1.5f
+      69: pop
+      70: ldc2_w        #15                 // long 3l
+      73: pop2
+      74: getstatic     #22                 // Field java/lang/System.out:Ljava/io/PrintStream;
+      77: pop
+      78: iconst_0
+      79: ireturn
+}
+`
+
+// verboseOutput 不包括 Classfile、Size 和 SHA-256 这几行文件信息
+const verboseOutput = `public class test.Switch
+  minor version: 0
+  major version: 52
+  flags: (0x0021) ACC_PUBLIC, ACC_SUPER
+  this_class: #2                          // test/Switch
+  super_class: #4                         // java/lang/Object
+  interfaces: 0, fields: 1, methods: 1, attributes: 0
+Constant pool:
+   #1 = Utf8               test/Switch
+   #2 = Class              #1             // test/Switch
+   #3 = Utf8               java/lang/Object
+   #4 = Class              #3             // java/lang/Object
+   #5 = Long               1099511627776l
+   #7 = Utf8               L
+   #8 = Utf8               J
+   #9 = Utf8               ConstantValue
+  #10 = Utf8               m
+  #11 = Utf8               (I)I
+  #12 = Utf8               hi
+  #13 = String             #12            // hi
+  #14 = Float              1.5f
+  #15 = Long               3l
+  #17 = Utf8               java/lang/System
+  #18 = Class              #17            // java/lang/System
+  #19 = Utf8               out
+  #20 = Utf8               Ljava/io/PrintStream;
+  #21 = NameAndType        #19:#20        // out:Ljava/io/PrintStream;
+  #22 = Fieldref           #18.#21        // java/lang/System.out:Ljava/io/PrintStream;
+  #23 = Utf8               Code
+{
+  public static final long L;
+    descriptor: J
+    flags: (0x0019) ACC_PUBLIC, ACC_STATIC, ACC_FINAL
+    ConstantValue: long 1099511627776l
+
+  public static int m(int);
+    descriptor: (I)I
+    flags: (0x0009) ACC_PUBLIC, ACC_STATIC
+    Code:
+      stack=2, locals=301, args_size=1
+         0: iload_0
+         1: tableswitch   { // 1 to 2
+                       1: 24
+                       2: 35
+                 default: 74
+            }
+        24: iinc_w        300, 1000
+        30: iload_w       300
+        34: ireturn
+        35: iload_0
+        36: lookupswitch  { // 2
+                      -5: 74
+                     100: 64
+                 default: 74
+            }
+        64: ldc           #13                 // String hi
+        66: pop
+        67: ldc           #14                 // float 1.5f
+        69: pop
+        70: ldc2_w        #15                 // long 3l
+        73: pop2
+        74: getstatic     #22                 // Field java/lang/System.out:Ljava/io/PrintStream;
+        77: pop
+        78: iconst_0
+        79: ireturn
+}
+`
+
+func TestDisassemble(t *testing.T) {
+	tests := []struct {
+		name string
+		opts *options
+		want string
+	}{
+		{"-c", &options{code: true}, codeOutput},
+		{"-v", &options{verbose: true}, verboseOutput},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			out := bufio.NewWriter(&buf)
+			if err := disassemble(out, test.opts, newTestClass(), "Switch.class"); err != nil {
+				t.Fatalf("disassemble: %v", err)
+			}
+			out.Flush()
+			got := buf.String()
+			if test.opts.verbose {
+				got = got[strings.Index(got, "public class"):]
+			}
+			if got != test.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package javap
+
+import (
+	"bufio"
+	"fmt"
+	"jvm-go/classfile"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const tabColumn = 40 // 注释 // 相对于缩进的列
+
+type printer struct {
+	out  *bufio.Writer
+	opts *options
+	cf   *classfile.ClassFile
+	cp   classfile.ConstantPool
+}
+
+func (p *printer) println(indent int, s string) {
+	p.out.WriteString(strings.Repeat(" ", indent))
+	p.out.WriteString(s)
+	p.out.WriteByte('\n')
+}
+
+// printComment 输出 s，然后在 indent+tabColumn 列输出注释，和 javap 对齐的方式相同
+func (p *printer) printComment(indent int, s, comment string) {
+	p.println(indent, tab(s, tabColumn)+"// "+comment)
+}
+
+// tab 用空格把 s 补齐到 column 列，s 已经超过时补一个空格
+func tab(s string, column int) string {
+	if len(s) < column {
+		return s + strings.Repeat(" ", column-len(s))
+	}
+	return s + " "
+}
+
+func (p *printer) isInterface() bool {
+	return p.cf.AccessFlags()&0x0200 != 0
+}
+
+func (p *printer) printClass() {
+	cf := p.cf
+	if sfAttr := cf.SourceFileAttribute(); sfAttr != nil {
+		indent := 0
+		if p.opts.verbose {
+			indent = 2
+		}
+		p.println(indent, fmt.Sprintf("Compiled from %q", sfAttr.FileName()))
+	}
+
+	decl := p.classDeclaration()
+	if p.opts.verbose {
+		p.println(0, decl)
+		p.println(2, fmt.Sprintf("minor version: %d", cf.MinorVersion()))
+		p.println(2, fmt.Sprintf("major version: %d", cf.MajorVersion()))
+		p.println(2, "flags: "+flagsString(cf.AccessFlags(), classFlags))
+		p.printComment(2, fmt.Sprintf("this_class: #%d", cf.ThisClass()), cf.ClassName())
+		if cf.SuperClass() == 0 {
+			p.println(2, "super_class: #0")
+		} else {
+			p.printComment(2, fmt.Sprintf("super_class: #%d", cf.SuperClass()), cf.SuperClassName())
+		}
+		p.println(2, fmt.Sprintf("interfaces: %d, fields: %d, methods: %d, attributes: %d",
+			len(cf.Interfaces()), len(cf.Fields()), len(cf.Methods()), len(cf.Attributes())))
+		p.printConstantPool()
+		p.println(0, "{")
+	} else {
+		p.println(0, decl+" {")
+	}
+
+	// 输出字节码或者附加信息时成员之间有空行
+	separate := p.opts.verbose || p.opts.code || p.opts.lines
+	first := true
+	printMember := func(member *classfile.MemberInfo, isMethod bool) {
+		if member.AccessFlags()&0x0002 != 0 && !p.opts.private {
+			return
+		}
+		if separate && !first {
+			p.println(0, "")
+		}
+		first = false
+		if isMethod {
+			p.printMethod(member)
+		} else {
+			p.printField(member)
+		}
+	}
+	for _, field := range cf.Fields() {
+		printMember(field, false)
+	}
+	for _, method := range cf.Methods() {
+		printMember(method, true)
+	}
+	p.println(0, "}")
+
+	if p.opts.verbose {
+		for _, attrInfo := range cf.Attributes() {
+			p.printAttribute(0, attrInfo, nil)
+		}
+	}
+}
+
+// classDeclaration public class Foo<T extends java.lang.Object> extends Bar implements java.lang.Runnable
+func (p *printer) classDeclaration() string {
+	cf := p.cf
+	flags := cf.AccessFlags()
+	if flags&0x8000 != 0 { // ACC_MODULE
+		if mAttr := cf.ModuleAttribute(); mAttr != nil {
+			decl := "module " + mAttr.ModuleName()
+			if version := mAttr.ModuleVersion(); version != "" {
+				decl += "@" + version
+			}
+			return decl
+		}
+	}
+
+	var sb strings.Builder
+	if p.isInterface() {
+		sb.WriteString(modifiers(flags, 0x0001))
+		sb.WriteString("interface ")
+	} else {
+		sb.WriteString(modifiers(flags, 0x0001|0x0010|0x0400))
+		sb.WriteString("class ")
+	}
+	sb.WriteString(javaName(cf.ClassName()))
+
+	if sigAttr := findSignature(cf.Attributes()); sigAttr != nil {
+		tp := &typeParser{s: sigAttr.Signature()}
+		sb.WriteString(tp.typeParameters())
+		superClass := tp.fieldType()
+		var interfaces []string
+		for tp.peek() == 'L' {
+			interfaces = append(interfaces, tp.fieldType())
+		}
+		if p.isInterface() {
+			writeTypeList(&sb, " extends ", interfaces)
+		} else {
+			sb.WriteString(" extends " + superClass)
+			writeTypeList(&sb, " implements ", interfaces)
+		}
+		return sb.String()
+	}
+
+	var interfaces []string
+	for _, name := range cf.InterfaceNames() {
+		interfaces = append(interfaces, javaName(name))
+	}
+	if p.isInterface() {
+		writeTypeList(&sb, " extends ", interfaces)
+	} else {
+		if superName := cf.SuperClassName(); superName != "" && superName != "java/lang/Object" {
+			sb.WriteString(" extends " + javaName(superName))
+		}
+		writeTypeList(&sb, " implements ", interfaces)
+	}
+	return sb.String()
+}
+
+func writeTypeList(sb *strings.Builder, keyword string, types []string) {
+	if len(types) > 0 {
+		sb.WriteString(keyword + strings.Join(types, ", "))
+	}
+}
+
+func findSignature(attributes []classfile.AttributeInfo) *classfile.SignatureAttribute {
+	for _, attrInfo := range attributes {
+		if attr, ok := attrInfo.(*classfile.SignatureAttribute); ok {
+			return attr
+		}
+	}
+	return nil
+}
+
+func (p *printer) printField(field *classfile.MemberInfo) {
+	fieldType := fieldTypeName(field.Descriptor())
+	if sigAttr := findSignature(field.Attributes()); sigAttr != nil {
+		fieldType = fieldTypeName(sigAttr.Signature())
+	}
+	p.println(2, modifiers(field.AccessFlags(), 0xffff&^0x0020)+fieldType+" "+field.Name()+";")
+	if p.opts.sigs || p.opts.verbose {
+		p.println(4, "descriptor: "+field.Descriptor())
+	}
+	if p.opts.verbose {
+		p.println(4, "flags: "+flagsString(field.AccessFlags(), fieldFlags))
+		for _, attrInfo := range field.Attributes() {
+			p.printAttribute(4, attrInfo, nil)
+		}
+	}
+}
+
+func (p *printer) printMethod(method *classfile.MemberInfo) {
+	p.println(2, p.methodDeclaration(method)+";")
+	if p.opts.sigs || p.opts.verbose {
+		p.println(4, "descriptor: "+method.Descriptor())
+	}
+	if p.opts.verbose {
+		p.println(4, "flags: "+flagsString(method.AccessFlags(), methodFlags))
+		for _, attrInfo := range method.Attributes() {
+			p.printAttribute(4, attrInfo, method)
+		}
+		return
+	}
+	if codeAttr := method.CodeAttribute(); codeAttr != nil {
+		if p.opts.code {
+			p.println(4, "Code:")
+			p.printInstructions(4, codeAttr)
+			p.printExceptionTable(4, codeAttr)
+		}
+		if p.opts.lines {
+			for _, attrInfo := range codeAttr.Attributes() {
+				switch attrInfo.(type) {
+				case *classfile.LineNumberTableAttribute, *classfile.LocalVariableTableAttribute:
+					p.printAttribute(4, attrInfo, method)
+				}
+			}
+		}
+	}
+}
+
+// methodDeclaration public static <T extends java.lang.Object> void foo(java.lang.String...) throws java.io.IOException
+func (p *printer) methodDeclaration(method *classfile.MemberInfo) string {
+	name := method.Name()
+	flags := method.AccessFlags()
+	if name == "<clinit>" {
+		return "static {}"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(modifiers(flags, 0xffff&^0x0040&^0x0080)) // ACC_BRIDGE 和 ACC_VARARGS 不是修饰符
+	if p.isInterface() && flags&(0x0008|0x0400|0x0002) == 0 {
+		sb.WriteString("default ")
+	}
+
+	descriptor := method.Descriptor()
+	if sigAttr := findSignature(method.Attributes()); sigAttr != nil {
+		descriptor = sigAttr.Signature()
+	}
+	typeParams, params, ret, throws := (&typeParser{s: descriptor}).methodType()
+	if typeParams != "" {
+		sb.WriteString(typeParams + " ")
+	}
+	if name == "<init>" {
+		sb.WriteString(javaName(p.cf.ClassName()))
+	} else {
+		sb.WriteString(ret + " " + name)
+	}
+	if flags&0x0080 != 0 && len(params) > 0 && strings.HasSuffix(params[len(params)-1], "[]") { // ACC_VARARGS
+		params[len(params)-1] = strings.TrimSuffix(params[len(params)-1], "[]") + "..."
+	}
+	sb.WriteString("(" + strings.Join(params, ", ") + ")")
+
+	if len(throws) == 0 {
+		if exAttr := method.ExceptionsAttribute(); exAttr != nil {
+			for _, index := range exAttr.ExceptionIndexTable() {
+				throws = append(throws, classTypeName(p.className(index)))
+			}
+		}
+	}
+	writeTypeList(&sb, " throws ", throws)
+	return sb.String()
+}
+
+// 常量池
+
+func (p *printer) constant(index uint16) classfile.ConstantInfo {
+	if int(index) < len(p.cp) {
+		return p.cp[index]
+	}
+	return nil
+}
+
+func (p *printer) utf8(index uint16) string {
+	if utf8Info, ok := p.constant(index).(*classfile.ConstantUtf8Info); ok {
+		return utf8Info.Str()
+	}
+	return fmt.Sprintf("<invalid utf8 #%d>", index)
+}
+
+func (p *printer) className(index uint16) string {
+	if classInfo, ok := p.constant(index).(*classfile.ConstantClassInfo); ok {
+		return classInfo.Name()
+	}
+	return fmt.Sprintf("<invalid class #%d>", index)
+}
+
+func (p *printer) printConstantPool() {
+	p.println(0, "Constant pool:")
+	width := len(strconv.Itoa(len(p.cp))) + 1
+	for i, c := range p.cp {
+		if c == nil {
+			continue // 第 0 项，以及 long 和 double 之后的空位
+		}
+		index := fmt.Sprintf("%*s", width, "#"+strconv.Itoa(i))
+		tag, operands := p.constantOperands(c)
+		line := fmt.Sprintf("%s = %-18s %s", index, tag, operands)
+		if comment := p.constantComment(uint16(i), false); comment != "" && !isLiteral(c) {
+			line = fmt.Sprintf("%s = %-18s %-14s // %s", index, tag, operands, comment)
+		}
+		p.println(2, line)
+	}
+}
+
+// isLiteral 常量池里直接写出值的常量，没有注释
+func isLiteral(c classfile.ConstantInfo) bool {
+	switch c.(type) {
+	case *classfile.ConstantUtf8Info, *classfile.ConstantIntegerInfo, *classfile.ConstantFloatInfo,
+		*classfile.ConstantLongInfo, *classfile.ConstantDoubleInfo:
+		return true
+	}
+	return false
+}
+
+// constantOperands 返回常量的类型名和 javap -v 常量池里的操作数
+func (p *printer) constantOperands(c classfile.ConstantInfo) (string, string) {
+	switch x := c.(type) {
+	case *classfile.ConstantUtf8Info:
+		return "Utf8", escape(x.Str())
+	case *classfile.ConstantIntegerInfo:
+		return "Integer", strconv.Itoa(int(x.Value()))
+	case *classfile.ConstantFloatInfo:
+		return "Float", javaFloat(float64(x.Value()), 32) + "f"
+	case *classfile.ConstantLongInfo:
+		return "Long", strconv.FormatInt(x.Value(), 10) + "l"
+	case *classfile.ConstantDoubleInfo:
+		return "Double", javaFloat(x.Value(), 64) + "d"
+	case *classfile.ConstantClassInfo:
+		return "Class", fmt.Sprintf("#%d", x.NameIndex())
+	case *classfile.ConstantStringInfo:
+		return "String", fmt.Sprintf("#%d", x.StringIndex())
+	case *classfile.ConstantFieldrefInfo:
+		return "Fieldref", fmt.Sprintf("#%d.#%d", x.ClassIndex(), x.NameAndTypeIndex())
+	case *classfile.ConstantMethodrefInfo:
+		return "Methodref", fmt.Sprintf("#%d.#%d", x.ClassIndex(), x.NameAndTypeIndex())
+	case *classfile.ConstantInterfaceMethodrefInfo:
+		return "InterfaceMethodref", fmt.Sprintf("#%d.#%d", x.ClassIndex(), x.NameAndTypeIndex())
+	case *classfile.ConstantNameAndTypeInfo:
+		return "NameAndType", fmt.Sprintf("#%d:#%d", x.NameIndex(), x.DescriptorIndex())
+	case *classfile.ConstantMethodHandleInfo:
+		return "MethodHandle", fmt.Sprintf("%d:#%d", x.ReferenceKind(), x.ReferenceIndex())
+	case *classfile.ConstantMethodTypeInfo:
+		return "MethodType", fmt.Sprintf("#%d", x.DescriptorIndex())
+	case *classfile.ConstantInvokeDynamicInfo:
+		return "InvokeDynamic", fmt.Sprintf("#%d:#%d", x.BootstrapMethodAttrIndex(), x.NameAndTypeIndex())
+	case *classfile.ConstantModuleInfo:
+		return "Module", fmt.Sprintf("#%d", x.NameIndex())
+	case *classfile.ConstantPackageInfo:
+		return "Package", fmt.Sprintf("#%d", x.NameIndex())
+	}
+	return fmt.Sprintf("%T", c), ""
+}
+
+var refKindNames = []string{"", "REF_getField", "REF_getStatic", "REF_putField", "REF_putStatic",
+	"REF_invokeVirtual", "REF_invokeStatic", "REF_invokeSpecial", "REF_newInvokeSpecial", "REF_invokeInterface"}
+
+// constantComment 常量的值。inCode 为 true 时是字节码里的注释，成员属于当前类时省略类名
+func (p *printer) constantComment(index uint16, inCode bool) string {
+	switch x := p.constant(index).(type) {
+	case *classfile.ConstantUtf8Info:
+		return escape(x.Str())
+	case *classfile.ConstantIntegerInfo:
+		return strconv.Itoa(int(x.Value()))
+	case *classfile.ConstantFloatInfo:
+		return javaFloat(float64(x.Value()), 32) + "f"
+	case *classfile.ConstantLongInfo:
+		return strconv.FormatInt(x.Value(), 10) + "l"
+	case *classfile.ConstantDoubleInfo:
+		return javaFloat(x.Value(), 64) + "d"
+	case *classfile.ConstantClassInfo:
+		return quoteName(x.Name())
+	case *classfile.ConstantStringInfo:
+		return escape(x.String())
+	case *classfile.ConstantFieldrefInfo:
+		return p.memberrefComment(&x.ConstantMemberrefInfo, inCode)
+	case *classfile.ConstantMethodrefInfo:
+		return p.memberrefComment(&x.ConstantMemberrefInfo, inCode)
+	case *classfile.ConstantInterfaceMethodrefInfo:
+		return p.memberrefComment(&x.ConstantMemberrefInfo, inCode)
+	case *classfile.ConstantNameAndTypeInfo:
+		return quoteName(p.utf8(x.NameIndex())) + ":" + p.utf8(x.DescriptorIndex())
+	case *classfile.ConstantMethodHandleInfo:
+		kind := fmt.Sprintf("REF_%d", x.ReferenceKind())
+		if int(x.ReferenceKind()) < len(refKindNames) {
+			kind = refKindNames[x.ReferenceKind()]
+		}
+		return kind + " " + p.constantComment(x.ReferenceIndex(), false)
+	case *classfile.ConstantMethodTypeInfo:
+		return x.Descriptor()
+	case *classfile.ConstantInvokeDynamicInfo:
+		name, descriptor := x.NameAndType()
+		return fmt.Sprintf("#%d:%s:%s", x.BootstrapMethodAttrIndex(), quoteName(name), descriptor)
+	case *classfile.ConstantModuleInfo:
+		return x.Name()
+	case *classfile.ConstantPackageInfo:
+		return x.Name()
+	}
+	return fmt.Sprintf("<invalid constant #%d>", index)
+}
+
+func (p *printer) memberrefComment(ref *classfile.ConstantMemberrefInfo, inCode bool) string {
+	name, descriptor := ref.NameAndDescriptor()
+	comment := quoteName(name) + ":" + descriptor
+	if className := ref.ClassName(); !inCode || className != p.cf.ClassName() {
+		comment = quoteName(className) + "." + comment
+	}
+	return comment
+}
+
+// quoteName javap 给 <init> 和数组类名这种不是合法标识符的名字加上引号
+func quoteName(name string) string {
+	if strings.ContainsAny(name, "<>[;") {
+		return `"` + name + `"`
+	}
+	return name
+}
+
+// escape 转义字符串里的控制字符
+func escape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch r {
+		case '\t':
+			sb.WriteString(`\t`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		default:
+			if r < 0x20 || r == 0x7f {
+				sb.WriteString(fmt.Sprintf(`\u%04x`, r))
+			} else {
+				sb.WriteRune(r)
+			}
+		}
+	}
+	return sb.String()
+}
+
+// javaFloat 按照 Float.toString 和 Double.toString 的格式输出浮点数：
+// 1.0、0.001、1.0E7、1.0E-4、NaN、Infinity
+func javaFloat(val float64, bitSize int) string {
+	switch {
+	case math.IsNaN(val):
+		return "NaN"
+	case math.IsInf(val, 1):
+		return "Infinity"
+	case math.IsInf(val, -1):
+		return "-Infinity"
+	case val == 0:
+		if math.Signbit(val) {
+			return "-0.0"
+		}
+		return "0.0"
+	}
+	if abs := math.Abs(val); abs >= 1e-3 && abs < 1e7 {
+		s := strconv.FormatFloat(val, 'f', -1, bitSize)
+		if !strings.Contains(s, ".") {
+			s += ".0"
+		}
+		return s
+	}
+	s := strconv.FormatFloat(val, 'E', -1, bitSize)
+	mantissa, exponent, _ := strings.Cut(s, "E")
+	if !strings.Contains(mantissa, ".") {
+		mantissa += ".0"
+	}
+	exp, _ := strconv.Atoi(exponent)
+	return mantissa + "E" + strconv.Itoa(exp)
+}
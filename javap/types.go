@@ -0,0 +1,230 @@
+package javap
+
+import "fmt"
+import "strings"
+
+var primitiveNames = map[byte]string{
+	'B': "byte", 'C': "char", 'D': "double", 'F': "float",
+	'I': "int", 'J': "long", 'S': "short", 'Z': "boolean", 'V': "void",
+}
+
+// javaName 把内部形式的类名转换成 Java 源代码里的写法，java/lang/Object -> java.lang.Object
+func javaName(internalName string) string {
+	return strings.ReplaceAll(internalName, "/", ".")
+}
+
+// typeParser 把字段描述符、方法描述符和泛型签名转换成 Java 源代码里的写法。
+// 描述符是签名的子集，所以两者用同一个解析器
+type typeParser struct {
+	s string
+	i int
+}
+
+func (tp *typeParser) peek() byte {
+	if tp.i < len(tp.s) {
+		return tp.s[tp.i]
+	}
+	return 0
+}
+
+func (tp *typeParser) next() byte {
+	c := tp.peek()
+	tp.i++
+	return c
+}
+
+// typeParameters <T:Ljava/lang/Object;U::Ljava/lang/Comparable<TU;>;> -> <T extends java.lang.Object, U extends java.lang.Comparable<U>>
+func (tp *typeParser) typeParameters() string {
+	if tp.peek() != '<' {
+		return ""
+	}
+	tp.next()
+	var params []string
+	for tp.peek() != '>' && tp.peek() != 0 {
+		colon := strings.IndexByte(tp.s[tp.i:], ':')
+		if colon < 0 {
+			break
+		}
+		name := tp.s[tp.i : tp.i+colon]
+		tp.i += colon
+		var bounds []string
+		for tp.peek() == ':' {
+			tp.next()
+			if c := tp.peek(); c == ':' || c == '>' {
+				continue // 类的上界为空，只有接口上界
+			}
+			bounds = append(bounds, tp.fieldType())
+		}
+		if len(bounds) > 0 {
+			name += " extends " + strings.Join(bounds, " & ")
+		}
+		params = append(params, name)
+	}
+	tp.next() // >
+	return "<" + strings.Join(params, ", ") + ">"
+}
+
+// fieldType 解析一个类型：基本类型、类、类型变量或者数组
+func (tp *typeParser) fieldType() string {
+	switch c := tp.next(); c {
+	case '[':
+		return tp.fieldType() + "[]"
+	case 'T':
+		end := strings.IndexByte(tp.s[tp.i:], ';')
+		if end < 0 {
+			end = len(tp.s) - tp.i - 1
+		}
+		name := tp.s[tp.i : tp.i+end]
+		tp.i += end + 1
+		return name
+	case 'L':
+		return tp.classType()
+	default:
+		if name, ok := primitiveNames[c]; ok {
+			return name
+		}
+		return string(c)
+	}
+}
+
+// classType 解析 L 之后的类名和类型参数，内部类用 . 分隔，例如 Ljava/util/Map$Entry<TK;TV;>;
+func (tp *typeParser) classType() string {
+	var sb strings.Builder
+	for {
+		c := tp.next()
+		switch c {
+		case ';', 0:
+			return sb.String()
+		case '/', '.':
+			sb.WriteByte('.')
+		case '<':
+			var args []string
+			for tp.peek() != '>' && tp.peek() != 0 {
+				args = append(args, tp.typeArgument())
+			}
+			tp.next() // >
+			sb.WriteString("<" + strings.Join(args, ", ") + ">")
+		default:
+			sb.WriteByte(c)
+		}
+	}
+}
+
+func (tp *typeParser) typeArgument() string {
+	switch tp.peek() {
+	case '*':
+		tp.next()
+		return "?"
+	case '+':
+		tp.next()
+		return "? extends " + tp.fieldType()
+	case '-':
+		tp.next()
+		return "? super " + tp.fieldType()
+	}
+	return tp.fieldType()
+}
+
+// methodType 解析方法描述符或方法签名，返回类型参数、参数类型、返回值类型和 throws 子句里的类型
+func (tp *typeParser) methodType() (typeParams string, params []string, ret string, throws []string) {
+	typeParams = tp.typeParameters()
+	tp.next() // (
+	for tp.peek() != ')' && tp.peek() != 0 {
+		params = append(params, tp.fieldType())
+	}
+	tp.next() // )
+	ret = tp.fieldType()
+	for tp.peek() == '^' {
+		tp.next()
+		throws = append(throws, tp.fieldType())
+	}
+	return
+}
+
+// fieldTypeName I -> int，[Ljava/lang/String; -> java.lang.String[]
+func fieldTypeName(descriptor string) string {
+	return (&typeParser{s: descriptor}).fieldType()
+}
+
+// classTypeName 常量池里的类名，数组类是描述符
+func classTypeName(name string) string {
+	if strings.HasPrefix(name, "[") {
+		return fieldTypeName(name)
+	}
+	return javaName(name)
+}
+
+// argsSize 方法参数占用的局部变量槽位数，不包括 this
+func argsSize(descriptor string) int {
+	_, params, _, _ := (&typeParser{s: descriptor}).methodType()
+	size := 0
+	for _, param := range params {
+		if param == "long" || param == "double" {
+			size += 2
+		} else {
+			size++
+		}
+	}
+	return size
+}
+
+// 访问标志
+
+type flagName struct {
+	flag uint16
+	name string
+}
+
+var classFlags = []flagName{
+	{0x0001, "ACC_PUBLIC"}, {0x0010, "ACC_FINAL"}, {0x0020, "ACC_SUPER"}, {0x0200, "ACC_INTERFACE"},
+	{0x0400, "ACC_ABSTRACT"}, {0x1000, "ACC_SYNTHETIC"}, {0x2000, "ACC_ANNOTATION"}, {0x4000, "ACC_ENUM"},
+	{0x8000, "ACC_MODULE"},
+}
+
+var innerClassFlags = []flagName{
+	{0x0001, "ACC_PUBLIC"}, {0x0002, "ACC_PRIVATE"}, {0x0004, "ACC_PROTECTED"}, {0x0008, "ACC_STATIC"},
+	{0x0010, "ACC_FINAL"}, {0x0200, "ACC_INTERFACE"}, {0x0400, "ACC_ABSTRACT"}, {0x1000, "ACC_SYNTHETIC"},
+	{0x2000, "ACC_ANNOTATION"}, {0x4000, "ACC_ENUM"},
+}
+
+var fieldFlags = []flagName{
+	{0x0001, "ACC_PUBLIC"}, {0x0002, "ACC_PRIVATE"}, {0x0004, "ACC_PROTECTED"}, {0x0008, "ACC_STATIC"},
+	{0x0010, "ACC_FINAL"}, {0x0040, "ACC_VOLATILE"}, {0x0080, "ACC_TRANSIENT"}, {0x1000, "ACC_SYNTHETIC"},
+	{0x4000, "ACC_ENUM"},
+}
+
+var methodFlags = []flagName{
+	{0x0001, "ACC_PUBLIC"}, {0x0002, "ACC_PRIVATE"}, {0x0004, "ACC_PROTECTED"}, {0x0008, "ACC_STATIC"},
+	{0x0010, "ACC_FINAL"}, {0x0020, "ACC_SYNCHRONIZED"}, {0x0040, "ACC_BRIDGE"}, {0x0080, "ACC_VARARGS"},
+	{0x0100, "ACC_NATIVE"}, {0x0400, "ACC_ABSTRACT"}, {0x0800, "ACC_STRICT"}, {0x1000, "ACC_SYNTHETIC"},
+}
+
+// flagsString (0x0021) ACC_PUBLIC, ACC_SUPER
+func flagsString(flags uint16, names []flagName) string {
+	var set []string
+	for _, fn := range names {
+		if flags&fn.flag != 0 {
+			set = append(set, fn.name)
+		}
+	}
+	return strings.TrimSpace(fmt.Sprintf("(0x%04x) %s", flags, strings.Join(set, ", ")))
+}
+
+// 修饰符按照 java.lang.reflect.Modifier.toString 的顺序
+var modifierNames = []flagName{
+	{0x0001, "public"}, {0x0004, "protected"}, {0x0002, "private"}, {0x0400, "abstract"},
+	{0x0008, "static"}, {0x0010, "final"}, {0x0080, "transient"}, {0x0040, "volatile"},
+	{0x0020, "synchronized"}, {0x0100, "native"}, {0x0800, "strictfp"},
+}
+
+// modifiers 返回修饰符，后面带一个空格。mask 去掉对这种成员没有意义的标志，
+// 比如方法的 0x0040 是 ACC_BRIDGE 而不是 volatile
+func modifiers(flags, mask uint16) string {
+	var sb strings.Builder
+	for _, mn := range modifierNames {
+		if flags&mask&mn.flag != 0 {
+			sb.WriteString(mn.name + " ")
+		}
+	}
+	return sb.String()
+}
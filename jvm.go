@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"jvm-go/classpath"
 	"jvm-go/instructions/base"
+	"jvm-go/interpreter"
 	"jvm-go/rtda"
 	"jvm-go/rtda/heap"
 	"strings"
@@ -19,7 +20,23 @@ type JVM struct {
 // newJVM 创建一个新的 JVM 实例。
 // cmd: 命令行参数
 func newJVM(cmd *Cmd) *JVM {
-	cp := classpath.Parse(cmd.XjreOption, cmd.cpOption)          // 解析类路径
+	if cmd.XssOption > 0 {
+		rtda.SetMaxStackSize(uint(cmd.XssOption)) // 设置线程栈大小
+	}
+	if cmd.XmxOption > 0 {
+		heap.SetMaxHeapSize(int64(cmd.XmxOption)) // 设置最大堆大小
+	}
+	switch cmd.XverifyOption { // 设置字节码验证的范围
+	case "none":
+		heap.SetVerifyMode(heap.VerifyNone)
+	case "all":
+		heap.SetVerifyMode(heap.VerifyAll)
+	}
+	cpOption := cmd.cpOption
+	if cmd.jarOption != "" {
+		cpOption = cmd.jarOption // -jar 模式下忽略 -cp，用户类路径是 jar 包和它的 Class-Path
+	}
+	cp := classpath.Parse(cmd.XjreOption, cpOption)              // 解析类路径
 	classLoader := heap.NewClassLoader(cp, cmd.verboseClassFlag) // 创建类加载器
 	return &JVM{
 		cmd:         cmd,
@@ -30,15 +47,16 @@ func newJVM(cmd *Cmd) *JVM {
 
 // start 启动 JVM。
 func (vm *JVM) start() {
-	vm.initVM()   // 初始化虚拟机
-	vm.execMain() // 执行 main 方法
+	vm.initVM()                        // 初始化虚拟机
+	vm.execMain()                      // 执行 main 方法
+	interpreter.WaitNonDaemonThreads() // 等待其他非守护线程结束
 }
 
 // initVM 初始化虚拟机。
 func (vm *JVM) initVM() {
-	vmClass := vm.classLoader.LoadClass("sun/misc/VM") // 加载 sun.misc.VM 类
-	base.InitClass(vm.mainThread, vmClass)             // 初始化 sun.misc.VM 类，包括执行<clinit>方法
-	interpret(vm.mainThread, vm.cmd.verboseInstFlag)   // 解释执行初始化类的方法，例如<clinit>
+	vmClass := vm.classLoader.LoadClass("sun/misc/VM")           // 加载 sun.misc.VM 类
+	base.InitClass(vm.mainThread, vmClass)                       // 初始化 sun.misc.VM 类，包括执行<clinit>方法
+	interpreter.Interpret(vm.mainThread, vm.cmd.verboseInstFlag) // 解释执行初始化类的方法，例如<clinit>
 }
 
 // execMain 执行 main 方法。
@@ -51,11 +69,13 @@ func (vm *JVM) execMain() {
 		return
 	}
 
-	argsArr := vm.createArgsArray()                  // 创建参数数组
-	frame := vm.mainThread.NewFrame(mainMethod)      // 创建栈帧
-	frame.LocalVars().SetRef(0, argsArr)             // 将参数数组存入局部变量表
-	vm.mainThread.PushFrame(frame)                   // 将栈帧压入主线程的栈
-	interpret(vm.mainThread, vm.cmd.verboseInstFlag) // 解释执行 main 方法
+	argsArr := vm.createArgsArray()                              // 创建参数数组
+	frame := vm.mainThread.NewFrame(mainMethod)                  // 创建栈帧
+	frame.LocalVars().SetRef(0, argsArr)                         // 将参数数组存入局部变量表
+	vm.mainThread.PushFrame(frame)                               // 将栈帧压入主线程的栈
+	interpreter.Interpret(vm.mainThread, vm.cmd.verboseInstFlag) // 解释执行 main 方法
+	interpreter.ExitThread(vm.mainThread)                        // 执行 Thread.exit()
+	vm.mainThread.Exit()
 }
 
 // createArgsArray 创建cmd参数数组。
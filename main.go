@@ -1,13 +1,49 @@
 package main
 
+import (
+	"fmt"
+	"jvm-go/classpath"
+	"jvm-go/jasmin"
+	"jvm-go/javap"
+	"os"
+
+	// 注册本地方法
+	_ "jvm-go/native/java/io"
+	_ "jvm-go/native/java/lang"
+	_ "jvm-go/native/java/lang/invoke"
+	_ "jvm-go/native/java/lang/reflect"
+	_ "jvm-go/native/java/security"
+	_ "jvm-go/native/java/util/concurrent/atomic"
+	_ "jvm-go/native/sun/io"
+	_ "jvm-go/native/sun/misc"
+	_ "jvm-go/native/sun/reflect"
+)
+
 func main() {
+	// jvm-go javap [-c] [-v] [-p] class|file.class 反汇编类文件
+	if len(os.Args) > 1 && os.Args[1] == "javap" {
+		os.Exit(javap.Main(os.Args[2:]))
+	}
+	// jvm-go jasmin [-d dir] file.j 汇编 Jasmin 格式的源文件
+	if len(os.Args) > 1 && os.Args[1] == "jasmin" {
+		os.Exit(jasmin.Main(os.Args[2:]))
+	}
 	cmd := parseCmd()
 
 	if cmd.versionFlag {
 		println("version 0.0.1")
-	} else if cmd.helpFlag || cmd.class == "" {
+	} else if cmd.helpFlag || (cmd.class == "" && cmd.jarOption == "") {
 		printUsage()
 	} else {
+		if cmd.jarOption != "" {
+			// 主类由 jar 包 MANIFEST 的 Main-Class 指定
+			mainClass, err := classpath.ReadMainClass(cmd.jarOption)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				os.Exit(1)
+			}
+			cmd.class = mainClass
+		}
 		newJVM(cmd).start()
 	}
 }
@@ -26,6 +26,14 @@ func init() {
 	native.Register(jlClass, "getDeclaredMethods0", "(Z)[Ljava/lang/reflect/Method;", getDeclaredMethods0)
 	native.Register(jlClass, "getComponentType", "()Ljava/lang/Class;", getComponentType)
 	native.Register(jlClass, "isAssignableFrom", "(Ljava/lang/Class;)Z", isAssignableFrom)
+	native.Register(jlClass, "getClassLoader0", "()Ljava/lang/ClassLoader;", getClassLoader0)
+	native.Register(jlClass, "getRawAnnotations", "()[B", getRawAnnotations)
+	native.Register(jlClass, "getRawTypeAnnotations", "()[B", getRawTypeAnnotations)
+	native.Register(jlClass, "getConstantPool", "()Lsun/reflect/ConstantPool;", getConstantPool)
+	native.Register(jlClass, "getNestHost0", "()Ljava/lang/Class;", getNestHost0)
+	native.Register(jlClass, "getNestMembers0", "()[Ljava/lang/Class;", getNestMembers0)
+	native.Register(jlClass, "getPermittedSubclasses0", "()[Ljava/lang/Class;", getPermittedSubclasses0)
+	native.Register(jlClass, "isRecord0", "()Z", isRecord0)
 }
 
 // static native Class<?> getPrimitiveClass(String name);
@@ -92,14 +100,14 @@ func forName0(frame *rtda.Frame) {
 	vars := frame.LocalVars()
 	jName := vars.GetRef(0)
 	initialize := vars.GetBoolean(1)
-	//jLoader := vars.GetRef(2)
+	jLoader := vars.GetRef(2)
 
 	goName := heap.GoString(jName)
 	goName = strings.Replace(goName, ".", "/", -1)
-	goClass := frame.Method().Class().Loader().LoadClass(goName)
+	goClass := heap.LoaderOf(jLoader).LoadClass(goName)
 	jClass := goClass.JClass()
 
-	if initialize && !goClass.InitStarted() {
+	if initialize && goClass.NeedsInit(frame.Thread()) {
 		// undo forName0
 		thread := frame.Thread()
 		frame.SetNextPC(thread.PC())
@@ -189,3 +197,119 @@ func isAssignableFrom(frame *rtda.Frame) {
 	stack := frame.OperandStack()
 	stack.PushBoolean(ok)
 }
+
+// native ClassLoader getClassLoader0();
+// ()Ljava/lang/ClassLoader;
+func getClassLoader0(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	loader := this.Extra().(*heap.Class).Loader()
+
+	if loader.JLoader() == nil && loader.LoaderType() != heap.BootstrapClassLoader {
+		if !initSystemClassLoader(frame) {
+			return
+		}
+	}
+	frame.OperandStack().PushRef(loader.JLoader())
+}
+
+// initSystemClassLoader 内置的扩展类加载器和应用类加载器对应 sun.misc.Launcher 创建的类加载器，
+// 第一次用到时调用 ClassLoader.getSystemClassLoader() 创建它们，再建立对应关系。
+// 需要执行 Java 代码时撤销当前指令并返回 false，Java 代码返回后重新执行
+func initSystemClassLoader(frame *rtda.Frame) bool {
+	thread := frame.Thread()
+	classLoaderClass := heap.LoaderOf(nil).LoadClass(jlClassLoader)
+	sclSet := heap.LookupField(classLoaderClass, "sclSet", "Z")
+	if classLoaderClass.StaticVars().GetInt(sclSet.SlotId()) == 0 {
+		frame.RevertNextPC()
+		shimFrame := rtda.NewShimFrame(thread, rtda.NewOperandStack(1)) // 丢弃返回值
+		thread.PushFrame(shimFrame)
+		getSystemClassLoader := classLoaderClass.GetStaticMethod("getSystemClassLoader", "()Ljava/lang/ClassLoader;")
+		base.InvokeMethod(shimFrame, getSystemClassLoader)
+		if classLoaderClass.NeedsInit(thread) {
+			base.InitClass(thread, classLoaderClass)
+		}
+		return false
+	}
+
+	// 系统类加载器可能是 java.system.class.loader 指定的类加载器，沿着父加载器找到 Launcher 创建的类加载器
+	scl := classLoaderClass.GetRefVar("scl", "Ljava/lang/ClassLoader;")
+	for jLoader := scl; jLoader != nil; jLoader = jLoader.GetRefVar("parent", "Ljava/lang/ClassLoader;") {
+		heap.LoaderOf(jLoader)
+	}
+	return true
+}
+
+// native byte[] getRawAnnotations();
+// ()[B
+func getRawAnnotations(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	class := this.Extra().(*heap.Class)
+	frame.OperandStack().PushRef(toByteArr(class.Loader(), class.AnnotationData()))
+}
+
+// native byte[] getRawTypeAnnotations();
+// ()[B
+func getRawTypeAnnotations(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	class := this.Extra().(*heap.Class)
+	frame.OperandStack().PushRef(toByteArr(class.Loader(), class.TypeAnnotationData()))
+}
+
+// native ConstantPool getConstantPool();
+// ()Lsun/reflect/ConstantPool;
+// sun.reflect.ConstantPool 的 constantPoolOop 字段存放类对象，它的本地方法从类对象找到运行时常量池，
+// 见 native/sun/reflect/ConstantPool.go
+func getConstantPool(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	cpClass := frame.Method().Class().Loader().LoadClass("sun/reflect/ConstantPool")
+	cpObj := cpClass.NewObject()
+	cpObj.SetRefVar("constantPoolOop", "Ljava/lang/Object;", this)
+	frame.OperandStack().PushRef(cpObj)
+}
+
+// private native Class<?> getNestHost0();
+// ()Ljava/lang/Class;
+func getNestHost0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	class := this.Extra().(*heap.Class)
+	frame.OperandStack().PushRef(class.NestHost().JClass())
+}
+
+// private native Class<?>[] getNestMembers0();
+// ()[Ljava/lang/Class;
+// 第一个元素是嵌套宿主，加载失败或者宿主不是这个类的成员会被忽略
+func getNestMembers0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	host := this.Extra().(*heap.Class).NestHost()
+	members := []*heap.Class{host}
+	for _, member := range loadClassesIfPossible(host.Loader(), host.NestMemberNames()) {
+		if member != host && member.NestHost() == host {
+			members = append(members, member)
+		}
+	}
+	frame.OperandStack().PushRef(toClassArr(host.Loader(), members))
+}
+
+// private native Class<?>[] getPermittedSubclasses0();
+// ()[Ljava/lang/Class;
+// 不是密封类时返回 null，加载失败的子类会被忽略
+func getPermittedSubclasses0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	class := this.Extra().(*heap.Class)
+	stack := frame.OperandStack()
+	if !class.IsSealed() {
+		stack.PushRef(nil)
+		return
+	}
+	subclasses := loadClassesIfPossible(class.Loader(), class.PermittedSubclassNames())
+	stack.PushRef(toClassArr(class.Loader(), subclasses))
+}
+
+// private native boolean isRecord0();
+// ()Z
+func isRecord0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	class := this.Extra().(*heap.Class)
+	frame.OperandStack().PushBoolean(class.IsRecord())
+}
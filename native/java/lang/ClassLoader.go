@@ -0,0 +1,248 @@
+package lang
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/native"
+	"jvm-go/native/sun/misc"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"strings"
+)
+
+const jlClassLoader = "java/lang/ClassLoader"
+
+func init() {
+	native.Register(jlClassLoader, "defineClass0", "(Ljava/lang/String;[BIILjava/security/ProtectionDomain;)Ljava/lang/Class;", defineClass0)
+	native.Register(jlClassLoader, "defineClass1", "(Ljava/lang/String;[BIILjava/security/ProtectionDomain;Ljava/lang/String;)Ljava/lang/Class;", defineClass1)
+	native.Register(jlClassLoader, "defineClass2", "(Ljava/lang/String;Ljava/nio/ByteBuffer;IILjava/security/ProtectionDomain;Ljava/lang/String;)Ljava/lang/Class;", defineClass2)
+	native.Register(jlClassLoader, "findLoadedClass0", "(Ljava/lang/String;)Ljava/lang/Class;", findLoadedClass0)
+	native.Register(jlClassLoader, "findBootstrapClass", "(Ljava/lang/String;)Ljava/lang/Class;", findBootstrapClass)
+	native.Register(jlClassLoader, "resolveClass0", "(Ljava/lang/Class;)V", resolveClass0)
+	native.Register(jlClassLoader, "getResource", "(Ljava/lang/String;)Ljava/net/URL;", getResource)
+	native.Register(jlClassLoader, "getResourceAsStream", "(Ljava/lang/String;)Ljava/io/InputStream;", getResourceAsStream)
+	native.Register("java/net/URLClassLoader", "getResourceAsStream", "(Ljava/lang/String;)Ljava/io/InputStream;", getResourceAsStream)
+	native.Register(jlClassLoader, "getSystemResourceAsStream", "(Ljava/lang/String;)Ljava/io/InputStream;", getSystemResourceAsStream)
+	native.Register("java/net/URL", "openStream", "()Ljava/io/InputStream;", openStream)
+}
+
+// private native Class<?> defineClass0(String name, byte[] b, int off, int len,
+//
+//	ProtectionDomain pd);
+//
+// (Ljava/lang/String;[BIILjava/security/ProtectionDomain;)Ljava/lang/Class;
+func defineClass0(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	data := heap.ByteArrayRange(vars.GetRef(2), vars.GetInt(3), vars.GetInt(4))
+	_defineClass(frame, data, nil)
+}
+
+// private native Class<?> defineClass1(String name, byte[] b, int off, int len,
+//
+//	ProtectionDomain pd, String source);
+//
+// (Ljava/lang/String;[BIILjava/security/ProtectionDomain;Ljava/lang/String;)Ljava/lang/Class;
+func defineClass1(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	data := heap.ByteArrayRange(vars.GetRef(2), vars.GetInt(3), vars.GetInt(4))
+	_defineClass(frame, data, vars.GetRef(6))
+}
+
+// private native Class<?> defineClass2(String name, java.nio.ByteBuffer b,
+//
+//	int off, int len, ProtectionDomain pd,
+//	String source);
+//
+// (Ljava/lang/String;Ljava/nio/ByteBuffer;IILjava/security/ProtectionDomain;Ljava/lang/String;)Ljava/lang/Class;
+func defineClass2(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	buf := vars.GetRef(2)
+	off := vars.GetInt(3)
+	length := vars.GetInt(4)
+	if off < 0 || length < 0 {
+		panic("java.lang.ArrayIndexOutOfBoundsException")
+	}
+
+	// 只有直接缓冲区才会调用 defineClass2，内容在 Unsafe 分配的本地内存里
+	addressField := heap.LookupField(buf.Class(), "address", "J")
+	address := buf.Fields().GetLong(addressField.SlotId())
+	mem := misc.MemoryAt(address + int64(off))
+	if int(length) > len(mem) {
+		panic("java.lang.ArrayIndexOutOfBoundsException")
+	}
+	data := make([]byte, length)
+	copy(data, mem)
+	_defineClass(frame, data, vars.GetRef(6))
+}
+
+// _defineClass 用 this 对应的类加载器定义类，name 为 null 时使用 class 文件里的类名
+func _defineClass(frame *rtda.Frame, data []byte, jSource *heap.Object) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	name := ""
+	if jName := vars.GetRef(1); jName != nil {
+		name = strings.Replace(heap.GoString(jName), ".", "/", -1)
+	}
+	source := ""
+	if jSource != nil {
+		source = heap.GoString(jSource)
+	}
+
+	class := heap.LoaderOf(this).DefineClass(name, data, source)
+	frame.OperandStack().PushRef(class.JClass())
+}
+
+// private native final Class<?> findLoadedClass0(String name);
+// (Ljava/lang/String;)Ljava/lang/Class;
+func findLoadedClass0(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	name := strings.Replace(heap.GoString(vars.GetRef(1)), ".", "/", -1)
+
+	stack := frame.OperandStack()
+	if class := heap.LoaderOf(this).FindLoadedClass(name); class != nil {
+		stack.PushRef(class.JClass())
+	} else {
+		stack.PushRef(nil)
+	}
+}
+
+// private native Class<?> findBootstrapClass(String name);
+// (Ljava/lang/String;)Ljava/lang/Class;
+func findBootstrapClass(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	name := strings.Replace(heap.GoString(vars.GetRef(1)), ".", "/", -1)
+
+	stack := frame.OperandStack()
+	if class := heap.LoaderOf(nil).FindClass(name); class != nil {
+		stack.PushRef(class.JClass())
+	} else {
+		stack.PushRef(nil)
+	}
+}
+
+// private native void resolveClass0(Class<?> c);
+// (Ljava/lang/Class;)V
+func resolveClass0(frame *rtda.Frame) {
+	if frame.LocalVars().GetRef(1) == nil {
+		panic("java.lang.NullPointerException")
+	}
+	// 类在加载时已经连接好了
+}
+
+// public URL getResource(String name)
+// (Ljava/lang/String;)Ljava/net/URL;
+func getResource(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	if !isBuiltinLoader(vars.GetThis()) {
+		invokeJavaImpl(frame)
+		return
+	}
+	jName := vars.GetRef(1)
+	if jName == nil {
+		panic("java.lang.NullPointerException")
+	}
+	spec := heap.ResourceURL(heap.GoString(jName))
+	stack := frame.OperandStack()
+	if spec == "" {
+		stack.PushRef(nil)
+		return
+	}
+
+	// new URL(spec)，构造函数返回以后栈顶剩下的 URL 对象就是返回值
+	loader := frame.Method().Class().Loader()
+	urlClass := loader.LoadClass("java/net/URL")
+	if urlClass.NeedsInit(frame.Thread()) {
+		frame.RevertNextPC()
+		base.InitClass(frame.Thread(), urlClass)
+		return
+	}
+	url := urlClass.NewObject()
+	stack.PushRef(url)
+	stack.PushRef(url)
+	stack.PushRef(heap.JString(loader, spec))
+	base.InvokeMethod(frame, urlClass.GetConstructor("(Ljava/lang/String;)V"))
+}
+
+// public InputStream getResourceAsStream(String name)
+// (Ljava/lang/String;)Ljava/io/InputStream;
+// ClassLoader 和 URLClassLoader 的 getResourceAsStream 都由这个函数实现
+func getResourceAsStream(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	if !isBuiltinLoader(vars.GetThis()) {
+		invokeJavaImpl(frame)
+		return
+	}
+	pushResourceAsStream(frame, vars.GetRef(1))
+}
+
+// isBuiltinLoader 判断是不是 sun.misc.Launcher 创建的扩展类加载器或应用类加载器，
+// 它们在虚拟机里对应的类加载器都从类路径加载类，所以资源也从类路径读取
+func isBuiltinLoader(jLoader *heap.Object) bool {
+	return heap.LoaderOf(jLoader).LoaderType() != heap.UserDefinedClassLoader
+}
+
+// invokeJavaImpl 用原来的 Java 代码执行 getResource(name) 或 getResourceAsStream(name)，
+// 用户自定义类加载器查找资源的方式由它自己决定
+func invokeJavaImpl(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	stack := frame.OperandStack()
+	stack.PushRef(vars.GetThis())
+	stack.PushRef(vars.GetRef(1))
+	base.InvokeMethod(frame, frame.Method().JavaImpl())
+}
+
+// public static InputStream getSystemResourceAsStream(String name)
+// (Ljava/lang/String;)Ljava/io/InputStream;
+func getSystemResourceAsStream(frame *rtda.Frame) {
+	pushResourceAsStream(frame, frame.LocalVars().GetRef(0))
+}
+
+// public final InputStream openStream() throws java.io.IOException
+// ()Ljava/io/InputStream;
+// getResource 返回的 file: 和 jar: URL 指向类路径里的资源，虚拟机打不开本地文件和 jar 包，
+// 直接从类路径读取；其他 URL 用原来的 Java 代码打开
+func openStream(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	spec := heap.GoString(this.GetRefVar("protocol", "Ljava/lang/String;")) + ":"
+	if jFile := this.GetRefVar("file", "Ljava/lang/String;"); jFile != nil {
+		spec += heap.GoString(jFile)
+	}
+	if data := heap.ReadResourceURL(spec); data != nil {
+		pushByteArrayInputStream(frame, data)
+		return
+	}
+
+	stack := frame.OperandStack()
+	stack.PushRef(this)
+	base.InvokeMethod(frame, frame.Method().JavaImpl())
+}
+
+// pushResourceAsStream 从类路径读取资源，把读取资源内容的 ByteArrayInputStream 压入操作数栈，找不到资源时压入 null
+func pushResourceAsStream(frame *rtda.Frame, jName *heap.Object) {
+	if jName == nil {
+		panic("java.lang.NullPointerException")
+	}
+	data := heap.ReadResource(heap.GoString(jName))
+	if data == nil {
+		frame.OperandStack().PushRef(nil)
+		return
+	}
+	pushByteArrayInputStream(frame, data)
+}
+
+// pushByteArrayInputStream new ByteArrayInputStream(data)，构造函数返回以后栈顶剩下的对象就是返回值
+func pushByteArrayInputStream(frame *rtda.Frame, data []byte) {
+	loader := frame.Method().Class().Loader()
+	streamClass := loader.LoadClass("java/io/ByteArrayInputStream")
+	if streamClass.NeedsInit(frame.Thread()) {
+		frame.RevertNextPC()
+		base.InitClass(frame.Thread(), streamClass)
+		return
+	}
+	stream := streamClass.NewObject()
+	stack := frame.OperandStack()
+	stack.PushRef(stream)
+	stack.PushRef(stream)
+	stack.PushRef(heap.NewByteArray(loader, castUint8sToInt8s(data)))
+	base.InvokeMethod(frame, streamClass.GetConstructor("([B)V"))
+}
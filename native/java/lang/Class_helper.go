@@ -22,6 +22,29 @@ func toClassArr(loader *heap.ClassLoader, classes []*heap.Class) *heap.Object {
 	return classArr
 }
 
+// loadClassesIfPossible 依次加载类，跳过加载失败的类
+func loadClassesIfPossible(loader *heap.ClassLoader, names []string) []*heap.Class {
+	classes := make([]*heap.Class, 0, len(names))
+	for _, name := range names {
+		if class := loadClassIfPossible(loader, name); class != nil {
+			classes = append(classes, class)
+		}
+	}
+	return classes
+}
+
+func loadClassIfPossible(loader *heap.ClassLoader, name string) (class *heap.Class) {
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*heap.LoadRequest); ok {
+				panic(r) // 用户自定义类加载器加载完之后会重新执行本地方法
+			}
+			class = nil
+		}
+	}()
+	return loader.LoadClass(name)
+}
+
 // []byte => byte[]
 func toByteArr(loader *heap.ClassLoader, goBytes []byte) *heap.Object {
 	if goBytes != nil {
@@ -42,3 +65,10 @@ func getSignatureStr(loader *heap.ClassLoader, signature string) *heap.Object {
 	}
 	return nil
 }
+
+// byte[] => []byte
+func castInt8sToUint8s(jBytes []int8) (goBytes []byte) {
+	ptr := unsafe.Pointer(&jBytes)
+	goBytes = *((*[]byte)(ptr))
+	return
+}
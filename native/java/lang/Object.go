@@ -3,6 +3,7 @@ package lang
 import (
 	"jvm-go/native"
 	"jvm-go/rtda"
+	"time"
 	"unsafe"
 )
 
@@ -12,6 +13,8 @@ func init() {
 	native.Register(jlObject, "getClass", "()Ljava/lang/Class;", getClass)
 	native.Register(jlObject, "hashCode", "()I", hashCode)
 	native.Register(jlObject, "clone", "()Ljava/lang/Object;", clone)
+	native.Register(jlObject, "wait", "(J)V", wait)
+	native.Register(jlObject, "notify", "()V", notify)
 	native.Register(jlObject, "notifyAll", "()V", notifyAll)
 }
 
@@ -44,8 +47,50 @@ func clone(frame *rtda.Frame) {
 	frame.OperandStack().PushRef(this.Clone())
 }
 
+// public final native void wait(long timeout) throws InterruptedException;
+// (J)V
+func wait(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	timeout := vars.GetLong(1)
+	thread := frame.Thread()
+
+	if timeout < 0 {
+		panic("java.lang.IllegalArgumentException: timeout value is negative")
+	}
+	monitor := this.Monitor()
+	if !monitor.HasOwner(thread) {
+		panic("java.lang.IllegalMonitorStateException")
+	}
+	if thread.ClearInterrupted() {
+		panic("java.lang.InterruptedException")
+	}
+
+	interrupted, _ := monitor.Wait(thread, time.Duration(timeout)*time.Millisecond, thread.InterruptCh())
+	if interrupted {
+		thread.ClearInterrupted()
+		panic("java.lang.InterruptedException")
+	}
+	if thread.IsInterrupted() {
+		// 被通知的同时被中断，正常返回并保留中断状态。Wait 可能已经取走了中断通知，重新发一次
+		thread.Interrupt()
+	}
+}
+
+// public final native void notify();
+// ()V
+func notify(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	if !this.Monitor().Notify(frame.Thread()) {
+		panic("java.lang.IllegalMonitorStateException")
+	}
+}
+
 // public final native void notifyAll();
 // ()V
 func notifyAll(frame *rtda.Frame) {
-	// todo
+	this := frame.LocalVars().GetThis()
+	if !this.Monitor().NotifyAll(frame.Thread()) {
+		panic("java.lang.IllegalMonitorStateException")
+	}
 }
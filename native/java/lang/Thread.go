@@ -1,8 +1,13 @@
 package lang
 
 import (
+	"jvm-go/instructions/base"
+	"jvm-go/interpreter"
 	"jvm-go/native"
 	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"runtime"
+	"time"
 )
 
 func init() {
@@ -10,23 +15,63 @@ func init() {
 	native.Register("java/lang/Thread", "setPriority0", "(I)V", setPriority0)
 	native.Register("java/lang/Thread", "isAlive", "()Z", isAlive)
 	native.Register("java/lang/Thread", "start0", "()V", start0)
+	native.Register("java/lang/Thread", "sleep", "(J)V", sleep)
+	native.Register("java/lang/Thread", "yield", "()V", yield)
+	native.Register("java/lang/Thread", "holdsLock", "(Ljava/lang/Object;)Z", holdsLock)
+	native.Register("java/lang/Thread", "interrupt0", "()V", interrupt0)
+	native.Register("java/lang/Thread", "isInterrupted", "(Z)Z", isInterrupted)
 }
 
 // public static native Thread currentThread();
 // ()Ljava/lang/Thread;
 func currentThread(frame *rtda.Frame) {
-	//jThread := frame.Thread().JThread()
-	classLoader := frame.Method().Class().Loader()
-	threadClass := classLoader.LoadClass("java/lang/Thread")
+	thread := frame.Thread()
+	jThread := thread.JThread()
+	if jThread == nil {
+		// 主线程不是由 Thread.start() 启动的，第一次用到时再创建对应的 Thread 对象
+		jThread = newMainJThread(thread, frame.Method().Class().Loader())
+	}
+
+	frame.OperandStack().PushRef(jThread)
+}
+
+// newMainJThread 和 HotSpot 一样用构造函数创建主线程的线程组和 Thread 对象：
+// ThreadGroup() 创建 system 线程组，ThreadGroup(ThreadGroup, String) 创建 main 线程组，
+// Thread(ThreadGroup, String) 创建 Thread 对象。构造函数在 shim 栈帧里执行，
+// 当前栈帧等它们都返回以后再继续。Thread 的构造函数要调用 currentThread()，
+// 从自己身上继承优先级，所以先把对象和线程关联起来并设置好优先级
+func newMainJThread(thread *rtda.Thread, loader *heap.ClassLoader) *heap.Object {
+	threadClass := loader.LoadClass("java/lang/Thread")
 	jThread := threadClass.NewObject()
+	jThread.SetIntVar("priority", "I", 5) // Thread.NORM_PRIORITY
+	thread.SetJThread(jThread)
 
-	threadGroupClass := classLoader.LoadClass("java/lang/ThreadGroup")
-	jGroup := threadGroupClass.NewObject()
+	threadGroupClass := loader.LoadClass("java/lang/ThreadGroup")
+	systemGroup := threadGroupClass.NewObject()
+	mainGroup := threadGroupClass.NewObject()
+	jName := heap.JString(loader, "main")
 
-	jThread.SetRefVar("group", "Ljava/lang/ThreadGroup;", jGroup)
-	jThread.SetIntVar("priority", "I", 1)
+	// 后压入的栈帧先执行
+	invokeConstructor(thread, threadClass, "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", jThread, mainGroup, jName)
+	invokeConstructor(thread, threadGroupClass, "(Ljava/lang/ThreadGroup;Ljava/lang/String;)V", mainGroup, systemGroup, jName)
+	invokeConstructor(thread, threadGroupClass, "()V", systemGroup)
+	for _, class := range []*heap.Class{threadClass, threadGroupClass} {
+		if class.NeedsInit(thread) {
+			base.InitClass(thread, class)
+		}
+	}
+	return jThread
+}
 
-	frame.OperandStack().PushRef(jThread)
+// invokeConstructor 通过 shim 栈帧调用构造函数，args[0] 是要初始化的对象
+func invokeConstructor(thread *rtda.Thread, class *heap.Class, descriptor string, args ...*heap.Object) {
+	ops := rtda.NewOperandStack(uint(len(args)))
+	for _, arg := range args {
+		ops.PushRef(arg)
+	}
+	shimFrame := rtda.NewShimFrame(thread, ops)
+	thread.PushFrame(shimFrame)
+	base.InvokeMethod(shimFrame, class.GetConstructor(descriptor))
 }
 
 // private native void setPriority0(int newPriority);
@@ -41,15 +86,110 @@ func setPriority0(frame *rtda.Frame) {
 // public final native boolean isAlive();
 // ()Z
 func isAlive(frame *rtda.Frame) {
-	//vars := frame.LocalVars()
-	//this := vars.GetThis()
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+
+	alive := false
+	if thread, ok := this.Extra().(*rtda.Thread); ok {
+		alive = thread.IsAlive()
+	}
 
 	stack := frame.OperandStack()
-	stack.PushBoolean(false)
+	stack.PushBoolean(alive)
 }
 
 // private native void start0();
 // ()V
 func start0(frame *rtda.Frame) {
-	// todo
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+
+	newThread := rtda.NewThread()
+	newThread.SetJThread(this)
+
+	// 通过 shim 栈帧调用 run()，run() 返回后 shim 栈帧随之返回，
+	// 然后由 interpreter.StartThread 调用 Thread.exit()；没有被捕获的异常交给 dispatchUncaughtException()
+	runMethod := heap.LookupMethodInClass(this.Class(), "run", "()V")
+	ops := rtda.NewOperandStack(1)
+	ops.PushRef(this)
+	shimFrame := rtda.NewShimFrame(newThread, ops)
+	newThread.PushFrame(shimFrame)
+	base.InvokeMethod(shimFrame, runMethod)
+
+	daemon := this.GetIntVar("daemon", "Z") == 1
+	interpreter.StartThread(newThread, daemon)
+}
+
+// public static native void sleep(long millis) throws InterruptedException;
+// (J)V
+func sleep(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	millis := vars.GetLong(0)
+	thread := frame.Thread()
+	if millis < 0 {
+		panic("java.lang.IllegalArgumentException: timeout value is negative")
+	}
+
+	deadline := time.Now().Add(time.Duration(millis) * time.Millisecond)
+	for {
+		if thread.ClearInterrupted() {
+			panic("java.lang.InterruptedException: sleep interrupted")
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 {
+			return
+		}
+
+		timer := time.NewTimer(remaining)
+		select {
+		case <-timer.C:
+		case <-thread.InterruptCh(): // 由循环开头检查中断状态
+		}
+		timer.Stop()
+	}
+}
+
+// public static native void yield();
+// ()V
+func yield(frame *rtda.Frame) {
+	runtime.Gosched()
+}
+
+// public static native boolean holdsLock(Object obj);
+// (Ljava/lang/Object;)Z
+func holdsLock(frame *rtda.Frame) {
+	obj := frame.LocalVars().GetRef(0)
+	if obj == nil {
+		panic("java.lang.NullPointerException")
+	}
+
+	frame.OperandStack().PushBoolean(obj.Monitor().HasOwner(frame.Thread()))
+}
+
+// private native void interrupt0();
+// ()V
+func interrupt0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	// 线程还没有启动时什么也不做
+	if thread, ok := this.Extra().(*rtda.Thread); ok {
+		thread.Interrupt()
+	}
+}
+
+// private native boolean isInterrupted(boolean ClearInterrupted);
+// (Z)Z
+func isInterrupted(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	clearInterrupted := vars.GetBoolean(1)
+
+	interrupted := false
+	if thread, ok := this.Extra().(*rtda.Thread); ok {
+		if clearInterrupted {
+			interrupted = thread.ClearInterrupted()
+		} else {
+			interrupted = thread.IsInterrupted()
+		}
+	}
+	frame.OperandStack().PushBoolean(interrupted)
 }
@@ -38,9 +38,12 @@ func fillInStackTrace(frame *rtda.Frame) {
 func createStackTraceElements(tObj *heap.Object, thread *rtda.Thread) []*StackTraceElement {
 	skip := distanceToObject(tObj.Class()) + 2
 	frames := thread.GetFrames()[skip:]
-	stes := make([]*StackTraceElement, len(frames))
-	for i, frame := range frames {
-		stes[i] = createStackTraceElement(frame)
+	stes := make([]*StackTraceElement, 0, len(frames))
+	for _, frame := range frames {
+		// 虚拟机抛出异常时使用的 shim 栈帧不属于调用栈
+		if !heap.IsShimMethod(frame.Method()) {
+			stes = append(stes, createStackTraceElement(frame))
+		}
 	}
 	return stes
 }
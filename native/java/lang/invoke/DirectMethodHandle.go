@@ -0,0 +1,88 @@
+package invoke
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const jliDirectMethodHandle = "java/lang/invoke/DirectMethodHandle"
+
+// 这些方法在类加载时被改成了本地方法，见 heap.hackClass()
+func init() {
+	_dmh(make3, "make", "(BLjava/lang/Class;Ljava/lang/invoke/MemberName;)Ljava/lang/invoke/DirectMethodHandle;")
+	_dmh(make2, "make", "(Ljava/lang/Class;Ljava/lang/invoke/MemberName;)Ljava/lang/invoke/DirectMethodHandle;")
+	_dmh(make1, "make", "(Ljava/lang/invoke/MemberName;)Ljava/lang/invoke/DirectMethodHandle;")
+	_dmh(makeFromReflect, "make", "(Ljava/lang/reflect/Method;)Ljava/lang/invoke/DirectMethodHandle;")
+	_dmh(makeFromReflect, "make", "(Ljava/lang/reflect/Field;)Ljava/lang/invoke/DirectMethodHandle;")
+	_dmh(makeAllocator, "makeAllocator", "(Ljava/lang/invoke/MemberName;)Ljava/lang/invoke/DirectMethodHandle;")
+}
+
+func _dmh(method func(frame *rtda.Frame), name, desc string) {
+	native.Register(jliDirectMethodHandle, name, desc, method)
+}
+
+// static DirectMethodHandle make(byte refKind, Class<?> receiver, MemberName member);
+func make3(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	refKind := uint8(vars.GetInt(0))
+	member := vars.GetRef(2)
+	pushMethodHandle(frame, refKind, member)
+}
+
+// static DirectMethodHandle make(Class<?> receiver, MemberName member);
+func make2(frame *rtda.Frame) {
+	member := frame.LocalVars().GetRef(1)
+	pushMethodHandle(frame, getReferenceKind(member), member)
+}
+
+// static DirectMethodHandle make(MemberName member);
+func make1(frame *rtda.Frame) {
+	member := frame.LocalVars().GetRef(0)
+	pushMethodHandle(frame, getReferenceKind(member), member)
+}
+
+// static DirectMethodHandle make(Method method);
+// static DirectMethodHandle make(Field field);
+func makeFromReflect(frame *rtda.Frame) {
+	reflectObj := frame.LocalVars().GetRef(0)
+	if reflectObj == nil {
+		panic("java.lang.NullPointerException")
+	}
+	member := reflectObj.Extra()
+	refKind := defaultReferenceKind(member)
+	loader := frame.Method().Class().Loader()
+	pushMethodHandle(frame, refKind, heap.NewJMemberName(loader, member, refKind))
+}
+
+// private static DirectMethodHandle makeAllocator(MemberName ctor);
+func makeAllocator(frame *rtda.Frame) {
+	member := frame.LocalVars().GetRef(0)
+	pushMethodHandle(frame, heap.REF_newInvokeSpecial, member)
+}
+
+func pushMethodHandle(frame *rtda.Frame, refKind uint8, jMemberName *heap.Object) {
+	if jMemberName == nil {
+		panic("java.lang.NullPointerException")
+	}
+	member := jMemberName.Extra()
+	if member == nil {
+		panic("java.lang.InternalError: unresolved MemberName")
+	}
+
+	mh := heap.NewMethodHandle(refKind, memberClass(member), member)
+	loader := frame.Method().Class().Loader()
+	base.InitJMethodHandle(frame, heap.NewJMethodHandle(loader, mh), jMemberName)
+}
+
+func memberClass(member interface{}) *heap.Class {
+	switch m := member.(type) {
+	case *heap.Method:
+		return m.Class()
+	case *heap.Field:
+		return m.Class()
+	default:
+		panic("java.lang.InternalError: bad member")
+	}
+}
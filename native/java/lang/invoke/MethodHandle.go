@@ -0,0 +1,71 @@
+package invoke
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const jliMethodHandle = "java/lang/invoke/MethodHandle"
+
+func init() {
+	native.Register(jliMethodHandle, "invokeExact", "([Ljava/lang/Object;)Ljava/lang/Object;", invokeReflectively)
+	native.Register(jliMethodHandle, "invoke", "([Ljava/lang/Object;)Ljava/lang/Object;", invokeReflectively)
+	// 下面这些方法在类加载时被改成了本地方法，见 heap.hackClass()
+	native.Register(jliMethodHandle, "<init>", "(Ljava/lang/invoke/MethodType;Ljava/lang/invoke/LambdaForm;)V", mhInit)
+	native.Register(jliMethodHandle, "asTypeUncached", "(Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/MethodHandle;", asTypeUncached)
+	native.Register(jliMethodHandle, "bindTo", "(Ljava/lang/Object;)Ljava/lang/invoke/MethodHandle;", bindTo)
+}
+
+// public final native @PolymorphicSignature Object invokeExact(Object... args) throws Throwable;
+// public final native @PolymorphicSignature Object invoke(Object... args) throws Throwable;
+// ([Ljava/lang/Object;)Ljava/lang/Object;
+// invokevirtual 指令直接处理签名多态方法（见 base.InvokeMethodHandle），只有反射调用才会走到这里
+func invokeReflectively(frame *rtda.Frame) {
+	panic("java.lang.UnsupportedOperationException: cannot reflectively invoke MethodHandle")
+}
+
+// MethodHandle(MethodType type, LambdaForm form);
+// (Ljava/lang/invoke/MethodType;Ljava/lang/invoke/LambdaForm;)V
+// 不支持 LambdaForm，只能创建虚拟机直接实现的方法句柄（extra 是 heap.MethodHandle），
+// BoundMethodHandle 等其他方法句柄在创建时就抛出异常，而不是等到调用时
+func mhInit(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	jType := vars.GetRef(1)
+	if jType == nil {
+		panic("java.lang.NullPointerException")
+	}
+	if _, ok := this.Extra().(*heap.MethodHandle); !ok {
+		panic("java.lang.UnsupportedOperationException: " + this.Class().JavaName() +
+			" is not supported, only direct method handles are")
+	}
+	this.SetRefVar("type", "Ljava/lang/invoke/MethodType;", jType)
+	this.SetRefVar("form", "Ljava/lang/invoke/LambdaForm;", vars.GetRef(2))
+}
+
+// /*non-public*/ MethodHandle asTypeUncached(MethodType newType);
+// (Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/MethodHandle;
+// 新的方法句柄和原来的指向同一个成员，调用时由适配方法转换参数和返回值
+func asTypeUncached(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	this := vars.GetThis()
+	newType := vars.GetRef(1)
+	if newType == nil {
+		panic("java.lang.NullPointerException")
+	}
+
+	mh := this.Extra().(*heap.MethodHandle).AsType(heap.MethodTypeDescriptor(newType))
+	jMethodHandle := heap.NewJMethodHandle(this.Class().Loader(), mh)
+	this.SetRefVar("asTypeCache", "Ljava/lang/invoke/MethodHandle;", jMethodHandle)
+	jMemberName := this.GetRefVar("member", "Ljava/lang/invoke/MemberName;")
+	base.InitJMethodHandle(frame, jMethodHandle, jMemberName)
+}
+
+// public MethodHandle bindTo(Object x);
+// (Ljava/lang/Object;)Ljava/lang/invoke/MethodHandle;
+// 绑定参数需要 BoundMethodHandle 和 LambdaForm，不支持
+func bindTo(frame *rtda.Frame) {
+	panic("java.lang.UnsupportedOperationException: MethodHandle.bindTo is not supported, only direct method handles are")
+}
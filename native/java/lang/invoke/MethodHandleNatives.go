@@ -0,0 +1,292 @@
+package invoke
+
+import (
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const jliMethodHandleNatives = "java/lang/invoke/MethodHandleNatives"
+
+func init() {
+	_mhn(mhnInit, "init", "(Ljava/lang/invoke/MemberName;Ljava/lang/Object;)V")
+	_mhn(expand, "expand", "(Ljava/lang/invoke/MemberName;)V")
+	_mhn(resolve, "resolve", "(Ljava/lang/invoke/MemberName;Ljava/lang/Class;)Ljava/lang/invoke/MemberName;")
+	_mhn(getMembers, "getMembers", "(Ljava/lang/Class;Ljava/lang/String;Ljava/lang/String;ILjava/lang/Class;I[Ljava/lang/invoke/MemberName;)I")
+	_mhn(fieldOffset, "objectFieldOffset", "(Ljava/lang/invoke/MemberName;)J")
+	_mhn(fieldOffset, "staticFieldOffset", "(Ljava/lang/invoke/MemberName;)J")
+	_mhn(staticFieldBase, "staticFieldBase", "(Ljava/lang/invoke/MemberName;)Ljava/lang/Object;")
+	_mhn(getMemberVMInfo, "getMemberVMInfo", "(Ljava/lang/invoke/MemberName;)Ljava/lang/Object;")
+	_mhn(getConstant, "getConstant", "(I)I")
+	_mhn(getNamedCon, "getNamedCon", "(I[Ljava/lang/Object;)I")
+	_mhn(setCallSiteTarget, "setCallSiteTargetNormal", "(Ljava/lang/invoke/CallSite;Ljava/lang/invoke/MethodHandle;)V")
+	_mhn(setCallSiteTarget, "setCallSiteTargetVolatile", "(Ljava/lang/invoke/CallSite;Ljava/lang/invoke/MethodHandle;)V")
+}
+
+func _mhn(method func(frame *rtda.Frame), name, desc string) {
+	native.Register(jliMethodHandleNatives, name, desc, method)
+}
+
+// static native void init(MemberName self, Object ref);
+// (Ljava/lang/invoke/MemberName;Ljava/lang/Object;)V
+// ref 是 java.lang.reflect.Method、Constructor 或 Field 对象
+func mhnInit(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	self := vars.GetRef(0)
+	ref := vars.GetRef(1)
+	if self == nil || ref == nil {
+		panic("java.lang.NullPointerException")
+	}
+
+	member := ref.Extra()
+	heap.SetMemberName(self, member, defaultReferenceKind(member))
+}
+
+// static native void expand(MemberName self);
+// (Ljava/lang/invoke/MemberName;)V
+func expand(frame *rtda.Frame) {
+	self := frame.LocalVars().GetRef(0)
+	if self == nil {
+		panic("java.lang.NullPointerException")
+	}
+	// init 和 resolve 已经填好了所有字段
+}
+
+// static native MemberName resolve(MemberName self, Class<?> caller) throws LinkageError, ClassNotFoundException;
+// (Ljava/lang/invoke/MemberName;Ljava/lang/Class;)Ljava/lang/invoke/MemberName;
+func resolve(frame *rtda.Frame) {
+	self := frame.LocalVars().GetRef(0)
+	if self == nil {
+		panic("java.lang.NullPointerException")
+	}
+	if self.Extra() != nil {
+		frame.OperandStack().PushRef(self) // 已经解析过
+		return
+	}
+
+	jClass := self.GetRefVar("clazz", "Ljava/lang/Class;")
+	jName := self.GetRefVar("name", "Ljava/lang/String;")
+	if jClass == nil || jName == nil {
+		panic("java.lang.IllegalArgumentException: unresolvable MemberName")
+	}
+	class := jClass.Extra().(*heap.Class)
+	name := heap.GoString(jName)
+	descriptor := memberDescriptor(self.GetRefVar("type", "Ljava/lang/Object;"))
+	flags := self.GetIntVar("flags", "I")
+	refKind := uint8(flags >> heap.MN_REFERENCE_KIND_SHIFT & heap.MN_REFERENCE_KIND_MASK)
+
+	var member interface{}
+	if flags&heap.MN_IS_FIELD != 0 {
+		field := heap.LookupField(class, name, descriptor)
+		if field == nil {
+			panic("java.lang.NoSuchFieldError: " + name)
+		}
+		member = field
+	} else {
+		method := heap.LookupMethod(class, name, descriptor)
+		if method == nil {
+			panic("java.lang.NoSuchMethodError: " + class.JavaName() + "." + name + descriptor)
+		}
+		member = method
+	}
+	if refKind == 0 {
+		refKind = defaultReferenceKind(member)
+	}
+
+	heap.SetMemberName(self, member, refKind)
+	frame.OperandStack().PushRef(self)
+}
+
+// static native int getMembers(Class<?> defc, String matchName, String matchSig,
+//
+//	int matchFlags, Class<?> caller, int skip, MemberName[] results);
+//
+// (Ljava/lang/Class;Ljava/lang/String;Ljava/lang/String;ILjava/lang/Class;I[Ljava/lang/invoke/MemberName;)I
+// 按名字、描述符和种类过滤 defc 的成员，跳过前 skip 个，剩下的依次填进 results，
+// 返回跳过之后匹配的总数，大于 results 的长度时调用者会用更大的数组重试
+func getMembers(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	jDefc := vars.GetRef(0)
+	jMatchName := vars.GetRef(1)
+	jMatchSig := vars.GetRef(2)
+	matchFlags := vars.GetInt(3)
+	skip := vars.GetInt(5)
+	results := vars.GetRef(6)
+	if jDefc == nil || results == nil {
+		panic("java.lang.NullPointerException")
+	}
+
+	matchName, matchSig := "", ""
+	if jMatchName != nil {
+		matchName = heap.GoString(jMatchName)
+	}
+	if jMatchSig != nil {
+		matchSig = heap.GoString(jMatchSig)
+	}
+
+	var members []interface{}
+	for _, class := range searchClasses(jDefc.Extra().(*heap.Class), matchFlags) {
+		if matchFlags&heap.MN_IS_FIELD != 0 {
+			for _, field := range class.Fields() {
+				if (jMatchName == nil || field.Name() == matchName) &&
+					(jMatchSig == nil || field.Descriptor() == matchSig) {
+					members = append(members, field)
+				}
+			}
+		}
+		if matchFlags&(heap.MN_IS_METHOD|heap.MN_IS_CONSTRUCTOR) != 0 {
+			for _, method := range class.Methods() {
+				if method.Name() == "<clinit>" {
+					continue
+				}
+				kind := int32(heap.MN_IS_METHOD)
+				if method.Name() == "<init>" {
+					kind = heap.MN_IS_CONSTRUCTOR
+				}
+				if matchFlags&kind != 0 &&
+					(jMatchName == nil || method.Name() == matchName) &&
+					(jMatchSig == nil || method.Descriptor() == matchSig) {
+					members = append(members, method)
+				}
+			}
+		}
+	}
+
+	if skip < 0 || int(skip) > len(members) {
+		skip = int32(len(members))
+	}
+	members = members[skip:]
+	buf := results.Refs()
+	for i := 0; i < len(members) && i < len(buf); i++ {
+		if buf[i] == nil {
+			panic("java.lang.NullPointerException")
+		}
+		heap.SetMemberName(buf[i], members[i], defaultReferenceKind(members[i]))
+	}
+	frame.OperandStack().PushInt(int32(len(members)))
+}
+
+// searchClasses 返回 getMembers 要搜索的类：defc 本身，
+// 按 matchFlags 再加上它的超类和（直接、间接）超接口
+func searchClasses(defc *heap.Class, matchFlags int32) []*heap.Class {
+	classes := []*heap.Class{defc}
+	if matchFlags&heap.MN_SEARCH_SUPERCLASSES != 0 {
+		for c := defc.SuperClass(); c != nil; c = c.SuperClass() {
+			classes = append(classes, c)
+		}
+	}
+	if matchFlags&heap.MN_SEARCH_INTERFACES != 0 {
+		seen := map[*heap.Class]bool{}
+		for i := 0; i < len(classes); i++ {
+			for _, iface := range classes[i].Interfaces() {
+				if !seen[iface] {
+					seen[iface] = true
+					classes = append(classes, iface)
+				}
+			}
+		}
+	}
+	return classes
+}
+
+// static native long objectFieldOffset(MemberName self);
+// static native long staticFieldOffset(MemberName self);
+// (Ljava/lang/invoke/MemberName;)J
+// 和 sun.misc.Unsafe 一样，用字段的 slotId 作为偏移量
+func fieldOffset(frame *rtda.Frame) {
+	self := frame.LocalVars().GetRef(0)
+	field, ok := self.Extra().(*heap.Field)
+	if !ok {
+		panic("java.lang.InternalError: not a field")
+	}
+	frame.OperandStack().PushLong(int64(field.SlotId()))
+}
+
+// static native Object staticFieldBase(MemberName self);
+// (Ljava/lang/invoke/MemberName;)Ljava/lang/Object;
+func staticFieldBase(frame *rtda.Frame) {
+	self := frame.LocalVars().GetRef(0)
+	field, ok := self.Extra().(*heap.Field)
+	if !ok {
+		panic("java.lang.InternalError: not a field")
+	}
+	frame.OperandStack().PushRef(field.Class().JClass())
+}
+
+// static native Object getMemberVMInfo(MemberName self);
+// (Ljava/lang/invoke/MemberName;)Ljava/lang/Object;
+func getMemberVMInfo(frame *rtda.Frame) {
+	// 没有 vmindex 和 vmtarget
+	frame.OperandStack().PushRef(nil)
+}
+
+// static native int getConstant(int which);
+// (I)I
+func getConstant(frame *rtda.Frame) {
+	// GC_COUNT_GWT 等开关都是关闭的
+	frame.OperandStack().PushInt(0)
+}
+
+// private static native int getNamedCon(int which, Object[] name);
+// (I[Ljava/lang/Object;)I
+func getNamedCon(frame *rtda.Frame) {
+	frame.OperandStack().PushInt(0)
+}
+
+// static native void setCallSiteTargetNormal(CallSite site, MethodHandle target);
+// static native void setCallSiteTargetVolatile(CallSite site, MethodHandle target);
+// (Ljava/lang/invoke/CallSite;Ljava/lang/invoke/MethodHandle;)V
+func setCallSiteTarget(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	site := vars.GetRef(0)
+	target := vars.GetRef(1)
+	site.SetRefVar("target", "Ljava/lang/invoke/MethodHandle;", target)
+}
+
+// MemberName.type 可能是 String、MethodType 或者 Class（字段类型）
+func memberDescriptor(jType *heap.Object) string {
+	if jType == nil {
+		panic("java.lang.IllegalArgumentException: unresolvable MemberName")
+	}
+	switch jType.Class().Name() {
+	case "java/lang/String":
+		return heap.GoString(jType)
+	case "java/lang/invoke/MethodType":
+		return heap.MethodTypeDescriptor(jType)
+	case "java/lang/Class":
+		return jType.Extra().(*heap.Class).Descriptor()
+	default:
+		panic("java.lang.IllegalArgumentException: bad MemberName type " + jType.Class().Name())
+	}
+}
+
+func getReferenceKind(jMemberName *heap.Object) uint8 {
+	flags := jMemberName.GetIntVar("flags", "I")
+	if refKind := uint8(flags >> heap.MN_REFERENCE_KIND_SHIFT & heap.MN_REFERENCE_KIND_MASK); refKind != 0 {
+		return refKind
+	}
+	return defaultReferenceKind(jMemberName.Extra())
+}
+
+func defaultReferenceKind(member interface{}) uint8 {
+	switch m := member.(type) {
+	case *heap.Method:
+		switch {
+		case m.Name() == "<init>":
+			return heap.REF_newInvokeSpecial
+		case m.IsStatic():
+			return heap.REF_invokeStatic
+		case m.Class().IsInterface():
+			return heap.REF_invokeInterface
+		default:
+			return heap.REF_invokeVirtual
+		}
+	case *heap.Field:
+		if m.IsStatic() {
+			return heap.REF_getStatic
+		}
+		return heap.REF_getField
+	default:
+		panic("java.lang.InternalError: bad member")
+	}
+}
@@ -0,0 +1,67 @@
+package reflect
+
+import (
+	"jvm-go/instructions/base"
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const jlrExecutable = "java/lang/reflect/Executable"
+
+func init() {
+	native.Register(jlrExecutable, "getParameters0", "()[Ljava/lang/reflect/Parameter;", getParameters0)
+}
+
+// private native Parameter[] getParameters0();
+// ()[Ljava/lang/reflect/Parameter;
+// 方法没有 MethodParameters 属性时返回 null，Java 代码会生成 arg0, arg1 ... 这样的参数名
+func getParameters0(frame *rtda.Frame) {
+	this := frame.LocalVars().GetThis()
+	method := executableMethod(this)
+	stack := frame.OperandStack()
+	mpAttr := method.MethodParameters()
+	if mpAttr == nil {
+		stack.PushRef(nil)
+		return
+	}
+
+	loader := method.Class().Loader()
+	parameterClass := loader.LoadClass("java/lang/reflect/Parameter")
+	parameters := mpAttr.Parameters()
+	parameterArr := parameterClass.ArrayClass().NewArray(uint(len(parameters)))
+	stack.PushRef(parameterArr)
+
+	thread := frame.Thread()
+	constructor := parameterClass.GetConstructor("(Ljava/lang/String;ILjava/lang/reflect/Executable;I)V")
+	parameterObjs := parameterArr.Refs()
+	for i, parameter := range parameters {
+		parameterObj := parameterClass.NewObject()
+		parameterObjs[i] = parameterObj
+
+		var name *heap.Object
+		if parameter.Name() != "" {
+			name = heap.JString(loader, parameter.Name())
+		}
+		ops := rtda.NewOperandStack(5)
+		ops.PushRef(parameterObj)                   // this
+		ops.PushRef(name)                           // name
+		ops.PushInt(int32(parameter.AccessFlags())) // modifiers
+		ops.PushRef(this)                           // executable
+		ops.PushInt(int32(i))                       // index
+
+		shimFrame := rtda.NewShimFrame(thread, ops)
+		thread.PushFrame(shimFrame)
+		base.InvokeMethod(shimFrame, constructor)
+	}
+}
+
+// executableMethod 返回 Method 或 Constructor 对象对应的方法，
+// 复制出来的对象没有 extra，要从 root 字段找到原始对象
+func executableMethod(executable *heap.Object) *heap.Method {
+	if extra := executable.Extra(); extra != nil {
+		return extra.(*heap.Method)
+	}
+	rootDescriptor := "L" + executable.Class().Name() + ";"
+	return executable.GetRefVar("root", rootDescriptor).Extra().(*heap.Method)
+}
@@ -0,0 +1,29 @@
+package reflect
+
+import (
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+	"strings"
+)
+
+const jlrProxy = "java/lang/reflect/Proxy"
+
+func init() {
+	native.Register(jlrProxy, "defineClass0", "(Ljava/lang/ClassLoader;Ljava/lang/String;[BII)Ljava/lang/Class;", defineClass0)
+}
+
+// private static native Class<?> defineClass0(ClassLoader loader, String name,
+//
+//	byte[] b, int off, int len);
+//
+// (Ljava/lang/ClassLoader;Ljava/lang/String;[BII)Ljava/lang/Class;
+// 定义 ProxyGenerator 生成的代理类，注解对象也是代理类的实例
+func defineClass0(frame *rtda.Frame) {
+	vars := frame.LocalVars()
+	jLoader := vars.GetRef(0)
+	name := strings.Replace(heap.GoString(vars.GetRef(1)), ".", "/", -1)
+	data := heap.ByteArrayRange(vars.GetRef(2), vars.GetInt(3), vars.GetInt(4))
+	class := heap.LoaderOf(jLoader).DefineClass(name, data, "__JVM_DefineClass__")
+	frame.OperandStack().PushRef(class.JClass())
+}
@@ -47,5 +47,9 @@ func memoryAt(address int64) []byte {
 		}
 	}
 	panic("invalid address!")
-	return nil
+}
+
+// MemoryAt 返回从 address 开始的本地内存，其他包的本地方法用它读取直接缓冲区（DirectByteBuffer）的内容
+func MemoryAt(address int64) []byte {
+	return memoryAt(address)
 }
@@ -0,0 +1,159 @@
+package reflect
+
+import (
+	"jvm-go/native"
+	"jvm-go/rtda"
+	"jvm-go/rtda/heap"
+)
+
+const srConstantPool = "sun/reflect/ConstantPool"
+
+func init() {
+	native.Register(srConstantPool, "getSize0", "(Ljava/lang/Object;)I", getSize0)
+	native.Register(srConstantPool, "getClassAt0", "(Ljava/lang/Object;I)Ljava/lang/Class;", getClassAt0)
+	native.Register(srConstantPool, "getClassAtIfLoaded0", "(Ljava/lang/Object;I)Ljava/lang/Class;", getClassAtIfLoaded0)
+	native.Register(srConstantPool, "getMemberRefInfoAt0", "(Ljava/lang/Object;I)[Ljava/lang/String;", getMemberRefInfoAt0)
+	native.Register(srConstantPool, "getIntAt0", "(Ljava/lang/Object;I)I", getIntAt0)
+	native.Register(srConstantPool, "getLongAt0", "(Ljava/lang/Object;I)J", getLongAt0)
+	native.Register(srConstantPool, "getFloatAt0", "(Ljava/lang/Object;I)F", getFloatAt0)
+	native.Register(srConstantPool, "getDoubleAt0", "(Ljava/lang/Object;I)D", getDoubleAt0)
+	native.Register(srConstantPool, "getStringAt0", "(Ljava/lang/Object;I)Ljava/lang/String;", getStringAt0)
+	native.Register(srConstantPool, "getUTF8At0", "(Ljava/lang/Object;I)Ljava/lang/String;", getUTF8At0)
+}
+
+// private native int getSize0(Object constantPoolOop);
+// (Ljava/lang/Object;)I
+func getSize0(frame *rtda.Frame) {
+	cp := _constantPool(frame)
+	frame.OperandStack().PushInt(int32(cp.Size()))
+}
+
+// private native Class<?> getClassAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)Ljava/lang/Class;
+func getClassAt0(frame *rtda.Frame) {
+	classRef, ok := _constantAt(frame).(*heap.ClassRef)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushRef(classRef.ResolvedClass().JClass())
+}
+
+// private native Class<?> getClassAtIfLoaded0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)Ljava/lang/Class;
+// 类符号引用还没有解析时返回 null
+func getClassAtIfLoaded0(frame *rtda.Frame) {
+	classRef, ok := _constantAt(frame).(*heap.ClassRef)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	if class := classRef.LoadedClass(); class != nil {
+		frame.OperandStack().PushRef(class.JClass())
+	} else {
+		frame.OperandStack().PushRef(nil)
+	}
+}
+
+// private native String[] getMemberRefInfoAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)[Ljava/lang/String;
+// 返回 {类名, 成员名, 描述符}
+func getMemberRefInfoAt0(frame *rtda.Frame) {
+	memberRef, ok := _constantAt(frame).(interface {
+		ClassName() string
+		Name() string
+		Descriptor() string
+	})
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+
+	loader := frame.Method().Class().Loader()
+	infoArr := loader.LoadClass("java/lang/String").ArrayClass().NewArray(3)
+	infos := infoArr.Refs()
+	infos[0] = heap.JString(loader, memberRef.ClassName())
+	infos[1] = heap.JString(loader, memberRef.Name())
+	infos[2] = heap.JString(loader, memberRef.Descriptor())
+	frame.OperandStack().PushRef(infoArr)
+}
+
+// private native int getIntAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)I
+func getIntAt0(frame *rtda.Frame) {
+	val, ok := _constantAt(frame).(int32)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushInt(val)
+}
+
+// private native long getLongAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)J
+func getLongAt0(frame *rtda.Frame) {
+	val, ok := _constantAt(frame).(int64)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushLong(val)
+}
+
+// private native float getFloatAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)F
+func getFloatAt0(frame *rtda.Frame) {
+	val, ok := _constantAt(frame).(float32)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushFloat(val)
+}
+
+// private native double getDoubleAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)D
+func getDoubleAt0(frame *rtda.Frame) {
+	val, ok := _constantAt(frame).(float64)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushDouble(val)
+}
+
+// private native String getStringAt0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)Ljava/lang/String;
+func getStringAt0(frame *rtda.Frame) {
+	val, ok := _constantAt(frame).(string)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushRef(heap.JString(frame.Method().Class().Loader(), val))
+}
+
+// private native String getUTF8At0(Object constantPoolOop, int index);
+// (Ljava/lang/Object;I)Ljava/lang/String;
+// 注解的类型名、元素名和字符串元素值都是 CONSTANT_Utf8
+func getUTF8At0(frame *rtda.Frame) {
+	cp := _constantPool(frame)
+	index := int(frame.LocalVars().GetInt(2))
+	_checkIndex(cp, index)
+	val, ok := cp.GetUtf8(index)
+	if !ok {
+		panic("java.lang.IllegalArgumentException: Wrong type at constant pool index")
+	}
+	frame.OperandStack().PushRef(heap.JString(frame.Method().Class().Loader(), val))
+}
+
+// constantPoolOop 是类对象，见 java.lang.Class.getConstantPool
+func _constantPool(frame *rtda.Frame) *heap.ConstantPool {
+	cpOop := frame.LocalVars().GetRef(1)
+	return cpOop.Extra().(*heap.Class).ConstantPool()
+}
+
+func _constantAt(frame *rtda.Frame) heap.Constant {
+	cp := _constantPool(frame)
+	index := int(frame.LocalVars().GetInt(2))
+	_checkIndex(cp, index)
+	return cp.LookupConstant(index)
+}
+
+func _checkIndex(cp *heap.ConstantPool, index int) {
+	if index <= 0 || index >= cp.Size() {
+		panic("java.lang.IllegalArgumentException: Constant pool index out of bounds")
+	}
+}
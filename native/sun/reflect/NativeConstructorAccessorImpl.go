@@ -21,7 +21,7 @@ func newInstance0(frame *rtda.Frame) {
 
 	goConstructor := getGoConstructor(constructorObj)
 	goClass := goConstructor.Class()
-	if !goClass.InitStarted() {
+	if goClass.NeedsInit(frame.Thread()) {
 		frame.RevertNextPC()
 		base.InitClass(frame.Thread(), goClass)
 		return
@@ -16,6 +16,8 @@ type Frame struct {
 	method *heap.Method
 	// 下一条指令的地址
 	nextPC int
+	// synchronized 方法持有的监视器，栈帧弹出时释放
+	monitor *heap.Monitor
 }
 
 func newFrame(thread *Thread, method *heap.Method) *Frame {
@@ -47,6 +49,10 @@ func (fra *Frame) SetNextPC(nextPC int) {
 	fra.nextPC = nextPC
 }
 
+func (fra *Frame) SetMonitor(monitor *heap.Monitor) {
+	fra.monitor = monitor
+}
+
 func (fra *Frame) RevertNextPC() {
 	fra.nextPC = fra.thread.pc
 }
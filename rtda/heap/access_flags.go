@@ -19,4 +19,5 @@ const (
 	ACC_SYNTHETIC    = 0x1000 // class field method
 	ACC_ANNOTATION   = 0x2000 // class
 	ACC_ENUM         = 0x4000 // class field
+	ACC_MODULE       = 0x8000 // class
 )
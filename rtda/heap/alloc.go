@@ -0,0 +1,98 @@
+package heap
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"time"
+	"unsafe"
+)
+
+// 对象头和字段 slot 占用的字节数，用来估算对象大小
+const (
+	objectHeaderSize = int64(unsafe.Sizeof(Object{}))
+	slotSize         = int64(unsafe.Sizeof(Slot{}))
+)
+
+var (
+	maxHeapSize int64        = 1024 * 1024 * 1024 // 堆的最大字节数，对应 -Xmx 选项
+	heapUsed    atomic.Int64                      // 还没有被回收的 Java 对象和数组占用的字节数
+	gcLock      sync.Mutex
+)
+
+// SetMaxHeapSize 设置堆的最大字节数，必须在启动虚拟机之前调用
+func SetMaxHeapSize(size int64) {
+	maxHeapSize = size
+}
+
+// allocate 在创建对象之前登记要分配的字节数，对象创建以后由 track 在它被回收时减去。
+// 只统计 Java 对象和数组，类、方法、栈帧这些虚拟机自己的数据不算在堆里。
+// 对象由 Go 的垃圾回收器回收，登记的数量超过上限时先执行一次垃圾回收，
+// 等被回收的对象减去自己的大小以后仍然放不下，才抛出 OutOfMemoryError
+func allocate(size int64) int64 {
+	if heapUsed.Add(size) <= maxHeapSize {
+		return size
+	}
+
+	gcLock.Lock()
+	defer gcLock.Unlock()
+	if heapUsed.Load() > maxHeapSize {
+		collect()
+	}
+	if heapUsed.Load() > maxHeapSize {
+		heapUsed.Add(-size)
+		panic("java.lang.OutOfMemoryError: Java heap space")
+	}
+	return size
+}
+
+// track 对象被回收时从 heapUsed 减去 allocate 登记的字节数
+func track(ob *Object, size int64) *Object {
+	runtime.AddCleanup(ob, release, size)
+	return ob
+}
+
+func release(size int64) {
+	heapUsed.Add(-size)
+}
+
+// collect 执行一次垃圾回收，等待被回收对象的 cleanup 执行完。
+// cleanup 在单独的 goroutine 里执行，用一个同时变成垃圾的哨兵对象判断它们已经执行过了
+func collect() {
+	done := make(chan struct{})
+	runtime.AddCleanup(new([32]byte), func(done chan struct{}) { close(done) }, done)
+	runtime.GC()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+	}
+}
+
+func allocateObject(slotCount uint) int64 {
+	return allocate(objectHeaderSize + int64(slotCount)*slotSize)
+}
+
+func allocateArray(count uint, elementSize uintptr) int64 {
+	// 先检查元素个数，避免计算字节数时溢出
+	if int64(count) > maxHeapSize/int64(elementSize) {
+		panic("java.lang.OutOfMemoryError: Java heap space")
+	}
+	return allocate(objectHeaderSize + int64(count)*int64(elementSize))
+}
+
+func allocateClone(ob *Object) int64 {
+	switch data := ob.data.(type) {
+	case []int8:
+		return allocateArray(uint(len(data)), 1)
+	case []int16, []uint16:
+		return allocateArray(uint(ob.ArrayLength()), 2)
+	case []int32, []float32:
+		return allocateArray(uint(ob.ArrayLength()), 4)
+	case []int64, []float64:
+		return allocateArray(uint(ob.ArrayLength()), 8)
+	case []*Object:
+		return allocateArray(uint(len(data)), unsafe.Sizeof(data[0]))
+	default: // Slots
+		return allocateObject(uint(len(data.(Slots))))
+	}
+}
@@ -0,0 +1,34 @@
+package heap
+
+import (
+	"fmt"
+	"runtime"
+	"testing"
+)
+
+// -Xmx 只限制存活的 Java 对象和数组，被回收的对象不算
+func TestMaxHeapSize(t *testing.T) {
+	byteArrayClass := newTestLoader(t).LoadClass("[B")
+	defer SetMaxHeapSize(maxHeapSize)
+	collect() // 之前的测试留下的垃圾
+	SetMaxHeapSize(heapUsed.Load() + 1024*1024)
+
+	for i := 0; i < 100; i++ {
+		byteArrayClass.NewArray(64 * 1024) // 不可达，垃圾回收以后可以继续分配
+	}
+
+	var live []*Object
+	msg := func() (msg string) {
+		defer func() {
+			msg = fmt.Sprint(recover())
+		}()
+		for len(live) < 100 {
+			live = append(live, byteArrayClass.NewArray(64*1024))
+		}
+		return ""
+	}()
+	if msg != "java.lang.OutOfMemoryError: Java heap space" || len(live) > 16 {
+		t.Errorf("allocated %d live arrays, got %q", len(live), msg)
+	}
+	runtime.KeepAlive(live)
+}
@@ -1,5 +1,7 @@
 package heap
 
+import "unsafe"
+
 func (cl *Class) IsArray() bool {
 	return cl.name[0] == '['
 }
@@ -15,26 +17,36 @@ func (cl *Class) NewArray(count uint) *Object {
 	}
 	switch cl.Name() {
 	case "[Z":
-		return &Object{cl, make([]int8, count), nil}
+		size := allocateArray(count, 1)
+		return track(&Object{class: cl, data: make([]int8, count)}, size)
 	case "[B":
-		return &Object{cl, make([]int8, count), nil}
+		size := allocateArray(count, 1)
+		return track(&Object{class: cl, data: make([]int8, count)}, size)
 	case "[C":
-		return &Object{cl, make([]uint16, count), nil}
+		size := allocateArray(count, 2)
+		return track(&Object{class: cl, data: make([]uint16, count)}, size)
 	case "[S":
-		return &Object{cl, make([]int16, count), nil}
+		size := allocateArray(count, 2)
+		return track(&Object{class: cl, data: make([]int16, count)}, size)
 	case "[I":
-		return &Object{cl, make([]int32, count), nil}
+		size := allocateArray(count, 4)
+		return track(&Object{class: cl, data: make([]int32, count)}, size)
 	case "[J":
-		return &Object{cl, make([]int64, count), nil}
+		size := allocateArray(count, 8)
+		return track(&Object{class: cl, data: make([]int64, count)}, size)
 	case "[F":
-		return &Object{cl, make([]float32, count), nil}
+		size := allocateArray(count, 4)
+		return track(&Object{class: cl, data: make([]float32, count)}, size)
 	case "[D":
-		return &Object{cl, make([]float64, count), nil}
+		size := allocateArray(count, 8)
+		return track(&Object{class: cl, data: make([]float64, count)}, size)
 	default:
-		return &Object{cl, make([]*Object, count), nil}
+		size := allocateArray(count, unsafe.Sizeof((*Object)(nil)))
+		return track(&Object{class: cl, data: make([]*Object, count)}, size)
 	}
 }
 
 func NewByteArray(loader *ClassLoader, bytes []int8) *Object {
-	return &Object{loader.LoadClass("[B"), bytes, nil}
+	size := allocateArray(uint(len(bytes)), 1)
+	return track(&Object{class: loader.LoadClass("[B"), data: bytes}, size)
 }
@@ -1,9 +1,24 @@
 package heap
 
+import "unsafe"
+
 func (ob *Object) Bytes() []int8 {
 	return ob.data.([]int8)
 }
 
+// ByteArrayRange 返回 byte[] 里从 off 开始的 length 个字节，和 Java 数组共享内存。
+// jBytes 为 null 时抛出 NullPointerException，越界时抛出 ArrayIndexOutOfBoundsException
+func ByteArrayRange(jBytes *Object, off, length int32) []byte {
+	if jBytes == nil {
+		panic("java.lang.NullPointerException")
+	}
+	if off < 0 || length < 0 || off > jBytes.ArrayLength()-length {
+		panic("java.lang.ArrayIndexOutOfBoundsException")
+	}
+	bytes := jBytes.Bytes()[off : off+length]
+	return *(*[]byte)(unsafe.Pointer(&bytes))
+}
+
 func (ob *Object) Shorts() []int16 {
 	return ob.data.([]int16)
 }
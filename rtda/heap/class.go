@@ -1,38 +1,71 @@
 package heap
 
 import "strings"
+import "sync"
+import "sync/atomic"
 import "jvm-go/classfile"
 
 // name, superClassName and interfaceNames are all binary names(jvms8-4.2.1)
 type Class struct {
-	accessFlags       uint16
-	name              string // thisClassName
-	superClassName    string
-	interfaceNames    []string
-	constantPool      *ConstantPool
-	fields            []*Field
-	methods           []*Method
-	sourceFile        string
+	majorVersion       uint16 // class 文件的主版本号
+	accessFlags        uint16
+	name               string // thisClassName
+	superClassName     string
+	interfaceNames     []string
+	constantPool       *ConstantPool
+	fields             []*Field
+	methods            []*Method
+	sourceFile         string
+	annotationData     []byte                // RuntimeVisibleAnnotations_attribute
+	typeAnnotationData []byte                // RuntimeVisibleTypeAnnotations_attribute
+	nestHostName       string                // NestHost_attribute，为空时类自己是嵌套宿主
+	nestMemberNames    []string              // NestMembers_attribute
+	nestHost           atomic.Pointer[Class] // 第一次用到时确定，见 NestHost()
+	// PermittedSubclasses_attribute，不为 nil 时是密封类
+	permittedSubclassNames []string
+	// Record_attribute，不为 nil 时是记录类
+	recordComponents  []*RecordComponent
 	loader            *ClassLoader
 	superClass        *Class
 	interfaces        []*Class
 	instanceSlotCount uint
 	staticSlotCount   uint
 	staticVars        Slots
-	initStarted       bool
+	initState         atomic.Int32 // 初始化状态，jvms 5.5
+	initThread        interface{}  // 正在初始化这个类的线程
+	initLock          sync.Mutex   // 保护 initState 的变化和 initThread
+	initCond          *sync.Cond   // 初始化完成（或失败）时通知等待的线程
 	jClass            *Object
+	vtable            []*Method     // 虚方法表
+	itable            []itableEntry // 接口方法表
+	concatRecipe      interface{}   // 字符串拼接调用点的配方，见 NewStringConcatClass
 }
 
 func newClass(cf *classfile.ClassFile) *Class {
 	class := &Class{}
+	class.majorVersion = cf.MajorVersion()
 	class.accessFlags = cf.AccessFlags()
 	class.name = cf.ClassName()
 	class.superClassName = cf.SuperClassName()
 	class.interfaceNames = cf.InterfaceNames()
-	class.constantPool = newConstantPool(class, cf.ConstantPool())
+	class.constantPool = newConstantPool(class, cf.ConstantPool(), cf.BootstrapMethodsAttribute())
 	class.fields = newFields(class, cf.Fields())
 	class.methods = newMethods(class, cf.Methods())
 	class.sourceFile = getSourceFile(cf)
+	class.annotationData = cf.RuntimeVisibleAnnotationsAttributeData()
+	class.typeAnnotationData = cf.RuntimeVisibleTypeAnnotationsAttributeData()
+	if nhAttr := cf.NestHostAttribute(); nhAttr != nil {
+		class.nestHostName = nhAttr.HostClassName()
+	}
+	if nmAttr := cf.NestMembersAttribute(); nmAttr != nil {
+		class.nestMemberNames = nmAttr.ClassNames()
+	}
+	if psAttr := cf.PermittedSubclassesAttribute(); psAttr != nil {
+		class.permittedSubclassNames = psAttr.ClassNames()
+	}
+	if recordAttr := cf.RecordAttribute(); recordAttr != nil {
+		class.recordComponents = newRecordComponents(class, recordAttr.Components())
+	}
 	return class
 }
 
@@ -67,6 +100,19 @@ func (cl *Class) IsAnnotation() bool {
 func (cl *Class) IsEnum() bool {
 	return cl.accessFlags&ACC_ENUM != 0
 }
+func (cl *Class) IsModule() bool {
+	return cl.accessFlags&ACC_MODULE != 0
+}
+
+// IsSealed 判断是不是密封类（接口），jvms 4.7.31
+func (cl *Class) IsSealed() bool {
+	return cl.permittedSubclassNames != nil
+}
+
+// IsRecord 和 Class.isRecord() 一样，要求直接父类是 java.lang.Record 并且有 Record 属性
+func (cl *Class) IsRecord() bool {
+	return cl.recordComponents != nil && cl.superClassName == "java/lang/Record"
+}
 
 // getters
 func (cl *Class) AccessFlags() uint16 {
@@ -75,6 +121,11 @@ func (cl *Class) AccessFlags() uint16 {
 func (cl *Class) Name() string {
 	return cl.name
 }
+
+// Descriptor 返回类型描述符，例如 Ljava/lang/String; 和 I
+func (cl *Class) Descriptor() string {
+	return toDescriptor(cl.name)
+}
 func (cl *Class) ConstantPool() *ConstantPool {
 	return cl.constantPool
 }
@@ -87,6 +138,24 @@ func (cl *Class) Methods() []*Method {
 func (cl *Class) SourceFile() string {
 	return cl.sourceFile
 }
+func (cl *Class) AnnotationData() []byte {
+	return cl.annotationData
+}
+func (cl *Class) TypeAnnotationData() []byte {
+	return cl.typeAnnotationData
+}
+func (cl *Class) NestMemberNames() []string {
+	return cl.nestMemberNames
+}
+func (cl *Class) PermittedSubclassNames() []string {
+	return cl.permittedSubclassNames
+}
+func (cl *Class) RecordComponents() []*RecordComponent {
+	return cl.recordComponents
+}
+func (cl *Class) StringConcatRecipe() interface{} {
+	return cl.concatRecipe
+}
 func (cl *Class) Loader() *ClassLoader {
 	return cl.loader
 }
@@ -99,21 +168,20 @@ func (cl *Class) Interfaces() []*Class {
 func (cl *Class) StaticVars() Slots {
 	return cl.staticVars
 }
-func (cl *Class) InitStarted() bool {
-	return cl.initStarted
-}
 func (cl *Class) JClass() *Object {
 	return cl.jClass
 }
 
-func (cl *Class) StartInit() {
-	cl.initStarted = true
-}
-
 // jvms 5.4.4
 func (cl *Class) isAccessibleTo(other *Class) bool {
 	return cl.IsPublic() ||
-		cl.GetPackageName() == other.GetPackageName()
+		cl.IsSameRuntimePackage(other)
+}
+
+// IsSameRuntimePackage 判断两个类是否属于同一个运行时包，
+// 运行时包由包名和定义类加载器共同决定，不同类加载器加载的同名包互相不能访问包私有成员，jvms 5.3
+func (cl *Class) IsSameRuntimePackage(other *Class) bool {
+	return cl.loader == other.loader && cl.GetPackageName() == other.GetPackageName()
 }
 
 func (cl *Class) GetPackageName() string {
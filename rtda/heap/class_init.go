@@ -0,0 +1,81 @@
+package heap
+
+import "sync"
+
+// 类的初始化状态，jvms 5.5
+const (
+	classLinked           = iota // 已经连接，还没有初始化
+	classBeingInitialized        // 正在由 initThread 初始化
+	classInitialized             // 初始化完成
+	classErroneous               // 初始化失败
+)
+
+// NeedsInit 判断 thread 使用类之前是否需要调用 InitClass()。
+// 类已经初始化完成，或者正在由 thread 自己初始化（递归请求）时不需要
+func (cl *Class) NeedsInit(thread interface{}) bool {
+	switch cl.initState.Load() {
+	case classInitialized:
+		return false
+	case classBeingInitialized:
+		cl.initLock.Lock()
+		defer cl.initLock.Unlock()
+		return cl.initThread != thread
+	default:
+		return true
+	}
+}
+
+// BeginInit 执行 jvms 5.5 初始化过程的第 1~6 步。
+// 其他线程正在初始化这个类时一直等到它完成；
+// 返回 true 表示 thread 要负责初始化这个类，返回 false 表示类已经初始化完成或者正在由 thread 自己初始化；
+// 类之前初始化失败时抛出 NoClassDefFoundError
+func (cl *Class) BeginInit(thread interface{}) bool {
+	cl.initLock.Lock()
+	defer cl.initLock.Unlock()
+
+	for cl.initState.Load() == classBeingInitialized && cl.initThread != thread {
+		cl.getInitCond().Wait()
+	}
+
+	switch cl.initState.Load() {
+	case classBeingInitialized, classInitialized:
+		return false
+	case classErroneous:
+		panic("java.lang.NoClassDefFoundError: Could not initialize class " + cl.JavaName())
+	}
+	cl.initThread = thread
+	cl.initState.Store(classBeingInitialized)
+	return true
+}
+
+// FinishInit 第 10 步：<clinit> 正常返回，标记为初始化完成并通知等待的线程
+func (cl *Class) FinishInit() {
+	cl.endInit(classInitialized)
+}
+
+// FailInit 第 11、12 步：父类或者 <clinit> 抛出了异常，标记为错误状态并通知等待的线程
+func (cl *Class) FailInit() {
+	cl.endInit(classErroneous)
+}
+
+func (cl *Class) endInit(state int32) {
+	cl.initLock.Lock()
+	defer cl.initLock.Unlock()
+
+	cl.initThread = nil
+	cl.initState.Store(state)
+	cl.getInitCond().Broadcast()
+}
+
+// 调用者需要持有 initLock
+func (cl *Class) getInitCond() *sync.Cond {
+	if cl.initCond == nil {
+		cl.initCond = sync.NewCond(&cl.initLock)
+	}
+	return cl.initCond
+}
+
+// markInitialized 数组类、基本类型和运行时生成的类没有 <clinit>，创建后直接是初始化完成的状态
+func (cl *Class) markInitialized() {
+	cl.initState.Store(classInitialized)
+}
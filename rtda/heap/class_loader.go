@@ -5,6 +5,7 @@ import (
 	"jvm-go/classfile"
 	"jvm-go/classpath"
 	"strings"
+	"sync"
 )
 
 /*
@@ -14,6 +15,9 @@ class names:  - 注释：列出了JVM中类名的几种类型
   - non-array classes: java/lang/Object ... - 非数组类
   - array classes: [Ljava/lang/Object; ... - 数组类
 */
+// classMapLock 保护所有类加载器的 classMap
+var classMapLock sync.RWMutex
+
 // 类加载器类型常量
 const (
 	BootstrapClassLoader   = iota // 引导类加载器
@@ -22,12 +26,16 @@ const (
 	UserDefinedClassLoader        // 用户自定义类加载器
 )
 
+// builtinLoaders 虚拟机内置的类加载器，下标是类加载器类型
+var builtinLoaders [UserDefinedClassLoader]*ClassLoader
+
 type ClassLoader struct { // 定义类加载器结构体
 	parent      *ClassLoader         // 父类加载器
 	cp          *classpath.Classpath // 类路径，用于查找和加载类文件
 	verboseFlag bool                 // 是否启用 verbose 输出，用于调试
 	classMap    map[string]*Class    // 已加载的类，key 为类名，value 为 Class 结构体指针
 	loaderType  int                  // 类加载器类型
+	jLoader     *Object              // 对应的 java.lang.ClassLoader 对象，启动类加载器没有
 }
 
 // NewClassLoader 创建一个新的类加载器
@@ -59,6 +67,10 @@ func NewClassLoader(cp *classpath.Classpath, verboseFlag bool) *ClassLoader {
 		loaderType:  ApplicationClassLoader,
 	}
 
+	builtinLoaders[BootstrapClassLoader] = bootstrapLoader
+	builtinLoaders[ExtensionClassLoader] = extensionLoader
+	builtinLoaders[ApplicationClassLoader] = applicationLoader
+
 	// 加载基础类和基本类型类
 	bootstrapLoader.loadBasicClasses()     // 加载基础类（例如 java/lang/Class）
 	bootstrapLoader.loadPrimitiveClasses() // 加载基本类型类（例如 int, boolean 等）
@@ -91,8 +103,8 @@ func (cl *ClassLoader) loadPrimitiveClass(className string) {
 		accessFlags: ACC_PUBLIC, // 访问标志，设置为 public
 		name:        className,  // 类名
 		loader:      cl,         // 类加载器
-		initStarted: true,       // 初始化状态，设置为已初始化
 	}
+	class.markInitialized()                                   // 基本类型不需要初始化
 	class.jClass = cl.classMap["java/lang/Class"].NewObject() // 创建对应的 java/lang/Class 对象
 	class.jClass.extra = class                                // 存储 Class 结构体指针
 	cl.classMap[className] = class                            // 将类添加到 classMap 中
@@ -101,6 +113,25 @@ func (cl *ClassLoader) loadPrimitiveClass(className string) {
 // LoadClass 加载类，如果类已经加载，则直接返回
 // 实现双亲委派机制
 func (cl *ClassLoader) LoadClass(name string) *Class {
+	classMapLock.RLock()
+	class, ok := cl.classMap[name]
+	classMapLock.RUnlock()
+	if ok {
+		return class
+	}
+
+	// 多个 Java 线程可能同时加载类，加载过程中持有写锁，
+	// 其他线程要么看不到这个类，要么看到的是已经连接好的类
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	if class = cl.loadClass(name); class == nil {
+		panic("java.lang.ClassNotFoundException: " + name)
+	}
+	return class
+}
+
+// loadClass 在持有 classMapLock 写锁的情况下加载类，找不到时返回 nil
+func (cl *ClassLoader) loadClass(name string) *Class {
 	// 1. 检查类是否已经被当前类加载器加载
 	if class, ok := cl.classMap[name]; ok {
 		return class
@@ -109,9 +140,10 @@ func (cl *ClassLoader) LoadClass(name string) *Class {
 	// 2. 双亲委派机制：如果有父类加载器，先委托父类加载器加载
 	if cl.parent != nil {
 		// 尝试由父类加载器加载
-		class := cl.parent.LoadClass(name)
+		class := cl.parent.loadClass(name)
 		// 如果父类加载器成功加载了类，则返回
 		if class != nil {
+			cl.addClass(name, class) // 记录当前类加载器为初始类加载器
 			return class
 		}
 	}
@@ -120,26 +152,37 @@ func (cl *ClassLoader) LoadClass(name string) *Class {
 	var class *Class
 	if name[0] == '[' { // 判断是否是数组类
 		class = cl.loadArrayClass(name) // 加载数组类
+	} else if cl.loaderType == UserDefinedClassLoader {
+		// 用户自定义类加载器要调用 Java 代码（ClassLoader.loadClass）加载类，见 LoadRequest
+		panic(&LoadRequest{Loader: cl, Name: name})
 	} else {
 		class = cl.loadNonArrayClass(name) // 加载非数组类
 	}
+	if class == nil {
+		return nil
+	}
 
 	// 4. 为类创建 java.lang.Class 实例
-	if jlClassClass, ok := cl.classMap["java/lang/Class"]; ok { // 获取 java/lang/Class 类
-		class.jClass = jlClassClass.NewObject() // 创建对应的 java/lang/Class 对象
-		class.jClass.extra = class              // 存储 Class 结构体指针
-	} else if cl.parent != nil {
-		// 如果当前类加载器没有加载 java/lang/Class，尝试从父类加载器获取
-		jlClassClass := cl.parent.LoadClass("java/lang/Class")
-		if jlClassClass != nil {
-			class.jClass = jlClassClass.NewObject()
-			class.jClass.extra = class
-		}
+	if class.jClass == nil {
+		cl.createJClass(class)
 	}
 
 	return class
 }
 
+// createJClass 为类创建 java.lang.Class 实例
+func (cl *ClassLoader) createJClass(class *Class) {
+	jlClassClass, ok := cl.classMap["java/lang/Class"] // 获取 java/lang/Class 类
+	if !ok && cl.parent != nil {
+		// 如果当前类加载器没有加载 java/lang/Class，尝试从父类加载器获取
+		jlClassClass = cl.parent.loadClass("java/lang/Class")
+	}
+	if jlClassClass != nil {
+		class.jClass = jlClassClass.NewObject() // 创建对应的 java/lang/Class 对象
+		class.jClass.extra = class              // 存储 Class 结构体指针
+	}
+}
+
 // loadArrayClass 加载数组类
 func (cl *ClassLoader) loadArrayClass(name string) *Class {
 	// 数组类由定义其元素类型的类加载器加载
@@ -149,14 +192,21 @@ func (cl *ClassLoader) loadArrayClass(name string) *Class {
 	if componentType != "" && componentType[0] != '[' && componentType[0] != 'L' {
 		// 如果是基本类型数组，不需要加载元素类型
 	} else if componentType != "" {
-		// 如果是引用类型数组，先加载元素类型
+		// 如果是引用类型数组，先加载元素类型，当前类加载器加载不了元素类型时交给子加载器
+		componentClassName := componentType
 		if componentType[0] == 'L' {
 			// 去除 L 和 ; 得到类名
-			componentClassName := componentType[1 : len(componentType)-1]
-			cl.LoadClass(componentClassName)
-		} else {
-			// 如果是多维数组，递归加载
-			cl.LoadClass(componentType)
+			componentClassName = componentType[1 : len(componentType)-1]
+		}
+		componentClass := cl.loadClass(componentClassName)
+		if componentClass == nil {
+			return nil
+		}
+		// 数组类由元素类型的定义类加载器定义，当前类加载器只是初始类加载器，jvms 5.3.3
+		if componentClass.loader != cl {
+			class := componentClass.loader.loadClass(name)
+			cl.classMap[name] = class
+			return class
 		}
 	}
 
@@ -164,14 +214,16 @@ func (cl *ClassLoader) loadArrayClass(name string) *Class {
 		accessFlags: ACC_PUBLIC,                       // 访问标志
 		name:        name,                             // 类名
 		loader:      cl,                               // 类加载器
-		initStarted: true,                             // 初始化状态
-		superClass:  cl.LoadClass("java/lang/Object"), // 父类为 java/lang/Object
+		superClass:  cl.loadClass("java/lang/Object"), // 父类为 java/lang/Object
 		interfaces: []*Class{ // 实现的接口
-			cl.LoadClass("java/lang/Cloneable"),  // Cloneable 接口
-			cl.LoadClass("java/io/Serializable"), // Serializable 接口
+			cl.loadClass("java/lang/Cloneable"),  // Cloneable 接口
+			cl.loadClass("java/io/Serializable"), // Serializable 接口
 		},
 	}
 
+	class.markInitialized()  // 数组类不需要初始化
+	buildMethodTables(class) // 数组类的方法都继承自 java/lang/Object
+
 	// 将类添加到类加载器的缓存中
 	cl.classMap[name] = class
 
@@ -203,22 +255,51 @@ func (cl *ClassLoader) loadNonArrayClass(name string) *Class {
 	// 读取类文件数据
 	data, entry, err := cl.cp.ReadClass(name)
 	if err != nil {
+		if cl.loaderType == BootstrapClassLoader && name == "java/lang/Record" {
+			return cl.defineRecordClass() // Java 8 的类库没有这个类
+		}
 		// 如果读取失败，返回 nil，让其他类加载器尝试
 		return nil
 	}
 
-	// 定义类
-	class := cl.defineClass(data)
-	// 连接类
-	link(class)
+	// 定义并连接类
+	class := cl.defineAndLinkClass(name, data)
 
 	if cl.verboseFlag { // 如果启用 verbose 输出
 		fmt.Printf("[Loaded %s from %s by %s]\n", name, entry, cl.getLoaderName()) // 打印加载信息
 	}
 
-	// 将类添加到类加载器的缓存中
-	cl.classMap[name] = class
+	return class
+}
+
+// defineAndLinkClass 定义并连接类，name 为空时使用 class 文件里的类名。
+// 解析父类或者连接失败（例如 VerifyError）时把类从 classMap 里移除，再次加载时会重新报错
+func (cl *ClassLoader) defineAndLinkClass(name string, data []byte) *Class {
+	class := parseClass(data)             // 解析类文件数据
+	if name != "" && class.name != name { // class 文件里的类名和要加载的类名不一致
+		panic("java.lang.NoClassDefFoundError: " + class.name + " (wrong name: " + name + ")")
+	}
+	if class.IsModule() { // module-info.class 只描述模块，不能当作类加载
+		panic("java.lang.NoClassDefFoundError: " + toJavaName(class.name) +
+			" is not a class because access_flag ACC_MODULE is set")
+	}
+	// 核心类只能由启动类加载器定义，否则其他类加载器可以用同名的类冒充它们
+	if cl.loaderType != BootstrapClassLoader && strings.HasPrefix(class.name, "java/") {
+		panic("java.lang.SecurityException: Prohibited package name: " + toJavaName(class.GetPackageName()))
+	}
+	if _, ok := cl.classMap[class.name]; ok {
+		panic("java.lang.LinkageError: loader " + cl.getLoaderName() +
+			" attempted duplicate class definition for name: \"" + class.name + "\"")
+	}
 
+	defer func() {
+		if r := recover(); r != nil {
+			delete(cl.classMap, class.name)
+			panic(r)
+		}
+	}()
+	cl.defineClass(class) // 定义类
+	link(class)           // 连接类
 	return class
 }
 
@@ -260,6 +341,52 @@ func (cl *ClassLoader) getLoaderName() string {
 	}
 }
 
+// ReadResource 从类路径读取资源，找不到时返回 nil。
+// 所有类加载器看到的都是同一个类路径，用户自定义类加载器按双亲委派也会先在类路径里查找
+func ReadResource(name string) []byte {
+	data, _, err := readResource(name)
+	if err != nil {
+		return nil
+	}
+	return data
+}
+
+// ResourceURL 返回类路径里资源的 URL，找不到资源或者资源没有对应的 URL 时返回空字符串
+func ResourceURL(name string) string {
+	_, entry, err := readResource(name)
+	if err != nil {
+		return ""
+	}
+	return classpath.ResourceURL(entry, name)
+}
+
+// ReadResourceURL 读取 ResourceURL 返回的 URL 指向的资源，spec 不是类路径里的资源时返回 nil。
+// file: URL 不知道类路径目录在哪里结束，依次把路径的每个后缀当作资源名，找到的资源的 URL 必须和 spec 相同
+func ReadResourceURL(spec string) []byte {
+	path := spec
+	if i := strings.Index(spec, "!/"); strings.HasPrefix(spec, "jar:") && i >= 0 {
+		path = spec[i+1:]
+	}
+	for i := 0; i < len(path); i++ {
+		if path[i] != '/' {
+			continue
+		}
+		name := path[i+1:]
+		if data, entry, err := readResource(name); err == nil && classpath.ResourceURL(entry, name) == spec {
+			return data
+		}
+	}
+	return nil
+}
+
+// readResource 本地方法在任意 Java 线程上读取资源，jar 包第一次使用时才打开，
+// 所以和类加载一样要持有 classMapLock
+func readResource(name string) ([]byte, classpath.Entry, error) {
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	return builtinLoaders[BootstrapClassLoader].cp.ReadResource(name)
+}
+
 func (cl *ClassLoader) readClass(name string) ([]byte, classpath.Entry) {
 	data, entry, err := cl.cp.ReadClass(name) // 从类路径中读取类文件数据
 	if err != nil {                           // 如果读取失败
@@ -270,21 +397,30 @@ func (cl *ClassLoader) readClass(name string) ([]byte, classpath.Entry) {
 
 // defineClass 定义类
 // jvms 5.3.5  -  注释：JVM规范参考
-func (cl *ClassLoader) defineClass(data []byte) *Class {
-	class := parseClass(data)       // 解析类文件数据
-	hackClass(class)                // 对类进行 hack（特殊处理）
-	class.loader = cl               // 设置类加载器
-	resolveSuperClass(class)        // 解析父类
-	resolveInterfaces(class)        // 解析接口
-	cl.classMap[class.name] = class // 将类添加到 classMap 中
-	return class
+func (cl *ClassLoader) defineClass(class *Class) {
+	hackClass(class)               // 对类进行 hack（特殊处理）
+	class.loader = cl              // 设置类加载器
+	resolveSuperClass(class)       // 解析父类
+	resolveInterfaces(class)       // 解析接口
+	checkSealedSuperTypes(class)   // 检查父类和接口是否允许被继承
+	cl.addClass(class.name, class) // 将类添加到 classMap 中
 }
 
 func parseClass(data []byte) *Class {
 	cf, err := classfile.Parse(data) // 解析 class 文件
 	if err != nil {
-		panic(err) // 如果解析失败，则抛出异常
+		// 如果解析失败，则抛出 ClassFormatError
+		if msg := err.Error(); strings.HasPrefix(msg, "java.lang.") {
+			panic(msg)
+		}
+		panic("java.lang.ClassFormatError: " + err.Error())
 	}
+	// 常量池里的引用类型不对、描述符格式错误等问题在创建 Class 结构体时才会发现
+	defer func() {
+		if r := recover(); r != nil {
+			panic(fmt.Sprintf("java.lang.ClassFormatError: %v", r))
+		}
+	}()
 	return newClass(cf) // 创建 Class 结构体
 }
 
@@ -292,7 +428,10 @@ func parseClass(data []byte) *Class {
 // jvms 5.4.3.1  -  注释：JVM规范参考
 func resolveSuperClass(class *Class) {
 	if class.name != "java/lang/Object" { // 如果不是 java/lang/Object 类
-		class.superClass = class.loader.LoadClass(class.superClassName) // 加载父类
+		class.superClass = class.loader.loadClass(class.superClassName) // 加载父类
+		if class.superClass == nil {
+			panic("java.lang.NoClassDefFoundError: " + class.superClassName)
+		}
 	}
 }
 
@@ -302,33 +441,61 @@ func resolveInterfaces(class *Class) {
 	if interfaceCount > 0 {                     // 如果有接口
 		class.interfaces = make([]*Class, interfaceCount)    // 创建接口数组
 		for i, interfaceName := range class.interfaceNames { // 遍历接口名
-			class.interfaces[i] = class.loader.LoadClass(interfaceName) // 加载接口
+			class.interfaces[i] = class.loader.loadClass(interfaceName) // 加载接口
+			if class.interfaces[i] == nil {
+				panic("java.lang.NoClassDefFoundError: " + interfaceName)
+			}
 		}
 	}
 }
 
-// link 连接类
-func link(class *Class) {
-	verify(class)  // 验证类
-	prepare(class) // 准备类
+// checkSealedSuperTypes 父类或接口是密封的时候，它的 PermittedSubclasses 属性必须列出这个类，
+// 并且两者属于同一个运行时包（没有模块系统，所有类都在未命名模块里），jvms 5.3.5
+func checkSealedSuperTypes(class *Class) {
+	if super := class.superClass; super != nil && !super.permitsSubclass(class) {
+		panic("java.lang.IncompatibleClassChangeError: class " + toJavaName(class.name) +
+			" cannot inherit from sealed class " + toJavaName(super.name))
+	}
+	for _, iface := range class.interfaces {
+		if !iface.permitsSubclass(class) {
+			panic("java.lang.IncompatibleClassChangeError: class " + toJavaName(class.name) +
+				" cannot implement sealed interface " + toJavaName(iface.name))
+		}
+	}
 }
 
-// verify 验证类
-func verify(class *Class) {
-	// 这里需要实现类的验证逻辑，例如检查类文件的魔数、版本号、常量池等。
-	// 目前只是占位符，实际实现需要根据JVM规范进行。  jvms 4.10
-	if !class.initStarted {
-		verify(class.superClass) //递归验证父类
-		verifyInterfaces(class)  //验证接口
-		verifyFields(class)      //验证字段
-		verifyMethods(class)     //验证方法
-		class.initStarted = true //完成初始化
+func (cl *Class) permitsSubclass(sub *Class) bool {
+	if !cl.IsSealed() {
+		return true
+	}
+	if !cl.IsSameRuntimePackage(sub) {
+		return false
+	}
+	for _, name := range cl.permittedSubclassNames {
+		if name == sub.name {
+			return true
+		}
 	}
+	return false
 }
 
-func verifyInterfaces(class *Class) {
-	for _, iface := range class.interfaces {
-		verify(iface)
+// link 连接类
+func link(class *Class) {
+	verify(class)            // 验证类
+	prepare(class)           // 准备类
+	buildMethodTables(class) // 生成虚方法表和接口方法表
+}
+
+// verify 验证类  jvms 4.10
+// 父类和接口在解析时已经加载并连接过了，这里只验证类自己
+func verify(class *Class) {
+	verifyFields(class)  //验证字段
+	verifyMethods(class) //验证方法
+	// 默认和 HotSpot 一样，启动类加载器加载的类是可信的，不检查字节码
+	if verifyMode == VerifyAll || verifyMode == VerifyRemote && class.loader.loaderType != BootstrapClassLoader {
+		for _, method := range class.methods {
+			verifyMethodCode(method)
+		}
 	}
 }
 
@@ -336,44 +503,57 @@ func verifyFields(class *Class) {
 	for _, field := range class.fields {
 		// 检查字段名是否有效
 		if !isValidFieldName(field.name) {
-			panic(fmt.Errorf("invalid field name: %s", field.name))
+			panic("java.lang.ClassFormatError: Illegal field name \"" + field.name + "\" in class " + class.name)
 		}
 		// 检查字段描述符是否有效
 		if !isValidFieldDescriptor(field.descriptor) {
-			panic(fmt.Errorf("invalid field descriptor: %s", field.descriptor))
+			panic("java.lang.ClassFormatError: Field \"" + field.name + "\" in class " + class.name +
+				" has illegal signature \"" + field.descriptor + "\"")
 		}
 		// 其他字段验证逻辑，例如检查字段的访问标志、final字段是否初始化等
 	}
 }
 
+// isValidFieldName 检查字段名是不是合法的非限定名，jvms 4.2.2
 func isValidFieldName(name string) bool {
-	// 检查字段名是否为空或包含非法字符
-	if name == "" || strings.ContainsAny(name, ". ;[") {
-		return true
-	}
-	// 可以根据需要添加更多的验证规则
-
-	return true
-
+	// 不能为空，也不能包含 . ; [ /
+	return name != "" && !strings.ContainsAny(name, ".;[/")
 }
 
 // isValidFieldDescriptor 检查字段描述符是否有效
 func isValidFieldDescriptor(descriptor string) bool {
+	return fieldDescriptorLength(descriptor) == len(descriptor)
+}
 
-	// 检查基本类型描述符
-	if strings.ContainsAny(descriptor, "ZBCSIJFD") {
-		return true
+// fieldDescriptorLength 返回 descriptor 开头的字段类型描述符的长度，不是合法的描述符时返回 -1
+func fieldDescriptorLength(descriptor string) int {
+	// 数组最多 255 维
+	i := 0
+	for i < len(descriptor) && descriptor[i] == '[' {
+		i++
 	}
-	// 检查对象类型描述符
-	if strings.HasPrefix(descriptor, "L") && strings.HasSuffix(descriptor, ";") {
-		return true
+	if i > 255 || i == len(descriptor) {
+		return -1
 	}
 
-	// 检查数组类型描述符
-	if strings.HasPrefix(descriptor, "[") {
-		return isValidFieldDescriptor(descriptor[1:]) || isValidFieldDescriptor("L"+descriptor[1:]+";")
+	switch descriptor[i] {
+	case 'Z', 'B', 'C', 'S', 'I', 'J', 'F', 'D':
+		return i + 1
+	case 'L':
+		end := strings.IndexByte(descriptor[i:], ';')
+		if end < 0 {
+			return -1
+		}
+		// 类名的每一段都是非限定名
+		for _, part := range strings.Split(descriptor[i+1:i+end], "/") {
+			if !isValidFieldName(part) {
+				return -1
+			}
+		}
+		return i + end + 1
+	default:
+		return -1
 	}
-	return false
 }
 
 // verifyMethods 验证方法
@@ -381,50 +561,43 @@ func verifyMethods(class *Class) {
 	for _, method := range class.methods {
 		// 检查方法名是否有效
 		if !isValidMethodName(method.name) {
-			panic(fmt.Errorf("invalid method name: %s", method.name))
+			panic("java.lang.ClassFormatError: Illegal method name \"" + method.name + "\" in class " + class.name)
 		}
 		// 检查方法描述符是否有效
 		if !isValidMethodDescriptor(method.descriptor) {
-			panic(fmt.Errorf("invalid method descriptor: %s", method.descriptor))
+			panic("java.lang.ClassFormatError: Method \"" + method.name + "\" in class " + class.name +
+				" has illegal signature \"" + method.descriptor + "\"")
 		}
 		// 其他方法验证逻辑，例如检查方法的访问标志、返回值类型、参数类型等
-		//  例如，可以检查方法的字节码，确保没有非法指令或操作
+		// 字节码由 verifyMethodCode 检查
 	}
 }
 
 func isValidMethodName(name string) bool {
-	// 检查方法名是否为空或包含非法字符。"<init>" 和 "<clinit>" 是特殊方法名，应该允许。
-	if name == "" || strings.ContainsAny(name, ". ;[") {
-		return false
+	// "<init>" 和 "<clinit>" 是特殊方法名，其他方法名除了非限定名的规则外还不能包含 < 和 >
+	if name == "<init>" || name == "<clinit>" {
+		return true
 	}
-	return true
+	return isValidFieldName(name) && !strings.ContainsAny(name, "<>")
 }
 
 func isValidMethodDescriptor(descriptor string) bool {
-	// 方法描述符的格式较为复杂，需要使用更严格的校验逻辑，例如正则表达式
-	// 这里只是一个简单的示例，实际实现中需要根据JVM规范进行更详细的验证
-	if descriptor == "" || !strings.HasPrefix(descriptor, "(") || !strings.Contains(descriptor, ")") {
+	if !strings.HasPrefix(descriptor, "(") {
 		return false
 	}
-
-	return isValidTypeDescriptor(descriptor[1:strings.Index(descriptor, ")")]) && isValidTypeDescriptor(descriptor[strings.Index(descriptor, ")")+1:])
-
-}
-
-func isValidTypeDescriptor(descriptor string) bool {
-	// 校验数组类型
-	if strings.HasPrefix(descriptor, "[") {
-		return isValidTypeDescriptor(descriptor[1:])
-	}
-	// 基本类型和void
-	if descriptor == "V" || strings.Contains("ZBCSIJFD", descriptor) {
-		return true
+	i := 1
+	for i < len(descriptor) && descriptor[i] != ')' {
+		n := fieldDescriptorLength(descriptor[i:])
+		if n < 0 {
+			return false
+		}
+		i += n
 	}
-	// 对象类型
-	if strings.HasPrefix(descriptor, "L") && strings.HasSuffix(descriptor, ";") {
-		return len(descriptor) > 2
+	if i == len(descriptor) {
+		return false
 	}
-	return false
+	returnType := descriptor[i+1:]
+	return returnType == "V" || isValidFieldDescriptor(returnType)
 }
 
 // prepare 准备类，主要进行静态变量的分配和初始化。
@@ -514,7 +687,7 @@ func initStaticFinalVar(class *Class, field *Field) {
 			vars.SetDouble(slotId, val)              // 将值设置到静态变量中
 		case "Ljava/lang/String;": // String
 			goStr := cp.GetConstant(cpIndex).(string) // 从常量池获取值，转换为 Go 字符串
-			jStr := JString(class.Loader(), goStr)    // 创建 Java 字符串对象
+			jStr := internString(class.loader, goStr) // 创建 Java 字符串对象
 			vars.SetRef(slotId, jStr)                 // 将 Java 字符串对象设置到静态变量中
 		}
 	}
@@ -526,5 +699,43 @@ func hackClass(class *Class) {
 	if class.name == "java/lang/ClassLoader" {
 		loadLibrary := class.GetStaticMethod("loadLibrary", "(Ljava/lang/Class;Ljava/lang/String;Z)V")
 		loadLibrary.code = []byte{0xb1} // 0xb1 是 return void 指令
+		// 虚拟机打不开 jar 包，内置类加载器的资源直接从类路径读取，见 native/java/lang/ClassLoader.go
+		for _, method := range class.methods {
+			switch method.name {
+			case "getResource", "getResourceAsStream", "getSystemResourceAsStream":
+				method.replaceWithNative()
+			}
+		}
+	}
+	if class.name == "java/net/URL" {
+		// getResource 返回的 URL 也从类路径读取
+		if method := class.GetInstanceMethod("openStream", "()Ljava/io/InputStream;"); method != nil {
+			method.replaceWithNative()
+		}
+	}
+	if class.name == "java/net/URLClassLoader" {
+		// ExtClassLoader 和 AppClassLoader 继承 URLClassLoader，它覆盖了 getResourceAsStream
+		if method := class.GetInstanceMethod("getResourceAsStream", "(Ljava/lang/String;)Ljava/io/InputStream;"); method != nil {
+			method.replaceWithNative()
+		}
+	}
+	if class.name == "java/lang/invoke/MethodHandle" {
+		// 只支持直接方法句柄，见 native/java/lang/invoke/MethodHandle.go
+		for _, method := range class.methods {
+			switch method.name + method.descriptor {
+			case "<init>(Ljava/lang/invoke/MethodType;Ljava/lang/invoke/LambdaForm;)V",
+				"asTypeUncached(Ljava/lang/invoke/MethodType;)Ljava/lang/invoke/MethodHandle;",
+				"bindTo(Ljava/lang/Object;)Ljava/lang/invoke/MethodHandle;":
+				method.replaceWithNative()
+			}
+		}
+	}
+	if class.name == "java/lang/invoke/DirectMethodHandle" {
+		// 不支持 LambdaForm，直接方法句柄由本地方法创建，见 native/java/lang/invoke
+		for _, method := range class.methods {
+			if method.IsStatic() && (method.name == "make" || method.name == "makeAllocator") {
+				method.replaceWithNative()
+			}
+		}
 	}
 }
@@ -16,6 +16,7 @@ func (clm *ClassMember) copyMemberInfo(memberInfo *classfile.MemberInfo) {
 	clm.accessFlags = memberInfo.AccessFlags()
 	clm.name = memberInfo.Name()
 	clm.descriptor = memberInfo.Descriptor()
+	clm.annotationData = memberInfo.RuntimeVisibleAnnotationsAttributeData()
 }
 
 func (clm *ClassMember) IsPublic() bool {
@@ -65,10 +66,10 @@ func (clm *ClassMember) isAccessibleTo(d *Class) bool {
 	c := clm.class
 	if clm.IsProtected() {
 		return d == c || d.IsSubClassOf(c) ||
-			c.GetPackageName() == d.GetPackageName()
+			c.IsSameRuntimePackage(d)
 	}
 	if !clm.IsPrivate() {
-		return c.GetPackageName() == d.GetPackageName()
+		return c.IsSameRuntimePackage(d)
 	}
-	return d == c
+	return d == c || d.IsNestmateOf(c) // Java 11 开始同一个嵌套里的类可以访问私有成员
 }
@@ -1,5 +1,7 @@
 package heap
 
+import "strings"
+
 var primitiveTypes = map[string]string{
 	"void":    "V",
 	"boolean": "Z",
@@ -66,3 +68,8 @@ func toClassName(descriptor string) string {
 	}
 	panic("Invalid descriptor: " + descriptor)
 }
+
+// java/lang/String => java.lang.String
+func toJavaName(className string) string {
+	return strings.Replace(className, "/", ".", -1)
+}
@@ -0,0 +1,46 @@
+package heap
+
+// NestHost 返回类的嵌套宿主，jvms 5.4.4。
+// 没有 NestHost 属性的类是自己的宿主。和 JDK 15 以后一样，宿主加载失败、
+// 和类不在同一个运行时包、或者宿主的 NestMembers 属性没有列出这个类时，类也是自己的宿主
+func (cl *Class) NestHost() *Class {
+	if host := cl.nestHost.Load(); host != nil {
+		return host
+	}
+	host := cl.resolveNestHost()
+	cl.nestHost.Store(host)
+	return host
+}
+
+func (cl *Class) resolveNestHost() (host *Class) {
+	if cl.nestHostName == "" || cl.nestHostName == cl.name || cl.loader == nil {
+		return cl
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			if _, ok := r.(*LoadRequest); ok {
+				panic(r) // 用户自定义类加载器加载完宿主后会重新执行
+			}
+			host = cl
+		}
+	}()
+	host = cl.loader.LoadClass(cl.nestHostName)
+	if !host.IsSameRuntimePackage(cl) || !host.hasNestMember(cl.name) {
+		return cl
+	}
+	return host
+}
+
+func (cl *Class) hasNestMember(name string) bool {
+	for _, memberName := range cl.nestMemberNames {
+		if memberName == name {
+			return true
+		}
+	}
+	return false
+}
+
+// IsNestmateOf 判断两个类是否属于同一个嵌套，同一个嵌套里的类可以互相访问私有成员
+func (cl *Class) IsNestmateOf(other *Class) bool {
+	return cl == other || cl.NestHost() == other.NestHost()
+}
@@ -0,0 +1,218 @@
+package heap
+
+// codeWriter 为虚拟机内部生成的类（lambda 实现类等）生成字节码，
+// 用到的符号引用直接追加到生成类的常量池里
+type codeWriter struct {
+	cp       *ConstantPool
+	code     []byte
+	maxStack uint
+}
+
+func (w *codeWriter) emit(bytes ...byte) {
+	w.code = append(w.code, bytes...)
+}
+
+func (w *codeWriter) emitU2(opcode byte, index uint) {
+	w.emit(opcode, byte(index>>8), byte(index))
+}
+
+func (w *codeWriter) addConst(c Constant) uint {
+	if len(w.cp.consts) == 0 {
+		w.cp.consts = append(w.cp.consts, nil) // 常量池索引从 1 开始
+	}
+	w.cp.consts = append(w.cp.consts, c)
+	return uint(len(w.cp.consts) - 1)
+}
+
+func (w *codeWriter) newClassRef(className string) *ClassRef {
+	ref := &ClassRef{}
+	ref.cp = w.cp
+	ref.className = className
+	return ref
+}
+
+func (w *codeWriter) newMethodRef(className, name, descriptor string) *MethodRef {
+	ref := &MethodRef{}
+	ref.cp = w.cp
+	ref.className = className
+	ref.name = name
+	ref.descriptor = descriptor
+	return ref
+}
+
+// 已经解析好的方法，不再需要访问检查
+func (w *codeWriter) newResolvedMethodRef(class *Class, method *Method) *MethodRef {
+	ref := w.newMethodRef(class.name, method.name, method.descriptor)
+	ref.class = class
+	ref.setMethod(method)
+	return ref
+}
+
+func (w *codeWriter) newResolvedInterfaceMethodRef(class *Class, method *Method) *InterfaceMethodRef {
+	ref := &InterfaceMethodRef{}
+	ref.cp = w.cp
+	ref.className = class.name
+	ref.class = class
+	ref.name = method.name
+	ref.descriptor = method.descriptor
+	ref.setMethod(method)
+	return ref
+}
+
+// 字段直接设置成已解析状态
+func (w *codeWriter) newFieldRef(field *Field) *FieldRef {
+	ref := &FieldRef{}
+	ref.cp = w.cp
+	ref.className = field.class.name
+	ref.class = field.class
+	ref.name = field.name
+	ref.descriptor = field.descriptor
+	ref.field = field
+	return ref
+}
+
+func (w *codeWriter) emitLoad(descriptor string, slot uint) {
+	var opcode byte
+	switch descriptor[0] {
+	case 'J':
+		opcode = 0x16 // lload
+	case 'F':
+		opcode = 0x17 // fload
+	case 'D':
+		opcode = 0x18 // dload
+	case 'L', '[':
+		opcode = 0x19 // aload
+	default:
+		opcode = 0x15 // iload
+	}
+	if slot > 0xff {
+		w.emit(0xc4) // wide
+		w.emitU2(opcode, slot)
+	} else {
+		w.emit(opcode, byte(slot))
+	}
+}
+
+func (w *codeWriter) emitAstore(slot uint) {
+	if slot > 0xff {
+		w.emit(0xc4) // wide
+		w.emitU2(0x3a, slot)
+	} else {
+		w.emit(0x3a, byte(slot)) // astore
+	}
+}
+
+func (w *codeWriter) emitReturn(descriptor string) {
+	switch descriptor[0] {
+	case 'V':
+		w.emit(0xb1) // return
+	case 'J':
+		w.emit(0xad) // lreturn
+	case 'F':
+		w.emit(0xae) // freturn
+	case 'D':
+		w.emit(0xaf) // dreturn
+	case 'L', '[':
+		w.emit(0xb0) // areturn
+	default:
+		w.emit(0xac) // ireturn
+	}
+}
+
+// 把栈顶 from 类型的值转换成 to 类型
+func (w *codeWriter) emitConversion(from, to string) {
+	if from == to {
+		return
+	}
+	fromPrimitive := isPrimitiveDescriptor(from)
+	toPrimitive := isPrimitiveDescriptor(to)
+	switch {
+	case fromPrimitive && toPrimitive:
+		w.emitWidening(from, to)
+	case fromPrimitive:
+		w.emitBoxing(from)
+		if to != "Ljava/lang/Object;" {
+			w.emitCheckcast(to)
+		}
+	case toPrimitive:
+		if primitive, ok := wrapperPrimitives[from]; ok {
+			w.emitUnboxing(primitive)
+			w.emitWidening(primitive, to)
+		} else {
+			w.emitCheckcast("L" + primitiveWrappers[to] + ";")
+			w.emitUnboxing(to)
+		}
+	default:
+		if to != "Ljava/lang/Object;" {
+			w.emitCheckcast(to)
+		}
+	}
+}
+
+func (w *codeWriter) emitCheckcast(descriptor string) {
+	w.emitU2(0xc0, w.addConst(w.newClassRef(toClassName(descriptor))))
+}
+
+func (w *codeWriter) emitBoxing(primitive string) {
+	wrapper := primitiveWrappers[primitive]
+	ref := w.newMethodRef(wrapper, "valueOf", "("+primitive+")L"+wrapper+";")
+	w.emitU2(0xb8, w.addConst(ref)) // invokestatic
+}
+
+func (w *codeWriter) emitUnboxing(primitive string) {
+	wrapper := primitiveWrappers[primitive]
+	name := toClassName(primitive) + "Value"
+	ref := w.newMethodRef(wrapper, name, "()"+primitive)
+	w.emitU2(0xb6, w.addConst(ref)) // invokevirtual
+}
+
+// 基本类型的拓宽转换，jls 5.1.2
+func (w *codeWriter) emitWidening(from, to string) {
+	switch from + to {
+	case "IJ", "BJ", "SJ", "CJ":
+		w.emit(0x85) // i2l
+	case "IF", "BF", "SF", "CF":
+		w.emit(0x86) // i2f
+	case "ID", "BD", "SD", "CD":
+		w.emit(0x87) // i2d
+	case "JF":
+		w.emit(0x89) // l2f
+	case "JD":
+		w.emit(0x8a) // l2d
+	case "FD":
+		w.emit(0x8d) // f2d
+	}
+}
+
+var primitiveWrappers = map[string]string{
+	"Z": "java/lang/Boolean",
+	"B": "java/lang/Byte",
+	"C": "java/lang/Character",
+	"S": "java/lang/Short",
+	"I": "java/lang/Integer",
+	"J": "java/lang/Long",
+	"F": "java/lang/Float",
+	"D": "java/lang/Double",
+}
+
+var wrapperPrimitives = map[string]string{
+	"Ljava/lang/Boolean;":   "Z",
+	"Ljava/lang/Byte;":      "B",
+	"Ljava/lang/Character;": "C",
+	"Ljava/lang/Short;":     "S",
+	"Ljava/lang/Integer;":   "I",
+	"Ljava/lang/Long;":      "J",
+	"Ljava/lang/Float;":     "F",
+	"Ljava/lang/Double;":    "D",
+}
+
+func isPrimitiveDescriptor(descriptor string) bool {
+	return descriptor[0] != 'L' && descriptor[0] != '['
+}
+
+func typeSlotCount(descriptor string) uint {
+	if descriptor == "J" || descriptor == "D" {
+		return 2
+	}
+	return 1
+}
@@ -5,6 +5,9 @@ import "jvm-go/classfile"
 
 type Constant interface{}
 
+// utf8Constant CONSTANT_Utf8，字节码不会直接引用，反射（sun.reflect.ConstantPool）解析注解时会用到
+type utf8Constant string
+
 /*
 	运行时常量池主要存放两类信息：字面量（literal）和符号引用（symbolic reference）。
 	字面量包括整数、浮点数和字符串字面量；
@@ -16,7 +19,9 @@ type ConstantPool struct {
 	consts []Constant
 }
 
-func newConstantPool(class *Class, cfCp classfile.ConstantPool) *ConstantPool {
+func newConstantPool(class *Class, cfCp classfile.ConstantPool,
+	bmAttr *classfile.BootstrapMethodsAttribute) *ConstantPool {
+
 	cpCount := len(cfCp)
 	consts := make([]Constant, cpCount)
 	rtCp := &ConstantPool{class, consts}
@@ -54,6 +59,15 @@ func newConstantPool(class *Class, cfCp classfile.ConstantPool) *ConstantPool {
 		case *classfile.ConstantInterfaceMethodrefInfo:
 			methodrefInfo := cpInfo.(*classfile.ConstantInterfaceMethodrefInfo)
 			consts[i] = newInterfaceMethodRef(rtCp, methodrefInfo)
+		case *classfile.ConstantMethodTypeInfo:
+			consts[i] = newMethodTypeRef(rtCp, cpInfo.(*classfile.ConstantMethodTypeInfo))
+		case *classfile.ConstantMethodHandleInfo:
+			consts[i] = newMethodHandleRef(rtCp, cpInfo.(*classfile.ConstantMethodHandleInfo))
+		case *classfile.ConstantInvokeDynamicInfo:
+			indyInfo := cpInfo.(*classfile.ConstantInvokeDynamicInfo)
+			consts[i] = newInvokeDynamicRef(rtCp, indyInfo, bmAttr)
+		case *classfile.ConstantUtf8Info:
+			consts[i] = utf8Constant(cpInfo.(*classfile.ConstantUtf8Info).Str())
 		default:
 			// todo
 			consts[i] = nil
@@ -62,6 +76,25 @@ func newConstantPool(class *Class, cfCp classfile.ConstantPool) *ConstantPool {
 	return rtCp
 }
 
+// Size 常量池的大小，和 class 文件里的 constant_pool_count 相同
+func (cp *ConstantPool) Size() int {
+	return len(cp.consts)
+}
+
+// LookupConstant 和 GetConstant 一样返回常量，但是索引无效时返回 nil
+func (cp *ConstantPool) LookupConstant(index int) Constant {
+	if index <= 0 || index >= len(cp.consts) {
+		return nil
+	}
+	return cp.consts[index]
+}
+
+// GetUtf8 返回 CONSTANT_Utf8 常量的字符串，索引上不是 CONSTANT_Utf8 时返回 false
+func (cp *ConstantPool) GetUtf8(index int) (string, bool) {
+	s, ok := cp.LookupConstant(index).(utf8Constant)
+	return string(s), ok
+}
+
 func (cp *ConstantPool) GetConstant(index uint) Constant {
 	if c := cp.consts[index]; c != nil {
 		return c
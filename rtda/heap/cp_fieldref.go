@@ -35,6 +35,7 @@ func (fr *FieldRef) resolveFieldRef() {
 	if !field.isAccessibleTo(d) {
 		panic("java.lang.IllegalAccessError")
 	}
+	checkSignatureLoaders(d, &field.ClassMember, "field")
 
 	fr.field = field
 }
@@ -4,7 +4,8 @@ import "jvm-go/classfile"
 
 type InterfaceMethodRef struct {
 	MemberRef
-	method *Method
+	method      *Method
+	itableIndex int // 方法在所属接口 methods 里的下标，不是接口方法时为 -1
 }
 
 func newInterfaceMethodRef(cp *ConstantPool, refInfo *classfile.ConstantInterfaceMethodrefInfo) *InterfaceMethodRef {
@@ -36,11 +37,31 @@ func (imref *InterfaceMethodRef) resolveInterfaceMethodRef() {
 	if !method.isAccessibleTo(d) {
 		panic("java.lang.IllegalAccessError")
 	}
+	checkSignatureLoaders(d, &method.ClassMember, "interface method")
 
+	imref.setMethod(method)
+}
+
+func (imref *InterfaceMethodRef) setMethod(method *Method) {
+	imref.itableIndex = -1
+	if method.class.IsInterface() {
+		for i, m := range method.class.methods {
+			if m == method {
+				imref.itableIndex = i
+			}
+		}
+	}
 	imref.method = method
 }
 
-// todo
+// ItableIndex 返回解析后的方法在所属接口 methods 里的下标，
+// 配合 Class.InterfaceMethod() 使用，不是接口方法时返回 -1
+func (imref *InterfaceMethodRef) ItableIndex() int {
+	imref.ResolvedInterfaceMethod()
+	return imref.itableIndex
+}
+
+// jvms 5.4.3.4 先找接口自己声明的方法，再找 java.lang.Object 的公有实例方法，最后找父接口
 func lookupInterfaceMethod(iface *Class, name, descriptor string) *Method {
 	for _, method := range iface.methods {
 		if method.name == name && method.descriptor == descriptor {
@@ -48,5 +69,10 @@ func lookupInterfaceMethod(iface *Class, name, descriptor string) *Method {
 		}
 	}
 
-	return lookupMethodInInterfaces(iface.interfaces, name, descriptor)
+	method := LookupMethodInClass(iface.loader.LoadClass("java/lang/Object"), name, descriptor)
+	if method != nil && method.IsPublic() && !method.IsStatic() {
+		return method
+	}
+
+	return lookupMethodInInterfaces(iface, name, descriptor)
 }
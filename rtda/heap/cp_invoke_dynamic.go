@@ -0,0 +1,68 @@
+package heap
+
+import (
+	"jvm-go/classfile"
+	"sync/atomic"
+)
+
+// InvokeDynamicRef 动态调用点限定符，jvms8 5.1
+// 第一次执行 invokedynamic 指令时调用引导方法链接出调用点，之后一直使用这个调用点
+type InvokeDynamicRef struct {
+	cp                 *ConstantPool
+	name               string
+	descriptor         string
+	parsedDescriptor   *MethodDescriptor
+	bootstrapMethodRef uint   // 常量池里的 MethodHandle 常量
+	bootstrapArguments []uint // 常量池里的静态参数
+	callSite           atomic.Value
+}
+
+func newInvokeDynamicRef(cp *ConstantPool, info *classfile.ConstantInvokeDynamicInfo,
+	bmAttr *classfile.BootstrapMethodsAttribute) *InvokeDynamicRef {
+
+	ref := &InvokeDynamicRef{cp: cp}
+	ref.name, ref.descriptor = info.NameAndType()
+	ref.parsedDescriptor = parseMethodDescriptor(ref.descriptor)
+
+	if bmAttr == nil {
+		panic("java.lang.ClassFormatError: missing BootstrapMethods attribute")
+	}
+	bm := bmAttr.BootstrapMethods()[info.BootstrapMethodAttrIndex()]
+	ref.bootstrapMethodRef = uint(bm.BootstrapMethodRef())
+	ref.bootstrapArguments = make([]uint, len(bm.BootstrapArguments()))
+	for i, argIndex := range bm.BootstrapArguments() {
+		ref.bootstrapArguments[i] = uint(argIndex)
+	}
+	return ref
+}
+
+func (idr *InvokeDynamicRef) Name() string {
+	return idr.name
+}
+func (idr *InvokeDynamicRef) Descriptor() string {
+	return idr.descriptor
+}
+func (idr *InvokeDynamicRef) ParsedDescriptor() *MethodDescriptor {
+	return idr.parsedDescriptor
+}
+
+func (idr *InvokeDynamicRef) BootstrapMethod() *MethodHandleRef {
+	return idr.cp.GetConstant(idr.bootstrapMethodRef).(*MethodHandleRef)
+}
+func (idr *InvokeDynamicRef) BootstrapArguments() []Constant {
+	args := make([]Constant, len(idr.bootstrapArguments))
+	for i, index := range idr.bootstrapArguments {
+		args[i] = idr.cp.GetConstant(index)
+	}
+	return args
+}
+
+// CallSite 返回已经链接好的调用点，还没有链接时返回 nil
+func (idr *InvokeDynamicRef) CallSite() interface{} {
+	return idr.callSite.Load()
+}
+
+// SetCallSite 记录链接好的调用点，多个线程同时链接时以最后一次为准
+func (idr *InvokeDynamicRef) SetCallSite(callSite interface{}) {
+	idr.callSite.Store(callSite)
+}
@@ -0,0 +1,115 @@
+package heap
+
+import (
+	"jvm-go/classfile"
+	"sync/atomic"
+)
+
+// 方法句柄的引用类型 jvms8 4.4.8
+const (
+	REF_getField         = 1
+	REF_getStatic        = 2
+	REF_putField         = 3
+	REF_putStatic        = 4
+	REF_invokeVirtual    = 5
+	REF_invokeStatic     = 6
+	REF_invokeSpecial    = 7
+	REF_newInvokeSpecial = 8
+	REF_invokeInterface  = 9
+)
+
+// MethodHandleRef 方法句柄符号引用，指向常量池里的字段或方法符号引用
+type MethodHandleRef struct {
+	cp             *ConstantPool
+	referenceKind  uint8
+	referenceIndex uint
+	jMethodHandle  atomic.Pointer[Object]
+}
+
+func newMethodHandleRef(cp *ConstantPool, info *classfile.ConstantMethodHandleInfo) *MethodHandleRef {
+	return &MethodHandleRef{
+		cp:             cp,
+		referenceKind:  info.ReferenceKind(),
+		referenceIndex: uint(info.ReferenceIndex()),
+	}
+}
+
+func (mhr *MethodHandleRef) ReferenceKind() uint8 {
+	return mhr.referenceKind
+}
+
+// Reference 返回方法句柄指向的 FieldRef、MethodRef 或 InterfaceMethodRef
+func (mhr *MethodHandleRef) Reference() Constant {
+	return mhr.cp.GetConstant(mhr.referenceIndex)
+}
+
+// MemberRef 返回被引用成员的符号信息，不会触发解析
+func (mhr *MethodHandleRef) MemberRef() *MemberRef {
+	switch ref := mhr.Reference().(type) {
+	case *FieldRef:
+		return &ref.MemberRef
+	case *MethodRef:
+		return &ref.MemberRef
+	case *InterfaceMethodRef:
+		return &ref.MemberRef
+	default:
+		panic("java.lang.ClassFormatError: bad method handle reference")
+	}
+}
+
+// ResolvedMethodHandle 解析被引用的字段或方法，返回对应的方法句柄，jvms8 5.4.3.5
+func (mhr *MethodHandleRef) ResolvedMethodHandle() *MethodHandle {
+	switch ref := mhr.Reference().(type) {
+	case *FieldRef:
+		if mhr.referenceKind > REF_putStatic {
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+		field := ref.ResolvedField()
+		if field.IsStatic() != (mhr.referenceKind == REF_getStatic || mhr.referenceKind == REF_putStatic) {
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+		return NewMethodHandle(mhr.referenceKind, ref.ResolvedClass(), field)
+	case *MethodRef:
+		mhr.checkMethodName(ref.name)
+		method := ref.ResolvedMethod()
+		if method.IsStatic() != (mhr.referenceKind == REF_invokeStatic) {
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+		return NewMethodHandle(mhr.referenceKind, ref.ResolvedClass(), method)
+	case *InterfaceMethodRef:
+		mhr.checkMethodName(ref.name)
+		method := ref.ResolvedInterfaceMethod()
+		if method.IsStatic() != (mhr.referenceKind == REF_invokeStatic) {
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+		return NewMethodHandle(mhr.referenceKind, ref.ResolvedClass(), method)
+	default:
+		panic("java.lang.ClassFormatError: bad method handle reference")
+	}
+}
+
+// checkMethodName REF_newInvokeSpecial 只能引用 <init>，其他种类不能引用 <init> 和 <clinit>，jvms8 4.4.8、5.4.3.5
+func (mhr *MethodHandleRef) checkMethodName(name string) {
+	switch mhr.referenceKind {
+	case REF_newInvokeSpecial:
+		if name != "<init>" {
+			panic("java.lang.IncompatibleClassChangeError: REF_newInvokeSpecial to " + name)
+		}
+	case REF_invokeVirtual, REF_invokeStatic, REF_invokeSpecial, REF_invokeInterface:
+		if name == "<init>" || name == "<clinit>" {
+			panic("java.lang.IncompatibleClassChangeError: method handle to " + name)
+		}
+	default:
+		panic("java.lang.IncompatibleClassChangeError")
+	}
+}
+
+// JMethodHandle 返回 ldc 已经创建的 java.lang.invoke.MethodHandle 对象，还没有创建时返回 nil
+func (mhr *MethodHandleRef) JMethodHandle() *Object {
+	return mhr.jMethodHandle.Load()
+}
+
+// SetJMethodHandle 缓存 ldc 创建的对象，其他线程已经缓存了别的对象时返回 false
+func (mhr *MethodHandleRef) SetJMethodHandle(jMethodHandle *Object) bool {
+	return mhr.jMethodHandle.CompareAndSwap(nil, jMethodHandle)
+}
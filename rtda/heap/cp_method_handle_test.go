@@ -0,0 +1,61 @@
+package heap
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// 方法句柄常量引用的方法名和引用种类必须一致，jvms8 4.4.8、5.4.3.5
+func TestResolvedMethodHandle(t *testing.T) {
+	cb := newTestClass(52, "test/Check", "java/lang/Object")
+	for _, name := range []string{"m", "<clinit>"} {
+		method := cb.AddMethod(ACC_STATIC, name, "()V")
+		code := cb.NewCode()
+		code.Op(0xb1) // return
+		cb.SetCode(method, code)
+	}
+
+	tests := []struct {
+		kind       uint8
+		name       string
+		descriptor string // 方法句柄的类型
+		err        string // 为空表示解析成功
+	}{
+		{REF_invokeStatic, "m", "()V", ""},
+		{REF_newInvokeSpecial, "<init>", "()Ltest/Check;", ""},
+		{REF_newInvokeSpecial, "m", "", "java.lang.IncompatibleClassChangeError: REF_newInvokeSpecial to m"},
+		{REF_invokeVirtual, "<init>", "", "java.lang.IncompatibleClassChangeError: method handle to <init>"},
+		{REF_invokeStatic, "<clinit>", "", "java.lang.IncompatibleClassChangeError: method handle to <clinit>"},
+		{REF_getField, "m", "", "java.lang.IncompatibleClassChangeError"},
+	}
+	indexes := make([]uint16, len(tests))
+	for i, test := range tests {
+		indexes[i] = cb.MethodHandle(test.kind, cb.Methodref("test/Check", test.name, "()V"))
+	}
+
+	class := newTestLoader(t, cb).LoadClass("test/Check")
+	for i, test := range tests {
+		t.Run(fmt.Sprintf("%d %s", test.kind, test.name), func(t *testing.T) {
+			ref := class.ConstantPool().GetConstant(uint(indexes[i])).(*MethodHandleRef)
+			var mh *MethodHandle
+			err := func() (msg string) {
+				defer func() {
+					if r := recover(); r != nil {
+						msg = fmt.Sprint(r)
+					}
+				}()
+				mh = ref.ResolvedMethodHandle()
+				return ""
+			}()
+			switch {
+			case test.err == "" && err != "":
+				t.Errorf("unexpected error: %s", err)
+			case test.err != "" && !strings.HasPrefix(err, test.err):
+				t.Errorf("got %q, want %q", err, test.err)
+			case test.err == "" && mh.Descriptor() != test.descriptor:
+				t.Errorf("descriptor = %s, want %s", mh.Descriptor(), test.descriptor)
+			}
+		})
+	}
+}
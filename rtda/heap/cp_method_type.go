@@ -0,0 +1,30 @@
+package heap
+
+import (
+	"jvm-go/classfile"
+	"sync/atomic"
+)
+
+// MethodTypeRef 方法类型符号引用，只有一个方法描述符
+type MethodTypeRef struct {
+	cp          *ConstantPool
+	descriptor  string
+	jMethodType atomic.Pointer[Object]
+}
+
+func newMethodTypeRef(cp *ConstantPool, info *classfile.ConstantMethodTypeInfo) *MethodTypeRef {
+	return &MethodTypeRef{cp: cp, descriptor: info.Descriptor()}
+}
+
+func (mtr *MethodTypeRef) Descriptor() string {
+	return mtr.descriptor
+}
+
+// ResolvedMethodType 返回对应的 java.lang.invoke.MethodType 对象，jvms8 5.4.3.5
+func (mtr *MethodTypeRef) ResolvedMethodType() *Object {
+	if jMethodType := mtr.jMethodType.Load(); jMethodType != nil {
+		return jMethodType
+	}
+	mtr.jMethodType.CompareAndSwap(nil, NewJMethodType(mtr.cp.class.loader, mtr.descriptor))
+	return mtr.jMethodType.Load()
+}
@@ -4,8 +4,9 @@ import "jvm-go/classfile"
 
 // MethodRef 表示对方法的符号引用。它包含了对方法所属类和方法本身的引用。
 type MethodRef struct {
-	MemberRef         // 继承自 MemberRef，包含类名和方法描述符等信息
-	method    *Method // 指向解析后的实际方法
+	MemberRef           // 继承自 MemberRef，包含类名和方法描述符等信息
+	method      *Method // 指向解析后的实际方法
+	vtableIndex int     // 方法在虚方法表里的下标，不参与动态分派的方法为 -1
 }
 
 // newMethodRef 创建一个新的 MethodRef 实例。
@@ -56,14 +57,33 @@ func (mref *MethodRef) resolveMethodRef() {
 	}
 
 	method := lookupMethod(c, mref.name, mref.descriptor) // 在类 c 中查找方法
+	if method == nil {
+		method = lookupSignaturePolymorphicMethod(c, mref.name) // MethodHandle.invokeExact() 等方法的描述符由调用点决定
+	}
 	if method == nil {
 		panic("java.lang.NoSuchMethodError") // 找不到方法
 	}
 	if !method.isAccessibleTo(d) {
 		panic("java.lang.IllegalAccessError") // 方法不可访问
 	}
+	checkSignatureLoaders(d, &method.ClassMember, "method") // 类加载约束
+
+	mref.setMethod(method) // 保存解析后的方法
+}
+
+// setMethod 保存解析后的方法，同时记下它在被引用类的虚方法表里的下标
+func (mref *MethodRef) setMethod(method *Method) {
+	mref.vtableIndex = -1
+	if isVirtualMethod(method) {
+		mref.vtableIndex = vtableIndexOf(mref.class.vtable, method)
+	}
+	mref.method = method
+}
 
-	mref.method = method // 保存解析后的方法
+// VtableIndex 返回解析后的方法在虚方法表里的下标，私有方法等不在虚方法表里的返回 -1
+func (mref *MethodRef) VtableIndex() int {
+	mref.ResolvedMethod()
+	return mref.vtableIndex
 }
 
 // lookupMethod 在指定的类及其父类和接口中查找方法。
@@ -80,7 +100,27 @@ func (mref *MethodRef) resolveMethodRef() {
 func lookupMethod(class *Class, name, descriptor string) *Method {
 	method := LookupMethodInClass(class, name, descriptor) // 先在类及其父类中查找
 	if method == nil {
-		method = lookupMethodInInterfaces(class.interfaces, name, descriptor) // 如果找不到，则在接口中查找
+		method = lookupMethodInInterfaces(class, name, descriptor) // 如果找不到，则在接口中查找
 	}
 	return method
 }
+
+// lookupSignaturePolymorphicMethod 查找签名多态方法，jvms 5.4.3.3
+func lookupSignaturePolymorphicMethod(class *Class, name string) *Method {
+	if class.name != "java/lang/invoke/MethodHandle" && class.name != "java/lang/invoke/VarHandle" {
+		return nil
+	}
+	var found *Method
+	for _, method := range class.methods {
+		if method.name == name {
+			if found != nil {
+				return nil // 同名方法只能有一个
+			}
+			found = method
+		}
+	}
+	if found != nil && found.IsSignaturePolymorphic() {
+		return found
+	}
+	return nil
+}
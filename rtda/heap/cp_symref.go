@@ -25,3 +25,13 @@ func (sr *SymRef) resolveClassRef() { // SymRef 的方法，用于解析符号
 
 	sr.class = c // 将已解析的类对象赋值给 sr.class 字段
 }
+
+// ClassName 返回符号引用的类名，不会触发类的解析
+func (sr *SymRef) ClassName() string {
+	return sr.className
+}
+
+// LoadedClass 返回已经解析的类，还没有解析时返回 nil
+func (sr *SymRef) LoadedClass() *Class {
+	return sr.class
+}
@@ -0,0 +1,131 @@
+package heap
+
+import "strconv"
+
+// LambdaInfo 描述 LambdaMetafactory 要生成的函数式接口实现类
+type LambdaInfo struct {
+	Caller        *Class           // 执行 invokedynamic 指令的类
+	Interfaces    []string         // 要实现的接口，第一个是函数式接口
+	MethodName    string           // 函数式接口的方法名
+	MethodTypes   []string         // 要实现的方法描述符，第一个是接口方法，其余是桥接方法
+	CapturedTypes []string         // 捕获参数的类型，依次存放在 arg$1, arg$2 ... 字段里
+	Impl          *MethodHandleRef // 实现方法
+}
+
+// NewLambdaClass 生成实现函数式接口的类。
+// 生成的方法把捕获参数和方法参数压栈后调用实现方法，
+// 参数和返回值类型不一致时插入 checkcast、装箱拆箱或基本类型转换指令
+func NewLambdaClass(info *LambdaInfo) *Class {
+	fields := make([]*Field, len(info.CapturedTypes))
+	for i, capturedType := range info.CapturedTypes {
+		field := &Field{}
+		field.accessFlags = ACC_PRIVATE | ACC_FINAL
+		field.name = "arg$" + strconv.Itoa(i+1)
+		field.descriptor = capturedType
+		fields[i] = field
+	}
+	return newSyntheticClass(info.Caller, "Lambda", "java/lang/Object", info.Interfaces, fields,
+		func(class *Class) []*Method {
+			methods := make([]*Method, len(info.MethodTypes))
+			for i, methodType := range info.MethodTypes {
+				methods[i] = newLambdaMethod(class, info, methodType, i > 0)
+			}
+			return methods
+		})
+}
+
+func newLambdaMethod(class *Class, info *LambdaInfo, descriptor string, bridge bool) *Method {
+	method := &Method{}
+	method.class = class
+	method.accessFlags = ACC_PUBLIC | ACC_FINAL
+	if bridge {
+		method.accessFlags |= ACC_BRIDGE | ACC_SYNTHETIC
+	}
+	method.name = info.MethodName
+	method.descriptor = descriptor
+	method.parsedDescriptor = parseMethodDescriptor(descriptor)
+	method.calcArgSlotCount(method.parsedDescriptor.parameterTypes)
+
+	writer := &codeWriter{cp: class.constantPool}
+	writer.writeLambdaBody(class, info.Impl, method.parsedDescriptor)
+	method.code = writer.code
+	method.maxStack = writer.maxStack
+	method.maxLocals = method.argSlotCount
+	return method
+}
+
+func (w *codeWriter) writeLambdaBody(class *Class, impl *MethodHandleRef, md *MethodDescriptor) {
+	implRef := impl.MemberRef()
+	implMd := parseMethodDescriptor(implRef.descriptor)
+	kind := impl.ReferenceKind()
+
+	// 实现方法需要的参数，包括接收者
+	implArgs := implMd.parameterTypes
+	implReturnType := implMd.returnType
+	switch kind {
+	case REF_invokeVirtual, REF_invokeSpecial, REF_invokeInterface:
+		implArgs = append([]string{"L" + implRef.className + ";"}, implArgs...)
+	case REF_newInvokeSpecial:
+		implReturnType = "L" + implRef.className + ";"
+		w.emitU2(0xbb, w.addConst(w.newClassRef(implRef.className))) // new
+		w.emit(0x59)                                                 // dup
+	}
+	if len(class.fields)+len(md.parameterTypes) != len(implArgs) {
+		panic("java.lang.invoke.LambdaConversionException: " +
+			"incorrect number of parameters for " + implRef.name + implRef.descriptor)
+	}
+
+	// 捕获参数
+	for _, field := range class.fields {
+		w.emit(0x2a) // aload_0
+		w.emitU2(0xb4, w.addConst(w.newFieldRef(field)))
+	}
+
+	// 方法参数
+	slot := uint(1)
+	for i, paramType := range md.parameterTypes {
+		w.emitLoad(paramType, slot)
+		slot += typeSlotCount(paramType)
+		w.emitConversion(paramType, implArgs[len(class.fields)+i])
+	}
+
+	argSlotCount := uint(0)
+	for _, argType := range implArgs {
+		argSlotCount += typeSlotCount(argType)
+	}
+	w.maxStack = argSlotCount + 4 // new, dup 以及装箱拆箱
+
+	// 调用实现方法，直接使用宿主类常量池里的符号引用
+	implIndex := w.addConst(impl.Reference())
+	switch kind {
+	case REF_invokeVirtual:
+		w.emitU2(0xb6, implIndex) // invokevirtual
+	case REF_invokeStatic:
+		w.emitU2(0xb8, implIndex) // invokestatic
+	case REF_invokeSpecial, REF_newInvokeSpecial:
+		w.emitU2(0xb7, implIndex) // invokespecial
+	case REF_invokeInterface:
+		w.emitU2(0xb9, implIndex) // invokeinterface
+		w.emit(byte(argSlotCount), 0)
+	default:
+		panic("java.lang.invoke.LambdaConversionException: unsupported reference kind " +
+			strconv.Itoa(int(kind)))
+	}
+
+	// 返回值
+	returnType := md.returnType
+	if returnType == "V" {
+		switch implReturnType {
+		case "V":
+		case "J", "D":
+			w.emit(0x58) // pop2
+		default:
+			w.emit(0x57) // pop
+		}
+	} else if implReturnType == "V" {
+		w.emit(0x01) // aconst_null
+	} else {
+		w.emitConversion(implReturnType, returnType)
+	}
+	w.emitReturn(returnType)
+}
@@ -0,0 +1,155 @@
+package heap
+
+import "fmt"
+
+// 类加载约束，jvms 5.3.4
+// 一个类通过字段或者方法引用另一个类加载器加载的类时，字段类型、参数类型和返回值类型
+// 在两个类加载器里必须是同一个类，否则一个类加载器的对象会被当作另一个类加载器里的同名类使用。
+// 约束 N^L1 = N^L2 在解析引用时记录下来，类还没有加载时，之后类加载器加载这个名字的类时也要满足约束
+
+// loaderConstraint 同一个类名上互相约束的类加载器，它们加载的这个名字的类必须相同
+type loaderConstraint struct {
+	loaders []*ClassLoader
+	class   *Class // 约束里的类加载器已经加载的类，都还没有加载时为 nil
+}
+
+// loaderConstraints 类名 -> 这个类名上的约束，受 classMapLock 保护
+var loaderConstraints = map[string][]*loaderConstraint{}
+
+// checkSignatureLoaders 解析字段和方法引用时检查类加载约束。
+// d 是引用所在的类，member 是解析得到的字段或方法，kind（field 或 method）用于错误信息
+func checkSignatureLoaders(d *Class, member *ClassMember, kind string) {
+	if d.loader == member.class.loader {
+		return
+	}
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	if typeName, ok := addSignatureConstraints(member.descriptor, d.loader, member.class.loader); !ok {
+		panic(fmt.Sprintf("java.lang.LinkageError: loader constraint violation: when resolving %s \"%s\" "+
+			"the class loader %s of the current class, %s, and the class loader %s for the %s's defining class, %s, "+
+			"have different Class objects for the type %s used in the signature",
+			kind, member.name, d.loader.getLoaderName(), d.JavaName(),
+			member.class.loader.getLoaderName(), kind, member.class.JavaName(), toJavaName(typeName)))
+	}
+}
+
+// checkOverrideLoaders 覆盖另一个类加载器加载的类的方法时检查类加载约束，jvms 5.4.5。
+// 在连接类时调用，已经持有 classMapLock
+func checkOverrideLoaders(method, overridden *Method) {
+	if method.class.loader == overridden.class.loader {
+		return
+	}
+	if typeName, ok := addSignatureConstraints(method.descriptor, method.class.loader, overridden.class.loader); !ok {
+		panic(fmt.Sprintf("java.lang.LinkageError: loader constraint violation: when overriding method \"%s%s\" "+
+			"the class loader %s of the current class, %s, and the class loader %s for its super type %s "+
+			"have different Class objects for the type %s used in the signature",
+			method.name, method.descriptor, method.class.loader.getLoaderName(), method.class.JavaName(),
+			overridden.class.loader.getLoaderName(), overridden.class.JavaName(), toJavaName(typeName)))
+	}
+}
+
+// addSignatureConstraints 为字段描述符或者方法描述符里的每个引用类型加上约束，
+// 违反约束时返回违反约束的类名和 false
+func addSignatureConstraints(descriptor string, l1, l2 *ClassLoader) (string, bool) {
+	var types []string
+	if descriptor[0] == '(' {
+		md := parseMethodDescriptor(descriptor)
+		types = append(types, md.parameterTypes...)
+		types = append(types, md.returnType)
+	} else {
+		types = []string{descriptor}
+	}
+
+	for _, t := range types {
+		if name := constraintClassName(t); name != "" && !addLoaderConstraint(name, l1, l2) {
+			return name, false
+		}
+	}
+	return "", true
+}
+
+// constraintClassName 返回类型描述符对应的要约束的类名，数组约束的是元素类型，基本类型不需要约束
+func constraintClassName(descriptor string) string {
+	i := 0
+	for i < len(descriptor) && descriptor[i] == '[' {
+		i++
+	}
+	if i < len(descriptor) && descriptor[i] == 'L' {
+		return descriptor[i+1 : len(descriptor)-1]
+	}
+	return ""
+}
+
+// addLoaderConstraint 加上约束 name^l1 = name^l2，和已经加载的类或者已有的约束冲突时返回 false
+func addLoaderConstraint(name string, l1, l2 *ClassLoader) bool {
+	if l1 == l2 {
+		return true
+	}
+	c1, c2 := findLoaderConstraint(name, l1), findLoaderConstraint(name, l2)
+
+	// 两个类加载器已经加载的类和约束里确定的类必须是同一个
+	var class *Class
+	for _, c := range []*Class{l1.classMap[name], l2.classMap[name], c1.loadedClass(), c2.loadedClass()} {
+		if c == nil {
+			continue
+		}
+		if class != nil && class != c {
+			return false
+		}
+		class = c
+	}
+
+	switch {
+	case c1 == nil && c2 == nil:
+		c1 = &loaderConstraint{loaders: []*ClassLoader{l1, l2}}
+		loaderConstraints[name] = append(loaderConstraints[name], c1)
+	case c1 == nil:
+		c2.loaders = append(c2.loaders, l1)
+		c1 = c2
+	case c2 == nil:
+		c1.loaders = append(c1.loaders, l2)
+	case c1 != c2: // 合并两个约束
+		c1.loaders = append(c1.loaders, c2.loaders...)
+		constraints := loaderConstraints[name]
+		for i, c := range constraints {
+			if c == c2 {
+				loaderConstraints[name] = append(constraints[:i], constraints[i+1:]...)
+				break
+			}
+		}
+	}
+	c1.class = class
+	return true
+}
+
+func findLoaderConstraint(name string, loader *ClassLoader) *loaderConstraint {
+	for _, c := range loaderConstraints[name] {
+		for _, l := range c.loaders {
+			if l == loader {
+				return c
+			}
+		}
+	}
+	return nil
+}
+
+func (lc *loaderConstraint) loadedClass() *Class {
+	if lc == nil {
+		return nil
+	}
+	return lc.class
+}
+
+// addClass 把类记录到类加载器的 classMap 里，这个类加载器可能是定义类加载器也可能只是初始类加载器。
+// 类加载器在这个类名上有约束时，类必须和约束里其他类加载器加载的类相同
+func (cl *ClassLoader) addClass(name string, class *Class) {
+	if c := findLoaderConstraint(name, cl); c != nil {
+		if c.class != nil && c.class != class {
+			panic("java.lang.LinkageError: loader constraint violation: loader " + cl.getLoaderName() +
+				" wants to load class " + toJavaName(name) + ". A different class with the same name was previously loaded by " +
+				c.class.loader.getLoaderName() + ".")
+		}
+		c.class = class
+	}
+	cl.classMap[name] = class
+}
@@ -1,6 +1,9 @@
 package heap
 
-import "jvm-go/classfile"
+import (
+	"jvm-go/classfile"
+	"sync/atomic"
+)
 
 // Method 结构体表示一个方法
 type Method struct {
@@ -16,8 +19,12 @@ type Method struct {
 	// 存放行号表，用于调试
 	// 程序抛出异常或使用调试器单步执行时，虚拟机可以利用行号表显示程序当前执行到源代码的哪一行
 	lineNumberTable *classfile.LineNumberTableAttribute
+	// 存放 StackMapTable，只在验证字节码时使用
+	stackMapTable *classfile.StackMapTableAttribute
 	// 存放方法抛出的异常类型
 	exceptions *classfile.ExceptionsAttribute // todo: 重命名为更具描述性的名称
+	// 存放参数的名字和访问标志，没有 MethodParameters 属性时为 nil
+	methodParameters *classfile.MethodParametersAttribute
 	// 存放参数的注解数据
 	parameterAnnotationData []byte // RuntimeVisibleParameterAnnotations_attribute
 	// 存放注解的默认值
@@ -26,6 +33,12 @@ type Method struct {
 	parsedDescriptor *MethodDescriptor
 	// 存放参数占用的局部变量槽数量，用于确定参数在局部变量表中的起始位置
 	argSlotCount uint
+	// 存放解码后的字节码，由解释器第一次执行方法时生成
+	decodedCode atomic.Value
+	// 多个默认方法冲突时，虚方法表里用来占位的方法记录冲突的方法
+	conflictingMethods []*Method
+	// 被 replaceWithNative 改成本地方法之前的 Java 实现
+	javaImpl *Method
 }
 
 // newMethods 函数根据 class 文件中的方法信息创建 Method 对象数组
@@ -59,11 +72,12 @@ func (me *Method) copyAttributes(cfMethod *classfile.MemberInfo) {
 		me.maxLocals = codeAttr.MaxLocals()                      // 获取局部变量表的大小
 		me.code = codeAttr.Code()                                // 获取字节码
 		me.lineNumberTable = codeAttr.LineNumberTableAttribute() // 获取行号表
+		me.stackMapTable = codeAttr.StackMapTableAttribute()     // 获取栈映射帧
 		me.exceptionTable = newExceptionTable(codeAttr.ExceptionTable(),
 			me.class.constantPool) // 创建异常处理表
 	}
 	me.exceptions = cfMethod.ExceptionsAttribute()                                          // 获取方法抛出的异常类型
-	me.annotationData = cfMethod.RuntimeVisibleAnnotationsAttributeData()                   // 获取方法的注解数据  //此处代码中没有这个字段，应该是笔误
+	me.methodParameters = cfMethod.MethodParametersAttribute()                              // 获取参数的名字和访问标志
 	me.parameterAnnotationData = cfMethod.RuntimeVisibleParameterAnnotationsAttributeData() // 获取参数的注解数据
 	me.annotationDefaultData = cfMethod.AnnotationDefaultAttributeData()                    // 获取注解的默认值
 }
@@ -100,6 +114,20 @@ func (me *Method) injectCodeAttribute(returnType string) {
 	}
 }
 
+// replaceWithNative 把 Java 方法改成本地方法，由 native 包里注册的函数实现
+func (me *Method) replaceWithNative() {
+	javaImpl := *me
+	me.javaImpl = &javaImpl
+	me.accessFlags |= ACC_NATIVE
+	me.injectCodeAttribute(me.parsedDescriptor.returnType)
+	me.exceptionTable = nil
+}
+
+// JavaImpl 返回被改成本地方法之前的 Java 实现，本地方法只处理一部分情况时用它执行其余的情况
+func (me *Method) JavaImpl() *Method {
+	return me.javaImpl
+}
+
 func (me *Method) IsSynchronized() bool {
 	return 0 != me.accessFlags&ACC_SYNCHRONIZED
 }
@@ -119,6 +147,15 @@ func (me *Method) IsStrict() bool {
 	return 0 != me.accessFlags&ACC_STRICT
 }
 
+// IsSignaturePolymorphic 判断是不是签名多态方法，例如 MethodHandle.invokeExact()，jvms 2.9.3
+func (me *Method) IsSignaturePolymorphic() bool {
+	className := me.class.name
+	return (className == "java/lang/invoke/MethodHandle" || className == "java/lang/invoke/VarHandle") &&
+		me.IsVarargs() && me.IsNative() &&
+		len(me.parsedDescriptor.parameterTypes) == 1 &&
+		me.parsedDescriptor.parameterTypes[0] == "[Ljava/lang/Object;"
+}
+
 // getters
 func (me *Method) MaxStack() uint {
 	return me.maxStack
@@ -129,6 +166,18 @@ func (me *Method) MaxLocals() uint {
 func (me *Method) Code() []byte {
 	return me.code
 }
+
+// DecodedCode 返回解码后的字节码，还没有解码时返回 nil。
+// heap 包不依赖 instructions 包，所以这里不关心具体类型
+func (me *Method) DecodedCode() interface{} {
+	return me.decodedCode.Load()
+}
+func (me *Method) SetDecodedCode(decodedCode interface{}) {
+	me.decodedCode.Store(decodedCode)
+}
+func (me *Method) MethodParameters() *classfile.MethodParametersAttribute {
+	return me.methodParameters
+}
 func (me *Method) ParameterAnnotationData() []byte {
 	return me.parameterAnnotationData
 }
@@ -15,3 +15,27 @@ func (self *MethodDescriptor) addParameterType(t string) {
 
 	self.parameterTypes = append(self.parameterTypes, t)
 }
+
+func (self *MethodDescriptor) ParameterTypes() []string {
+	return self.parameterTypes
+}
+func (self *MethodDescriptor) ReturnType() string {
+	return self.returnType
+}
+
+// ParseMethodDescriptor 解析方法描述符，供签名多态方法等需要按调用点描述符处理参数的地方使用
+func ParseMethodDescriptor(descriptor string) *MethodDescriptor {
+	return parseMethodDescriptor(descriptor)
+}
+
+// ArgSlotCount 返回参数占用的局部变量槽数量，不包括 this
+func (self *MethodDescriptor) ArgSlotCount() uint {
+	slotCount := uint(0)
+	for _, paramType := range self.parameterTypes {
+		slotCount++
+		if paramType == "J" || paramType == "D" {
+			slotCount++
+		}
+	}
+	return slotCount
+}
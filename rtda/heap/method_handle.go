@@ -0,0 +1,257 @@
+package heap
+
+import "sync"
+
+// MemberName.flags
+const (
+	MN_IS_METHOD            = 0x00010000
+	MN_IS_CONSTRUCTOR       = 0x00020000
+	MN_IS_FIELD             = 0x00040000
+	MN_SEARCH_SUPERCLASSES  = 0x00100000
+	MN_SEARCH_INTERFACES    = 0x00200000
+	MN_REFERENCE_KIND_SHIFT = 24
+	MN_REFERENCE_KIND_MASK  = 0x0F
+)
+
+// MethodHandle 虚拟机直接实现的方法句柄，存放在 java.lang.invoke.MethodHandle 对象的 extra 里。
+// 只支持直接访问字段和调用方法（相当于 DirectMethodHandle），不支持 LambdaForm 组合出来的句柄。
+// 调用时按调用点的描述符生成适配方法，由适配方法完成参数和返回值的类型转换
+type MethodHandle struct {
+	kind       uint8
+	class      *Class // 被引用的类
+	method     *Method
+	field      *Field
+	descriptor string   // 方法句柄的类型
+	memberType string   // 直接访问成员的类型，asType 以后和 descriptor 不同
+	invokers   sync.Map // 调用点描述符 -> 适配方法
+}
+
+// NewMethodHandle 创建直接方法句柄，member 是 *Method 或 *Field
+func NewMethodHandle(kind uint8, class *Class, member interface{}) *MethodHandle {
+	mh := &MethodHandle{kind: kind, class: class}
+	receiver := "L" + class.name + ";"
+	switch m := member.(type) {
+	case *Field:
+		mh.field = m
+		switch kind {
+		case REF_getField:
+			mh.descriptor = "(" + receiver + ")" + m.descriptor
+		case REF_getStatic:
+			mh.descriptor = "()" + m.descriptor
+		case REF_putField:
+			mh.descriptor = "(" + receiver + m.descriptor + ")V"
+		case REF_putStatic:
+			mh.descriptor = "(" + m.descriptor + ")V"
+		default:
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+	case *Method:
+		mh.method = m
+		switch kind {
+		case REF_invokeStatic:
+			mh.descriptor = m.descriptor
+		case REF_newInvokeSpecial:
+			mh.descriptor = m.descriptor[:len(m.descriptor)-1] + receiver
+		case REF_invokeVirtual, REF_invokeSpecial, REF_invokeInterface:
+			mh.descriptor = "(" + receiver + m.descriptor[1:]
+		default:
+			panic("java.lang.IncompatibleClassChangeError")
+		}
+	}
+	mh.memberType = mh.descriptor
+	return mh
+}
+
+// AsType 返回类型是 descriptor 的方法句柄，调用时把参数和返回值在 descriptor 和成员的类型之间转换，
+// 见 MethodHandle.asType()
+func (mh *MethodHandle) AsType(descriptor string) *MethodHandle {
+	md := parseMethodDescriptor(descriptor)
+	if len(md.parameterTypes) != len(parseMethodDescriptor(mh.memberType).parameterTypes) {
+		panic("java.lang.invoke.WrongMethodTypeException: cannot convert MethodHandle" +
+			mh.descriptor + " to " + descriptor)
+	}
+	return &MethodHandle{
+		kind:       mh.kind,
+		class:      mh.class,
+		method:     mh.method,
+		field:      mh.field,
+		descriptor: descriptor,
+		memberType: mh.memberType,
+	}
+}
+
+func (mh *MethodHandle) Kind() uint8 {
+	return mh.kind
+}
+
+// Member 返回被引用的 *Method 或 *Field
+func (mh *MethodHandle) Member() interface{} {
+	if mh.method != nil {
+		return mh.method
+	}
+	return mh.field
+}
+
+func (mh *MethodHandle) Descriptor() string {
+	return mh.descriptor
+}
+
+// Invoker 返回按调用点描述符调用这个方法句柄的适配方法。
+// 适配方法是静态的，第一个参数是方法句柄对象本身，其余参数和调用点一致
+func (mh *MethodHandle) Invoker(descriptor string) *Method {
+	if invoker, ok := mh.invokers.Load(descriptor); ok {
+		return invoker.(*Method)
+	}
+	invoker, _ := mh.invokers.LoadOrStore(descriptor, newMethodHandleInvoker(mh, descriptor))
+	return invoker.(*Method)
+}
+
+func newMethodHandleInvoker(mh *MethodHandle, descriptor string) *Method {
+	md := parseMethodDescriptor(descriptor)
+	targetMd := parseMethodDescriptor(mh.memberType)
+	if len(md.parameterTypes) != len(targetMd.parameterTypes) {
+		panic("java.lang.invoke.WrongMethodTypeException: cannot convert MethodHandle" +
+			mh.descriptor + " to " + descriptor)
+	}
+
+	class := newSyntheticClass(mh.class, "MethodHandleInvoker", "java/lang/Object", nil, nil,
+		func(class *Class) []*Method {
+			return []*Method{newInvokeMethod(class, mh, md, targetMd, descriptor)}
+		})
+	return class.methods[0]
+}
+
+// newInvokeMethod 生成适配方法 invoke，第 0 个参数是方法句柄，其余参数和返回值与调用点描述符 descriptor 相同
+func newInvokeMethod(class *Class, mh *MethodHandle, md, targetMd *MethodDescriptor, descriptor string) *Method {
+	invoker := newSyntheticMethod(class, ACC_STATIC|ACC_SYNTHETIC, "invoke",
+		"(Ljava/lang/invoke/MethodHandle;"+descriptor[1:])
+
+	w := &codeWriter{cp: class.constantPool}
+	if mh.kind == REF_newInvokeSpecial {
+		w.emitU2(0xbb, w.addConst(w.newClassRef(mh.class.name))) // new
+		w.emit(0x59)                                             // dup
+	}
+	slot := uint(1) // 第 0 个参数是方法句柄
+	argSlotCount := uint(0)
+	for i, paramType := range md.parameterTypes {
+		w.emitLoad(paramType, slot)
+		slot += typeSlotCount(paramType)
+		w.emitConversion(paramType, targetMd.parameterTypes[i])
+		argSlotCount += typeSlotCount(targetMd.parameterTypes[i])
+	}
+	w.maxStack = argSlotCount + 4
+
+	switch mh.kind {
+	case REF_getField:
+		w.emitU2(0xb4, w.addConst(w.newFieldRef(mh.field))) // getfield
+	case REF_getStatic:
+		w.emitU2(0xb2, w.addConst(w.newFieldRef(mh.field))) // getstatic
+	case REF_putField:
+		w.emitU2(0xb5, w.addConst(w.newFieldRef(mh.field))) // putfield
+	case REF_putStatic:
+		w.emitU2(0xb3, w.addConst(w.newFieldRef(mh.field))) // putstatic
+	case REF_invokeVirtual:
+		w.emitU2(0xb6, w.addConst(w.newResolvedMethodRef(mh.class, mh.method))) // invokevirtual
+	case REF_invokeStatic:
+		w.emitU2(0xb8, w.addConst(w.newResolvedMethodRef(mh.class, mh.method))) // invokestatic
+	case REF_invokeSpecial, REF_newInvokeSpecial:
+		w.emitU2(0xb7, w.addConst(w.newResolvedMethodRef(mh.class, mh.method))) // invokespecial
+	case REF_invokeInterface:
+		w.emitU2(0xb9, w.addConst(w.newResolvedInterfaceMethodRef(mh.class, mh.method)))
+		w.emit(byte(argSlotCount), 0)
+	}
+
+	returnType := md.returnType
+	targetReturnType := targetMd.returnType
+	if returnType == "V" {
+		switch targetReturnType {
+		case "V":
+		case "J", "D":
+			w.emit(0x58) // pop2
+		default:
+			w.emit(0x57) // pop
+		}
+	} else if targetReturnType == "V" {
+		w.emit(0x01) // aconst_null
+	} else {
+		w.emitConversion(targetReturnType, returnType)
+	}
+	w.emitReturn(returnType)
+
+	invoker.code = w.code
+	invoker.maxStack = w.maxStack
+	invoker.maxLocals = invoker.argSlotCount
+	return invoker
+}
+
+// NewJMethodHandle 创建 java.lang.invoke.DirectMethodHandle 对象，方法句柄本身放在 extra 里。
+// 对象还没有初始化，要调用构造函数 DirectMethodHandle(MethodType, LambdaForm, MemberName)，见 base.InitJMethodHandle
+func NewJMethodHandle(loader *ClassLoader, mh *MethodHandle) *Object {
+	jMethodHandle := loader.LoadClass("java/lang/invoke/DirectMethodHandle").NewObject()
+	jMethodHandle.extra = mh
+	return jMethodHandle
+}
+
+// NewJMemberName 创建指向 member 的 java.lang.invoke.MemberName 对象，member 是 *Method 或 *Field
+func NewJMemberName(loader *ClassLoader, member interface{}, refKind uint8) *Object {
+	jMemberName := loader.LoadClass("java/lang/invoke/MemberName").NewObject()
+	SetMemberName(jMemberName, member, refKind)
+	return jMemberName
+}
+
+// SetMemberName 把解析好的成员写回 MemberName，成员本身放在 extra 里
+func SetMemberName(jMemberName *Object, member interface{}, refKind uint8) {
+	loader := jMemberName.class.loader
+	var class *Class
+	var name string
+	var jType *Object
+	var flags int32
+	switch m := member.(type) {
+	case *Method:
+		class, name = m.class, m.name
+		jType = NewJMethodType(loader, m.descriptor)
+		flags = int32(m.accessFlags) | MN_IS_METHOD
+		if name == "<init>" {
+			flags = int32(m.accessFlags) | MN_IS_CONSTRUCTOR
+		}
+	case *Field:
+		class, name = m.class, m.name
+		jType = m.Type().JClass()
+		flags = int32(m.accessFlags) | MN_IS_FIELD
+	default:
+		panic("java.lang.InternalError: bad member")
+	}
+	flags |= int32(refKind) << MN_REFERENCE_KIND_SHIFT
+
+	jMemberName.SetRefVar("clazz", "Ljava/lang/Class;", class.jClass)
+	jMemberName.SetRefVar("name", "Ljava/lang/String;", JString(loader, name))
+	jMemberName.SetRefVar("type", "Ljava/lang/Object;", jType)
+	jMemberName.SetIntVar("flags", "I", flags)
+	jMemberName.extra = member
+}
+
+// NewJMethodType 按方法描述符创建 java.lang.invoke.MethodType 对象，只设置 rtype 和 ptypes 字段
+func NewJMethodType(loader *ClassLoader, descriptor string) *Object {
+	md := parseMethodDescriptor(descriptor)
+	ptypes := loader.LoadClass("[Ljava/lang/Class;").NewArray(uint(len(md.parameterTypes)))
+	for i, paramType := range md.parameterTypes {
+		ptypes.Refs()[i] = loader.LoadClass(toClassName(paramType)).JClass()
+	}
+	rtype := loader.LoadClass(toClassName(md.returnType)).JClass()
+
+	jMethodType := loader.LoadClass("java/lang/invoke/MethodType").NewObject()
+	jMethodType.SetRefVar("rtype", "Ljava/lang/Class;", rtype)
+	jMethodType.SetRefVar("ptypes", "[Ljava/lang/Class;", ptypes)
+	return jMethodType
+}
+
+// MethodTypeDescriptor 根据 java.lang.invoke.MethodType 对象的 rtype 和 ptypes 字段得到方法描述符
+func MethodTypeDescriptor(jMethodType *Object) string {
+	descriptor := "("
+	ptypes := jMethodType.GetRefVar("ptypes", "[Ljava/lang/Class;")
+	for _, ptype := range ptypes.Refs() {
+		descriptor += toDescriptor(ptype.extra.(*Class).name)
+	}
+	rtype := jMethodType.GetRefVar("rtype", "Ljava/lang/Class;")
+	return descriptor + ")" + toDescriptor(rtype.extra.(*Class).name)
+}
@@ -11,19 +11,119 @@ func LookupMethodInClass(class *Class, name, descriptor string) *Method {
 	return nil
 }
 
-func lookupMethodInInterfaces(ifaces []*Class, name, descriptor string) *Method {
-	for _, iface := range ifaces {
+// lookupMethodInInterfaces 在 class 的所有父接口里查找方法，jvms 5.4.3.3
+// 最具体的方法里只有一个非抽象方法时返回它，否则任选一个非私有、非静态的方法
+func lookupMethodInInterfaces(class *Class, name, descriptor string) *Method {
+	if method, _ := selectMaximallySpecificMethod(class, name, descriptor); method != nil {
+		return method
+	}
+	for _, iface := range superInterfaces(class) {
 		for _, method := range iface.methods {
-			if method.name == name && method.descriptor == descriptor {
+			if method.name == name && method.descriptor == descriptor &&
+				!method.IsPrivate() && !method.IsStatic() {
 				return method
 			}
 		}
+	}
+	return nil
+}
+
+// selectMaximallySpecificMethod 按最具体的父接口方法选择方法，jvms 5.4.3.3
+// 只有一个非抽象方法时返回它；有多个非抽象方法时返回 nil 和冲突的方法；
+// 都是抽象方法时任选一个返回
+func selectMaximallySpecificMethod(class *Class, name, descriptor string) (*Method, []*Method) {
+	methods := maximallySpecificMethods(class, name, descriptor)
+	var nonAbstract []*Method
+	for _, method := range methods {
+		if !method.IsAbstract() {
+			nonAbstract = append(nonAbstract, method)
+		}
+	}
+	switch {
+	case len(nonAbstract) == 1:
+		return nonAbstract[0], nil
+	case len(nonAbstract) > 1:
+		return nil, nonAbstract
+	case len(methods) > 0:
+		return methods[0], nil
+	default:
+		return nil, nil
+	}
+}
+
+// maximallySpecificMethods 返回 class 的父接口里名字和描述符匹配、非私有、非静态，
+// 并且所在接口没有被其他候选方法所在接口继承的方法
+func maximallySpecificMethods(class *Class, name, descriptor string) []*Method {
+	var candidates []*Method
+	for _, iface := range superInterfaces(class) {
+		for _, method := range iface.methods {
+			if method.name == name && method.descriptor == descriptor &&
+				!method.IsPrivate() && !method.IsStatic() {
+				candidates = append(candidates, method)
+			}
+		}
+	}
 
-		method := lookupMethodInInterfaces(iface.interfaces, name, descriptor)
-		if method != nil {
+	var methods []*Method
+	for _, method := range candidates {
+		maximallySpecific := true
+		for _, other := range candidates {
+			if other.class.isSubInterfaceOf(method.class) {
+				maximallySpecific = false
+				break
+			}
+		}
+		if maximallySpecific {
+			methods = append(methods, method)
+		}
+	}
+	return methods
+}
+
+// superInterfaces 返回 class 直接或间接实现（继承）的所有接口，包括父类实现的接口
+func superInterfaces(class *Class) []*Class {
+	var ifaces []*Class
+	for c := class; c != nil; c = c.superClass {
+		ifaces = collectInterfaces(c.interfaces, ifaces)
+	}
+	return ifaces
+}
+
+// LookupSpecialMethod 按 invokespecial 的规则选择方法，jvms 6.5.invokespecial
+// class 是父类或者符号引用指向的类（接口）。多个默认方法冲突时抛出 IncompatibleClassChangeError，
+// 找不到方法时返回 nil
+func LookupSpecialMethod(class *Class, name, descriptor string) *Method {
+	if class.IsInterface() {
+		for _, method := range class.methods {
+			if method.name == name && method.descriptor == descriptor && !method.IsStatic() {
+				return method
+			}
+		}
+		// 接口可以调用 java.lang.Object 的公有方法
+		method := LookupMethodInClass(class.loader.LoadClass("java/lang/Object"), name, descriptor)
+		if method != nil && method.IsPublic() && !method.IsStatic() {
 			return method
 		}
+	} else if method := LookupMethodInClass(class, name, descriptor); method != nil {
+		return method
 	}
 
-	return nil
+	method, conflicts := selectMaximallySpecificMethod(class, name, descriptor)
+	if conflicts != nil {
+		panic(conflictingDefaultMethodsError(conflicts))
+	}
+	return method
+}
+
+// LookupMethod 按方法解析的规则在类或接口里查找方法，找不到返回 nil
+func LookupMethod(class *Class, name, descriptor string) *Method {
+	if class.IsInterface() {
+		return lookupInterfaceMethod(class, name, descriptor)
+	}
+	return lookupMethod(class, name, descriptor)
+}
+
+// LookupField 按字段解析的规则查找字段，找不到返回 nil
+func LookupField(class *Class, name, descriptor string) *Field {
+	return lookupField(class, name, descriptor)
 }
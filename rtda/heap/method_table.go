@@ -0,0 +1,210 @@
+package heap
+
+// itableEntry 接口方法表的一项，methods 的下标和接口的 methods 一致，
+// 存放的是这个类对接口方法的实现
+type itableEntry struct {
+	iface   *Class
+	methods []*Method
+}
+
+// buildMethodTables 连接类时生成虚方法表和接口方法表。
+// 子类的虚方法表以父类的虚方法表开头，覆盖的方法占用父类方法的位置，
+// 所以同一个方法在父类和所有子类里的下标相同，invokevirtual 只需要按下标取方法
+func buildMethodTables(class *Class) {
+	if class.IsInterface() {
+		return // 接口方法通过实现类的接口方法表调用
+	}
+	buildVtable(class)
+	buildItable(class)
+}
+
+func buildVtable(class *Class) {
+	var vtable []*Method
+	if class.superClass != nil {
+		vtable = append(vtable, class.superClass.vtable...)
+	}
+
+	for _, method := range class.methods {
+		if !isVirtualMethod(method) {
+			continue
+		}
+		overridden := false
+		for i, m := range vtable {
+			if m.name == method.name && m.descriptor == method.descriptor && canOverride(method, m) {
+				checkOverrideLoaders(method, m)
+				vtable[i] = method
+				overridden = true
+			}
+		}
+		if !overridden {
+			vtable = append(vtable, method)
+		}
+	}
+
+	// 类没有实现的接口方法按最具体的父接口方法选择（抽象方法或者默认方法），
+	// 父类选好的默认方法也要重新选择，因为这个类可能实现了更具体的接口
+	selectedMethods := map[string]bool{}
+	for _, iface := range superInterfaces(class) {
+		for _, method := range iface.methods {
+			key := method.name + method.descriptor
+			if !isVirtualMethod(method) || selectedMethods[key] {
+				continue
+			}
+			selectedMethods[key] = true
+			i := indexOfMethod(vtable, method.name, method.descriptor)
+			if i >= 0 && !isInterfaceMethodEntry(vtable[i]) {
+				continue // 类自己或者父类实现了这个方法
+			}
+			selected, conflicts := selectMaximallySpecificMethod(class, method.name, method.descriptor)
+			if conflicts != nil {
+				selected = newConflictMethod(class, conflicts)
+			}
+			if i < 0 {
+				vtable = append(vtable, selected)
+			} else {
+				vtable[i] = selected
+			}
+		}
+	}
+
+	class.vtable = vtable
+}
+
+func buildItable(class *Class) {
+	var itable []itableEntry
+	for c := class; c != nil; c = c.superClass {
+		for _, iface := range collectInterfaces(c.interfaces, nil) {
+			if containsInterface(itable, iface) {
+				continue
+			}
+			methods := make([]*Method, len(iface.methods))
+			for i, method := range iface.methods {
+				if isVirtualMethod(method) {
+					if j := indexOfMethod(class.vtable, method.name, method.descriptor); j >= 0 {
+						methods[i] = class.vtable[j]
+					}
+				}
+			}
+			itable = append(itable, itableEntry{iface: iface, methods: methods})
+		}
+	}
+	class.itable = itable
+}
+
+// 虚方法表里的方法是从接口选出来的，而不是类声明的
+func isInterfaceMethodEntry(method *Method) bool {
+	return method.class.IsInterface() || method.conflictingMethods != nil
+}
+
+// newConflictMethod 多个默认方法冲突时放进虚方法表占位，调用时抛出 IncompatibleClassChangeError
+func newConflictMethod(class *Class, conflicts []*Method) *Method {
+	method := &Method{}
+	method.class = class
+	method.accessFlags = ACC_PUBLIC | ACC_SYNTHETIC
+	method.name = conflicts[0].name
+	method.descriptor = conflicts[0].descriptor
+	method.parsedDescriptor = conflicts[0].parsedDescriptor
+	method.argSlotCount = conflicts[0].argSlotCount
+	method.conflictingMethods = conflicts
+	return method
+}
+
+func conflictingDefaultMethodsError(conflicts []*Method) string {
+	msg := "java.lang.IncompatibleClassChangeError: Conflicting default methods:"
+	for _, method := range conflicts {
+		msg += " " + method.class.JavaName() + "." + method.name
+	}
+	return msg
+}
+
+func containsInterface(itable []itableEntry, iface *Class) bool {
+	for _, entry := range itable {
+		if entry.iface == iface {
+			return true
+		}
+	}
+	return false
+}
+
+// collectInterfaces 收集 ifaces 以及它们的所有父接口，去掉重复的
+func collectInterfaces(ifaces []*Class, result []*Class) []*Class {
+	for _, iface := range ifaces {
+		found := false
+		for _, c := range result {
+			if c == iface {
+				found = true
+				break
+			}
+		}
+		if !found {
+			result = append(result, iface)
+			result = collectInterfaces(iface.interfaces, result)
+		}
+	}
+	return result
+}
+
+// 静态方法、私有方法和构造函数不参与动态分派
+func isVirtualMethod(method *Method) bool {
+	return !method.IsStatic() && !method.IsPrivate() && method.name != "<init>"
+}
+
+// jvms 5.4.5 方法覆盖：包私有的方法只能被同一个运行时包里的方法覆盖
+func canOverride(method, overridden *Method) bool {
+	if overridden.IsPublic() || overridden.IsProtected() {
+		return true
+	}
+	return overridden.class.IsSameRuntimePackage(method.class)
+}
+
+func indexOfMethod(table []*Method, name, descriptor string) int {
+	for i, method := range table {
+		if method.name == name && method.descriptor == descriptor {
+			return i
+		}
+	}
+	return -1
+}
+
+// vtableIndexOf 返回方法在虚方法表里的下标，
+// 表里没有这个方法时（比如解析到的是被默认方法取代的接口方法）按名字和描述符查找
+func vtableIndexOf(vtable []*Method, method *Method) int {
+	for i, m := range vtable {
+		if m == method {
+			return i
+		}
+	}
+	return indexOfMethod(vtable, method.name, method.descriptor)
+}
+
+// VirtualMethod 按下标从虚方法表里取方法，下标由 MethodRef.VtableIndex() 得到。
+// 选中的是互相冲突的默认方法时抛出 IncompatibleClassChangeError
+func (cl *Class) VirtualMethod(index int) *Method {
+	return checkConflict(cl.vtable[index])
+}
+
+// InterfaceMethod 从接口方法表里取类对接口方法的实现，
+// index 是方法在接口 methods 里的下标，类没有实现接口时返回 nil。
+// 和 VirtualMethod 一样，默认方法冲突时抛出 IncompatibleClassChangeError
+func (cl *Class) InterfaceMethod(iface *Class, index int) *Method {
+	if methods := cl.itableMethods(iface); methods != nil && methods[index] != nil {
+		return checkConflict(methods[index])
+	}
+	return nil
+}
+
+func checkConflict(method *Method) *Method {
+	if method.conflictingMethods != nil {
+		panic(conflictingDefaultMethodsError(method.conflictingMethods))
+	}
+	return method
+}
+
+func (cl *Class) itableMethods(iface *Class) []*Method {
+	for _, entry := range cl.itable {
+		if entry.iface == iface {
+			return entry.methods
+		}
+	}
+	return nil
+}
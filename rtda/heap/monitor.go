@@ -0,0 +1,155 @@
+package heap
+
+import (
+	"sync"
+	"time"
+)
+
+// Monitor 对象监视器，同一个线程可以重复进入
+// jvms 2.11.10
+type Monitor struct {
+	owner      interface{} // 持有监视器的线程
+	entryCount int         // 持有线程进入监视器的次数
+	lock       sync.Mutex  // 保护 owner 和 entryCount
+	released   *sync.Cond  // 监视器被释放时通知等待进入的线程
+	waitSet    []*waiter   // 调用了 wait() 的线程
+}
+
+// waiter 等待集中的线程，被 notify 时从 notified 收到通知
+type waiter struct {
+	notified chan struct{}
+}
+
+func newMonitor() *Monitor {
+	monitor := &Monitor{}
+	monitor.released = sync.NewCond(&monitor.lock)
+	return monitor
+}
+
+// Enter 进入监视器，如果监视器被其他线程持有，则一直等到它被释放
+func (mo *Monitor) Enter(thread interface{}) {
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+
+	for mo.owner != nil && mo.owner != thread {
+		mo.released.Wait()
+	}
+	mo.owner = thread
+	mo.entryCount++
+}
+
+// Exit 退出监视器，thread 并不持有监视器时返回 false
+func (mo *Monitor) Exit(thread interface{}) bool {
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+
+	if mo.owner != thread {
+		return false
+	}
+	mo.entryCount--
+	if mo.entryCount == 0 {
+		mo.owner = nil
+		mo.released.Signal()
+	}
+	return true
+}
+
+// HasOwner 判断 thread 是否持有监视器
+func (mo *Monitor) HasOwner(thread interface{}) bool {
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+
+	return mo.owner == thread
+}
+
+// Wait 释放监视器并等待，直到被 notify、超时（timeout 为 0 表示不超时）或者收到 interrupt，
+// 返回前重新进入监视器，并恢复之前的进入次数。interrupted 表示是因为 interrupt 才醒来的，
+// 同时被通知和中断时算作被通知，调用者应该正常返回并保留中断状态（jls 17.2.4）。
+// thread 并不持有监视器时 ok 为 false。
+// jls 17.2.1
+func (mo *Monitor) Wait(thread interface{}, timeout time.Duration, interrupt <-chan struct{}) (interrupted, ok bool) {
+	mo.lock.Lock()
+	if mo.owner != thread {
+		mo.lock.Unlock()
+		return false, false
+	}
+
+	w := &waiter{notified: make(chan struct{}, 1)}
+	mo.waitSet = append(mo.waitSet, w)
+	entryCount := mo.entryCount
+	mo.owner = nil
+	mo.entryCount = 0
+	mo.released.Signal()
+	mo.lock.Unlock()
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+	select {
+	case <-w.notified:
+	case <-timeoutCh:
+	case <-interrupt:
+		interrupted = true
+	}
+
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+	if !mo.removeWaiter(w) {
+		// 已经被通知过了，即使同时收到了 interrupt 也当作被通知，否则这次通知就丢了
+		interrupted = false
+	}
+	for mo.owner != nil {
+		mo.released.Wait()
+	}
+	mo.owner = thread
+	mo.entryCount = entryCount
+	return interrupted, true
+}
+
+// Notify 唤醒等待集中的一个线程，thread 并不持有监视器时返回 false
+func (mo *Monitor) Notify(thread interface{}) bool {
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+
+	if mo.owner != thread {
+		return false
+	}
+	mo.notifyOne()
+	return true
+}
+
+// NotifyAll 唤醒等待集中的所有线程，thread 并不持有监视器时返回 false
+func (mo *Monitor) NotifyAll(thread interface{}) bool {
+	mo.lock.Lock()
+	defer mo.lock.Unlock()
+
+	if mo.owner != thread {
+		return false
+	}
+	for len(mo.waitSet) > 0 {
+		mo.notifyOne()
+	}
+	return true
+}
+
+func (mo *Monitor) notifyOne() {
+	if len(mo.waitSet) > 0 {
+		w := mo.waitSet[0]
+		mo.waitSet = mo.waitSet[1:]
+		w.notified <- struct{}{}
+	}
+}
+
+// removeWaiter 把 w 从等待集中移除，w 已经不在等待集中（被通知过）时返回 false
+func (mo *Monitor) removeWaiter(w *waiter) bool {
+	for i, x := range mo.waitSet {
+		if x == w {
+			mo.waitSet = append(mo.waitSet[:i], mo.waitSet[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,7 @@
 package heap
 
+import "sync/atomic"
+
 type Object struct {
 	// 类
 	class *Class
@@ -7,14 +9,17 @@ type Object struct {
 	data interface{} // Slots for Object, []int32 for int[] ...
 	// 额外数据
 	extra interface{}
+	// 监视器，第一次使用时创建
+	monitor atomic.Pointer[Monitor]
 }
 
 // create normal (non-array) object
 func newObject(class *Class) *Object {
-	return &Object{
+	size := allocateObject(class.instanceSlotCount)
+	return track(&Object{
 		class: class,
 		data:  newSlots(class.instanceSlotCount),
-	}
+	}, size)
 }
 
 // getters & setters
@@ -34,6 +39,15 @@ func (ob *Object) SetExtra(extra interface{}) {
 	ob.extra = extra
 }
 
+// Monitor 返回对象的监视器，多个线程同时调用时只会创建一个
+func (ob *Object) Monitor() *Monitor {
+	if monitor := ob.monitor.Load(); monitor != nil {
+		return monitor
+	}
+	ob.monitor.CompareAndSwap(nil, newMonitor())
+	return ob.monitor.Load()
+}
+
 func (ob *Object) IsInstanceOf(class *Class) bool {
 	return class.IsAssignableFrom(ob.class)
 }
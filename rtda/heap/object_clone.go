@@ -1,10 +1,11 @@
 package heap
 
 func (ob *Object) Clone() *Object {
-	return &Object{
+	size := allocateClone(ob)
+	return track(&Object{
 		class: ob.class,
 		data:  ob.cloneData(),
-	}
+	}, size)
 }
 
 func (ob *Object) cloneData() interface{} {
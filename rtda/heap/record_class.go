@@ -0,0 +1,182 @@
+package heap
+
+import "strings"
+
+// defineRecordClass Java 8 的类库里没有 java.lang.Record，记录类的父类由启动类加载器生成：
+//
+//	public abstract class Record {
+//	    protected Record() {}
+//	    public abstract boolean equals(Object obj);
+//	    public abstract int hashCode();
+//	    public abstract String toString();
+//	}
+func (cl *ClassLoader) defineRecordClass() *Class {
+	class := &Class{
+		accessFlags:    ACC_PUBLIC | ACC_ABSTRACT | ACC_SUPER,
+		name:           "java/lang/Record",
+		superClassName: "java/lang/Object",
+		sourceFile:     "Record.java",
+	}
+	class.constantPool = &ConstantPool{class: class}
+
+	writer := &codeWriter{cp: class.constantPool}
+	writer.emit(0x2a)                                                                              // aload_0
+	writer.emitU2(0xb7, writer.addConst(writer.newMethodRef("java/lang/Object", "<init>", "()V"))) // invokespecial
+	writer.emit(0xb1)                                                                              // return
+	constructor := newSyntheticMethod(class, ACC_PROTECTED, "<init>", "()V")
+	constructor.code = writer.code
+	constructor.maxStack = 1
+	constructor.maxLocals = 1
+
+	class.methods = []*Method{
+		constructor,
+		newSyntheticMethod(class, ACC_PUBLIC|ACC_ABSTRACT, "equals", "(Ljava/lang/Object;)Z"),
+		newSyntheticMethod(class, ACC_PUBLIC|ACC_ABSTRACT, "hashCode", "()I"),
+		newSyntheticMethod(class, ACC_PUBLIC|ACC_ABSTRACT, "toString", "()Ljava/lang/String;"),
+	}
+	cl.defineClass(class)
+	link(class)
+	return class
+}
+
+func newSyntheticMethod(class *Class, accessFlags uint16, name, descriptor string) *Method {
+	method := &Method{}
+	method.class = class
+	method.accessFlags = accessFlags
+	method.name = name
+	method.descriptor = descriptor
+	method.parsedDescriptor = parseMethodDescriptor(descriptor)
+	method.calcArgSlotCount(method.parsedDescriptor.parameterTypes)
+	return method
+}
+
+// NewObjectMethodsClass 为记录类里 ObjectMethods.bootstrap 的调用点生成一个类。
+// 静态方法 name（toString、hashCode 或 equals）的描述符和调用点相同，
+// 按 fields 依次处理记录类的组件，结果和 java.lang.runtime.ObjectMethods 生成的方法相同
+func NewObjectMethodsClass(caller, recordClass *Class, name, descriptor string, names []string, fields []*Field) *Class {
+	return newSyntheticClass(caller, "ObjectMethods", "java/lang/Object", nil, nil, func(class *Class) []*Method {
+		method := newSyntheticMethod(class, ACC_STATIC|ACC_SYNTHETIC, name, descriptor)
+		writer := &codeWriter{cp: class.constantPool}
+		switch name {
+		case "toString":
+			writer.writeRecordToString(recordClass, names, fields)
+		case "hashCode":
+			writer.writeRecordHashCode(fields)
+		case "equals":
+			writer.writeRecordEquals(recordClass, fields)
+		}
+		method.code = writer.code
+		method.maxStack = 4
+		method.maxLocals = 3
+		return []*Method{method}
+	})
+}
+
+func (w *codeWriter) emitLdcString(s string) {
+	w.emitU2(0x13, w.addConst(s)) // ldc_w
+}
+
+// 记录类名[组件1=值1, 组件2=值2]，类名是不带外部类的简单名字
+func (w *codeWriter) writeRecordToString(recordClass *Class, names []string, fields []*Field) {
+	simpleName := recordClass.name[strings.LastIndexAny(recordClass.name, "/$")+1:]
+	builder := "java/lang/StringBuilder"
+	w.emitU2(0xbb, w.addConst(w.newClassRef(builder))) // new
+	w.emit(0x59)                                       // dup
+	w.emitLdcString(simpleName + "[")
+	w.emitU2(0xb7, w.addConst(w.newMethodRef(builder, "<init>", "(Ljava/lang/String;)V"))) // invokespecial
+	appendString := w.newMethodRef(builder, "append", "(Ljava/lang/String;)Ljava/lang/StringBuilder;")
+	for i, field := range fields {
+		prefix := names[i] + "="
+		if i > 0 {
+			prefix = ", " + prefix
+		}
+		w.emitLdcString(prefix)
+		w.emitU2(0xb6, w.addConst(appendString))         // invokevirtual
+		w.emit(0x2a)                                     // aload_0
+		w.emitU2(0xb4, w.addConst(w.newFieldRef(field))) // getfield
+		var argType string
+		switch field.descriptor {
+		case "Z", "C", "J", "F", "D", "Ljava/lang/String;":
+			argType = field.descriptor
+		case "B", "S", "I":
+			argType = "I"
+		default:
+			argType = "Ljava/lang/Object;"
+		}
+		appendValue := w.newMethodRef(builder, "append", "("+argType+")Ljava/lang/StringBuilder;")
+		w.emitU2(0xb6, w.addConst(appendValue)) // invokevirtual
+	}
+	w.emitLdcString("]")
+	w.emitU2(0xb6, w.addConst(appendString))                                                // invokevirtual
+	w.emitU2(0xb6, w.addConst(w.newMethodRef(builder, "toString", "()Ljava/lang/String;"))) // invokevirtual
+	w.emit(0xb0)                                                                            // areturn
+}
+
+// result = 31 * result + hash(c)，基本类型用包装类的静态 hashCode 方法，引用类型用 Objects.hashCode
+func (w *codeWriter) writeRecordHashCode(fields []*Field) {
+	w.emit(0x03) // iconst_0
+	for _, field := range fields {
+		w.emit(0x10, 31)                                 // bipush 31
+		w.emit(0x68)                                     // imul
+		w.emit(0x2a)                                     // aload_0
+		w.emitU2(0xb4, w.addConst(w.newFieldRef(field))) // getfield
+		var hashCode *MethodRef
+		if isPrimitiveDescriptor(field.descriptor) {
+			hashCode = w.newMethodRef(primitiveWrappers[field.descriptor], "hashCode", "("+field.descriptor+")I")
+		} else {
+			hashCode = w.newMethodRef("java/util/Objects", "hashCode", "(Ljava/lang/Object;)I")
+		}
+		w.emitU2(0xb8, w.addConst(hashCode)) // invokestatic
+		w.emit(0x60)                         // iadd
+	}
+	w.emit(0xac) // ireturn
+}
+
+// 参数 o 是同一个记录类的实例，并且每个组件都相等。
+// 浮点数用 Float.compare 和 Double.compare 比较，引用类型用 Objects.equals
+func (w *codeWriter) writeRecordEquals(recordClass *Class, fields []*Field) {
+	var branches []int // 跳到 return false 的分支指令的位置
+	branch := func(opcode byte) {
+		branches = append(branches, len(w.code))
+		w.emit(opcode, 0, 0)
+	}
+
+	recordClassRef := w.addConst(w.newClassRef(recordClass.name))
+	w.emit(0x2b)                   // aload_1
+	w.emitU2(0xc1, recordClassRef) // instanceof
+	branch(0x99)                   // ifeq
+	w.emit(0x2b)                   // aload_1
+	w.emitU2(0xc0, recordClassRef) // checkcast
+	w.emit(0x4d)                   // astore_2
+	for _, field := range fields {
+		fieldRef := w.addConst(w.newFieldRef(field))
+		w.emit(0x2a)             // aload_0
+		w.emitU2(0xb4, fieldRef) // getfield
+		w.emit(0x2c)             // aload_2
+		w.emitU2(0xb4, fieldRef) // getfield
+		switch field.descriptor {
+		case "J":
+			w.emit(0x94) // lcmp
+			branch(0x9a) // ifne
+		case "F", "D":
+			compare := w.newMethodRef(primitiveWrappers[field.descriptor], "compare",
+				"("+field.descriptor+field.descriptor+")I")
+			w.emitU2(0xb8, w.addConst(compare)) // invokestatic
+			branch(0x9a)                        // ifne
+		case "Z", "B", "C", "S", "I":
+			branch(0xa0) // if_icmpne
+		default:
+			equals := w.newMethodRef("java/util/Objects", "equals", "(Ljava/lang/Object;Ljava/lang/Object;)Z")
+			w.emitU2(0xb8, w.addConst(equals)) // invokestatic
+			branch(0x99)                       // ifeq
+		}
+	}
+	w.emit(0x04, 0xac) // iconst_1; ireturn
+	falsePc := len(w.code)
+	w.emit(0x03, 0xac) // iconst_0; ireturn
+	for _, pc := range branches {
+		offset := falsePc - pc
+		w.code[pc+1] = byte(offset >> 8)
+		w.code[pc+2] = byte(offset)
+	}
+}
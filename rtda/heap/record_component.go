@@ -0,0 +1,72 @@
+package heap
+
+import "jvm-go/classfile"
+
+// RecordComponent 记录类的一个组件，对应 Record 属性里的 record_component_info，jvms 4.7.30
+type RecordComponent struct {
+	class              *Class
+	name               string
+	descriptor         string
+	signature          string
+	annotationData     []byte // RuntimeVisibleAnnotations_attribute
+	typeAnnotationData []byte // RuntimeVisibleTypeAnnotations_attribute
+}
+
+func newRecordComponents(class *Class, cfComponents []*classfile.RecordComponentInfo) []*RecordComponent {
+	components := make([]*RecordComponent, len(cfComponents))
+	for i, cfComponent := range cfComponents {
+		components[i] = &RecordComponent{
+			class:      class,
+			name:       cfComponent.Name(),
+			descriptor: cfComponent.Descriptor(),
+			signature:  cfComponent.Signature(),
+		}
+		if attr := cfComponent.RuntimeVisibleAnnotationsAttribute(); attr != nil {
+			components[i].annotationData = attr.Info()
+		}
+		if attr := cfComponent.RuntimeVisibleTypeAnnotationsAttribute(); attr != nil {
+			components[i].typeAnnotationData = attr.Info()
+		}
+	}
+	return components
+}
+
+// getters
+func (rc *RecordComponent) Class() *Class {
+	return rc.class
+}
+func (rc *RecordComponent) Name() string {
+	return rc.name
+}
+func (rc *RecordComponent) Descriptor() string {
+	return rc.descriptor
+}
+func (rc *RecordComponent) Signature() string {
+	return rc.signature
+}
+func (rc *RecordComponent) AnnotationData() []byte {
+	return rc.annotationData
+}
+func (rc *RecordComponent) TypeAnnotationData() []byte {
+	return rc.typeAnnotationData
+}
+
+// Accessor 返回组件的访问方法，和组件同名、没有参数的公有实例方法
+func (rc *RecordComponent) Accessor() *Method {
+	for _, method := range rc.class.methods {
+		if !method.IsStatic() && method.name == rc.name && method.descriptor == "()"+rc.descriptor {
+			return method
+		}
+	}
+	return nil
+}
+
+// Field 返回组件对应的私有实例字段
+func (rc *RecordComponent) Field() *Field {
+	for _, field := range rc.class.fields {
+		if !field.IsStatic() && field.name == rc.name && field.descriptor == rc.descriptor {
+			return field
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package heap
+
+import (
+	"archive/zip"
+	"jvm-go/classpath"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// getResource 返回的 URL 要能用 ReadResourceURL 读回来
+func TestReadResourceURL(t *testing.T) {
+	dir := t.TempDir()
+	writeTestClasses(t, filepath.Join(dir, "classes"))
+	if err := os.MkdirAll(filepath.Join(dir, "classes", "res"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "classes", "res", "a.txt"), []byte("dir"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	jarPath := filepath.Join(dir, "lib.jar")
+	f, err := os.Create(jarPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zw := zip.NewWriter(f)
+	for name, data := range map[string]string{"res/a.txt": "jar", "res/b.txt": "jar b"} {
+		w, _ := zw.Create(name)
+		w.Write([]byte(data))
+	}
+	if err := zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+
+	cp := filepath.Join(dir, "classes") + string(os.PathListSeparator) + jarPath
+	NewClassLoader(classpath.Parse(filepath.Join(dir, "classes"), cp), false)
+
+	dirURL := ResourceURL("res/a.txt") // 目录在 jar 包前面
+	jarURL := ResourceURL("res/b.txt")
+	for _, test := range []struct {
+		spec string
+		data string // 为空表示不是类路径里的资源
+	}{
+		{dirURL, "dir"},
+		{jarURL, "jar b"},
+		{"jar:file:" + filepath.ToSlash(jarPath) + "!/res/a.txt", ""}, // 被目录里的同名资源覆盖
+		{"file:" + filepath.ToSlash(filepath.Join(dir, "lib.jar")), ""},
+		{"http://example.com/res/a.txt", ""},
+	} {
+		if data := ReadResourceURL(test.spec); string(data) != test.data {
+			t.Errorf("ReadResourceURL(%q) = %q, want %q", test.spec, data, test.data)
+		}
+	}
+	if dirURL[:5] != "file:" || jarURL[:8] != "jar:file" {
+		t.Errorf("ResourceURL = %q, %q", dirURL, jarURL)
+	}
+}
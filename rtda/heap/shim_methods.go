@@ -22,16 +22,80 @@ var (
 		},
 		code: _athrowCode,
 	}
+
+	// 初始化类的各个步骤由本地方法完成，局部变量依次是：
+	// 要初始化的类、已经完成的步骤、请求初始化的栈帧的 pc
+	_initClassMethod = &Method{
+		ClassMember: ClassMember{
+			accessFlags: ACC_STATIC | ACC_NATIVE,
+			name:        "<initClass>",
+			descriptor:  "()V",
+			class:       _shimClass,
+		},
+		maxLocals: 3,
+		code:      []byte{0xfe, 0xb1}, // invokenative, return
+	}
+
+	// 用户自定义类加载器用 Java 代码加载类，见 LoadRequest。局部变量依次是：
+	// java.lang.ClassLoader 对象、要加载的类名（java.lang.String）、已经完成的步骤、请求加载类的栈帧的 pc
+	_loadClassMethod = &Method{
+		ClassMember: ClassMember{
+			accessFlags: ACC_STATIC | ACC_NATIVE,
+			name:        "<loadClass>",
+			descriptor:  "()V",
+			class:       _shimClass,
+		},
+		maxStack:  2,
+		maxLocals: 4,
+		code:      []byte{0xfe, 0xb1}, // invokenative, return
+	}
+
+	// 字符串拼接调用点生成的类用 invokestatic 调用它，见 NewStringConcatClass。
+	// 参数依次是生成的类（java.lang.Class 对象）和已经转换成 String 的调用参数
+	_stringConcatMethod = &Method{
+		ClassMember: ClassMember{
+			accessFlags: ACC_STATIC | ACC_NATIVE,
+			name:        "<stringConcat>",
+			descriptor:  "(Ljava/lang/Class;[Ljava/lang/String;)Ljava/lang/String;",
+			class:       _shimClass,
+		},
+		maxStack:     1,
+		maxLocals:    2,
+		argSlotCount: 2,
+		code:         []byte{0xfe, 0xb0}, // invokenative, areturn
+	}
 )
 
+// shim 类没有 <clinit>，生成的代码可以直接调用它的静态方法
+func init() {
+	_shimClass.markInitialized()
+}
+
 func ShimReturnMethod() *Method {
 	return _returnMethod
 }
 
-//
-//func AthrowMethod() *Method {
-//	return _athrowMethod
-//}
+func ShimAthrowMethod() *Method {
+	return _athrowMethod
+}
+
+func ShimInitClassMethod() *Method {
+	return _initClassMethod
+}
+
+func ShimLoadClassMethod() *Method {
+	return _loadClassMethod
+}
+
+func ShimStringConcatMethod() *Method {
+	return _stringConcatMethod
+}
+
+// IsShimMethod 判断是不是 shim 栈帧的方法，shim 栈帧不会出现在异常的调用栈里
+func IsShimMethod(method *Method) bool {
+	return method.class == _shimClass
+}
+
 //
 //func BootstrapMethod() *Method {
 //	method := &Method{}
@@ -0,0 +1,58 @@
+package heap
+
+// NewStringConcatClass 为参数里有 String 以外的引用类型的字符串拼接调用点生成一个类。
+// 静态方法 concat 的描述符和调用点相同，它用 String.valueOf() 把参数都转换成 String 放进数组，
+// 再调用 shim 方法 <stringConcat>，由它按 recipe 拼接。recipe 由调用者解析，保存在生成的类里
+func NewStringConcatClass(caller *Class, descriptor string, recipe interface{}) *Class {
+	class := newSyntheticClass(caller, "StringConcat", "java/lang/Object", nil, nil, func(class *Class) []*Method {
+		concat := newSyntheticMethod(class, ACC_STATIC|ACC_SYNTHETIC, "concat", descriptor)
+		paramTypes := concat.parsedDescriptor.parameterTypes
+
+		writer := &codeWriter{cp: class.constantPool}
+		// 生成的类不在类加载器的 classMap 里，直接设置成已解析状态
+		classRef := writer.newClassRef(class.name)
+		classRef.class = class
+		writer.emitU2(0x13, writer.addConst(classRef)) // ldc_w
+		writer.emitSipush(len(paramTypes))
+		writer.emitU2(0xbd, writer.addConst(writer.newClassRef("java/lang/String"))) // anewarray
+		slot := uint(0)
+		for i, paramType := range paramTypes {
+			writer.emit(0x59) // dup
+			writer.emitSipush(i)
+			writer.emitLoad(paramType, slot)
+			slot += typeSlotCount(paramType)
+			if paramType != "Ljava/lang/String;" {
+				valueOf := writer.newMethodRef("java/lang/String", "valueOf",
+					"("+valueOfParameterType(paramType)+")Ljava/lang/String;")
+				writer.emitU2(0xb8, writer.addConst(valueOf)) // invokestatic
+			}
+			writer.emit(0x53) // aastore
+		}
+		shim := ShimStringConcatMethod()
+		writer.emitU2(0xb8, writer.addConst(writer.newResolvedMethodRef(shim.class, shim))) // invokestatic
+		writer.emit(0xb0)                                                                   // areturn
+
+		concat.code = writer.code
+		concat.maxStack = 6 // class, 数组, 数组, 索引, long 或 double
+		concat.maxLocals = concat.argSlotCount
+		return []*Method{concat}
+	})
+	class.concatRecipe = recipe
+	return class
+}
+
+func (w *codeWriter) emitSipush(n int) {
+	w.emit(0x11, byte(n>>8), byte(n)) // sipush
+}
+
+// valueOfParameterType 返回把 paramType 类型的值转换成字符串的 String.valueOf() 重载的参数类型
+func valueOfParameterType(paramType string) string {
+	switch paramType {
+	case "Z", "C", "I", "J", "F", "D":
+		return paramType
+	case "B", "S":
+		return "I"
+	default:
+		return "Ljava/lang/Object;"
+	}
+}
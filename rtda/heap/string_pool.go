@@ -1,23 +1,55 @@
 package heap
 
-import "unicode/utf16"
+import (
+	"sync"
+	"unicode/utf16"
+)
 
-var internedStrings = map[string]*Object{}
+var (
+	internedStrings     = map[string]*Object{}
+	internedStringsLock sync.Mutex // 多个 Java 线程会同时访问字符串池
+)
 
 // todo
 // go string -> java.lang.String
 func JString(loader *ClassLoader, goStr string) *Object {
+	return newInternedString(loader.LoadClass, goStr)
+}
+
+// internString 供类加载过程中使用，此时已经持有 classMapLock
+func internString(loader *ClassLoader, goStr string) *Object {
+	return newInternedString(loader.loadClass, goStr)
+}
+
+func newInternedString(loadClass func(name string) *Class, goStr string) *Object {
+	internedStringsLock.Lock()
+	internedStr, ok := internedStrings[goStr]
+	internedStringsLock.Unlock()
+	if ok {
+		return internedStr
+	}
+
+	jStr := newJString(loadClass, stringToUtf16(goStr))
+
+	internedStringsLock.Lock()
+	defer internedStringsLock.Unlock()
 	if internedStr, ok := internedStrings[goStr]; ok {
 		return internedStr
 	}
+	internedStrings[goStr] = jStr
+	return jStr
+}
 
-	chars := stringToUtf16(goStr)
-	jChars := &Object{loader.LoadClass("[C"), chars, nil}
+// NewJString 用 utf16 字符创建新的 java.lang.String，不放入字符串池
+func NewJString(loader *ClassLoader, chars []uint16) *Object {
+	return newJString(loader.LoadClass, chars)
+}
 
-	jStr := loader.LoadClass("java/lang/String").NewObject()
+func newJString(loadClass func(name string) *Class, chars []uint16) *Object {
+	size := allocateArray(uint(len(chars)), 2)
+	jChars := track(&Object{class: loadClass("[C"), data: chars}, size)
+	jStr := loadClass("java/lang/String").NewObject()
 	jStr.SetRefVar("value", "[C", jChars)
-
-	internedStrings[goStr] = jStr
 	return jStr
 }
 
@@ -42,6 +74,8 @@ func utf16ToString(s []uint16) string {
 // todo
 func InternString(jStr *Object) *Object {
 	goStr := GoString(jStr)
+	internedStringsLock.Lock()
+	defer internedStringsLock.Unlock()
 	if internedStr, ok := internedStrings[goStr]; ok {
 		return internedStr
 	}
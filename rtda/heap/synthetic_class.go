@@ -0,0 +1,48 @@
+package heap
+
+import (
+	"strconv"
+	"sync/atomic"
+)
+
+var syntheticClassCount atomic.Int32
+
+// newSyntheticClass 创建虚拟机内部生成的类（lambda 实现类、字符串拼接类等），类名是 caller$$suffix$序号。
+// 生成的类没有 <clinit>，创建时就是已初始化状态；常量池的 class 指向 caller，
+// 这样生成的代码能访问 caller 能访问的成员，包括它的私有方法。
+// fields 在准备阶段之前加入类；methods 在类准备好以后调用，生成的代码可以引用类和它的常量池。
+// 这个类不属于任何类加载器的 classMap，只能通过创建它的调用点使用
+func newSyntheticClass(caller *Class, suffix, superName string, interfaces []string,
+	fields []*Field, methods func(class *Class) []*Method) *Class {
+
+	loader := caller.loader
+	class := &Class{
+		accessFlags:    ACC_FINAL | ACC_SUPER | ACC_SYNTHETIC,
+		name:           caller.name + "$$" + suffix + "$" + strconv.Itoa(int(syntheticClassCount.Add(1))),
+		superClassName: superName,
+		interfaceNames: interfaces,
+		sourceFile:     "Unknown",
+		loader:         loader,
+	}
+	class.markInitialized()
+	class.constantPool = &ConstantPool{class: caller}
+
+	class.superClass = loader.LoadClass(superName)
+	class.interfaces = make([]*Class, len(interfaces))
+	for i, interfaceName := range interfaces {
+		class.interfaces[i] = loader.LoadClass(interfaceName)
+	}
+
+	for _, field := range fields {
+		field.class = class
+	}
+	class.fields = fields
+	prepare(class)
+
+	class.methods = methods(class)
+	buildMethodTables(class)
+
+	class.jClass = loader.LoadClass("java/lang/Class").NewObject()
+	class.jClass.extra = class
+	return class
+}
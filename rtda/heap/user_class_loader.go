@@ -0,0 +1,105 @@
+package heap
+
+import "fmt"
+
+// LoadRequest 用户自定义类加载器要加载一个它还没有记录的类。
+// 这需要执行 Java 代码 loader.loadClass(name)，但是类加载器是在解析符号引用的中途、
+// 持有 classMapLock 的时候加载类的，不能在这里执行 Java 代码。
+// 所以类加载器 panic 一个 *LoadRequest，由解释器撤销当前指令并压入调用 loadClass 的 shim 栈帧，
+// loadClass 返回后把类记录到类加载器里（AddLoadedClass），然后重新执行这条指令
+type LoadRequest struct {
+	Loader *ClassLoader
+	Name   string
+}
+
+// LoaderOf 返回 java.lang.ClassLoader 对象对应的类加载器，第一次使用时创建。
+// null 对应启动类加载器；sun.misc.Launcher 里的 ExtClassLoader 和 AppClassLoader
+// 对应虚拟机内置的扩展类加载器和应用类加载器；其他的都是用户自定义类加载器，各自有独立的命名空间。
+// 用户自定义类加载器的父加载器是启动类加载器，核心类直接由启动类加载器加载，其他的类交给 Java 代码加载
+func LoaderOf(jLoader *Object) *ClassLoader {
+	bootstrapLoader := builtinLoaders[BootstrapClassLoader]
+	if jLoader == nil {
+		return bootstrapLoader
+	}
+
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	if loader, ok := jLoader.extra.(*ClassLoader); ok {
+		return loader
+	}
+
+	var loader *ClassLoader
+	switch jLoader.class.name {
+	case "sun/misc/Launcher$ExtClassLoader":
+		loader = builtinLoaders[ExtensionClassLoader]
+	case "sun/misc/Launcher$AppClassLoader":
+		loader = builtinLoaders[ApplicationClassLoader]
+	}
+	if loader == nil || loader.jLoader != nil {
+		loader = &ClassLoader{
+			parent:      bootstrapLoader,
+			verboseFlag: bootstrapLoader.verboseFlag,
+			classMap:    make(map[string]*Class),
+			loaderType:  UserDefinedClassLoader,
+		}
+	}
+	loader.jLoader = jLoader
+	jLoader.extra = loader
+	return loader
+}
+
+func (cl *ClassLoader) LoaderType() int {
+	return cl.loaderType
+}
+
+// JLoader 返回类加载器对应的 java.lang.ClassLoader 对象。
+// 启动类加载器返回 nil，内置的扩展类加载器和应用类加载器在 Java 代码创建 Launcher 之前也返回 nil
+func (cl *ClassLoader) JLoader() *Object {
+	return cl.jLoader
+}
+
+// DefineClass 由 ClassLoader.defineClass 定义类，这个类加载器是类的定义类加载器。
+// name 为空时使用 class 文件里的类名，source 是 verbose 输出里显示的类的来源
+func (cl *ClassLoader) DefineClass(name string, data []byte, source string) *Class {
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+
+	class := cl.defineAndLinkClass(name, data)
+	cl.createJClass(class)
+
+	if cl.verboseFlag {
+		if source == "" {
+			source = "__JVM_DefineClass__"
+		}
+		fmt.Printf("[Loaded %s from %s by %s]\n", class.name, source, cl.getLoaderName())
+	}
+	return class
+}
+
+// FindLoadedClass 返回这个类加载器作为初始类加载器加载过的类，没有时返回 nil。
+// 内置的类加载器直接从类路径加载，这样 Launcher 里的类加载器就不用再自己读取和定义类路径上的类了
+func (cl *ClassLoader) FindLoadedClass(name string) *Class {
+	if cl.loaderType != UserDefinedClassLoader {
+		return cl.FindClass(name)
+	}
+	classMapLock.RLock()
+	defer classMapLock.RUnlock()
+	return cl.classMap[name]
+}
+
+// FindClass 和 LoadClass 一样加载类，但是找不到类时返回 nil。
+// 用户自定义类加载器还没有记录的类仍然要由 Java 代码加载（panic *LoadRequest）
+func (cl *ClassLoader) FindClass(name string) *Class {
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	return cl.loadClass(name)
+}
+
+// AddLoadedClass 把 Java 代码为 LoadRequest 加载的类记录到初始类加载器里
+func (cl *ClassLoader) AddLoadedClass(name string, class *Class) {
+	classMapLock.Lock()
+	defer classMapLock.Unlock()
+	if _, ok := cl.classMap[name]; !ok {
+		cl.addClass(name, class)
+	}
+}
@@ -0,0 +1,480 @@
+package heap
+
+import (
+	"fmt"
+	"jvm-go/classfile"
+	"strings"
+)
+
+// 字节码验证的范围，对应 -Xverify 选项
+const (
+	VerifyNone   = iota // 不验证
+	VerifyRemote        // 只验证不是由启动类加载器加载的类
+	VerifyAll           // 验证所有类
+)
+
+var verifyMode = VerifyRemote
+
+// SetVerifyMode 设置字节码验证的范围，必须在启动虚拟机之前调用
+func SetVerifyMode(mode int) {
+	verifyMode = mode
+}
+
+// vFrame 类型状态，也就是某条指令执行前局部变量表和操作数栈里每个位置的验证类型
+type vFrame struct {
+	locals     []vType
+	stack      []vType
+	thisUninit bool // flagThisUninit，构造函数还没有调用 super() 或 this()
+}
+
+func (f *vFrame) copy() *vFrame {
+	return &vFrame{
+		locals:     append([]vType(nil), f.locals...),
+		stack:      append([]vType(nil), f.stack...),
+		thisUninit: f.thisUninit,
+	}
+}
+
+// verifier 字节码验证器，有两种工作方式：
+//   - 类型检查，jvms 4.10.1。按指令顺序检查一遍字节码：每条指令根据当前类型状态算出下一个类型状态，
+//     跳转目标和异常处理器处的类型状态由 StackMapTable 给出，只需检查兼容性，不需要合并
+//   - 类型推导，jvms 4.10.2。用于没有 StackMapTable 的旧 class 文件，见 verifier_inference.go
+type verifier struct {
+	class     *Class
+	method    *Method
+	code      []byte
+	maxStack  int
+	maxLocals int
+	lengths   []int // 每个位置上的指令长度，不是指令开头的位置为 0
+	stackMaps map[int]*vFrame
+	frame     *vFrame // 当前指令执行前的类型状态
+	pc        int
+
+	inference   bool
+	frames      []*vFrame           // 类型推导得到的每条指令执行前的类型状态
+	queued      []bool              // 指令是否在 worklist 里
+	worklist    []int               // 类型状态有变化、需要重新推导的指令
+	subroutines map[int]*subroutine // 子程序入口 -> 子程序
+	jsrFrames   map[int]*vFrame     // jsr 指令执行前的类型状态
+}
+
+// verifyMethodCode 验证方法的字节码，出错时抛出 VerifyError。
+// 版本 50 及以上的 class 文件用 StackMapTable 做类型检查，更早的 class 文件用类型推导。
+// 和 HotSpot 一样，版本 50 的 class 文件类型检查失败时退回到类型推导
+func verifyMethodCode(method *Method) {
+	if method.IsAbstract() || method.IsNative() {
+		return
+	}
+	if method.code == nil {
+		panic("java.lang.ClassFormatError: Absent Code attribute in method " +
+			method.class.JavaName() + "." + method.name + method.descriptor)
+	}
+
+	switch version := method.class.majorVersion; {
+	case version < 50:
+		newVerifier(method, true).run()
+	case version == 50:
+		defer func() {
+			if r := recover(); r != nil {
+				if s, ok := r.(string); ok && strings.HasPrefix(s, "java.lang.VerifyError") {
+					newVerifier(method, true).run()
+					return
+				}
+				panic(r)
+			}
+		}()
+		fallthrough
+	default:
+		newVerifier(method, false).run()
+	}
+}
+
+func newVerifier(method *Method, inference bool) *verifier {
+	return &verifier{
+		class:     method.class,
+		method:    method,
+		code:      method.code,
+		maxStack:  int(method.maxStack),
+		maxLocals: int(method.maxLocals),
+		inference: inference,
+	}
+}
+
+func (v *verifier) run() {
+	defer func() {
+		if r := recover(); r != nil {
+			switch r.(type) {
+			case string, *LoadRequest: // 用户自定义类加载器加载类之后会重新验证
+				panic(r)
+			}
+			// 验证器本身的问题也不能让虚拟机崩溃
+			v.fail("%v", r)
+		}
+	}()
+	v.verify()
+}
+
+func (v *verifier) fail(format string, args ...interface{}) {
+	panic(fmt.Sprintf("java.lang.VerifyError: %s (%s.%s%s @%d)", fmt.Sprintf(format, args...),
+		v.class.JavaName(), v.method.name, v.method.descriptor, v.pc))
+}
+
+func (v *verifier) verify() {
+	if len(v.code) == 0 || len(v.code) > 65535 {
+		v.fail("Invalid code length %d", len(v.code))
+	}
+	v.findInstructions()
+	if v.inference {
+		v.checkExceptionTable()
+		v.inferTypes()
+	} else {
+		v.decodeStackMaps(v.initialFrame())
+		v.checkExceptionTable()
+		v.checkTypes()
+	}
+}
+
+func (v *verifier) checkTypes() {
+	frame := v.initialFrame()
+	fallThrough := true
+	for pc := 0; pc < len(v.code); pc += v.lengths[pc] {
+		v.pc = pc
+		if stackMap := v.stackMaps[pc]; stackMap != nil {
+			if fallThrough && !v.isFrameAssignable(frame, stackMap) {
+				v.fail("Instruction type does not match stack map")
+			}
+			frame = stackMap.copy()
+		} else if !fallThrough {
+			v.fail("Expecting a stackmap frame at branch target %d", pc)
+		}
+		v.frame = frame
+		v.checkHandlers()
+		fallThrough = v.execute()
+	}
+	if fallThrough {
+		v.fail("Falling off the end of the code")
+	}
+}
+
+// findInstructions 找出所有指令的开始位置，同时检查操作码是否合法
+func (v *verifier) findInstructions() {
+	v.lengths = make([]int, len(v.code))
+	for pc := 0; pc < len(v.code); {
+		v.pc = pc
+		n := instructionLength(v.code, pc)
+		if n <= 0 {
+			v.fail("Bad instruction: %#x", v.code[pc])
+		}
+		if pc+n > len(v.code) {
+			v.fail("Instruction truncated")
+		}
+		v.lengths[pc] = n
+		pc += n
+	}
+	v.pc = 0
+}
+
+// instructionLength 返回 pc 处指令的长度，非法的操作码返回 0
+func instructionLength(code []byte, pc int) int {
+	switch op := code[pc]; {
+	case op <= 0x0f, op >= 0x1a && op <= 0x35, op >= 0x3b && op <= 0x83, op >= 0x85 && op <= 0x98,
+		op >= 0xac && op <= 0xb1, op == 0xbe, op == 0xbf, op == 0xc2, op == 0xc3:
+		return 1
+	case op == 0x10, op == 0x12, op >= 0x15 && op <= 0x19, op >= 0x36 && op <= 0x3a, op == 0xa9, op == 0xbc:
+		return 2
+	case op == 0x11, op == 0x13, op == 0x14, op == 0x84, op >= 0x99 && op <= 0xa8, op >= 0xb2 && op <= 0xb8,
+		op == 0xbb, op == 0xbd, op == 0xc0, op == 0xc1, op == 0xc6, op == 0xc7:
+		return 3
+	case op == 0xc5: // multianewarray
+		return 4
+	case op == 0xb9, op == 0xba, op == 0xc8, op == 0xc9:
+		return 5
+	case op == 0xaa: // tableswitch
+		p := switchOperands(pc)
+		if p+12 > len(code) {
+			return 0
+		}
+		low, high := int64(readS4(code, p+4)), int64(readS4(code, p+8))
+		if low > high || high-low+1 > int64(len(code)) {
+			return 0
+		}
+		return p + 12 + int(high-low+1)*4 - pc
+	case op == 0xab: // lookupswitch
+		p := switchOperands(pc)
+		if p+8 > len(code) {
+			return 0
+		}
+		npairs := int64(readS4(code, p+4))
+		if npairs < 0 || npairs > int64(len(code)) {
+			return 0
+		}
+		return p + 8 + int(npairs)*8 - pc
+	case op == 0xc4: // wide
+		if pc+1 >= len(code) {
+			return 0
+		}
+		switch op := code[pc+1]; {
+		case op >= 0x15 && op <= 0x19, op >= 0x36 && op <= 0x3a, op == 0xa9:
+			return 4
+		case op == 0x84:
+			return 6
+		}
+	}
+	return 0
+}
+
+// switchOperands 返回 tableswitch 和 lookupswitch 的操作数开始的位置，操作码后面有 0~3 字节的填充
+func switchOperands(pc int) int {
+	return (pc + 4) &^ 3
+}
+
+func readU1(code []byte, pc int) int {
+	return int(code[pc])
+}
+func readU2(code []byte, pc int) int {
+	return int(code[pc])<<8 | int(code[pc+1])
+}
+func readS2(code []byte, pc int) int {
+	return int(int16(readU2(code, pc)))
+}
+func readS4(code []byte, pc int) int32 {
+	return int32(uint32(code[pc])<<24 | uint32(code[pc+1])<<16 | uint32(code[pc+2])<<8 | uint32(code[pc+3]))
+}
+
+// initialFrame 根据方法描述符得到方法入口处的类型状态
+func (v *verifier) initialFrame() *vFrame {
+	frame := &vFrame{locals: make([]vType, v.maxLocals)}
+	for i, t := range v.initialLocals() {
+		if i >= v.maxLocals {
+			v.fail("Arguments can't fit into locals")
+		}
+		frame.locals[i] = t
+	}
+	frame.thisUninit = v.method.name == "<init>" && v.class.name != "java/lang/Object"
+	return frame
+}
+
+// initialLocals 返回方法参数（包括 this）占用的局部变量，long 和 double 后面跟着 top
+func (v *verifier) initialLocals() []vType {
+	var locals []vType
+	if !v.method.IsStatic() {
+		if v.method.name == "<init>" && v.class.name != "java/lang/Object" {
+			locals = append(locals, vUninitializedThis)
+		} else {
+			locals = append(locals, refType(v.class.name))
+		}
+	}
+	for _, paramType := range v.method.parsedDescriptor.parameterTypes {
+		t := vTypeOf(paramType)
+		locals = append(locals, t)
+		if t.isCategory2() {
+			locals = append(locals, vTop)
+		}
+	}
+	return locals
+}
+
+// decodeStackMaps 把 StackMapTable 里压缩过的帧还原成完整的类型状态，jvms 4.7.4
+func (v *verifier) decodeStackMaps(initial *vFrame) {
+	v.stackMaps = make(map[int]*vFrame)
+	stackMapTable := v.method.stackMapTable
+	if stackMapTable == nil {
+		return
+	}
+
+	// 帧里的局部变量是不展开的形式，long 和 double 只算一项
+	var locals []vType
+	for i := 0; i < len(initial.locals); i++ {
+		if t := initial.locals[i]; t.tag != vtTop {
+			locals = append(locals, t)
+			if t.isCategory2() {
+				i++
+			}
+		}
+	}
+
+	offset := -1
+	for _, entry := range stackMapTable.Entries() {
+		offset += int(entry.OffsetDelta()) + 1
+		v.pc = offset
+
+		var stack []vType
+		switch frameType := entry.FrameType(); {
+		case frameType < classfile.SAME_LOCALS_1_STACK_ITEM, frameType == classfile.SAME_FRAME_EXTENDED:
+		case frameType < classfile.CHOP_FRAME:
+			stack = v.vTypesOf(entry.Stack())
+		case frameType < classfile.SAME_FRAME_EXTENDED:
+			k := classfile.SAME_FRAME_EXTENDED - int(frameType)
+			if k > len(locals) {
+				v.fail("StackMapTable error: chop frame removes too many locals")
+			}
+			locals = locals[:len(locals)-k]
+		case frameType < classfile.FULL_FRAME:
+			locals = append(append([]vType(nil), locals...), v.vTypesOf(entry.Locals())...)
+		default:
+			locals = v.vTypesOf(entry.Locals())
+			stack = v.vTypesOf(entry.Stack())
+		}
+
+		if offset >= len(v.code) || v.lengths[offset] == 0 {
+			v.fail("StackMapTable error: bad offset")
+		}
+		v.stackMaps[offset] = v.expandFrame(locals, stack)
+	}
+	v.pc = 0
+}
+
+func (v *verifier) vTypesOf(infos []*classfile.VerificationTypeInfo) []vType {
+	types := make([]vType, len(infos))
+	for i, info := range infos {
+		switch info.Tag() {
+		case classfile.ITEM_Top:
+			types[i] = vTop
+		case classfile.ITEM_Integer:
+			types[i] = vInt
+		case classfile.ITEM_Float:
+			types[i] = vFloat
+		case classfile.ITEM_Long:
+			types[i] = vLong
+		case classfile.ITEM_Double:
+			types[i] = vDouble
+		case classfile.ITEM_Null:
+			types[i] = vNull
+		case classfile.ITEM_UninitializedThis:
+			types[i] = vUninitializedThis
+		case classfile.ITEM_Object:
+			types[i] = refType(info.ClassName())
+		case classfile.ITEM_Uninitialized:
+			offset := int(info.Offset())
+			if offset >= len(v.code) || v.lengths[offset] == 0 || v.code[offset] != 0xbb {
+				v.fail("StackMapTable error: bad uninitialized type offset %d", offset)
+			}
+			types[i] = uninitializedType(offset)
+		}
+	}
+	return types
+}
+
+// expandFrame 把 long 和 double 展开成两项，并用 top 补齐局部变量表
+func (v *verifier) expandFrame(locals, stack []vType) *vFrame {
+	frame := &vFrame{
+		locals: make([]vType, 0, v.maxLocals),
+	}
+	for _, t := range locals {
+		frame.locals = append(frame.locals, t)
+		if t.isCategory2() {
+			frame.locals = append(frame.locals, vTop)
+		}
+		if t.tag == vtUninitializedThis {
+			frame.thisUninit = true
+		}
+	}
+	if len(frame.locals) > v.maxLocals {
+		v.fail("StackMapTable error: local size too big")
+	}
+	for len(frame.locals) < v.maxLocals {
+		frame.locals = append(frame.locals, vTop)
+	}
+
+	for _, t := range stack {
+		frame.stack = append(frame.stack, t)
+		if t.isCategory2() {
+			frame.stack = append(frame.stack, vTop)
+		}
+	}
+	if len(frame.stack) > v.maxStack {
+		v.fail("StackMapTable error: stack size too big")
+	}
+	return frame
+}
+
+// isFrameAssignable 判断类型状态 from 能不能流向类型状态 to，jvms 4.10.1.4
+func (v *verifier) isFrameAssignable(from, to *vFrame) bool {
+	if len(from.stack) != len(to.stack) {
+		return false
+	}
+	if from.thisUninit && !to.thisUninit {
+		return false
+	}
+	for i := range from.locals {
+		if !v.isAssignable(from.locals[i], to.locals[i]) {
+			return false
+		}
+	}
+	for i := range from.stack {
+		if !v.isAssignable(from.stack[i], to.stack[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// checkExceptionTable 检查异常处理表里的位置，处理器的位置必须有 StackMapTable 帧
+func (v *verifier) checkExceptionTable() {
+	for _, handler := range v.method.exceptionTable {
+		if handler.startPc >= handler.endPc || v.lengths[handler.startPc] == 0 ||
+			handler.endPc < len(v.code) && v.lengths[handler.endPc] == 0 || handler.endPc > len(v.code) {
+			v.fail("Illegal exception table range")
+		}
+		if handler.handlerPc >= len(v.code) || v.lengths[handler.handlerPc] == 0 {
+			v.fail("Illegal exception table handler")
+		}
+		if !v.inference && v.stackMaps[handler.handlerPc] == nil {
+			v.fail("Expecting a stackmap frame at exception handler %d", handler.handlerPc)
+		}
+		if handler.catchType != nil && !v.isAssignable(refType(handler.catchType.className), vThrowable) {
+			v.fail("Catch type is not a subclass of Throwable in exception handler %d", handler.handlerPc)
+		}
+	}
+}
+
+// checkHandlers 检查当前指令抛出异常时，类型状态能不能流向覆盖它的异常处理器
+func (v *verifier) checkHandlers() {
+	for _, handler := range v.method.exceptionTable {
+		if v.pc < handler.startPc || v.pc >= handler.endPc {
+			continue
+		}
+		catchType := vThrowable
+		if handler.catchType != nil {
+			catchType = refType(handler.catchType.className)
+		}
+		exFrame := &vFrame{
+			locals:     v.frame.locals,
+			stack:      []vType{catchType},
+			thisUninit: v.frame.thisUninit,
+		}
+		if v.maxStack < 1 {
+			v.fail("Operand stack overflow")
+		}
+		if v.inference {
+			v.mergeInto(handler.handlerPc, exFrame)
+		} else if !v.isFrameAssignable(exFrame, v.stackMaps[handler.handlerPc]) {
+			v.fail("Stack map does not match the one at exception handler %d", handler.handlerPc)
+		}
+	}
+}
+
+// checkBranch 检查跳转目标，跳转时的类型状态必须能流向目标处的 StackMapTable 帧。
+// 类型推导时则把类型状态合并到目标指令
+func (v *verifier) checkBranch(offset int) {
+	target := v.branchTarget(offset)
+	if v.inference {
+		v.mergeInto(target, v.frame)
+		return
+	}
+	stackMap := v.stackMaps[target]
+	if stackMap == nil {
+		v.fail("Expecting a stackmap frame at branch target %d", target)
+	}
+	if !v.isFrameAssignable(v.frame, stackMap) {
+		v.fail("Inconsistent stackmap frames at branch target %d", target)
+	}
+}
+
+func (v *verifier) branchTarget(offset int) int {
+	target := v.pc + offset
+	if target < 0 || target >= len(v.code) || v.lengths[target] == 0 {
+		v.fail("Illegal target of jump or branch")
+	}
+	return target
+}
@@ -0,0 +1,298 @@
+package heap
+
+// 类型推导验证，jvms 4.10.2
+// 没有 StackMapTable 的旧 class 文件用数据流分析推导每条指令处的类型状态：
+// 从方法入口开始，把每条指令执行后的类型状态合并到它的后继指令，直到所有指令的类型状态都不再变化。
+// 引用类型合并成最近的公共父类，其他不同的类型合并成 top
+
+// subroutine jsr 调用的子程序，jvms 4.10.2.5
+// ret 之后，子程序修改过的局部变量使用 ret 处的类型，其余的局部变量保持调用者在 jsr 处的类型
+type subroutine struct {
+	callers  []int   // 调用子程序的 jsr 指令
+	modified []bool  // 子程序修改过的局部变量
+	retFrame *vFrame // ret 指令执行前的类型状态，还没有推导到 ret 时为 nil
+}
+
+func (v *verifier) inferTypes() {
+	v.frames = make([]*vFrame, len(v.code))
+	v.queued = make([]bool, len(v.code))
+	v.subroutines = make(map[int]*subroutine)
+	v.jsrFrames = make(map[int]*vFrame)
+
+	v.mergeInto(0, v.initialFrame())
+	for len(v.worklist) > 0 {
+		pc := v.worklist[len(v.worklist)-1]
+		v.worklist = v.worklist[:len(v.worklist)-1]
+		v.queued[pc] = false
+
+		v.pc = pc
+		v.frame = v.frames[pc].copy()
+		v.checkHandlers()
+		if v.execute() {
+			v.checkHandlers() // store 指令修改了局部变量
+			next := pc + v.lengths[pc]
+			if next >= len(v.code) {
+				v.fail("Falling off the end of the code")
+			}
+			v.mergeInto(next, v.frame)
+		}
+	}
+}
+
+// mergeInto 把类型状态合并到 pc 处的指令，有变化时重新推导这条指令
+func (v *verifier) mergeInto(pc int, frame *vFrame) {
+	if old := v.frames[pc]; old == nil {
+		v.frames[pc] = frame.copy()
+	} else if merged, changed := v.mergeFrames(old, frame); changed {
+		v.frames[pc] = merged
+	} else {
+		return
+	}
+	if !v.queued[pc] {
+		v.queued[pc] = true
+		v.worklist = append(v.worklist, pc)
+	}
+}
+
+func (v *verifier) mergeFrames(old, frame *vFrame) (*vFrame, bool) {
+	if len(old.stack) != len(frame.stack) {
+		v.fail("Inconsistent stack height %d != %d", len(old.stack), len(frame.stack))
+	}
+
+	merged := old.copy()
+	changed := false
+	for i := range old.locals {
+		if t := v.mergeTypes(old.locals[i], frame.locals[i]); t != old.locals[i] {
+			merged.locals[i] = t
+			changed = true
+		}
+	}
+	for i := range old.stack {
+		t := v.mergeTypes(old.stack[i], frame.stack[i])
+		if t.tag == vtTop && (old.stack[i].tag != vtTop || frame.stack[i].tag != vtTop) {
+			v.fail("Mismatched stack types %s and %s", old.stack[i], frame.stack[i])
+		}
+		if t != old.stack[i] {
+			merged.stack[i] = t
+			changed = true
+		}
+	}
+	if frame.thisUninit && !old.thisUninit {
+		merged.thisUninit = true
+		changed = true
+	}
+	return merged, changed
+}
+
+func (v *verifier) mergeTypes(a, b vType) vType {
+	if a == b {
+		return a
+	}
+	if (a.tag == vtReference || a.tag == vtNull) && (b.tag == vtReference || b.tag == vtNull) {
+		if a.tag == vtNull {
+			return b
+		}
+		if b.tag == vtNull {
+			return a
+		}
+		return refType(v.commonSuperClass(a.name, b.name))
+	}
+	return vTop
+}
+
+// commonSuperClass 返回两个引用类型最近的公共父类，和 isJavaAssignable 一样把接口当作 Object
+func (v *verifier) commonSuperClass(a, b string) string {
+	if a[0] == '[' && b[0] == '[' {
+		componentA, componentB := a[1:], b[1:]
+		if (componentA[0] == 'L' || componentA[0] == '[') && (componentB[0] == 'L' || componentB[0] == '[') {
+			return getArrayClassName(v.commonSuperClass(toClassName(componentA), toClassName(componentB)))
+		}
+		return "java/lang/Object"
+	}
+	if a[0] == '[' || b[0] == '[' {
+		return "java/lang/Object"
+	}
+
+	classA, classB := v.loadClass(a), v.loadClass(b)
+	if classA.IsInterface() || classB.IsInterface() {
+		return "java/lang/Object"
+	}
+	for super := classB; super != nil; super = super.superClass {
+		for c := classA; c != nil; c = c.superClass {
+			if c == super {
+				return super.name
+			}
+		}
+	}
+	return "java/lang/Object"
+}
+
+// jsr 把返回地址压栈后跳转到子程序
+func (v *verifier) jsr(offset int) {
+	if !v.inference {
+		v.fail("Illegal instruction jsr in class file with StackMapTable")
+	}
+	target := v.branchTarget(offset)
+	sub := v.subroutineAt(target)
+	if !containsInt(sub.callers, v.pc) {
+		sub.callers = append(sub.callers, v.pc)
+	}
+	v.jsrFrames[v.pc] = v.frame.copy()
+
+	v.push(vType{tag: vtReturnAddress, offset: target})
+	v.mergeInto(target, v.frame)
+	if sub.retFrame != nil {
+		v.returnFrom(sub, v.pc)
+	}
+}
+
+// ret 从子程序返回到所有调用者的下一条指令
+func (v *verifier) ret(index int) {
+	if !v.inference {
+		v.fail("Illegal instruction ret in class file with StackMapTable")
+	}
+	v.checkLocal(index, vInt)
+	returnAddress := v.frame.locals[index]
+	if returnAddress.tag != vtReturnAddress {
+		v.fail("Bad local variable type: expected returnAddress, found %s", returnAddress)
+	}
+
+	sub := v.subroutines[returnAddress.offset]
+	sub.retFrame = v.frame.copy()
+	for _, caller := range sub.callers {
+		v.returnFrom(sub, caller)
+	}
+}
+
+func (v *verifier) returnFrom(sub *subroutine, caller int) {
+	frame := v.jsrFrames[caller].copy()
+	frame.stack = append([]vType(nil), sub.retFrame.stack...)
+	frame.thisUninit = sub.retFrame.thisUninit
+	for i, modified := range sub.modified {
+		if modified {
+			frame.locals[i] = sub.retFrame.locals[i]
+		}
+	}
+
+	next := caller + v.lengths[caller]
+	if next >= len(v.code) {
+		v.fail("Falling off the end of the code")
+	}
+	v.mergeInto(next, frame)
+}
+
+// subroutineAt 返回 entry 处的子程序。
+// 第一次遇到时从入口开始找出子程序里的所有指令，记录它修改的局部变量，嵌套调用的子程序修改的也算
+func (v *verifier) subroutineAt(entry int) *subroutine {
+	if sub := v.subroutines[entry]; sub != nil {
+		return sub
+	}
+	sub := &subroutine{modified: make([]bool, v.maxLocals)}
+	v.subroutines[entry] = sub
+
+	visited := make([]bool, len(v.code))
+	todo := []int{entry}
+	for len(todo) > 0 {
+		pc := todo[len(todo)-1]
+		todo = todo[:len(todo)-1]
+		if visited[pc] {
+			continue
+		}
+		visited[pc] = true
+
+		if index, category2, ok := storedLocal(v.code, pc); ok {
+			for i := index; i < v.maxLocals && i <= index+category2; i++ {
+				sub.modified[i] = true
+			}
+		}
+		if op := v.code[pc]; op == 0xa8 || op == 0xc9 { // jsr, jsr_w
+			if nested := v.branchTargets(pc)[0]; nested != entry {
+				for i, modified := range v.subroutineAt(nested).modified {
+					sub.modified[i] = sub.modified[i] || modified
+				}
+			}
+		}
+		todo = append(todo, v.successors(pc)...)
+	}
+	return sub
+}
+
+// successors 返回查找子程序指令时 pc 处指令的后继，jsr 的后继是它的下一条指令
+func (v *verifier) successors(pc int) []int {
+	next := pc + v.lengths[pc]
+	var successors []int
+	switch op := v.code[pc]; {
+	case op == 0xa9, op >= 0xac && op <= 0xb1, op == 0xbf: // ret, return, athrow
+	case op == 0xc4 && v.code[pc+1] == 0xa9: // wide ret
+	case op == 0xa7, op == 0xc8, op == 0xaa, op == 0xab: // goto, goto_w, tableswitch, lookupswitch
+		successors = v.branchTargets(pc)
+	case op >= 0x99 && op <= 0xa6, op == 0xc6, op == 0xc7: // if<cond>
+		successors = append(v.branchTargets(pc), next)
+	default:
+		successors = []int{next}
+	}
+
+	valid := successors[:0]
+	for _, target := range successors {
+		if target >= 0 && target < len(v.code) && v.lengths[target] != 0 {
+			valid = append(valid, target)
+		}
+	}
+	return valid
+}
+
+// branchTargets 返回跳转指令的所有跳转目标，不检查目标是否合法
+func (v *verifier) branchTargets(pc int) []int {
+	code := v.code
+	switch op := code[pc]; op {
+	case 0xc8, 0xc9: // goto_w, jsr_w
+		return []int{pc + int(readS4(code, pc+1))}
+	case 0xaa: // tableswitch
+		p := switchOperands(pc)
+		targets := []int{pc + int(readS4(code, p))}
+		low, high := readS4(code, p+4), readS4(code, p+8)
+		for i := 0; i < int(high-low)+1; i++ {
+			targets = append(targets, pc+int(readS4(code, p+12+i*4)))
+		}
+		return targets
+	case 0xab: // lookupswitch
+		p := switchOperands(pc)
+		targets := []int{pc + int(readS4(code, p))}
+		for i := 0; i < int(readS4(code, p+4)); i++ {
+			targets = append(targets, pc+int(readS4(code, p+12+i*8)))
+		}
+		return targets
+	default:
+		return []int{pc + readS2(code, pc+1)}
+	}
+}
+
+// storedLocal 如果 pc 处是 store 指令，返回它写入的局部变量，long 和 double 占两个
+func storedLocal(code []byte, pc int) (index, category2 int, ok bool) {
+	switch op := code[pc]; {
+	case op >= 0x36 && op <= 0x3a: // <t>store
+		return readU1(code, pc+1), storeCategory2(op - 0x36), true
+	case op >= 0x3b && op <= 0x4e: // <t>store_<n>
+		return int(op-0x3b) % 4, storeCategory2((op - 0x3b) / 4), true
+	case op == 0xc4 && code[pc+1] >= 0x36 && code[pc+1] <= 0x3a: // wide <t>store
+		return readU2(code, pc+2), storeCategory2(code[pc+1] - 0x36), true
+	}
+	return 0, 0, false
+}
+
+// storeCategory2 按 i、l、f、d、a 的顺序，lstore 和 dstore 写入两个局部变量
+func storeCategory2(i uint8) int {
+	if i == 1 || i == 3 {
+		return 1
+	}
+	return 0
+}
+
+func containsInt(s []int, x int) bool {
+	for _, y := range s {
+		if y == x {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,223 @@
+package heap
+
+import (
+	"jvm-go/classfile"
+	"testing"
+)
+
+// withVerifyMode 在 mode 下执行 f，结束后恢复默认的验证范围
+func withVerifyMode(mode int, f func()) {
+	defer SetVerifyMode(VerifyRemote)
+	SetVerifyMode(mode)
+	f()
+}
+
+// 版本 49 的 class 文件没有 StackMapTable，用类型推导验证
+func TestVerifyTypeInference(t *testing.T) {
+	tests := []struct {
+		name       string
+		flags      uint16
+		methodName string
+		descriptor string
+		gen        func(code *classfile.CodeBuilder)
+		err        string // 为空表示验证通过
+	}{
+		{"subroutine modifies local", ACC_STATIC, "m", "()I", func(code *classfile.CodeBuilder) {
+			sub := code.NewLabel()
+			code.Branch(0xa8, sub) // jsr
+			code.Load('I', 1)      // 子程序把局部变量 1 改成了 int
+			code.Op(0xac)          // ireturn
+			code.Mark(sub)
+			code.Store('L', 0) // 返回地址
+			code.Op(0x08)      // iconst_5
+			code.Store('I', 1)
+			code.OpU1(0xa9, 0) // ret 0
+		}, ""},
+		{"subroutine keeps caller's locals", ACC_STATIC, "m", "()F", func(code *classfile.CodeBuilder) {
+			sub := code.NewLabel()
+			code.Op(0x0b) // fconst_0
+			code.Store('F', 1)
+			code.Branch(0xa8, sub) // jsr
+			code.Load('F', 1)      // 子程序没有修改局部变量 1，仍然是调用者的 float
+			code.Op(0xae)          // freturn
+			code.Mark(sub)
+			code.Store('L', 0)
+			code.OpU1(0xa9, 0) // ret 0
+		}, ""},
+		{"nested subroutines and wide ret", ACC_STATIC, "m", "()I", func(code *classfile.CodeBuilder) {
+			outer, inner := code.NewLabel(), code.NewLabel()
+			code.Branch(0xa8, outer) // jsr
+			code.Branch(0xa8, outer) // jsr，同一个子程序有两个调用者
+			code.Load('I', 2)        // 内层子程序修改的局部变量也算外层子程序修改的
+			code.Op(0xac)            // ireturn
+			code.Mark(outer)
+			code.Store('L', 0)
+			code.Branch(0xa8, inner) // jsr
+			code.OpU1(0xa9, 0)       // ret 0
+			code.Mark(inner)
+			code.Emit(0xc4, 0x3a, 0x01, 0x00) // wide astore 256
+			code.Op(0x10)                     // bipush
+			code.Op(7)
+			code.Store('I', 2)
+			code.Emit(0xc4, 0xa9, 0x01, 0x00) // wide ret 256
+		}, ""},
+		{"ret of a non returnAddress", ACC_STATIC, "m", "()V", func(code *classfile.CodeBuilder) {
+			code.Op(0x03) // iconst_0
+			code.Store('I', 0)
+			code.OpU1(0xa9, 0) // ret 0
+		}, "Bad local variable type: expected returnAddress, found integer (test.Check.m()V @2)"},
+		{"subroutine returns with different stack", ACC_STATIC, "m", "()V", func(code *classfile.CodeBuilder) {
+			sub, join := code.NewLabel(), code.NewLabel()
+			code.Branch(0xa8, sub) // jsr，返回时栈上多了一个 int
+			code.Branch(0xa7, join)
+			code.Mark(join)
+			code.Op(0xb1) // return
+			code.Mark(sub)
+			code.Store('L', 0)
+			code.Op(0x03)      // iconst_0
+			code.OpU1(0xa9, 0) // ret 0
+		}, ""},
+		{"merge to common superclass", ACC_STATIC, "m", "(Ltest/Dog;Ltest/Cat;I)V", func(code *classfile.CodeBuilder) {
+			cat, join := code.NewLabel(), code.NewLabel()
+			code.Load('I', 2)
+			code.Branch(0x99, cat) // ifeq
+			code.Load('L', 0)
+			code.Branch(0xa7, join) // goto
+			code.Mark(cat)
+			code.Load('L', 1)
+			code.Mark(join)                                  // 栈顶合并成 test/Animal
+			code.Invoke(0xb6, "test/Animal", "speak", "()V") // invokevirtual
+			code.Op(0xb1)                                    // return
+		}, ""},
+		{"merged type is not the subclass", ACC_STATIC, "m", "(Ltest/Dog;Ltest/Cat;I)V", func(code *classfile.CodeBuilder) {
+			cat, join := code.NewLabel(), code.NewLabel()
+			code.Load('I', 2)
+			code.Branch(0x99, cat) // ifeq
+			code.Load('L', 0)
+			code.Store('L', 3)
+			code.Branch(0xa7, join) // goto
+			code.Mark(cat)
+			code.Load('L', 1)
+			code.Store('L', 3)
+			code.Mark(join) // pc 11，局部变量 3 合并成 test/Animal
+			code.Load('L', 3)
+			code.Invoke(0xb8, "test/Check", "takeDog", "(Ltest/Dog;)V") // invokestatic
+			code.Op(0xb1)                                               // return
+		}, "Bad type on operand stack: expected 'test/Dog', found 'test/Animal' (test.Check.m(Ltest/Dog;Ltest/Cat;I)V @12)"},
+		{"merge null and reference", ACC_STATIC, "m", "(Ltest/Dog;I)Ltest/Dog;", func(code *classfile.CodeBuilder) {
+			null, join := code.NewLabel(), code.NewLabel()
+			code.Load('I', 1)
+			code.Branch(0x99, null) // ifeq
+			code.Load('L', 0)
+			code.Branch(0xa7, join) // goto
+			code.Mark(null)
+			code.Op(0x01) // aconst_null
+			code.Mark(join)
+			code.Op(0xb0) // areturn
+		}, ""},
+		{"inconsistent stack height", ACC_STATIC, "m", "(I)V", func(code *classfile.CodeBuilder) {
+			join := code.NewLabel()
+			code.Load('I', 0)
+			code.Branch(0x99, join) // ifeq
+			code.Op(0x03)           // iconst_0
+			code.Mark(join)
+			code.Op(0xb1) // return
+		}, "Inconsistent stack height"},
+		{"uninitialized this on one path", ACC_PUBLIC, "<init>", "(I)V", func(code *classfile.CodeBuilder) {
+			join := code.NewLabel()
+			code.Load('I', 1)
+			code.Branch(0x99, join) // ifeq，跳过 super()
+			code.Load('L', 0)
+			code.Invoke(0xb7, "java/lang/Object", "<init>", "()V") // invokespecial
+			code.Mark(join)                                        // pc 8，合并以后 this 仍然可能没有初始化
+			code.Op(0xb1)                                          // return
+		}, "Constructor must call super() or this() before return (test.Check.<init>(I)V @8)"},
+		{"this initialized on both paths", ACC_PUBLIC, "<init>", "(I)V", func(code *classfile.CodeBuilder) {
+			other, join := code.NewLabel(), code.NewLabel()
+			code.Load('I', 1)
+			code.Branch(0x99, other) // ifeq
+			code.Load('L', 0)
+			code.Invoke(0xb7, "java/lang/Object", "<init>", "()V") // invokespecial
+			code.Branch(0xa7, join)                                // goto
+			code.Mark(other)
+			code.Load('L', 0)
+			code.Invoke(0xb7, "java/lang/Object", "<init>", "()V") // invokespecial
+			code.Mark(join)
+			code.Op(0xb1) // return
+		}, ""},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := newTestClass(49, "test/Check", "java/lang/Object")
+			takeDog := cb.AddMethod(ACC_PUBLIC|ACC_STATIC, "takeDog", "(Ltest/Dog;)V")
+			code := cb.NewCode()
+			code.Op(0xb1) // return
+			cb.SetCode(takeDog, code)
+
+			method := cb.AddMethod(test.flags, test.methodName, test.descriptor)
+			code = cb.NewCode()
+			test.gen(code)
+			cb.SetCode(method, code)
+
+			checkVerifyError(t, loadError(newTestLoader(t, cb), "test/Check"), test.err)
+		})
+	}
+}
+
+// jsr 和 ret 只能出现在没有 StackMapTable 的旧 class 文件里
+func TestVerifyJsrWithStackMapTable(t *testing.T) {
+	cb := newTestClass(52, "test/Check", "java/lang/Object")
+	method := cb.AddMethod(ACC_PUBLIC|ACC_STATIC, "m", "()V")
+	code := cb.NewCode()
+	sub := code.NewLabel()
+	code.Branch(0xa8, sub) // jsr
+	code.Op(0xb1)          // return
+	code.Mark(sub)
+	code.Store('L', 0)
+	code.OpU1(0xa9, 0) // ret 0
+	cb.SetCode(method, code)
+
+	checkVerifyError(t, loadError(newTestLoader(t, cb), "test/Check"),
+		"Illegal instruction jsr in class file with StackMapTable (test.Check.m()V @0)")
+}
+
+// -Xverify:none 不验证，remote 只验证不是由启动类加载器加载的类，all 验证所有类
+func TestVerifyMode(t *testing.T) {
+	badClass := func(name string) *classfile.ClassBuilder {
+		cb := newTestClass(49, name, "java/lang/Object")
+		method := cb.AddMethod(ACC_PUBLIC|ACC_STATIC, "m", "()V")
+		code := cb.NewCode()
+		code.Op(0x03) // iconst_0
+		code.Op(0xb0) // areturn
+		code.SetMaxStack(1)
+		cb.SetCode(method, code)
+		return cb
+	}
+
+	tests := []struct {
+		mode      int
+		bootErr   bool // 启动类加载器加载的 java/lang/Bad
+		remoteErr bool // 应用类加载器加载的 test/Bad
+	}{
+		{VerifyNone, false, false},
+		{VerifyRemote, false, true},
+		{VerifyAll, true, true},
+	}
+	for _, test := range tests {
+		withVerifyMode(test.mode, func() {
+			loader := newTestLoader(t, badClass("java/lang/Bad"), badClass("test/Bad"))
+			for _, c := range []struct {
+				name    string
+				wantErr bool
+			}{{"java/lang/Bad", test.bootErr}, {"test/Bad", test.remoteErr}} {
+				err := loadError(loader, c.name)
+				if c.wantErr {
+					checkVerifyError(t, err, "Method does not expect a return value")
+				} else if err != "" {
+					t.Errorf("mode %d: %s: unexpected error: %s", test.mode, c.name, err)
+				}
+			}
+		})
+	}
+}
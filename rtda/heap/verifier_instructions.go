@@ -0,0 +1,683 @@
+package heap
+
+import "strings"
+
+// execute 根据当前类型状态检查 pc 处的指令并算出下一个类型状态。
+// 返回 false 表示执行完这条指令后不会顺序执行下一条指令
+func (v *verifier) execute() bool {
+	code, pc := v.code, v.pc
+	switch op := code[pc]; op {
+	case 0x00: // nop
+	case 0x01: // aconst_null
+		v.push(vNull)
+	case 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x10, 0x11: // iconst_<i>, bipush, sipush
+		v.push(vInt)
+	case 0x09, 0x0a: // lconst_<l>
+		v.push(vLong)
+	case 0x0b, 0x0c, 0x0d: // fconst_<f>
+		v.push(vFloat)
+	case 0x0e, 0x0f: // dconst_<d>
+		v.push(vDouble)
+	case 0x12: // ldc
+		v.ldc(readU1(code, pc+1), false)
+	case 0x13: // ldc_w
+		v.ldc(readU2(code, pc+1), false)
+	case 0x14: // ldc2_w
+		v.ldc(readU2(code, pc+1), true)
+
+	// loads
+	case 0x15, 0x16, 0x17, 0x18, 0x19:
+		v.load(readU1(code, pc+1), loadStoreType(op-0x15))
+	case 0x1a, 0x1b, 0x1c, 0x1d, 0x1e, 0x1f, 0x20, 0x21, 0x22, 0x23, 0x24, 0x25, 0x26, 0x27, 0x28, 0x29,
+		0x2a, 0x2b, 0x2c, 0x2d: // <t>load_<n>
+		v.load(int(op-0x1a)%4, loadStoreType((op-0x1a)/4))
+	case 0x2e: // iaload
+		v.arrayLoad(vInt, "I")
+	case 0x2f: // laload
+		v.arrayLoad(vLong, "J")
+	case 0x30: // faload
+		v.arrayLoad(vFloat, "F")
+	case 0x31: // daload
+		v.arrayLoad(vDouble, "D")
+	case 0x32: // aaload
+		v.popType(vInt)
+		array := v.popArray()
+		if array.tag == vtNull {
+			v.push(vNull)
+		} else if component := array.name[1:]; component[0] == 'L' || component[0] == '[' {
+			v.push(vTypeOf(component))
+		} else {
+			v.fail("Bad type on operand stack in aaload")
+		}
+	case 0x33: // baload
+		v.arrayLoad(vInt, "B", "Z")
+	case 0x34: // caload
+		v.arrayLoad(vInt, "C")
+	case 0x35: // saload
+		v.arrayLoad(vInt, "S")
+
+	// stores
+	case 0x36, 0x37, 0x38, 0x39, 0x3a:
+		v.store(readU1(code, pc+1), loadStoreType(op-0x36))
+	case 0x3b, 0x3c, 0x3d, 0x3e, 0x3f, 0x40, 0x41, 0x42, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49, 0x4a,
+		0x4b, 0x4c, 0x4d, 0x4e: // <t>store_<n>
+		v.store(int(op-0x3b)%4, loadStoreType((op-0x3b)/4))
+	case 0x4f: // iastore
+		v.arrayStore(vInt, "I")
+	case 0x50: // lastore
+		v.arrayStore(vLong, "J")
+	case 0x51: // fastore
+		v.arrayStore(vFloat, "F")
+	case 0x52: // dastore
+		v.arrayStore(vDouble, "D")
+	case 0x53: // aastore
+		v.popType(vObject)
+		v.popType(vInt)
+		if array := v.popArray(); array.tag != vtNull && array.name[1] != 'L' && array.name[1] != '[' {
+			v.fail("Bad type on operand stack in aastore")
+		}
+	case 0x54: // bastore
+		v.arrayStore(vInt, "B", "Z")
+	case 0x55: // castore
+		v.arrayStore(vInt, "C")
+	case 0x56: // sastore
+		v.arrayStore(vInt, "S")
+
+	// stack
+	case 0x57: // pop
+		v.popSlots(1)
+	case 0x58: // pop2
+		v.popSlots(2)
+	case 0x59: // dup
+		v.dup(1, 1)
+	case 0x5a: // dup_x1
+		v.dup(1, 2)
+	case 0x5b: // dup_x2
+		v.dup(1, 3)
+	case 0x5c: // dup2
+		v.dup(2, 2)
+	case 0x5d: // dup2_x1
+		v.dup(2, 3)
+	case 0x5e: // dup2_x2
+		v.dup(2, 4)
+	case 0x5f: // swap
+		v.checkSlots(1)
+		v.checkSlots(2)
+		stack := v.frame.stack
+		n := len(stack)
+		stack[n-1], stack[n-2] = stack[n-2], stack[n-1]
+
+	// math
+	case 0x60, 0x61, 0x62, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69, 0x6a, 0x6b,
+		0x6c, 0x6d, 0x6e, 0x6f, 0x70, 0x71, 0x72, 0x73: // add, sub, mul, div, rem
+		t := numericType((op - 0x60) % 4)
+		v.popType(t)
+		v.popType(t)
+		v.push(t)
+	case 0x74, 0x75, 0x76, 0x77: // neg
+		t := numericType(op - 0x74)
+		v.popType(t)
+		v.push(t)
+	case 0x78, 0x79, 0x7a, 0x7b, 0x7c, 0x7d: // shl, shr, ushr
+		t := numericType((op - 0x78) % 2)
+		v.popType(vInt)
+		v.popType(t)
+		v.push(t)
+	case 0x7e, 0x7f, 0x80, 0x81, 0x82, 0x83: // and, or, xor
+		t := numericType((op - 0x7e) % 2)
+		v.popType(t)
+		v.popType(t)
+		v.push(t)
+	case 0x84: // iinc
+		v.checkLocalType(readU1(code, pc+1), vInt)
+
+	// conversions
+	case 0x85, 0x86, 0x87, 0x88, 0x89, 0x8a, 0x8b, 0x8c, 0x8d, 0x8e, 0x8f, 0x90:
+		from, to := (op-0x85)/3, (op-0x85)%3
+		if to >= from {
+			to++ // 跳过和 from 相同的类型
+		}
+		v.popType(numericType(from))
+		v.push(numericType(to))
+	case 0x91, 0x92, 0x93: // i2b, i2c, i2s
+		v.popType(vInt)
+		v.push(vInt)
+
+	// comparisons
+	case 0x94, 0x95, 0x96, 0x97, 0x98: // lcmp, fcmp<op>, dcmp<op>
+		t := []vType{vLong, vFloat, vFloat, vDouble, vDouble}[op-0x94]
+		v.popType(t)
+		v.popType(t)
+		v.push(vInt)
+	case 0x99, 0x9a, 0x9b, 0x9c, 0x9d, 0x9e: // if<cond>
+		v.popType(vInt)
+		v.checkBranch(readS2(code, pc+1))
+	case 0x9f, 0xa0, 0xa1, 0xa2, 0xa3, 0xa4: // if_icmp<cond>
+		v.popType(vInt)
+		v.popType(vInt)
+		v.checkBranch(readS2(code, pc+1))
+	case 0xa5, 0xa6: // if_acmp<cond>
+		v.popReference()
+		v.popReference()
+		v.checkBranch(readS2(code, pc+1))
+
+	// control
+	case 0xa7: // goto
+		v.checkBranch(readS2(code, pc+1))
+		return false
+	case 0xa8: // jsr
+		v.jsr(readS2(code, pc+1))
+		return false
+	case 0xa9: // ret
+		v.ret(readU1(code, pc+1))
+		return false
+	case 0xaa: // tableswitch
+		v.popType(vInt)
+		p := switchOperands(pc)
+		v.checkBranch(int(readS4(code, p)))
+		low, high := readS4(code, p+4), readS4(code, p+8)
+		for i := 0; i < int(high-low)+1; i++ {
+			v.checkBranch(int(readS4(code, p+12+i*4)))
+		}
+		return false
+	case 0xab: // lookupswitch
+		v.popType(vInt)
+		p := switchOperands(pc)
+		v.checkBranch(int(readS4(code, p)))
+		npairs := int(readS4(code, p+4))
+		for i := 0; i < npairs; i++ {
+			if i > 0 && readS4(code, p+8+i*8) <= readS4(code, p+i*8) {
+				v.fail("Bad lookupswitch instruction")
+			}
+			v.checkBranch(int(readS4(code, p+12+i*8)))
+		}
+		return false
+	case 0xac, 0xad, 0xae, 0xaf, 0xb0: // ireturn, lreturn, freturn, dreturn, areturn
+		returnType := v.method.parsedDescriptor.returnType
+		if returnType == "V" {
+			v.fail("Method does not expect a return value")
+		}
+		if vTypeOf(returnType).tag != loadStoreType(op-0xac).tag {
+			v.fail("Method expects a return value of type %s", returnType)
+		}
+		v.popType(vTypeOf(returnType))
+		return false
+	case 0xb1: // return
+		if v.method.parsedDescriptor.returnType != "V" {
+			v.fail("Method expects a return value")
+		}
+		if v.frame.thisUninit {
+			v.fail("Constructor must call super() or this() before return")
+		}
+		return false
+
+	// references
+	case 0xb2, 0xb3, 0xb4, 0xb5: // getstatic, putstatic, getfield, putfield
+		v.fieldAccess(op)
+	case 0xb6, 0xb7, 0xb8, 0xb9, 0xba: // invokevirtual, invokespecial, invokestatic, invokeinterface, invokedynamic
+		v.invoke(op)
+	case 0xbb: // new
+		className := v.classRef(readU2(code, pc+1)).className
+		if className[0] == '[' {
+			v.fail("Illegal new instruction")
+		}
+		uninit := uninitializedType(pc)
+		for _, t := range v.frame.stack {
+			if t == uninit {
+				v.fail("Uninitialized object exists on backward branch")
+			}
+		}
+		v.replace(uninit, vTop)
+		v.push(uninit)
+	case 0xbc: // newarray
+		atype := readU1(code, pc+1)
+		if atype < 4 || atype > 11 {
+			v.fail("Illegal newarray instruction")
+		}
+		v.popType(vInt)
+		v.push(refType("[" + "ZCFDBSIJ"[atype-4:atype-3]))
+	case 0xbd: // anewarray
+		arrayClassName := getArrayClassName(v.classRef(readU2(code, pc+1)).className)
+		if strings.LastIndexByte(arrayClassName, '[') >= 255 {
+			v.fail("Array with too many dimensions")
+		}
+		v.popType(vInt)
+		v.push(refType(arrayClassName))
+	case 0xbe: // arraylength
+		v.popArray()
+		v.push(vInt)
+	case 0xbf: // athrow
+		v.popType(vThrowable)
+		return false
+	case 0xc0: // checkcast
+		className := v.classRef(readU2(code, pc+1)).className
+		v.popType(vObject)
+		v.push(refType(className))
+	case 0xc1: // instanceof
+		v.classRef(readU2(code, pc+1))
+		v.popType(vObject)
+		v.push(vInt)
+	case 0xc2, 0xc3: // monitorenter, monitorexit
+		v.popType(vObject)
+
+	// extended
+	case 0xc4: // wide
+		index := readU2(code, pc+2)
+		switch op := code[pc+1]; {
+		case op >= 0x15 && op <= 0x19:
+			v.load(index, loadStoreType(op-0x15))
+		case op >= 0x36 && op <= 0x3a:
+			v.store(index, loadStoreType(op-0x36))
+		case op == 0x84: // iinc
+			v.checkLocalType(index, vInt)
+		default: // ret
+			v.ret(index)
+			return false
+		}
+	case 0xc5: // multianewarray
+		className := v.classRef(readU2(code, pc+1)).className
+		dimensions := readU1(code, pc+3)
+		if dimensions == 0 || len(className) < dimensions || className[:dimensions] != strings.Repeat("[", dimensions) {
+			v.fail("Illegal multianewarray instruction")
+		}
+		for i := 0; i < dimensions; i++ {
+			v.popType(vInt)
+		}
+		v.push(refType(className))
+	case 0xc6, 0xc7: // ifnull, ifnonnull
+		v.popReference()
+		v.checkBranch(readS2(code, pc+1))
+	case 0xc8: // goto_w
+		v.checkBranch(int(readS4(code, pc+1)))
+		return false
+	case 0xc9: // jsr_w
+		v.jsr(int(readS4(code, pc+1)))
+		return false
+	}
+	return true
+}
+
+// loadStoreType 按 i、l、f、d、a 的顺序返回指令操作的类型
+func loadStoreType(i uint8) vType {
+	return []vType{vInt, vLong, vFloat, vDouble, vObject}[i]
+}
+
+// numericType 按 i、l、f、d 的顺序返回指令操作的类型
+func numericType(i uint8) vType {
+	return []vType{vInt, vLong, vFloat, vDouble}[i]
+}
+
+func (v *verifier) push(t vType) {
+	v.frame.stack = append(v.frame.stack, t)
+	if t.isCategory2() {
+		v.frame.stack = append(v.frame.stack, vTop)
+	}
+	if len(v.frame.stack) > v.maxStack {
+		v.fail("Operand stack overflow")
+	}
+}
+
+func (v *verifier) pop() vType {
+	stack := v.frame.stack
+	if len(stack) == 0 {
+		v.fail("Unable to pop operand off an empty stack")
+	}
+	t := stack[len(stack)-1]
+	v.frame.stack = stack[:len(stack)-1]
+	return t
+}
+
+// popType 弹出一个能赋值给 expected 的值
+func (v *verifier) popType(expected vType) vType {
+	if expected.isCategory2() {
+		if v.pop().tag != vtTop {
+			v.fail("Bad type on operand stack: expected %s", expected)
+		}
+	}
+	t := v.pop()
+	if expected.isCategory2() && t != expected || !v.isAssignable(t, expected) || t.tag == vtTop {
+		v.fail("Bad type on operand stack: expected %s, found %s", expected, t)
+	}
+	return t
+}
+
+// popReference 弹出一个引用，可以是还没有初始化的对象
+func (v *verifier) popReference() vType {
+	t := v.pop()
+	if !t.isReference() {
+		v.fail("Bad type on operand stack: expected reference, found %s", t)
+	}
+	return t
+}
+
+// popArray 弹出一个数组或者 null
+func (v *verifier) popArray() vType {
+	t := v.pop()
+	if t.tag != vtNull && !t.isArray() {
+		v.fail("Bad type on operand stack: expected array, found %s", t)
+	}
+	return t
+}
+
+// checkSlots 检查操作数栈顶的 n 个位置不会把 long 或 double 拆开
+func (v *verifier) checkSlots(n int) {
+	stack := v.frame.stack
+	if len(stack) < n {
+		v.fail("Unable to pop operand off an empty stack")
+	}
+	if stack[len(stack)-n].tag == vtTop {
+		v.fail("Bad type on operand stack: attempt to split long or double")
+	}
+}
+
+func (v *verifier) popSlots(n int) {
+	v.checkSlots(n)
+	v.frame.stack = v.frame.stack[:len(v.frame.stack)-n]
+}
+
+// dup 复制栈顶的 n 个位置，插到从栈顶数第 depth 个位置下面
+func (v *verifier) dup(n, depth int) {
+	v.checkSlots(n)
+	v.checkSlots(depth)
+	stack := v.frame.stack
+	top := append([]vType(nil), stack[len(stack)-n:]...)
+	i := len(stack) - depth
+	stack = append(stack[:i], append(top, stack[i:]...)...)
+	if len(stack) > v.maxStack {
+		v.fail("Operand stack overflow")
+	}
+	v.frame.stack = stack
+}
+
+func (v *verifier) checkLocal(index int, t vType) {
+	if index >= v.maxLocals || t.isCategory2() && index+1 >= v.maxLocals {
+		v.fail("Illegal local variable number %d", index)
+	}
+}
+
+// checkLocalType 检查局部变量的类型，引用类型可以是还没有初始化的对象。返回局部变量实际的类型
+func (v *verifier) checkLocalType(index int, t vType) vType {
+	v.checkLocal(index, t)
+	local := v.frame.locals[index]
+	switch {
+	case t.isCategory2():
+		if local != t || v.frame.locals[index+1].tag != vtTop {
+			v.fail("Bad local variable type: expected %s, found %s", t, local)
+		}
+	case t.tag == vtReference:
+		if !local.isReference() {
+			v.fail("Bad local variable type: expected reference, found %s", local)
+		}
+	case local != t:
+		v.fail("Bad local variable type: expected %s, found %s", t, local)
+	}
+	return local
+}
+
+func (v *verifier) load(index int, t vType) {
+	v.push(v.checkLocalType(index, t))
+}
+
+func (v *verifier) store(index int, t vType) {
+	v.checkLocal(index, t)
+	if t.tag == vtReference {
+		// astore 也可以保存 jsr 压栈的返回地址
+		if t = v.pop(); !t.isReference() && t.tag != vtReturnAddress {
+			v.fail("Bad type on operand stack: expected reference, found %s", t)
+		}
+	} else {
+		v.popType(t)
+	}
+
+	locals := v.frame.locals
+	if index > 0 && locals[index-1].isCategory2() {
+		locals[index-1] = vTop // 覆盖了 long 或 double 的后一半
+	}
+	locals[index] = t
+	if t.isCategory2() {
+		locals[index+1] = vTop
+	}
+}
+
+func (v *verifier) arrayLoad(t vType, components ...string) {
+	v.popType(vInt)
+	v.checkArrayComponent(v.popArray(), components)
+	v.push(t)
+}
+
+func (v *verifier) arrayStore(t vType, components ...string) {
+	v.popType(t)
+	v.popType(vInt)
+	v.checkArrayComponent(v.popArray(), components)
+}
+
+func (v *verifier) checkArrayComponent(array vType, components []string) {
+	if array.tag == vtNull {
+		return
+	}
+	for _, component := range components {
+		if array.name[1:] == component {
+			return
+		}
+	}
+	v.fail("Bad type on operand stack: %s is not an array of %s", array, components[0])
+}
+
+// replace 把局部变量表和操作数栈里所有的 old 换成 new
+func (v *verifier) replace(old, new vType) {
+	for i, t := range v.frame.locals {
+		if t == old {
+			v.frame.locals[i] = new
+		}
+	}
+	for i, t := range v.frame.stack {
+		if t == old {
+			v.frame.stack[i] = new
+		}
+	}
+}
+
+func (v *verifier) constant(index int) Constant {
+	consts := v.class.constantPool.consts
+	if index <= 0 || index >= len(consts) || consts[index] == nil {
+		v.fail("Illegal constant pool index %d", index)
+	}
+	return consts[index]
+}
+
+func (v *verifier) classRef(index int) *ClassRef {
+	ref, ok := v.constant(index).(*ClassRef)
+	if !ok {
+		v.fail("Illegal type at constant pool entry %d", index)
+	}
+	return ref
+}
+
+func (v *verifier) ldc(index int, category2 bool) {
+	switch c := v.constant(index).(type) {
+	case int32:
+		v.push(vInt)
+	case float32:
+		v.push(vFloat)
+	case int64:
+		v.push(vLong)
+	case float64:
+		v.push(vDouble)
+	case string:
+		v.push(refType("java/lang/String"))
+	case *ClassRef:
+		v.push(refType("java/lang/Class"))
+	case *MethodTypeRef:
+		v.push(refType("java/lang/invoke/MethodType"))
+	case *MethodHandleRef:
+		v.push(refType("java/lang/invoke/MethodHandle"))
+	default:
+		v.fail("Illegal type at constant pool entry %d: %T", index, c)
+	}
+	if top := v.frame.stack[len(v.frame.stack)-1]; (top.tag == vtTop) != category2 {
+		v.fail("Illegal type at constant pool entry %d", index)
+	}
+}
+
+func (v *verifier) fieldAccess(op uint8) {
+	index := readU2(v.code, v.pc+1)
+	ref, ok := v.constant(index).(*FieldRef)
+	if !ok {
+		v.fail("Illegal type at constant pool entry %d", index)
+	}
+	if !isValidFieldDescriptor(ref.descriptor) {
+		v.fail("Illegal field descriptor %s", ref.descriptor)
+	}
+	fieldType := vTypeOf(ref.descriptor)
+	owner := refType(ref.className)
+
+	switch op {
+	case 0xb2: // getstatic
+		v.push(fieldType)
+	case 0xb3: // putstatic
+		v.popType(fieldType)
+	case 0xb4: // getfield
+		v.checkProtected(ref.className, ref.name, ref.descriptor, false)
+		v.popType(owner)
+		v.push(fieldType)
+	case 0xb5: // putfield
+		v.popType(fieldType)
+		// 构造函数在调用 super() 之前可以给自己声明的字段赋值
+		if n := len(v.frame.stack); n > 0 && v.frame.stack[n-1].tag == vtUninitializedThis &&
+			ref.className == v.class.name && v.class.getField(ref.name, ref.descriptor, false) != nil {
+			v.pop()
+		} else {
+			v.checkProtected(ref.className, ref.name, ref.descriptor, false)
+			v.popType(owner)
+		}
+	}
+}
+
+func (v *verifier) invoke(op uint8) {
+	index := readU2(v.code, v.pc+1)
+	var className, name, descriptor string
+	switch ref := v.constant(index).(type) {
+	case *MethodRef:
+		if op == 0xb9 || op == 0xba {
+			v.fail("Illegal type at constant pool entry %d", index)
+		}
+		className, name, descriptor = ref.className, ref.name, ref.descriptor
+	case *InterfaceMethodRef:
+		if op == 0xb6 || op == 0xba {
+			v.fail("Illegal type at constant pool entry %d", index)
+		}
+		className, name, descriptor = ref.className, ref.name, ref.descriptor
+	case *InvokeDynamicRef:
+		if op != 0xba || v.code[v.pc+3] != 0 || v.code[v.pc+4] != 0 {
+			v.fail("Illegal invokedynamic instruction")
+		}
+		name, descriptor = ref.name, ref.descriptor
+	default:
+		v.fail("Illegal type at constant pool entry %d", index)
+	}
+	if name[0] == '<' && (op != 0xb7 || name != "<init>") {
+		v.fail("Illegal call to internal method %s", name)
+	}
+	if !isValidMethodDescriptor(descriptor) {
+		v.fail("Illegal method descriptor %s", descriptor)
+	}
+
+	md := parseMethodDescriptor(descriptor)
+	argSlotCount := 1
+	for i := len(md.parameterTypes) - 1; i >= 0; i-- {
+		t := v.popType(vTypeOf(md.parameterTypes[i]))
+		argSlotCount++
+		if t.isCategory2() {
+			argSlotCount++
+		}
+	}
+
+	switch {
+	case op == 0xb7 && name == "<init>":
+		if md.returnType != "V" {
+			v.fail("Bad <init> method descriptor %s", descriptor)
+		}
+		receiver := v.popReference()
+		switch receiver.tag {
+		case vtUninitializedThis:
+			if className != v.class.name && className != v.class.superClassName {
+				v.fail("Bad <init> method call")
+			}
+			v.replace(receiver, refType(v.class.name))
+			v.frame.thisUninit = false
+		case vtUninitialized:
+			newClassName := v.classRef(readU2(v.code, receiver.offset+1)).className
+			if className != newClassName {
+				v.fail("Call to wrong <init> method")
+			}
+			v.replace(receiver, refType(newClassName))
+		default:
+			v.fail("Bad operand type when invoking <init>: %s", receiver)
+		}
+	case op == 0xb7: // invokespecial
+		v.popType(refType(v.class.name))
+	case op == 0xb6, op == 0xb9: // invokevirtual, invokeinterface
+		if op == 0xb6 {
+			v.checkProtected(className, name, descriptor, true)
+		}
+		v.popType(refType(className))
+		if op == 0xb9 && (readU1(v.code, v.pc+3) != argSlotCount || v.code[v.pc+4] != 0) {
+			v.fail("Inconsistent args count operand in invokeinterface")
+		}
+	}
+
+	if md.returnType != "V" {
+		v.push(vTypeOf(md.returnType))
+	}
+}
+
+// checkProtected 访问其他运行时包里父类的 protected 实例成员时，接收者必须是当前类或者它的子类，
+// 否则子类可以通过父类的引用访问别的子类对象的 protected 成员，jvms 4.10.1.8。
+// 接收者是栈顶的值，不出栈
+func (v *verifier) checkProtected(className, name, descriptor string, isMethod bool) {
+	n := len(v.frame.stack)
+	if n == 0 || className == v.class.name {
+		return
+	}
+	var superClass *Class
+	for c := v.class.superClass; c != nil; c = c.superClass {
+		if c.name == className {
+			superClass = c
+			break
+		}
+	}
+	if superClass == nil {
+		return // 不是父类的成员，不需要检查
+	}
+
+	// 从引用的类开始向上查找成员，找到的是声明它的类
+	var member *ClassMember
+	for c := superClass; c != nil && member == nil; c = c.superClass {
+		if isMethod {
+			for _, method := range c.methods {
+				if method.name == name && method.descriptor == descriptor {
+					member = &method.ClassMember
+					break
+				}
+			}
+		} else {
+			for _, field := range c.fields {
+				if field.name == name && field.descriptor == descriptor {
+					member = &field.ClassMember
+					break
+				}
+			}
+		}
+	}
+	if member == nil || !member.IsProtected() || member.IsStatic() || member.class.IsSameRuntimePackage(v.class) {
+		return
+	}
+
+	receiver := v.frame.stack[n-1]
+	if receiver.isArray() && isMethod && name == "clone" {
+		return // 数组的 clone 方法是 public 的
+	}
+	if !v.isAssignable(receiver, refType(v.class.name)) {
+		v.fail("Bad access to protected data in %s", name)
+	}
+}
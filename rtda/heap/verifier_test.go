@@ -0,0 +1,248 @@
+package heap
+
+import (
+	"fmt"
+	"jvm-go/classfile"
+	"jvm-go/classpath"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// newTestClass 创建一个类，superName 不为空时带有调用父类构造函数的默认构造函数
+func newTestClass(version uint16, name, superName string) *classfile.ClassBuilder {
+	cb := classfile.NewClassBuilder(version, ACC_PUBLIC|ACC_SUPER, name, superName)
+	if superName != "" {
+		init := cb.AddMethod(ACC_PUBLIC, "<init>", "()V")
+		code := cb.NewCode()
+		code.Load('L', 0)
+		code.Invoke(0xb7, superName, "<init>", "()V") // invokespecial
+		code.Op(0xb1)                                 // return
+		cb.SetCode(init, code)
+	}
+	return cb
+}
+
+// testClassLibrary 验证器只需要类层次结构，用最小的核心类代替 JDK。
+// test/Dog 和 test/Cat 都继承 test/Animal，用来检查引用类型的赋值和合并
+func testClassLibrary() []*classfile.ClassBuilder {
+	object := newTestClass(52, "java/lang/Object", "")
+	init := object.AddMethod(ACC_PUBLIC, "<init>", "()V")
+	code := object.NewCode()
+	code.Op(0xb1) // return
+	object.SetCode(init, code)
+
+	str := newTestClass(52, "java/lang/String", "java/lang/Object")
+	str.AddField(ACC_PRIVATE|ACC_FINAL, "value", "[C")
+
+	var ifaces []*classfile.ClassBuilder
+	for _, name := range []string{"java/lang/Cloneable", "java/io/Serializable"} {
+		ifaces = append(ifaces, classfile.NewClassBuilder(52, ACC_PUBLIC|ACC_INTERFACE|ACC_ABSTRACT, name, "java/lang/Object"))
+	}
+
+	animal := newTestClass(52, "test/Animal", "java/lang/Object")
+	speak := animal.AddMethod(ACC_PUBLIC, "speak", "()V")
+	code = animal.NewCode()
+	code.Op(0xb1) // return
+	animal.SetCode(speak, code)
+
+	return append(ifaces, object, str, animal,
+		newTestClass(52, "java/lang/Class", "java/lang/Object"),
+		newTestClass(52, "java/lang/Throwable", "java/lang/Object"),
+		newTestClass(52, "test/Dog", "test/Animal"),
+		newTestClass(52, "test/Cat", "test/Animal"))
+}
+
+// newTestLoader 把测试用的核心类和 classes 写到临时目录，用它作为 jre 目录和用户类路径创建类加载器
+func newTestLoader(t *testing.T, classes ...*classfile.ClassBuilder) *ClassLoader {
+	dir := t.TempDir()
+	writeTestClasses(t, dir, classes...)
+	return NewClassLoader(classpath.Parse(dir, dir), false)
+}
+
+// writeTestClasses 把测试用的核心类和 classes 写到目录 dir
+func writeTestClasses(t *testing.T, dir string, classes ...*classfile.ClassBuilder) {
+	for _, cb := range append(testClassLibrary(), classes...) {
+		data := cb.Bytes()
+		cf, err := classfile.Parse(data)
+		if err != nil {
+			t.Fatalf("Parse: %v", err)
+		}
+		path := filepath.Join(dir, filepath.FromSlash(cf.ClassName())+".class")
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// loadError 加载并连接类，返回抛出的异常，成功时返回空字符串
+func loadError(loader *ClassLoader, name string) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	loader.LoadClass(name)
+	return ""
+}
+
+// stackMapTable 用原始的 stack_map_frame 构造 StackMapTable 属性
+func stackMapTable(cb *classfile.ClassBuilder, frames ...[]byte) classfile.AttributeInfo {
+	info := []byte{byte(len(frames) >> 8), byte(len(frames))}
+	for _, frame := range frames {
+		info = append(info, frame...)
+	}
+	return cb.Attribute("StackMapTable", info)
+}
+
+// objectItem Object_variable_info
+func objectItem(cb *classfile.ClassBuilder, className string) []byte {
+	index := cb.Class(className)
+	return []byte{classfile.ITEM_Object, byte(index >> 8), byte(index)}
+}
+
+func checkVerifyError(t *testing.T, got, want string) {
+	t.Helper()
+	switch {
+	case want == "" && got != "":
+		t.Errorf("unexpected error: %s", got)
+	case want != "" && !strings.HasPrefix(got, "java.lang.VerifyError: "):
+		t.Errorf("got %q, want VerifyError containing %q", got, want)
+	case !strings.Contains(got, want):
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestVerifyTypeChecking(t *testing.T) {
+	tests := []struct {
+		name       string
+		descriptor string
+		gen        func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder)
+		err        string // 为空表示验证通过
+	}{
+		{"valid branch", "(I)I", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			zero := code.NewLabel()
+			code.Load('I', 0)
+			code.Branch(0x99, zero)                         // ifeq
+			code.Op(0x04)                                   // iconst_1
+			code.Op(0xac)                                   // ireturn
+			code.Mark(zero)                                 // pc 6
+			code.Op(0x03)                                   // iconst_0
+			code.Op(0xac)                                   // ireturn
+			code.AddAttribute(stackMapTable(cb, []byte{6})) // same_frame
+		}, ""},
+		{"valid reference merge", "(Ltest/Dog;Ltest/Cat;I)Ltest/Animal;", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			cat, end := code.NewLabel(), code.NewLabel()
+			code.Load('I', 2)
+			code.Branch(0x99, cat) // ifeq
+			code.Load('L', 0)
+			code.Branch(0xa7, end) // goto
+			code.Mark(cat)         // pc 8
+			code.Load('L', 1)
+			code.Mark(end) // pc 9
+			code.Op(0xb0)  // areturn
+			code.AddAttribute(stackMapTable(cb,
+				[]byte{8}, // same_frame
+				append([]byte{classfile.SAME_LOCALS_1_STACK_ITEM}, objectItem(cb, "test/Animal")...)))
+		}, ""},
+		{"error names method and pc", "()I", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			code.Op(0x04) // iconst_1
+			code.Op(0x0c) // fconst_1
+			code.Op(0x60) // iadd
+			code.Op(0xac) // ireturn
+		}, "Bad type on operand stack: expected integer, found float (test.Check.m()I @2)"},
+		{"stack height mismatch at branch target", "(I)V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			target := code.NewLabel()
+			code.Op(0x03) // iconst_0
+			code.Load('I', 0)
+			code.Branch(0x99, target)                       // ifeq
+			code.Op(0x57)                                   // pop
+			code.Op(0xb1)                                   // return
+			code.Mark(target)                               // pc 7
+			code.Op(0xb1)                                   // return
+			code.AddAttribute(stackMapTable(cb, []byte{7})) // same_frame，操作数栈是空的
+		}, "Inconsistent stackmap frames at branch target 7 (test.Check.m(I)V @2)"},
+		{"stack height mismatch on fall through", "(I)V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			target := code.NewLabel()
+			code.Load('I', 0)
+			code.Branch(0x99, target)                       // ifeq
+			code.Op(0x03)                                   // iconst_0
+			code.Mark(target)                               // pc 5
+			code.Op(0xb1)                                   // return
+			code.AddAttribute(stackMapTable(cb, []byte{5})) // same_frame
+		}, "Instruction type does not match stack map (test.Check.m(I)V @5)"},
+		{"bad local type", "()V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			code.Op(0x03) // iconst_0
+			code.Store('I', 0)
+			code.Load('L', 0)
+			code.Op(0x57) // pop
+			code.Op(0xb1) // return
+		}, "Bad local variable type: expected reference, found integer (test.Check.m()V @2)"},
+		{"branch target without frame", "(I)V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			target := code.NewLabel()
+			code.Load('I', 0)
+			code.Branch(0x99, target) // ifeq
+			code.Op(0xb1)             // return
+			code.Mark(target)
+			code.Op(0xb1) // return
+		}, "Expecting a stackmap frame at branch target 5 (test.Check.m(I)V @1)"},
+		{"branch into an instruction", "(I)V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			code.Load('I', 0)
+			code.Emit(0x99, 0x00, 0x01) // ifeq 2，跳到 ifeq 的操作数上
+			code.Op(0xb1)               // return
+			code.SetMaxStack(1)
+			code.SetMaxLocals(1)
+		}, "Illegal target of jump or branch (test.Check.m(I)V @1)"},
+		{"exception handler without frame", "()V", func(cb *classfile.ClassBuilder, code *classfile.CodeBuilder) {
+			start, end, handler := code.NewLabel(), code.NewLabel(), code.NewLabel()
+			code.Mark(start)
+			code.Op(0xb1) // return
+			code.Mark(end)
+			code.Mark(handler)
+			code.Op(0xbf) // athrow
+			code.TryCatch(start, end, handler, "java/lang/Throwable")
+		}, "Expecting a stackmap frame at exception handler 1"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			cb := newTestClass(52, "test/Check", "java/lang/Object")
+			method := cb.AddMethod(ACC_PUBLIC|ACC_STATIC, "m", test.descriptor)
+			code := cb.NewCode()
+			test.gen(cb, code)
+			cb.SetCode(method, code)
+
+			checkVerifyError(t, loadError(newTestLoader(t, cb), "test/Check"), test.err)
+		})
+	}
+}
+
+// 访问其他包里父类的 protected 实例字段时，对象必须是当前类或者它的子类，jvms 4.10.1.8
+func TestVerifyProtectedAccess(t *testing.T) {
+	base := newTestClass(52, "other/Base", "java/lang/Object")
+	base.AddField(ACC_PROTECTED, "f", "I")
+
+	for _, test := range []struct {
+		receiver string
+		err      string
+	}{
+		{"test/Sub", ""},
+		{"other/Base", "Bad access to protected data in f (test.Sub.get(Lother/Base;)I @1)"},
+	} {
+		t.Run(test.receiver, func(t *testing.T) {
+			cb := newTestClass(52, "test/Sub", "other/Base")
+			method := cb.AddMethod(ACC_PUBLIC|ACC_STATIC, "get", "(L"+test.receiver+";)I")
+			code := cb.NewCode()
+			code.Load('L', 0)
+			code.Field(0xb4, "other/Base", "f", "I") // getfield
+			code.Op(0xac)                            // ireturn
+			cb.SetCode(method, code)
+
+			checkVerifyError(t, loadError(newTestLoader(t, base, cb), "test/Sub"), test.err)
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package heap
+
+import "strconv"
+
+// 验证类型，jvms 4.10.1.2
+// long 和 double 在局部变量表和操作数栈里都占两个位置，第二个位置是 top
+const (
+	vtTop = iota
+	vtInteger
+	vtFloat
+	vtLong
+	vtDouble
+	vtReturnAddress
+	vtNull
+	vtUninitializedThis
+	vtUninitialized
+	vtReference
+)
+
+type vType struct {
+	tag    uint8
+	name   string // vtReference 的类名，数组类用描述符形式，例如 [I
+	offset int    // vtUninitialized 对应的 new 指令的位置，vtReturnAddress 对应的子程序入口
+}
+
+var (
+	vTop               = vType{tag: vtTop}
+	vInt               = vType{tag: vtInteger}
+	vFloat             = vType{tag: vtFloat}
+	vLong              = vType{tag: vtLong}
+	vDouble            = vType{tag: vtDouble}
+	vNull              = vType{tag: vtNull}
+	vUninitializedThis = vType{tag: vtUninitializedThis}
+	vObject            = refType("java/lang/Object")
+	vThrowable         = refType("java/lang/Throwable")
+)
+
+func refType(className string) vType {
+	return vType{tag: vtReference, name: className}
+}
+
+func uninitializedType(offset int) vType {
+	return vType{tag: vtUninitialized, offset: offset}
+}
+
+// vTypeOf 把字段描述符转换成验证类型，boolean、byte、char 和 short 都当作 int
+func vTypeOf(descriptor string) vType {
+	switch descriptor[0] {
+	case 'Z', 'B', 'C', 'S', 'I':
+		return vInt
+	case 'F':
+		return vFloat
+	case 'J':
+		return vLong
+	case 'D':
+		return vDouble
+	default:
+		return refType(toClassName(descriptor))
+	}
+}
+
+func (t vType) isCategory2() bool {
+	return t.tag == vtLong || t.tag == vtDouble
+}
+
+// isReference 判断是不是引用类型，包括还没有初始化的对象
+func (t vType) isReference() bool {
+	return t.tag >= vtNull
+}
+
+func (t vType) isArray() bool {
+	return t.tag == vtReference && t.name[0] == '['
+}
+
+func (t vType) String() string {
+	switch t.tag {
+	case vtTop:
+		return "top"
+	case vtInteger:
+		return "integer"
+	case vtFloat:
+		return "float"
+	case vtLong:
+		return "long"
+	case vtDouble:
+		return "double"
+	case vtReturnAddress:
+		return "returnAddress"
+	case vtNull:
+		return "null"
+	case vtUninitializedThis:
+		return "uninitializedThis"
+	case vtUninitialized:
+		return "uninitialized(" + strconv.Itoa(t.offset) + ")"
+	default:
+		return "'" + t.name + "'"
+	}
+}
+
+// isAssignable 判断 from 类型的值能不能用在需要 to 类型的地方，jvms 4.10.1.2
+func (v *verifier) isAssignable(from, to vType) bool {
+	if from == to || to.tag == vtTop {
+		return true
+	}
+	if to.tag != vtReference {
+		return false
+	}
+	switch from.tag {
+	case vtNull:
+		return true
+	case vtReference:
+		return v.isJavaAssignable(from.name, to.name)
+	default:
+		return false
+	}
+}
+
+// isJavaAssignable 按类名判断引用类型的赋值兼容性。
+// 和 checkcast 不同，验证器把接口当作 Object 处理，真正的检查推迟到 invokeinterface 执行时
+func (v *verifier) isJavaAssignable(from, to string) bool {
+	if from == to || to == "java/lang/Object" {
+		return true
+	}
+	if to[0] == '[' {
+		if from[0] != '[' {
+			return false
+		}
+		fromComponent, toComponent := from[1:], to[1:]
+		if (fromComponent[0] == 'L' || fromComponent[0] == '[') &&
+			(toComponent[0] == 'L' || toComponent[0] == '[') {
+			return v.isJavaAssignable(toClassName(fromComponent), toClassName(toComponent))
+		}
+		return false // 基本类型数组只能赋值给同样的数组
+	}
+	if from[0] == '[' {
+		return to == "java/lang/Cloneable" || to == "java/io/Serializable"
+	}
+
+	toClass := v.loadClass(to)
+	if toClass.IsInterface() {
+		return true
+	}
+	for c := v.loadClass(from); c != nil; c = c.superClass {
+		if c == toClass {
+			return true
+		}
+	}
+	return false
+}
+
+// loadClass 加载验证过程中用到的类。
+// 验证发生在类加载器持有 classMapLock 的时候，所以不能调用 LoadClass
+func (v *verifier) loadClass(name string) *Class {
+	if name == v.class.name {
+		return v.class
+	}
+	class := v.class.loader.loadClass(name)
+	if class == nil {
+		panic("java.lang.NoClassDefFoundError: " + name)
+	}
+	return class
+}
@@ -1,10 +1,33 @@
 package rtda
 
+import "unsafe"
+
+const (
+	// 栈帧本身和每个 slot 占用的字节数，用来估算栈的大小
+	frameOverhead = uint(unsafe.Sizeof(Frame{}) + unsafe.Sizeof(OperandStack{}))
+	slotSize      = uint(unsafe.Sizeof(Slot{}))
+
+	// 栈溢出后额外允许使用的空间，用来创建 StackOverflowError 对象、执行它的构造函数，
+	// 以及执行捕获它的异常处理代码
+	stackHeadroom = 64 * 1024
+)
+
+// 线程栈的最大字节数，对应 -Xss 选项
+var maxStackSize uint = 1024 * 1024
+
+// SetMaxStackSize 设置之后创建的线程的栈大小，必须在启动虚拟机之前调用
+func SetMaxStackSize(size uint) {
+	maxStackSize = size
+}
+
 // jvm stack
 type Stack struct {
-	maxSize uint
-	size    uint
-	_top    *Frame // stack is implemented as linked list
+	maxSize     uint   // 字节数
+	size        uint   // 已经使用的字节数
+	depth       uint   // 栈帧数
+	overflowing bool   // 正在抛出 StackOverflowError，可以使用 stackHeadroom
+	overflowAt  uint   // 发生栈溢出时的栈帧数，也就是引发溢出的栈帧的深度
+	_top        *Frame // stack is implemented as linked list
 }
 
 func newStack(maxSize uint) *Stack {
@@ -14,8 +37,17 @@ func newStack(maxSize uint) *Stack {
 }
 
 func (sta *Stack) push(frame *Frame) {
-	if sta.size >= sta.maxSize {
-		panic("java.lang.StackOverflowError")
+	size := frameSize(frame)
+	// 线程的第一个栈帧总是可以压入，否则抛出 StackOverflowError 时没有栈帧可以处理它
+	if sta.depth > 0 && sta.size+size > sta.maxSize {
+		if !sta.overflowing {
+			sta.overflowing = true
+			sta.overflowAt = sta.depth
+			panic("java.lang.StackOverflowError")
+		}
+		if sta.size+size > sta.maxSize+stackHeadroom {
+			panic("jvm stack overflow while throwing java.lang.StackOverflowError")
+		}
 	}
 
 	if sta._top != nil {
@@ -23,7 +55,8 @@ func (sta *Stack) push(frame *Frame) {
 	}
 
 	sta._top = frame
-	sta.size++
+	sta.size += size
+	sta.depth++
 }
 
 func (sta *Stack) pop() *Frame {
@@ -34,11 +67,25 @@ func (sta *Stack) pop() *Frame {
 	top := sta._top
 	sta._top = top.lower
 	top.lower = nil
-	sta.size--
+	sta.size -= frameSize(top)
+	sta.depth--
+	if sta.depth < sta.overflowAt {
+		// 引发溢出的栈帧已经弹出。在这之前异常处理代码如果就在这个栈帧里，
+		// 它调用的方法也可以使用 stackHeadroom
+		sta.overflowing = false
+	}
 
 	return top
 }
 
+func frameSize(frame *Frame) uint {
+	size := frameOverhead + uint(len(frame.localVars))*slotSize
+	if frame.operandStack != nil {
+		size += uint(len(frame.operandStack.slots)) * slotSize
+	}
+	return size
+}
+
 func (sta *Stack) top() *Frame {
 	if sta._top == nil {
 		panic("jvm stack is empty!")
@@ -48,7 +95,7 @@ func (sta *Stack) top() *Frame {
 }
 
 func (sta *Stack) getFrames() []*Frame {
-	frames := make([]*Frame, 0, sta.size)
+	frames := make([]*Frame, 0, sta.depth)
 	for frame := sta._top; frame != nil; frame = frame.lower {
 		frames = append(frames, frame)
 	}
@@ -0,0 +1,48 @@
+package rtda
+
+import (
+	"fmt"
+	"testing"
+)
+
+// pushError 压入栈帧，返回 panic 的内容
+func pushError(sta *Stack) (msg string) {
+	defer func() {
+		if r := recover(); r != nil {
+			msg = fmt.Sprint(r)
+		}
+	}()
+	sta.push(&Frame{})
+	return ""
+}
+
+func TestStackOverflow(t *testing.T) {
+	sta := newStack(frameOverhead / 2) // 一个栈帧也放不下
+	if err := pushError(sta); err != "" {
+		t.Fatalf("first push: %s", err)
+	}
+	if err := pushError(sta); err != "java.lang.StackOverflowError" {
+		t.Fatalf("second push: got %q", err)
+	}
+
+	// 创建异常对象和执行异常处理代码可以使用 stackHeadroom
+	for i := 0; i < 3; i++ {
+		if err := pushError(sta); err != "" {
+			t.Fatalf("push %d while overflowing: %s", i, err)
+		}
+	}
+	for i := 0; i < 3; i++ {
+		sta.pop()
+	}
+	if !sta.overflowing {
+		t.Fatalf("overflowing reset before the frame that overflowed is popped")
+	}
+
+	sta.pop() // 引发溢出的栈帧
+	if err := pushError(sta); err != "" {
+		t.Fatalf("push after unwinding: %s", err)
+	}
+	if err := pushError(sta); err != "java.lang.StackOverflowError" {
+		t.Fatalf("overflow again: got %q", err)
+	}
+}
@@ -12,8 +12,9 @@ func newLocalVars(maxLocals uint) LocalVars {
 	return nil
 }
 
+// 写入 int、float、long、double 和引用时整个 slot 一起覆盖，清除之前的 returnAddress 标记
 func (lv LocalVars) SetInt(index uint, val int32) {
-	lv[index].num = val
+	lv[index] = Slot{num: val}
 }
 func (lv LocalVars) GetInt(index uint) int32 {
 	return lv[index].num
@@ -21,7 +22,7 @@ func (lv LocalVars) GetInt(index uint) int32 {
 
 func (lv LocalVars) SetFloat(index uint, val float32) {
 	bits := math.Float32bits(val)
-	lv[index].num = int32(bits)
+	lv[index] = Slot{num: int32(bits)}
 }
 func (lv LocalVars) GetFloat(index uint) float32 {
 	bits := uint32(lv[index].num)
@@ -30,8 +31,8 @@ func (lv LocalVars) GetFloat(index uint) float32 {
 
 // long consumes two slots
 func (lv LocalVars) SetLong(index uint, val int64) {
-	lv[index].num = int32(val)
-	lv[index+1].num = int32(val >> 32)
+	lv[index] = Slot{num: int32(val)}
+	lv[index+1] = Slot{num: int32(val >> 32)}
 }
 func (lv LocalVars) GetLong(index uint) int64 {
 	low := uint32(lv[index].num)
@@ -50,12 +51,21 @@ func (lv LocalVars) GetDouble(index uint) float64 {
 }
 
 func (lv LocalVars) SetRef(index uint, ref *heap.Object) {
-	lv[index].ref = ref
+	lv[index] = Slot{ref: ref}
 }
 func (lv LocalVars) GetRef(index uint) *heap.Object {
 	return lv[index].ref
 }
 
+// returnAddress，jsr 下一条指令的地址，由 astore 从操作数栈复制过来
+func (lv LocalVars) GetReturnAddress(index uint) int {
+	if !lv[index].returnAddress {
+		// 没有经过验证的代码才会走到这里
+		panic("java.lang.VerifyError: Bad local variable type: expected returnAddress")
+	}
+	return int(lv[index].num)
+}
+
 func (lv LocalVars) SetSlot(index uint, slot Slot) {
 	lv[index] = slot
 }
@@ -25,8 +25,9 @@ func newOperandStack(maxStack uint) *OperandStack {
 	return nil
 }
 
+// 压入 int、float、long、double 和引用时整个 slot 一起覆盖，清除之前的 returnAddress 标记
 func (osa *OperandStack) PushInt(val int32) {
-	osa.slots[osa.size].num = val
+	osa.slots[osa.size] = Slot{num: val}
 	osa.size++
 }
 func (osa *OperandStack) PopInt() int32 {
@@ -36,7 +37,7 @@ func (osa *OperandStack) PopInt() int32 {
 
 func (osa *OperandStack) PushFloat(val float32) {
 	bits := math.Float32bits(val)
-	osa.slots[osa.size].num = int32(bits)
+	osa.slots[osa.size] = Slot{num: int32(bits)}
 	osa.size++
 }
 func (osa *OperandStack) PopFloat() float32 {
@@ -47,8 +48,8 @@ func (osa *OperandStack) PopFloat() float32 {
 
 // long consumes two slots
 func (osa *OperandStack) PushLong(val int64) {
-	osa.slots[osa.size].num = int32(val)
-	osa.slots[osa.size+1].num = int32(val >> 32)
+	osa.slots[osa.size] = Slot{num: int32(val)}
+	osa.slots[osa.size+1] = Slot{num: int32(val >> 32)}
 	osa.size += 2
 }
 func (osa *OperandStack) PopLong() int64 {
@@ -69,7 +70,7 @@ func (osa *OperandStack) PopDouble() float64 {
 }
 
 func (osa *OperandStack) PushRef(ref *heap.Object) {
-	osa.slots[osa.size].ref = ref
+	osa.slots[osa.size] = Slot{ref: ref}
 	osa.size++
 }
 func (osa *OperandStack) PopRef() *heap.Object {
@@ -79,6 +80,12 @@ func (osa *OperandStack) PopRef() *heap.Object {
 	return ref
 }
 
+// returnAddress，jsr 下一条指令的地址
+func (osa *OperandStack) PushReturnAddress(pc int) {
+	osa.slots[osa.size] = Slot{num: int32(pc), returnAddress: true}
+	osa.size++
+}
+
 func (osa *OperandStack) PushSlot(slot Slot) {
 	osa.slots[osa.size] = slot
 	osa.size++
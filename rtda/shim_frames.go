@@ -10,19 +10,11 @@ func NewShimFrame(thread *Thread, ops *OperandStack) *Frame {
 	}
 }
 
-//func newAthrowFrame(thread *Thread, ex *heap.Object, initArgs []interface{}) *Frame {
-//	// stackSlots := [ex, ex, initArgs]
-//	stackSlots := make([]interface{}, len(initArgs)+2)
-//	stackSlots[0] = ex
-//	stackSlots[1] = ex
-//	copy(stackSlots[2:], initArgs)
-//
-//	frame := &Frame{}
-//	frame.thread = thread
-//	frame.method = heap.AthrowMethod()
-//	frame.operandStack = &OperandStack{
-//		size:  uint(len(stackSlots)),
-//		slots: stackSlots,
-//	}
-//	return frame
-//}
+// NewAthrowShimFrame 创建执行 athrow 的 shim 栈帧，ops 的栈顶是要抛出的异常对象
+func NewAthrowShimFrame(thread *Thread, ops *OperandStack) *Frame {
+	return &Frame{
+		thread:       thread,
+		method:       heap.ShimAthrowMethod(),
+		operandStack: ops,
+	}
+}
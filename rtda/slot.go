@@ -2,8 +2,12 @@ package rtda
 
 import "jvm-go/rtda/heap"
 
+// Slot 局部变量表和操作数栈的一个位置。
+// jsr 压栈的 returnAddress 也放在 num 里，保存的是 jsr 下一条指令的地址，用 returnAddress 标记
 type Slot struct {
 	num int32
+	// num 是 returnAddress，只有 jsr 压栈的值才有这个标记，astore 连同标记一起复制
+	returnAddress bool
 	// 引用类型
 	ref *heap.Object
 }
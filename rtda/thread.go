@@ -1,6 +1,15 @@
 package rtda
 
-import "jvm-go/rtda/heap"
+import (
+	"jvm-go/rtda/heap"
+	"sync/atomic"
+)
+
+// java.lang.Thread.threadStatus 的取值，参见 sun.misc.VM.toThreadState
+const (
+	jThreadStatusTerminated = 0x0002
+	jThreadStatusRunnable   = 0x0004
+)
 
 /*
 JVM
@@ -17,7 +26,17 @@ type Thread struct {
 	pc int // the address of the instruction currently being executed
 	// 栈
 	stack *Stack
-	// todo
+	// 对应的 java.lang.Thread 对象
+	jThread *heap.Object
+	// 线程是否仍在运行
+	alive atomic.Bool
+	// 中断状态
+	interrupted atomic.Bool
+	// 线程被中断时，用来唤醒正在 wait 或 sleep 的线程
+	interruptCh chan struct{}
+	// 已经开始调用 Thread.dispatchUncaughtException() 或 Thread.exit()，
+	// 这之后没有被捕获的异常不再交给 Java 代码处理
+	exiting bool
 }
 
 func (th *Thread) NewFrame(method *heap.Method) *Frame {
@@ -25,9 +44,73 @@ func (th *Thread) NewFrame(method *heap.Method) *Frame {
 }
 
 func NewThread() *Thread {
-	return &Thread{
-		stack: newStack(1024),
+	thread := &Thread{
+		stack:       newStack(maxStackSize),
+		interruptCh: make(chan struct{}, 1),
 	}
+	thread.alive.Store(true)
+	return thread
+}
+
+func (th *Thread) JThread() *heap.Object {
+	return th.jThread
+}
+
+// SetJThread 把线程和 java.lang.Thread 对象互相关联起来
+func (th *Thread) SetJThread(jThread *heap.Object) {
+	th.jThread = jThread
+	jThread.SetExtra(th)
+	jThread.SetIntVar("threadStatus", "I", jThreadStatusRunnable)
+}
+
+func (th *Thread) IsAlive() bool {
+	return th.alive.Load()
+}
+
+// Exit 线程执行结束，唤醒在 Thread.join() 中等待的线程
+func (th *Thread) Exit() {
+	th.alive.Store(false)
+	if th.jThread != nil {
+		th.jThread.SetIntVar("threadStatus", "I", jThreadStatusTerminated)
+		monitor := th.jThread.Monitor()
+		monitor.Enter(th)
+		monitor.NotifyAll(th)
+		monitor.Exit(th)
+	}
+}
+
+func (th *Thread) IsExiting() bool {
+	return th.exiting
+}
+func (th *Thread) SetExiting() {
+	th.exiting = true
+}
+
+// Interrupt 设置中断状态，并唤醒正在等待的线程
+func (th *Thread) Interrupt() {
+	th.interrupted.Store(true)
+	select {
+	case th.interruptCh <- struct{}{}:
+	default:
+	}
+}
+
+func (th *Thread) IsInterrupted() bool {
+	return th.interrupted.Load()
+}
+
+// ClearInterrupted 清除中断状态，返回之前的状态
+func (th *Thread) ClearInterrupted() bool {
+	select {
+	case <-th.interruptCh:
+	default:
+	}
+	return th.interrupted.Swap(false)
+}
+
+// InterruptCh 线程被中断时可以从返回的 channel 收到通知
+func (th *Thread) InterruptCh() <-chan struct{} {
+	return th.interruptCh
 }
 
 func (th *Thread) PC() int {
@@ -42,7 +125,12 @@ func (th *Thread) PushFrame(frame *Frame) {
 	th.stack.push(frame)
 }
 func (th *Thread) PopFrame() *Frame {
-	return th.stack.pop()
+	frame := th.stack.pop()
+	// synchronized 方法无论正常返回还是异常退出，都要释放监视器
+	if frame.monitor != nil {
+		frame.monitor.Exit(th)
+	}
+	return frame
 }
 
 func (th *Thread) CurrentFrame() *Frame {
@@ -62,5 +150,7 @@ func (th *Thread) IsStackEmpty() bool {
 }
 
 func (th *Thread) ClearStack() {
-	th.stack.clear()
+	for !th.stack.isEmpty() {
+		th.PopFrame()
+	}
 }